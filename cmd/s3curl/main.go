@@ -17,6 +17,7 @@ func main() {
 	endpoint := flag.String("endpoint", os.Getenv("S3_ENDPOINT"), "S3 endpoint URL")
 	accessKey := flag.String("access-key", os.Getenv("S3_ACCESS_KEY"), "S3 access key")
 	secretKey := flag.String("secret-key", os.Getenv("S3_SECRET_KEY"), "S3 secret key")
+	sessionToken := flag.String("session-token", os.Getenv("S3_SESSION_TOKEN"), "Session token of temporary credentials (optional)")
 	region := flag.String("region", "us-east-1", "AWS region")
 	bucket := flag.String("bucket", "", "Bucket name")
 	key := flag.String("key", "test-file.txt", "Object key")
@@ -27,7 +28,7 @@ func main() {
 
 	if *endpoint == "" || *accessKey == "" || *secretKey == "" || *bucket == "" {
 		fmt.Fprintln(os.Stderr, "Usage: s3curl -endpoint URL -access-key KEY -secret-key SECRET -bucket BUCKET [-op upload|download|delete] [-key filename] [-data content]")
-		fmt.Fprintln(os.Stderr, "\nEnvironment variables: S3_ENDPOINT, S3_ACCESS_KEY, S3_SECRET_KEY")
+		fmt.Fprintln(os.Stderr, "\nEnvironment variables: S3_ENDPOINT, S3_ACCESS_KEY, S3_SECRET_KEY, S3_SESSION_TOKEN")
 		fmt.Fprintln(os.Stderr, "\nExamples:")
 		fmt.Fprintln(os.Stderr, "  s3curl -bucket mybucket -op upload -key test.txt -data 'Hello World'")
 		fmt.Fprintln(os.Stderr, "  s3curl -bucket mybucket -op download -key test.txt")
@@ -37,9 +38,10 @@ func main() {
 	}
 
 	creds := awsv4.Credentials{
-		AccessKey: *accessKey,
-		SecretKey: *secretKey,
-		Region:    *region,
+		AccessKey:    *accessKey,
+		SecretKey:    *secretKey,
+		SessionToken: *sessionToken,
+		Region:       *region,
 	}
 
 	url := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(*endpoint, "/"), *bucket, *key)
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/executor"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/workdir"
+	"storj.io/uplink"
+)
+
+// Doctor check outcomes
+const (
+	checkPass = "PASS"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+	checkSkip = "SKIP"
+)
+
+// checkResult is a single line of the doctor report
+type checkResult struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// doctorCommand verifies the runtime environment and prints a pass/fail report.
+// Returns 1 if any check failed.
+func doctorCommand(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics doctor [--config PATH]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Printf("%s  config: %v\n", checkFail, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	mc := metrics.NewCollector()
+	results := []checkResult{
+		{Name: "config", Status: checkPass, Detail: fmt.Sprintf("%s (%d tests)", *configPath, len(cfg.Tests))},
+		checkK6(ctx, cfg),
+		checkCurl(cfg),
+		checkS3(ctx, cfg, "", mc),
+	}
+	for _, gw := range cfg.S3Gateways {
+		results = append(results, checkS3(ctx, cfg, gw.Name, mc))
+	}
+	results = append(results, checkAccessGrant(cfg, &cfg.Satellite))
+	for i := range cfg.Satellites {
+		results = append(results, checkAccessGrant(cfg, &cfg.Satellites[i]))
+	}
+	results = append(results, checkWorkDir(cfg), checkPort(cfg))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	failed := false
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Status, r.Name, r.Detail)
+		if r.Status == checkFail {
+			failed = true
+		}
+	}
+	w.Flush()
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// usesExecutor returns true if any enabled test uses the executor
+func usesExecutor(cfg *config.Config, name string) bool {
+	for _, test := range cfg.Tests {
+		if test.Enabled && test.GetExecutor() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkK6 verifies the k6 binary runs and includes the xk6-storj extension
+func checkK6(ctx context.Context, cfg *config.Config) checkResult {
+	r := checkResult{Name: "k6"}
+	out, err := exec.CommandContext(ctx, cfg.K6.BinaryPath, "version").CombinedOutput()
+	if err != nil {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("%s: %v", cfg.K6.BinaryPath, err)
+		if !usesExecutor(cfg, "uplink") {
+			r.Status = checkSkip
+			r.Detail += " (no enabled uplink tests)"
+		}
+		return r
+	}
+
+	version := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	if !strings.Contains(string(out), "k6/x/storj") {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("%s does not include the xk6-storj extension", version)
+		if !usesExecutor(cfg, "uplink") {
+			r.Status = checkWarn
+		}
+		return r
+	}
+	r.Status, r.Detail = checkPass, version+" with xk6-storj"
+	return r
+}
+
+// checkCurl verifies curl is on PATH
+func checkCurl(cfg *config.Config) checkResult {
+	r := checkResult{Name: "curl"}
+	path, err := exec.LookPath("curl")
+	if err != nil {
+		r.Status, r.Detail = checkWarn, "curl not found in PATH"
+		if usesExecutor(cfg, "curl-s3") {
+			r.Status = checkFail
+		}
+		return r
+	}
+	r.Status, r.Detail = checkPass, path
+	return r
+}
+
+// checkS3 verifies the S3 credentials by calling HeadBucket on the default bucket
+func checkS3(ctx context.Context, cfg *config.Config, gateway string, mc *metrics.Collector) checkResult {
+	r := checkResult{Name: "s3 credentials"}
+	if gateway != "" {
+		r.Name += " (" + gateway + ")"
+		gwCfg := *cfg
+		gwCfg.S3, _ = cfg.GetGateway(gateway)
+		cfg = &gwCfg
+	}
+	if cfg.S3.Endpoint == "" || cfg.S3.AccessKey == "" {
+		r.Status, r.Detail = checkSkip, "no s3 endpoint/credentials configured"
+		return r
+	}
+
+	s3Exec, err := executor.NewS3(cfg, mc)
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+
+	bucket := cfg.Satellite.Bucket
+	err = s3Exec.HeadBucket(ctx, bucket)
+	var respErr *awshttp.ResponseError
+	switch {
+	case err == nil:
+		r.Status, r.Detail = checkPass, fmt.Sprintf("%s (bucket %s accessible)", cfg.S3.Endpoint, bucket)
+	case errors.As(err, &respErr) && respErr.HTTPStatusCode() == 404:
+		r.Status, r.Detail = checkWarn, fmt.Sprintf("bucket %s not found (created on first run)", bucket)
+	default:
+		r.Status, r.Detail = checkFail, fmt.Sprintf("HeadBucket %s: %v", bucket, err)
+	}
+	return r
+}
+
+// checkAccessGrant verifies a satellite's uplink access grant parses
+func checkAccessGrant(cfg *config.Config, sat *config.SatelliteConfig) checkResult {
+	r := checkResult{Name: "access grant"}
+	if sat != &cfg.Satellite {
+		r.Name += " (" + sat.Name + ")"
+	}
+	if sat.AccessGrant == "" {
+		r.Status, r.Detail = checkSkip, "no access grant configured"
+		for _, test := range cfg.Tests {
+			if test.Enabled && test.GetExecutor() == "uplink" && (test.Satellite == "" || test.Satellite == sat.GetName()) {
+				r.Status = checkFail
+			}
+		}
+		return r
+	}
+
+	access, err := uplink.ParseAccess(sat.AccessGrant)
+	if err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+	r.Status, r.Detail = checkPass, "satellite "+access.SatelliteAddress()
+	return r
+}
+
+// checkWorkDir verifies the work directory can be created and written
+func checkWorkDir(cfg *config.Config) checkResult {
+	r := checkResult{Name: "work dir"}
+	if err := workdir.Init(cfg.WorkDir.Path, cfg.WorkDir.MaxSize.Int64()); err != nil {
+		r.Status, r.Detail = checkFail, err.Error()
+		return r
+	}
+	dir := workdir.Dir()
+	f, err := os.CreateTemp(workdir.TempDir(), ".doctor-*")
+	if err != nil {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("%s not writable: %v", dir, err)
+		return r
+	}
+	f.Close()
+	os.Remove(f.Name())
+	r.Status, r.Detail = checkPass, dir
+	return r
+}
+
+// checkPort verifies the metrics port is free
+func checkPort(cfg *config.Config) checkResult {
+	r := checkResult{Name: "port"}
+	addr := fmt.Sprintf(":%d", cfg.Metrics.Port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		r.Status, r.Detail = checkFail, fmt.Sprintf("%s unavailable: %v", addr, err)
+		return r
+	}
+	ln.Close()
+	r.Status, r.Detail = checkPass, addr+" available"
+	return r
+}
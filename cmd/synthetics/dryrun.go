@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/scheduler"
+)
+
+// dryRunCommand asks a running probe which tests would run in the next
+// window, given its config and current state (disabled tags, when
+// conditions, fixtures, runs in flight, the disk budget), and why the others
+// would be skipped. Unlike schedule, it needs the probe: that state lives in
+// the running process.
+func dryRunCommand(args []string) int {
+	fs := flag.NewFlagSet("dry-run", flag.ContinueOnError)
+	addr := fs.String("addr", "http://localhost:8080", "Admin API address of the running probe")
+	window := fs.Duration("for", time.Hour, "Length of the window")
+	test := fs.String("test", "", "Only this test")
+	jsonOutput := fs.Bool("json", false, "Write the dry run as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics dry-run [--addr URL] [--for 1h] [--test NAME] [--json]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	q := url.Values{"for": {window.String()}}
+	if *test != "" {
+		q.Set("test", *test)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(*addr, "/") + "/api/v1/scheduler/dry-run?" + q.Encode())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reach the probe: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the dry run: %v\n", err)
+		return 1
+	}
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "Dry run failed: %s: %s\n", resp.Status, strings.TrimSpace(string(body)))
+		return 1
+	}
+	if *jsonOutput {
+		os.Stdout.Write(body)
+		return 0
+	}
+
+	var dry scheduler.DryRun
+	if err := json.Unmarshal(body, &dry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse the dry run: %v\n", err)
+		return 1
+	}
+	if err := printDryRun(dry); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// printDryRun writes the dry run as text: each test's firings, with the
+// reason of those that would be skipped
+func printDryRun(dry scheduler.DryRun) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Dry run from %s to %s\n\n", dry.From.Format(time.RFC3339), dry.To.Format(time.RFC3339))
+	for _, pt := range dry.Tests {
+		if !pt.Scheduled {
+			fmt.Fprintf(w, "%s (%s): not scheduled, %s\n", pt.Test, pt.Schedule, reasonText(pt.Reason, pt.Detail))
+			continue
+		}
+		fmt.Fprintf(w, "%s (%s): %d of %d firings would run\n", pt.Test, pt.Schedule, pt.WouldRun, len(pt.Runs))
+		for _, run := range pt.Runs {
+			when := run.Time.Format(time.RFC3339)
+			if !run.Latest.IsZero() {
+				when += " .. " + run.Latest.Format(time.RFC3339)
+			}
+			outcome := "run"
+			if !run.Run {
+				outcome = "skip: " + reasonText(run.Reason, run.Detail)
+			}
+			fmt.Fprintf(w, "  %s\t%s\n", when, outcome)
+		}
+	}
+	return w.Flush()
+}
+
+// reasonText joins a skip reason and its detail
+func reasonText(reason, detail string) string {
+	if detail == "" {
+		return reason
+	}
+	return reason + " (" + detail + ")"
+}
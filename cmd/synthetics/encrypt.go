@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethanadams/synthetics/internal/config"
+)
+
+// encryptCommand encrypts a secret read from stdin into an ENC[...] config
+// value, using the key in CONFIG_KEY or CONFIG_KEY_FILE. With --generate-key
+// it prints a new key instead.
+func encryptCommand(args []string) int {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	generate := fs.Bool("generate-key", false, "Print a new random key and exit")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics encrypt [--generate-key] < secret\n\n")
+		fmt.Fprintf(os.Stderr, "Encrypts stdin with the key in %s or %s.\n\n", config.KeyEnv, config.KeyFileEnv)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *generate {
+		key, err := config.GenerateKey()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate key: %v\n", err)
+			return 1
+		}
+		fmt.Println(key)
+		return 0
+	}
+
+	key, err := config.LoadKey()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	secret, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read stdin: %v\n", err)
+		return 1
+	}
+	value, err := config.Encrypt(key, strings.TrimRight(string(secret), "\r\n"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encrypt: %v\n", err)
+		return 1
+	}
+	fmt.Println(value)
+	return 0
+}
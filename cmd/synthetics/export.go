@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/netpath"
+)
+
+// exportSchemaVersion is bumped when fields of the export are renamed or
+// removed; adding fields keeps the version
+const exportSchemaVersion = 1
+
+// exportInventory is the effective test inventory of a config
+type exportInventory struct {
+	SchemaVersion int          `json:"schema_version"`
+	Probe         string       `json:"probe"` // agent.probe; empty in standalone mode
+	Tests         []exportTest `json:"tests"`
+}
+
+// exportTest is one test of the inventory with its target resolved: the
+// gateway endpoint or satellite address it runs against and the bucket it
+// uses
+type exportTest struct {
+	Name           string           `json:"name"`
+	Tenant         string           `json:"tenant"` // Empty for tests outside tenants
+	Enabled        bool             `json:"enabled"`
+	DisabledByTags []string         `json:"disabled_by_tags"` // Tags in disabled_tags that pause the test
+	Executor       string           `json:"executor"`
+	Schedule       string           `json:"schedule"`
+	Tags           []string         `json:"tags"`
+	Priority       int              `json:"priority"`
+	Gateway        string           `json:"gateway"`   // S3 gateway name, for gateway executors
+	Satellite      string           `json:"satellite"` // Satellite name, for uplink tests
+	Endpoint       string           `json:"endpoint"`  // Gateway URL or satellite host:port
+	Bucket         string           `json:"bucket"`
+	Targets        []string         `json:"targets"` // Hosts the test talks to
+	Compare        []exportEndpoint `json:"compare,omitempty"`
+	Steps          []exportStep     `json:"steps"`
+}
+
+type exportEndpoint struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+}
+
+type exportStep struct {
+	Name          string `json:"name"`
+	FileSizeBytes int64  `json:"file_size_bytes"` // 0 if the step sets no size
+}
+
+// exportCommand writes the effective test inventory in a stable
+// machine-readable schema, for IaC and inventory systems that track what is
+// monitored where
+func exportCommand(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	format := fs.String("format", "json", `Output format: "json", or "tfvars" (a Terraform variables file keyed by test name)`)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics export [--format json|tfvars] [--config PATH] > inventory.json\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "json" && *format != "tfvars" {
+		fmt.Fprintf(os.Stderr, "Invalid --format %q (expected json or tfvars)\n", *format)
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+	inv := buildInventory(cfg)
+
+	var out any = inv
+	if *format == "tfvars" {
+		byName := make(map[string]exportTest, len(inv.Tests))
+		for _, test := range inv.Tests {
+			byName[test.Name] = test
+		}
+		out = map[string]any{"synthetics_tests": byName}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write inventory: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildInventory resolves each test's target, sorted by test name so the
+// output diffs cleanly
+func buildInventory(cfg *config.Config) exportInventory {
+	inv := exportInventory{
+		SchemaVersion: exportSchemaVersion,
+		Tests:         make([]exportTest, 0, len(cfg.Tests)),
+	}
+	if cfg.Mode == config.ModeAgent {
+		inv.Probe = cfg.Agent.Probe
+	}
+	for _, test := range cfg.Tests {
+		et := exportTest{
+			Name:           test.Name,
+			Tenant:         test.Tenant,
+			Enabled:        test.Enabled,
+			DisabledByTags: []string{},
+			Executor:       test.GetExecutor(),
+			Schedule:       test.Schedule,
+			Tags:           append([]string{}, test.Tags...),
+			Priority:       test.Priority,
+			Targets:        append([]string{}, netpath.TestTargets(cfg, &test)...),
+			Steps:          make([]exportStep, 0, len(test.Steps)),
+		}
+		for _, tag := range test.Tags {
+			if slices.Contains(cfg.DisabledTags, tag) {
+				et.DisabledByTags = append(et.DisabledByTags, tag)
+			}
+		}
+
+		switch et.Executor {
+		case "uplink":
+			sat, _ := cfg.GetSatellite(test.Satellite)
+			et.Satellite = sat.GetName()
+			et.Endpoint = netpath.SatelliteAddr(sat.AccessGrant)
+			et.Bucket = test.GetBucket(sat.Bucket)
+		case "compare":
+			for _, ep := range test.Compare {
+				et.Compare = append(et.Compare, exportEndpoint{Name: ep.Name, Endpoint: ep.Endpoint})
+			}
+			et.Bucket = test.GetBucket(cfg.Satellite.Bucket)
+		case "rtt":
+		case "bakeoff":
+			sat, _ := cfg.GetSatellite(test.Satellite)
+			et.Satellite = sat.GetName()
+			gw, _ := cfg.GetGateway(test.Gateway)
+			et.Gateway = gw.GetName()
+			et.Endpoint = gw.Endpoint
+			et.Bucket = test.GetBucket(cfg.Satellite.Bucket)
+		default:
+			gw, _ := cfg.GetGateway(test.Gateway)
+			et.Gateway = gw.GetName()
+			et.Endpoint = gw.Endpoint
+			et.Bucket = test.GetBucket(cfg.Satellite.Bucket)
+		}
+
+		for _, step := range test.Steps {
+			es := exportStep{Name: step.Name}
+			if step.FileSize != nil {
+				es.FileSizeBytes = step.FileSize.Int64()
+			}
+			et.Steps = append(et.Steps, es)
+		}
+		inv.Tests = append(inv.Tests, et)
+	}
+	slices.SortFunc(inv.Tests, func(a, b exportTest) int { return strings.Compare(a.Name, b.Name) })
+	return inv
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/executor"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"gopkg.in/yaml.v3"
+)
+
+// inventoryObject is an existing object to generate a download test for
+type inventoryObject struct {
+	Bucket   string
+	Key      string
+	Name     string // Test name; derived from the key if empty
+	Schedule string
+	Executor string
+	Tags     []string
+}
+
+// generatedTest is the YAML of a generated download test
+type generatedTest struct {
+	Name     string          `yaml:"name"`
+	Executor string          `yaml:"executor"`
+	Schedule string          `yaml:"schedule"`
+	Enabled  bool            `yaml:"enabled"`
+	Bucket   string          `yaml:"bucket,omitempty"`
+	Filename string          `yaml:"filename"`
+	Tags     []string        `yaml:"tags,omitempty"`
+	Steps    []generatedStep `yaml:"steps"`
+}
+
+type generatedStep struct {
+	Name string `yaml:"name"`
+}
+
+// generateCommand writes download test definitions for existing objects,
+// read from a CSV file or an S3 bucket listing, as YAML to stdout. It helps
+// migrate canary objects monitored elsewhere into a config.
+func generateCommand(args []string) int {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	csvPath := fs.String("csv", "", `CSV file of objects ("-" for stdin)`)
+	bucket := fs.String("bucket", "", "List the objects of this bucket with the config's S3 credentials")
+	prefix := fs.String("prefix", "", "Only list objects under this key prefix (with --bucket)")
+	limit := fs.Int("limit", 0, "Stop after this many objects (0: no limit)")
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL, for the S3 credentials of --bucket")
+	executorType := fs.String("executor", "s3", "Executor of the tests (s3, http-s3, or curl-s3)")
+	schedule := fs.String("schedule", "*/15 * * * *", "Schedule of the tests")
+	namePrefix := fs.String("name-prefix", "download-", "Prefix of test names derived from object keys")
+	tags := fs.String("tags", "", "Comma-separated tags added to every test")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics generate (--csv FILE | --bucket NAME [--prefix P]) [--executor s3] [--schedule CRON] [--tags a,b] > tests.yaml\n\n")
+		fmt.Fprintf(os.Stderr, "The CSV has a header row with a key column and optional bucket, name, schedule,\n")
+		fmt.Fprintf(os.Stderr, "executor, and tags (space-separated) columns; without a header it is read as an\n")
+		fmt.Fprintf(os.Stderr, "S3 Inventory report (bucket, key, ...).\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (*csvPath == "") == (*bucket == "") {
+		fmt.Fprintf(os.Stderr, "Exactly one of --csv and --bucket is required\n")
+		return 2
+	}
+	switch *executorType {
+	case "s3", "http-s3", "curl-s3":
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid --executor %q (expected s3, http-s3, or curl-s3)\n", *executorType)
+		return 2
+	}
+
+	var objects []inventoryObject
+	var source string
+	var err error
+	if *csvPath != "" {
+		source = *csvPath
+		objects, err = readInventoryCSV(*csvPath)
+	} else {
+		source = "s3://" + *bucket + "/" + *prefix
+		objects, err = listInventory(*configPath, *bucket, *prefix, *limit)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read objects: %v\n", err)
+		return 1
+	}
+	if *limit > 0 && len(objects) > *limit {
+		objects = objects[:*limit]
+	}
+	if len(objects) == 0 {
+		fmt.Fprintf(os.Stderr, "No objects found in %s\n", source)
+		return 1
+	}
+
+	var extraTags []string
+	for _, tag := range strings.Split(*tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			extraTags = append(extraTags, tag)
+		}
+	}
+	tests := make([]generatedTest, 0, len(objects))
+	used := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		name := obj.Name
+		if name == "" {
+			name = *namePrefix + testNameFromKey(obj.Key)
+		}
+		name = uniqueName(name, used)
+		t := generatedTest{
+			Name:     name,
+			Executor: *executorType,
+			Schedule: *schedule,
+			Enabled:  true,
+			Bucket:   obj.Bucket,
+			Filename: obj.Key,
+			Tags:     append(obj.Tags, extraTags...),
+			Steps:    []generatedStep{{Name: "download"}},
+		}
+		if obj.Schedule != "" {
+			t.Schedule = obj.Schedule
+		}
+		if obj.Executor != "" {
+			t.Executor = obj.Executor
+		}
+		tests = append(tests, t)
+	}
+
+	fmt.Printf("# Generated by \"synthetics generate\" from %s: %d download tests\n", source, len(tests))
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string][]generatedTest{"tests": tests}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write tests: %v\n", err)
+		return 1
+	}
+	enc.Close()
+	fmt.Fprintf(os.Stderr, "Generated %d tests; run \"synthetics lint\" on the merged config to check their load\n", len(tests))
+	return 0
+}
+
+// readInventoryCSV reads objects from a CSV file with a header row naming a
+// key column, or from an S3 Inventory report (no header; bucket and key are
+// the first two columns, URL-encoded)
+func readInventoryCSV(path string) ([]inventoryObject, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["key"]; !ok {
+		return readInventoryReport(rows)
+	}
+	for name := range columns {
+		switch name {
+		case "key", "bucket", "name", "schedule", "executor", "tags", "size":
+		default:
+			return nil, fmt.Errorf("unknown column %q (expected key, bucket, name, schedule, executor, tags, size)", name)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var objects []inventoryObject
+	for n, row := range rows[1:] {
+		obj := inventoryObject{
+			Bucket:   field(row, "bucket"),
+			Key:      field(row, "key"),
+			Name:     field(row, "name"),
+			Schedule: field(row, "schedule"),
+			Executor: field(row, "executor"),
+			Tags:     strings.Fields(field(row, "tags")),
+		}
+		if obj.Key == "" {
+			return nil, fmt.Errorf("line %d: empty key", n+2)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// readInventoryReport reads the rows of an S3 Inventory CSV report, whose
+// first two columns are the bucket and URL-encoded key. An object listed once
+// per version gets one test; folder markers get none.
+func readInventoryReport(rows [][]string) ([]inventoryObject, error) {
+	var objects []inventoryObject
+	seen := make(map[string]bool)
+	for n, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("line %d: expected a header row with a key column, or bucket and key columns", n+1)
+		}
+		key, err := unescapeInventoryKey(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if key == "" || strings.HasSuffix(key, "/") || seen[row[0]+"/"+key] {
+			continue
+		}
+		seen[row[0]+"/"+key] = true
+		objects = append(objects, inventoryObject{Bucket: row[0], Key: key})
+	}
+	return objects, nil
+}
+
+// unescapeInventoryKey decodes a key as S3 Inventory writes it: URL-encoded
+// with "+" for spaces
+func unescapeInventoryKey(s string) (string, error) {
+	key, err := url.QueryUnescape(s)
+	if err != nil {
+		return "", fmt.Errorf("malformed key %q", s)
+	}
+	return key, nil
+}
+
+// listInventory lists the objects of a bucket with the config's S3
+// credentials
+func listInventory(configPath, bucket, prefix string, limit int) ([]inventoryObject, error) {
+	cfg, _, err := loadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.S3.Endpoint == "" {
+		return nil, errors.New("no s3 endpoint configured")
+	}
+	s3Exec, err := executor.NewS3(cfg, metrics.NewCollector())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	listed, err := s3Exec.ListObjects(ctx, bucket, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListObjectsV2 %s: %w", bucket, err)
+	}
+	objects := make([]inventoryObject, 0, len(listed))
+	for _, obj := range listed {
+		if strings.HasSuffix(obj.Key, "/") {
+			continue // Folder marker
+		}
+		objects = append(objects, inventoryObject{Bucket: bucket, Key: obj.Key})
+	}
+	return objects, nil
+}
+
+// testNameFromKey derives a test name from an object key: lowercase, with
+// runs of other characters than letters and digits replaced by "-"
+func testNameFromKey(key string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(key) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			b.WriteRune(c)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// uniqueName returns name, with a numeric suffix if it is already used
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
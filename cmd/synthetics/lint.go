@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ethanadams/synthetics/internal/config"
+)
+
+// lintCommand validates the config, prints the estimated gateway load of its
+// tests, and warns about minutes in which many tests fire together. Returns
+// 1 if there are warnings.
+func lintCommand(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	maxPerMinute := fs.Int("max-per-minute", config.DefaultMaxTestsPerMinute, "Warn when more tests than this fire in the same minute")
+	jsonOutput := fs.Bool("json", false, "Write the load report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics lint [--max-per-minute N] [--json] [--config PATH]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+	report := cfg.Lint(*maxPerMinute)
+	warnings := report.Warnings()
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+			return 1
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TEST\tSCHEDULE\tRUNS/H\tREQUESTS/RUN\tBYTES/RUN\tREQUESTS/H\tBYTES/H")
+		for _, t := range report.Tests {
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%d\t%s\t%.1f\t%s\n", t.Name, t.Schedule, t.RunsPerHour,
+				t.RequestsPerRun, formatBytes(float64(t.BytesPerRun)), t.RequestsPerHour, formatBytes(t.BytesPerHour))
+		}
+		fmt.Fprintf(w, "TOTAL\t\t%.2f\t\t\t%.1f\t%s\n", report.RunsPerHour, report.RequestsPerHour, formatBytes(report.BytesPerHour))
+		w.Flush()
+		if len(warnings) > 0 {
+			fmt.Println()
+		}
+		for _, warning := range warnings {
+			fmt.Printf("WARN  %s\n", warning)
+		}
+	}
+	if len(warnings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// formatBytes formats a byte count for human-readable output
+func formatBytes(bytes float64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	exp := 0
+	for bytes >= unit*unit && exp < 5 {
+		bytes /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", bytes/unit, "KMGTPE"[exp])
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// listEntry describes one configured test for the list command
+type listEntry struct {
+	Name     string   `json:"name"`
+	Tenant   string   `json:"tenant,omitempty"`
+	Enabled  bool     `json:"enabled"`
+	Executor string   `json:"executor"`
+	Schedule string   `json:"schedule"`
+	NextRun  string   `json:"next_run,omitempty"`
+	Steps    []string `json:"steps"`
+	Sizes    []string `json:"sizes,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// listCommand prints a table (or JSON) of the configured tests
+func listCommand(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	jsonOutput := fs.Bool("json", false, "Write the test list as JSON")
+	noNextRun := fs.Bool("no-next-run", false, "Omit the next run time (stable output for diffing)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics list [--json] [--no-next-run] [--config PATH]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+
+	now := time.Now()
+	entries := make([]listEntry, 0, len(cfg.Tests))
+	for _, test := range cfg.Tests {
+		entry := listEntry{
+			Name:     test.Name,
+			Tenant:   test.Tenant,
+			Enabled:  test.Enabled,
+			Executor: test.ExecutorKey(),
+			Schedule: test.Schedule,
+			Tags:     test.Tags,
+		}
+		if !*noNextRun && test.Enabled {
+			entry.NextRun = nextRun(test.Schedule, now)
+		}
+		for _, step := range test.Steps {
+			entry.Steps = append(entry.Steps, step.Name)
+			if step.FileSize != nil {
+				entry.Sizes = append(entry.Sizes, step.FileSize.String())
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write list: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if *noNextRun {
+		fmt.Fprintln(w, "NAME\tENABLED\tEXECUTOR\tSCHEDULE\tSTEPS\tSIZES\tTAGS")
+	} else {
+		fmt.Fprintln(w, "NAME\tENABLED\tEXECUTOR\tSCHEDULE\tNEXT RUN\tSTEPS\tSIZES\tTAGS")
+	}
+	for _, e := range entries {
+		cols := []string{e.Name, fmt.Sprintf("%t", e.Enabled), e.Executor, e.Schedule}
+		if !*noNextRun {
+			cols = append(cols, dash(e.NextRun))
+		}
+		cols = append(cols,
+			dash(strings.Join(e.Steps, ",")),
+			dash(strings.Join(e.Sizes, ",")),
+			dash(strings.Join(e.Tags, ",")))
+		fmt.Fprintln(w, strings.Join(cols, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// nextRun returns the next scheduled time for a cron expression, or an error marker
+func nextRun(schedule string, now time.Time) string {
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return "invalid schedule"
+	}
+	return sched.Next(now).Format(time.RFC3339)
+}
+
+// dash returns "-" for empty table cells
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,112 +2,336 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
 	"time"
 
+	"github.com/ethanadams/synthetics/internal/anomaly"
+	"github.com/ethanadams/synthetics/internal/api"
 	"github.com/ethanadams/synthetics/internal/config"
 	"github.com/ethanadams/synthetics/internal/executor"
+	"github.com/ethanadams/synthetics/internal/fleet"
 	"github.com/ethanadams/synthetics/internal/logging"
 	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/netpath"
+	"github.com/ethanadams/synthetics/internal/results"
 	"github.com/ethanadams/synthetics/internal/scheduler"
+	"github.com/ethanadams/synthetics/internal/subproc"
 	"github.com/ethanadams/synthetics/internal/testdata"
+	"github.com/ethanadams/synthetics/internal/version"
+	"github.com/ethanadams/synthetics/internal/workdir"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
-	// Load configuration
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "configs/config.yaml"
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "run-test":
+			os.Exit(runTestCommand(os.Args[2:]))
+		case "--once", "-once":
+			os.Exit(onceCommand(os.Args[2:]))
+		case "verify":
+			os.Exit(verifyCommand(os.Args[2:]))
+		case "list":
+			os.Exit(listCommand(os.Args[2:]))
+		case "schedule":
+			os.Exit(scheduleCommand(os.Args[2:]))
+		case "dry-run":
+			os.Exit(dryRunCommand(os.Args[2:]))
+		case "lint":
+			os.Exit(lintCommand(os.Args[2:]))
+		case "generate":
+			os.Exit(generateCommand(os.Args[2:]))
+		case "export":
+			os.Exit(exportCommand(os.Args[2:]))
+		case subproc.WrapperArg:
+			os.Exit(subproc.Exec(os.Args[2:]))
+		case "doctor":
+			os.Exit(doctorCommand(os.Args[2:]))
+		case "bench-sign":
+			os.Exit(benchSignCommand(os.Args[2:]))
+		case "encrypt":
+			os.Exit(encryptCommand(os.Args[2:]))
+		case "version", "--version", "-version":
+			fmt.Println(version.Get())
+			return
+		case "help", "-h", "--help":
+			printUsage()
+			return
+		}
+	}
+	serve()
+}
+
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: synthetics [command]\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  (none)      Run the scheduler and metrics server\n")
+	fmt.Fprintf(os.Stderr, "  --once      Run every enabled test once and exit (--output text|json|junit)\n")
+	fmt.Fprintf(os.Stderr, "  run-test    Run a single test once and exit\n")
+	fmt.Fprintf(os.Stderr, "  verify      Run a test group N times and judge availability and p95 (rollout gate)\n")
+	fmt.Fprintf(os.Stderr, "  list        List configured tests\n")
+	fmt.Fprintf(os.Stderr, "  schedule    Preview when tests fire, with jitter ranges and load per hour\n")
+	fmt.Fprintf(os.Stderr, "  dry-run     Ask a running probe which tests would run in the next window, and why not\n")
+	fmt.Fprintf(os.Stderr, "  lint        Estimate the load of the tests and warn about schedule collisions\n")
+	fmt.Fprintf(os.Stderr, "  generate    Write download tests for existing objects from a CSV or bucket listing\n")
+	fmt.Fprintf(os.Stderr, "  export      Write the test inventory with resolved targets as JSON (or Terraform tfvars)\n")
+	fmt.Fprintf(os.Stderr, "  doctor      Check the environment (k6, curl, credentials, ports)\n")
+	fmt.Fprintf(os.Stderr, "  bench-sign  Benchmark SigV4 request signing\n")
+	fmt.Fprintf(os.Stderr, "  encrypt     Encrypt a secret from stdin into an ENC[...] config value\n")
+	fmt.Fprintf(os.Stderr, "  version     Print version information\n")
+	fmt.Fprintf(os.Stderr, "\nThe config is read from CONFIG_PATH (default: %s) unless --config is given.\n", defaultConfigPath)
+}
+
+// defaultConfigPath is used when CONFIG_PATH is not set
+const defaultConfigPath = "configs/config.yaml"
+
+// configPathFromEnv returns CONFIG_PATH or the default path
+func configPathFromEnv() string {
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		return path
 	}
+	return defaultConfigPath
+}
+
+// logLoad logs the estimated load of the config's tests and any schedule
+// collisions
+func logLoad(cfg *config.Config) {
+	report := cfg.Lint(config.DefaultMaxTestsPerMinute)
+	log.Printf("Estimated load: %.1f runs/hour, %.0f requests/hour, %s/hour",
+		report.RunsPerHour, report.RequestsPerHour, formatBytes(report.BytesPerHour))
+	for _, warning := range report.Warnings() {
+		log.Printf("Warning: %s", warning)
+	}
+}
+
+// loadConfig loads a local or remote config. The source is nil for local files.
+func loadConfig(path string) (*config.Config, config.Source, error) {
+	if !config.IsRemote(path) {
+		cfg, err := config.Load(path)
+		return cfg, nil, err
+	}
+	src, err := config.NewSource(context.Background(), path, configPollInterval())
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := config.LoadSource(context.Background(), src)
+	return cfg, src, err
+}
 
-	cfg, err := config.Load(configPath)
+// serve runs the scheduler and HTTP server until interrupted
+func serve() {
+	// Load configuration
+	configPath := configPathFromEnv()
+	cfg, remote, err := loadConfig(configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Initialize logging level from config
-	logging.SetLevel(cfg.Logging.Level)
+	// Initialize logging level and format from config
+	logging.Setup(cfg.Logging.Level, cfg.Logging.Format)
 
-	log.Printf("Starting Storj Synthetics Monitor")
-	log.Printf("Config: bucket=%s, tests=%d",
-		cfg.Satellite.Bucket, len(cfg.Tests))
+	buildInfo := version.Get()
+	log.Printf("Starting Storj Synthetics Monitor %s (commit %s, profile %s)", buildInfo.Version, buildInfo.Commit, cfg.GetProfile())
+	metrics.RegisterBuildInfo(buildInfo, cfg.GetProfile())
+
+	switch cfg.Mode {
+	case config.ModeAggregator:
+		runAggregator(cfg)
+		return
+	case config.ModeStandalone, config.ModeAgent:
+	default:
+		log.Fatalf("Unknown mode %q (expected standalone, agent, or aggregator)", cfg.Mode)
+	}
+
+	log.Printf("Config: mode=%s, bucket=%s, tests=%d",
+		cfg.Mode, cfg.Satellite.Bucket, len(cfg.Tests))
+	logLoad(cfg)
+
+	// All run files go under the work directory
+	if err := workdir.Init(cfg.WorkDir.Path, cfg.WorkDir.MaxSize.Int64()); err != nil {
+		log.Fatalf("Failed to set up work directory: %v", err)
+	}
+	log.Printf("Work directory: %s", workdir.Dir())
+	if err := subproc.Configure(cfg.Subprocess); err != nil {
+		log.Fatalf("Failed to configure subprocess limits: %v", err)
+	}
 
 	// Generate test data files for all configured tests
 	if err := testdata.EnsureTestDataFiles(cfg); err != nil {
 		log.Printf("Warning: failed to ensure test data files: %v", err)
 	}
+	testdata.RemoveStale(cfg)
 
 	// Initialize metrics collector
 	metricsCollector := metrics.NewCollector()
+	registerTests(metricsCollector, cfg)
+	metricsCollector.SetAvailabilityHalfLife(cfg.Metrics.AvailabilityHalfLifeDuration())
+	metricsCollector.SetMaxSeries(cfg.Metrics.MaxSeries)
+	metricsCollector.RecordConfigReload(config.ReloadSuccess)
+	metrics.RegisterProbeMetrics(workdir.DataDir(), workdir.TempDir())
+	if cfg.Metrics.SnapshotFile != "" {
+		if err := metricsCollector.RestoreSnapshot(cfg.Metrics.SnapshotFile); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	var comparison *metrics.ShadowComparison
+	if cfg.Shadow.Config != "" {
+		comparison = metrics.NewShadowComparison(cfg.Shadow.WindowDuration())
+		prometheus.MustRegister(comparison)
+		metricsCollector.CompareWith(comparison, false)
+	}
 	log.Printf("Initialized metrics collector")
 
 	// Initialize executors
-	executors := make(map[string]executor.TestExecutor)
+	executors := buildExecutors(cfg, metricsCollector)
 
-	// Uplink executor (k6 + xk6-storj)
-	uplinkExec := executor.NewUplink(cfg, metricsCollector)
-	executors["uplink"] = uplinkExec
-	log.Printf("Initialized Uplink executor")
-
-	// S3 executor (AWS SDK)
-	if cfg.S3.Endpoint != "" && cfg.S3.AccessKey != "" {
-		s3Exec, err := executor.NewS3(cfg, metricsCollector)
-		if err != nil {
-			log.Printf("Warning: Failed to initialize S3 executor: %v", err)
-		} else {
-			executors["s3"] = s3Exec
-			log.Printf("Initialized S3 executor (endpoint: %s)", cfg.S3.Endpoint)
-		}
-	} else {
-		log.Printf("S3 executor disabled (no credentials configured)")
+	// Scheduler event log (optionally persisted across restarts)
+	events, err := scheduler.NewEventLog(cfg.Scheduler.Events.Size, cfg.Scheduler.Events.File)
+	if err != nil {
+		log.Fatalf("Failed to open event log: %v", err)
 	}
+	defer events.Close()
 
-	// HTTP S3 executor (standard library only, no AWS SDK)
-	if cfg.S3.Endpoint != "" && cfg.S3.AccessKey != "" {
-		httpS3Exec, err := executor.NewHttpS3(cfg, metricsCollector)
-		if err != nil {
-			log.Printf("Warning: Failed to initialize HTTP S3 executor: %v", err)
-		} else {
-			executors["http-s3"] = httpS3Exec
-			log.Printf("Initialized HTTP S3 executor (endpoint: %s)", cfg.S3.Endpoint)
-		}
+	// Per-test last-run state (optionally persisted across restarts)
+	state, err := scheduler.NewStateStore(cfg.Scheduler.StateFile)
+	if err != nil {
+		log.Fatalf("Failed to load run state: %v", err)
 	}
 
-	// Curl S3 executor (uses curl subprocess)
-	if cfg.S3.Endpoint != "" && cfg.S3.AccessKey != "" {
-		curlS3Exec, err := executor.NewCurlS3(cfg, metricsCollector)
+	// Every run and step, for lookup after the fact
+	var store *results.Store
+	if cfg.Results.Path != "" {
+		store, err = results.Open(cfg.Results.Path)
 		if err != nil {
-			log.Printf("Warning: Failed to initialize Curl S3 executor: %v", err)
-		} else {
-			executors["curl-s3"] = curlS3Exec
-			log.Printf("Initialized Curl S3 executor (endpoint: %s)", cfg.S3.Endpoint)
+			log.Fatalf("Failed to open results store: %v", err)
 		}
+		defer store.Close()
+		log.Printf("Recording results to %s (kept %v)", cfg.Results.Path, cfg.Results.RetentionDuration())
 	}
 
+	// Network path traces (periodic and after test failures)
+	tracer := netpath.New(cfg, metricsCollector)
+
+	// Runs far slower or faster than their test's baseline
+	detector := anomaly.New(cfg, metricsCollector)
+
 	// Initialize and start scheduler
-	sched := scheduler.New(cfg, executors)
+	sched := scheduler.New(cfg, executors, metricsCollector, events, state, tracer).WithResults(store).WithAnomaly(detector)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	go tracer.Run(ctx)
+	if store != nil {
+		go store.RunPrune(ctx, cfg.Results.RetentionDuration())
+	}
+	go metricsCollector.RunExpiry(ctx)
+	if cfg.Metrics.SnapshotFile != "" {
+		// Deferred before the scheduler stops, so it runs after: the
+		// snapshot includes the runs that finish during shutdown
+		defer func() {
+			if err := metricsCollector.SaveSnapshot(cfg.Metrics.SnapshotFile); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}()
+	}
+	go workdir.RunCleanup(ctx, cfg.WorkDir.CleanupAfterDuration())
+	go subproc.RunReaper(ctx, cfg.Subprocess.ReapIntervalDuration(), executor.K6Pattern(cfg.K6.BinaryPath), func(n int) {
+		metricsCollector.RecordOrphansKilled("k6", n)
+	})
 
 	if err := sched.Start(ctx); err != nil {
 		log.Fatalf("Failed to start scheduler: %v", err)
 	}
 	defer sched.Stop()
 
+	// Readiness: the scheduler, and optionally the endpoints, can run tests
+	readyChecks := []readyCheck{sched.Ready}
+	if cfg.Readiness.Connectivity {
+		conn := newConnectivity()
+		go conn.run(ctx, connectivityAddrs(cfg), cfg.Readiness.TimeoutDuration())
+		readyChecks = append(readyChecks, conn.check)
+	}
+
+	// Apply config changes without a restart: a remote config as it changes,
+	// a local file on SIGHUP or when it is written
+	source := remote
+	if source == nil {
+		source = config.NewFileSource(configPath)
+	}
+	current := cfg
+	go source.Watch(ctx, func(newCfg *config.Config) {
+		if err := testdata.EnsureTestDataFiles(newCfg); err != nil {
+			log.Printf("Warning: failed to ensure test data files: %v", err)
+		}
+		var newExecutors map[string]executor.TestExecutor
+		if executorSettingsChanged(current, newCfg) {
+			log.Printf("Executor settings changed, reinitializing executors")
+			newExecutors = buildExecutors(newCfg, metricsCollector)
+		}
+		registerTests(metricsCollector, newCfg)
+		if err := sched.Reload(newCfg, newExecutors); err != nil {
+			log.Printf("Warning: failed to apply config: %v", err)
+			metricsCollector.RecordConfigReload(config.ReloadApplyError)
+			return
+		}
+		current = newCfg
+		metricsCollector.RecordConfigReload(config.ReloadSuccess)
+		logLoad(newCfg)
+	}, func(status string, err error) {
+		metricsCollector.RecordConfigReload(status)
+	})
+
+	// Run the tests of a candidate config alongside, with their series
+	// labeled shadow="true". Tenants' series are labeled with the tenant.
+	var gatherer prometheus.Gatherer = metricsCollector.WithTenants(prometheus.DefaultGatherer)
+	if cfg.Shadow.Config != "" {
+		shadowSched, shadowGatherer, err := startShadow(ctx, cfg.Shadow.Config, comparison)
+		if err != nil {
+			log.Fatalf("Failed to start shadow config: %v", err)
+		}
+		defer shadowSched.Stop()
+		gatherer = prometheus.Gatherers{gatherer, shadowGatherer}
+	}
+
+	// Push results to the aggregator in agent mode
+	pushDone := make(chan struct{})
+	if cfg.Mode == config.ModeAgent {
+		pusher, err := fleet.NewPusher(cfg.Agent, gatherer)
+		if err != nil {
+			log.Fatalf("Failed to start agent: %v", err)
+		}
+		pusher.WithRuns(func(since time.Time) []fleet.Run { return finishedRuns(sched, since) })
+		go func() {
+			pusher.Run(ctx)
+			close(pushDone)
+		}()
+	} else {
+		close(pushDone)
+	}
+
 	// Set up HTTP server
 	mux := http.NewServeMux()
 
 	// Metrics endpoint for Prometheus
-	mux.Handle(cfg.Metrics.Path, promhttp.Handler())
+	mux.Handle(cfg.Metrics.Path, promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})))
 
 	// Health check endpoint
 	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/ready", readyHandler(readyChecks...))
+	mux.HandleFunc("GET /version", versionHandler)
+
+	// Admin API
+	api.New(ctx, sched).WithShadow(comparison).WithResults(store).Register(mux)
 
 	// Root handler with info
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -119,6 +343,18 @@ func main() {
 		fmt.Fprintf(w, "Endpoints:\n")
 		fmt.Fprintf(w, "  %s - Prometheus metrics\n", cfg.Metrics.Path)
 		fmt.Fprintf(w, "  /health - Health check\n")
+		fmt.Fprintf(w, "  /ready - Readiness check (503 until tests can run)\n")
+		fmt.Fprintf(w, "  /version - Build information\n")
+		fmt.Fprintf(w, "  /status - Endpoint and satellite status (JSON, or HTML with ?format=html)\n")
+		fmt.Fprintf(w, "  /api/v1/tags - Test groups (POST /api/v1/tags/{tag}/enable|disable|run, with api.token)\n")
+		fmt.Fprintf(w, "  /api/v1/tests/{name}/run - Run a test now and return its result (POST, with api.token)\n")
+		fmt.Fprintf(w, "  /api/v1/webhook/{tag} - Signed run trigger for CI and deploy pipelines (POST)\n")
+		fmt.Fprintf(w, "  /api/v1/verify/{tag} - Verify a test group against thresholds (POST with api.token; poll GET /api/v1/verify/{id})\n")
+		fmt.Fprintf(w, "  /api/config - Effective configuration (secrets redacted)\n")
+		fmt.Fprintf(w, "  /api/events - Scheduler events (?test=, type=, since=, limit=)\n")
+		fmt.Fprintf(w, "  /api/traces - Network path traces (?target=, limit=)\n")
+		fmt.Fprintf(w, "  /api/v1/shadow - Shadow vs active test comparison (with shadow.config)\n")
+		fmt.Fprintf(w, "  /api/v1/results - Stored runs with their steps (?test=, failed=, since=, until=, limit=; with results.path)\n")
 	})
 
 	server := &http.Server{
@@ -129,6 +365,206 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	serveUntilSignal(server)
+
+	// Stop the scheduler context and let the agent make its final push
+	cancel()
+	<-pushDone
+
+	log.Println("Shutdown complete")
+}
+
+// buildExecutors creates every executor the config supports. S3-based
+// executors are only created when S3 credentials are configured.
+func buildExecutors(cfg *config.Config, metricsCollector *metrics.Collector) map[string]executor.TestExecutor {
+	executors := make(map[string]executor.TestExecutor)
+
+	// Uplink executor (k6 + xk6-storj)
+	uplinkExec := executor.NewUplink(cfg, metricsCollector)
+	executors["uplink"] = uplinkExec
+	log.Printf("Initialized Uplink executor")
+
+	// S3-based executors for the s3 section, then for each named gateway
+	buildGatewayExecutors(executors, cfg, "", metricsCollector)
+	for _, gw := range cfg.S3Gateways {
+		gwCfg := *cfg
+		gwCfg.S3, _ = cfg.GetGateway(gw.Name)
+		buildGatewayExecutors(executors, &gwCfg, "@"+gw.Name, metricsCollector)
+	}
+
+	// Compare executor (http-s3 against multiple endpoints)
+	executors["compare"] = executor.NewCompare(cfg, metricsCollector)
+
+	// RTT executor (TCP connect or ping baseline to gateway and satellite)
+	executors["rtt"] = executor.NewRTT(cfg, metricsCollector)
+
+	// Bakeoff executor (the same steps through each executor in turn), last
+	// so it sees the others. One instance serves every gateway, since it
+	// looks its executors up by the test's gateway.
+	bakeoff := executor.NewBakeoff(cfg, metricsCollector, maps.Clone(executors))
+	executors["bakeoff"] = bakeoff
+	for _, gw := range cfg.S3Gateways {
+		executors["bakeoff@"+gw.Name] = bakeoff
+	}
+
+	return executors
+}
+
+// buildGatewayExecutors creates the executors that run against cfg.S3,
+// keyed by executor type plus suffix (see config.Test.ExecutorKey). All but
+// the canary and ttfb executors are only created when S3 credentials are
+// configured.
+func buildGatewayExecutors(executors map[string]executor.TestExecutor, cfg *config.Config, suffix string, metricsCollector *metrics.Collector) {
+	// Canary executor (long-lived objects verified for durability)
+	executors["canary"+suffix] = executor.NewCanary(cfg, metricsCollector)
+
+	// TTFB executor (first-byte SLA of a small hot object)
+	executors["ttfb"+suffix] = executor.NewTTFB(cfg, metricsCollector)
+
+	gateway := cfg.S3.Endpoint
+	if suffix != "" {
+		gateway = cfg.S3.Name + ": " + gateway
+	}
+	if cfg.S3.Endpoint == "" || cfg.S3.AccessKey == "" {
+		if suffix == "" {
+			log.Printf("S3 executor disabled (no credentials configured)")
+		} else {
+			log.Printf("S3 executors of gateway %s disabled (no credentials configured)", cfg.S3.Name)
+		}
+		return
+	}
+
+	// S3 executor (AWS SDK)
+	s3Exec, err := executor.NewS3(cfg, metricsCollector)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize S3 executor (%s): %v", gateway, err)
+	} else {
+		executors["s3"+suffix] = s3Exec
+		log.Printf("Initialized S3 executor (endpoint: %s)", gateway)
+	}
+
+	// HTTP S3 executor (standard library only, no AWS SDK)
+	httpS3Exec, err := executor.NewHttpS3(cfg, metricsCollector)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize HTTP S3 executor (%s): %v", gateway, err)
+	} else {
+		executors["http-s3"+suffix] = httpS3Exec
+		log.Printf("Initialized HTTP S3 executor (endpoint: %s)", gateway)
+	}
+
+	// Curl S3 executor (uses curl subprocess)
+	curlS3Exec, err := executor.NewCurlS3(cfg, metricsCollector)
+	if err != nil {
+		log.Printf("Warning: Failed to initialize Curl S3 executor (%s): %v", gateway, err)
+	} else {
+		executors["curl-s3"+suffix] = curlS3Exec
+		log.Printf("Initialized Curl S3 executor (endpoint: %s)", gateway)
+	}
+}
+
+// executorSettingsChanged reports whether the settings executors are built
+// from differ between two configs
+func executorSettingsChanged(old, cfg *config.Config) bool {
+	return !reflect.DeepEqual(old.S3, cfg.S3) ||
+		!reflect.DeepEqual(old.S3Gateways, cfg.S3Gateways) ||
+		!reflect.DeepEqual(old.Satellite, cfg.Satellite) ||
+		!reflect.DeepEqual(old.Satellites, cfg.Satellites) ||
+		!reflect.DeepEqual(old.K6, cfg.K6) ||
+		!reflect.DeepEqual(old.Payload, cfg.Payload) ||
+		old.UserAgent != cfg.UserAgent
+}
+
+// registerTests registers each test's tenant, tags, and metric verbosity with
+// the collector
+func registerTests(mc *metrics.Collector, cfg *config.Config) {
+	for _, test := range cfg.Tests {
+		verbosity, _ := metrics.ParseVerbosity(test.Metrics) // Validated by config.Parse
+		var staleAfter time.Duration
+		if cfg.Metrics.StaleIntervals > 0 {
+			interval, _ := config.ParseCronInterval(test.Schedule)
+			staleAfter = time.Duration(cfg.Metrics.StaleIntervals) * interval
+		}
+		mc.RegisterTest(test.Name, test.Tenant, test.Tags, verbosity, staleAfter)
+	}
+}
+
+// configPollInterval returns CONFIG_POLL_INTERVAL (default 1m)
+func configPollInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("CONFIG_POLL_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// finishedRuns returns the runs the scheduler finished since the given time,
+// from its completed and failed events, for the aggregator's run history
+func finishedRuns(sched *scheduler.Scheduler, since time.Time) []fleet.Run {
+	var runs []fleet.Run
+	for _, e := range sched.Events(scheduler.EventFilter{Since: since}) {
+		if e.Type != scheduler.EventCompleted && e.Type != scheduler.EventFailed {
+			continue
+		}
+		runs = append(runs, fleet.Run{
+			Test:            e.Test,
+			RunID:           e.RunID,
+			Time:            e.Time,
+			Success:         e.Type == scheduler.EventCompleted,
+			DurationSeconds: e.DurationSeconds,
+			Trigger:         e.Detail,
+			Error:           e.Error,
+		})
+	}
+	return runs
+}
+
+// runAggregator serves merged metrics pushed by agents. No tests run locally.
+func runAggregator(cfg *config.Config) {
+	agg := fleet.NewAggregator(cfg.Aggregator.Token, cfg.Aggregator.StaleAfterDuration())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agg.RunEviction(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Metrics.Path, promhttp.HandlerFor(
+		prometheus.Gatherers{prometheus.DefaultGatherer, agg},
+		promhttp.HandlerOpts{},
+	))
+	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/ready", readyHandler()) // Ready once serving
+	mux.HandleFunc("GET /version", versionHandler)
+	agg.Register(mux)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "Storj Synthetics Aggregator\n\n")
+		fmt.Fprintf(w, "Endpoints:\n")
+		fmt.Fprintf(w, "  %s - Prometheus metrics (all probes)\n", cfg.Metrics.Path)
+		fmt.Fprintf(w, "  /health - Health check\n")
+		fmt.Fprintf(w, "  /ready - Readiness check\n")
+		fmt.Fprintf(w, "  /version - Build information\n")
+		fmt.Fprintf(w, "  /api/v1/probes - Connected probes\n")
+		fmt.Fprintf(w, "  /api/v1/runs - Run history of all probes (?probe=, test=, failed=, since=, limit=)\n")
+		fmt.Fprintf(w, "  /dashboard - Probes and the latest run of each of their tests (HTML)\n")
+	})
+
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Metrics.Port),
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	log.Printf("Running in aggregator mode")
+	serveUntilSignal(server)
+	log.Println("Shutdown complete")
+}
+
+// serveUntilSignal runs the HTTP server until SIGINT/SIGTERM, then shuts it down gracefully
+func serveUntilSignal(server *http.Server) {
 	// Start HTTP server in a goroutine
 	go func() {
 		log.Printf("Starting HTTP server on %s", server.Addr)
@@ -151,8 +587,13 @@ func main() {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Printf("HTTP server shutdown error: %v", err)
 	}
+}
 
-	log.Println("Shutdown complete")
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(version.Get()); err != nil {
+		log.Printf("Failed to write version response: %v", err)
+	}
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/executor"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/subproc"
+	"github.com/ethanadams/synthetics/internal/testdata"
+	"github.com/ethanadams/synthetics/internal/workdir"
+)
+
+// Outcomes of a test in a one-shot run
+const (
+	onceSkipped = "skipped"
+	oncePassed  = "passed"
+	onceWarning = "warning" // Passed, but slower than exit_policy.slow_after
+	onceFailed  = "failed"
+)
+
+// onceTest is the outcome of one test in a one-shot run
+type onceTest struct {
+	Name       string         `json:"name"`
+	Status     string         `json:"status"`
+	Ignored    bool           `json:"ignored,omitempty"` // Failed, but exit_policy ignores it
+	SkipReason string         `json:"skip_reason,omitempty"`
+	Warning    string         `json:"warning,omitempty"`
+	Result     *result.Result `json:"result,omitempty"` // Nil if skipped
+}
+
+// onceReport is the outcome of a one-shot run
+type onceReport struct {
+	Start           time.Time  `json:"start"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Passed          int        `json:"passed"`
+	Warnings        int        `json:"warnings"`
+	Failed          int        `json:"failed"`  // Including ignored failures
+	Ignored         int        `json:"ignored"` // Failures that don't affect the exit code
+	Skipped         int        `json:"skipped"`
+	ExitCode        int        `json:"exit_code"`
+	Tests           []onceTest `json:"tests"`
+}
+
+// onceCommand runs every enabled test (or those with --tag) once, one after
+// another, for CI smoke runs. Logs go to stderr and the report to stdout.
+// Returns 0 if all ran tests passed, 1 if any failed (unless exit_policy
+// ignores them), exit_policy.warning_exit_code if some were slow, and 2 on
+// usage or setup errors.
+func onceCommand(args []string) int {
+	fs := flag.NewFlagSet("--once", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	output := fs.String("output", "text", "Report format: text, json, or junit")
+	tag := fs.String("tag", "", "Only run tests with this tag")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics --once [--output text|json|junit] [--tag TAG] [--config PATH]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	var write func(io.Writer, *onceReport) error
+	switch *output {
+	case "text":
+		write = writeOnceText
+	case "json":
+		write = writeOnceJSON
+	case "junit":
+		write = writeOnceJUnit
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format %q (expected text, json, or junit)\n", *output)
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+	logging.SetLevel(cfg.Logging.Level)
+
+	selected := *cfg
+	selected.Tests = nil
+	for _, test := range cfg.Tests {
+		if test.Enabled && (*tag == "" || test.HasTag(*tag)) {
+			selected.Tests = append(selected.Tests, test)
+		}
+	}
+	if len(selected.Tests) == 0 {
+		fmt.Fprintf(os.Stderr, "No enabled tests to run\n")
+		return 2
+	}
+	if err := workdir.Init(cfg.WorkDir.Path, cfg.WorkDir.MaxSize.Int64()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up work directory: %v\n", err)
+		return 2
+	}
+	if err := subproc.Configure(cfg.Subprocess); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	if err := testdata.EnsureTestDataFiles(&selected); err != nil {
+		log.Printf("Warning: failed to ensure test data files: %v", err)
+	}
+
+	metricsCollector := metrics.NewCollector()
+	registerTests(metricsCollector, &selected)
+	executors := buildExecutors(cfg, metricsCollector)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	report := &onceReport{Start: time.Now(), Tests: []onceTest{}}
+	for i := range selected.Tests {
+		if ctx.Err() != nil {
+			break
+		}
+		test := &selected.Tests[i]
+		report.add(applyExitPolicy(&cfg.ExitPolicy, test, runOnce(ctx, executors, test, cfg.S3.Endpoint, metricsCollector)))
+	}
+	report.DurationSeconds = time.Since(report.Start).Seconds()
+	switch {
+	case report.Failed > report.Ignored:
+		report.ExitCode = 1
+	case report.Warnings > 0:
+		report.ExitCode = cfg.ExitPolicy.WarningExitCode
+	}
+
+	if err := write(os.Stdout, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 2
+	}
+	return report.ExitCode
+}
+
+// applyExitPolicy marks ignored failures and slow passes
+func applyExitPolicy(policy *config.ExitPolicy, test *config.Test, t onceTest) onceTest {
+	switch t.Status {
+	case onceFailed:
+		t.Ignored = policy.Ignores(test)
+	case oncePassed:
+		if slow := policy.SlowAfterDuration(); slow > 0 && t.Result.Duration() > slow {
+			t.Status = onceWarning
+			t.Warning = fmt.Sprintf("took %.2fs, over slow_after %v", t.Result.DurationSeconds, slow)
+		}
+	}
+	return t
+}
+
+// runOnce runs a single test of a one-shot run
+func runOnce(ctx context.Context, executors map[string]executor.TestExecutor, test *config.Test, endpoint string, mc *metrics.Collector) onceTest {
+	exec, ok := executors[test.ExecutorKey()]
+	if !ok {
+		return onceTest{Name: test.Name, Status: onceSkipped, SkipReason: fmt.Sprintf("executor %s is not available", test.ExecutorKey())}
+	}
+	applicable, err := test.Applicable(time.Now(), endpoint)
+	if err != nil {
+		return onceTest{Name: test.Name, Status: onceSkipped, SkipReason: err.Error()}
+	}
+
+	res, err := exec.RunTest(ctx, applicable)
+	mc.RecordResult(res)
+	if err != nil {
+		log.Printf("FAIL %s (%s) in %.2fs: %v", test.Name, res.Executor, res.DurationSeconds, err)
+		return onceTest{Name: test.Name, Status: onceFailed, Result: res}
+	}
+	log.Printf("PASS %s (%s) in %.2fs", test.Name, res.Executor, res.DurationSeconds)
+	return onceTest{Name: test.Name, Status: oncePassed, Result: res}
+}
+
+func (r *onceReport) add(t onceTest) {
+	switch t.Status {
+	case oncePassed:
+		r.Passed++
+	case onceWarning:
+		r.Warnings++
+	case onceFailed:
+		r.Failed++
+		if t.Ignored {
+			r.Ignored++
+		}
+	case onceSkipped:
+		r.Skipped++
+	}
+	r.Tests = append(r.Tests, t)
+}
+
+func writeOnceText(w io.Writer, r *onceReport) error {
+	for _, t := range r.Tests {
+		switch t.Status {
+		case onceSkipped:
+			fmt.Fprintf(w, "SKIP %s: %s\n", t.Name, t.SkipReason)
+		case onceFailed:
+			label := "FAIL"
+			if t.Ignored {
+				label = "FAIL (ignored)"
+			}
+			fmt.Fprintf(w, "%s %s (%s) in %.2fs: %s\n", label, t.Name, t.Result.Executor, t.Result.DurationSeconds, t.Result.Error)
+		case onceWarning:
+			fmt.Fprintf(w, "WARN %s (%s): %s\n", t.Name, t.Result.Executor, t.Warning)
+		default:
+			fmt.Fprintf(w, "PASS %s (%s) in %.2fs\n", t.Name, t.Result.Executor, t.Result.DurationSeconds)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d passed, %d warnings, %d failed (%d ignored), %d skipped in %.2fs\n",
+		r.Passed, r.Warnings, r.Failed, r.Ignored, r.Skipped, r.DurationSeconds)
+	return err
+}
+
+func writeOnceJSON(w io.Writer, r *onceReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// JUnit XML report, in the subset CI systems (Jenkins, GitLab, GitHub
+// Actions reporters) render: one test case per test, classed by executor
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func writeOnceJUnit(w io.Writer, r *onceReport) error {
+	suite := junitSuite{
+		Name:      "synthetics",
+		Tests:     len(r.Tests),
+		Failures:  r.Failed,
+		Skipped:   r.Skipped,
+		Time:      junitSeconds(r.DurationSeconds),
+		Timestamp: r.Start.UTC().Format(time.RFC3339),
+	}
+	for _, t := range r.Tests {
+		c := junitCase{Name: t.Name, Classname: "synthetics", Time: junitSeconds(0)}
+		if t.Result != nil {
+			c.Classname += "." + t.Result.Executor
+			c.Time = junitSeconds(t.Result.DurationSeconds)
+			c.SystemOut = stepSummary(t.Result)
+		}
+		switch t.Status {
+		case onceSkipped:
+			c.Skipped = &junitMessage{Message: t.SkipReason}
+		case onceFailed:
+			c.Failure = &junitMessage{Message: t.Result.Error, Type: t.Result.ErrorClass, Text: t.Result.FailedStep}
+			if t.Ignored {
+				c.SystemOut = "Failure ignored by exit_policy\n" + c.SystemOut
+			}
+		case onceWarning:
+			c.SystemOut = "WARNING: " + t.Warning + "\n" + c.SystemOut
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+// stepSummary lists a run's steps, one per line
+func stepSummary(res *result.Result) string {
+	var b strings.Builder
+	for _, step := range res.Steps {
+		status := "ok"
+		if !step.Success {
+			status = "FAILED (" + step.ErrorClass + "): " + step.Error
+		}
+		fmt.Fprintf(&b, "%s %.3fs %s\n", step.Name, step.DurationSeconds, status)
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/netpath"
+)
+
+// connectivityRetry is how long the connectivity check waits between attempts
+const connectivityRetry = 10 * time.Second
+
+// readyCheck returns an error while the probe isn't ready to run tests
+type readyCheck func() error
+
+// readyHandler serves /ready: 200 once every check passes, 503 with the
+// failing checks until then
+func readyHandler(checks ...readyCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var failed []string
+		for _, check := range checks {
+			if err := check(); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		if len(failed) > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "Not ready:\n  %s\n", strings.Join(failed, "\n  "))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Ready\n")
+	}
+}
+
+// connectivity checks, once at startup, that every configured S3 endpoint
+// and satellite accepts TCP connections
+type connectivity struct {
+	mu  sync.Mutex
+	err error
+}
+
+// newConnectivity creates a connectivity check that fails until run succeeds
+func newConnectivity() *connectivity {
+	return &connectivity{err: errors.New("connectivity not checked yet")}
+}
+
+// run connects to every address until all have accepted a connection, or
+// until ctx is done. Addresses are retried every connectivityRetry; one that
+// accepted a connection isn't tried again.
+func (c *connectivity) run(ctx context.Context, addrs []string, timeout time.Duration) {
+	pending := slices.Clone(addrs)
+	for {
+		var failed []string
+		var errs []string
+		for _, addr := range pending {
+			conn, err := (&net.Dialer{Timeout: timeout}).DialContext(ctx, "tcp", addr)
+			if err != nil {
+				failed = append(failed, addr)
+				errs = append(errs, err.Error())
+				continue
+			}
+			conn.Close()
+		}
+		pending = failed
+
+		c.mu.Lock()
+		c.err = nil
+		if len(pending) > 0 {
+			c.err = fmt.Errorf("connectivity: %s", strings.Join(errs, "; "))
+		}
+		c.mu.Unlock()
+		if len(pending) == 0 {
+			log.Printf("Connectivity check passed (%d endpoints)", len(addrs))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(connectivityRetry):
+		}
+	}
+}
+
+// check returns the connectivity error, if the check hasn't passed yet
+func (c *connectivity) check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// connectivityAddrs returns the host:port of every S3 endpoint and satellite
+// of the config
+func connectivityAddrs(cfg *config.Config) []string {
+	var addrs []string
+	add := func(addr string) {
+		if addr != "" && !slices.Contains(addrs, addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	add(netpath.EndpointAddr(cfg.S3.Endpoint))
+	for _, gw := range cfg.S3Gateways {
+		add(netpath.EndpointAddr(gw.Endpoint))
+	}
+	for _, sat := range cfg.AllSatellites() {
+		add(netpath.SatelliteAddr(sat.AccessGrant))
+	}
+	return addrs
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/subproc"
+	"github.com/ethanadams/synthetics/internal/testdata"
+	"github.com/ethanadams/synthetics/internal/workdir"
+)
+
+// runTestCommand runs a single test once. Logs go to stderr; with --json the
+// result is written to stdout. Returns 0 on success, 1 on test failure, and
+// 2 on usage or setup errors.
+func runTestCommand(args []string) int {
+	fs := flag.NewFlagSet("run-test", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	executorName := fs.String("executor", "", "Override the test's executor (uplink, s3, http-s3, curl-s3, compare, rtt, canary)")
+	jsonOutput := fs.Bool("json", false, "Write the result as JSON to stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics run-test <name> [--executor X] [--json] [--config PATH]\n\n")
+		fs.PrintDefaults()
+	}
+
+	// Allow flags both before and after the test name
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	testName := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Unexpected arguments: %v\n", fs.Args())
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+	logging.SetLevel(cfg.Logging.Level)
+
+	var test *config.Test
+	for i := range cfg.Tests {
+		if cfg.Tests[i].Name == testName {
+			test = &cfg.Tests[i]
+			break
+		}
+	}
+	if test == nil {
+		fmt.Fprintf(os.Stderr, "Test not found: %s\n", testName)
+		return 2
+	}
+	if *executorName != "" {
+		test.Executor = *executorName
+	}
+	test, err = test.Applicable(time.Now(), cfg.S3.Endpoint)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "SKIP %s: %v\n", testName, err)
+		return 0
+	}
+
+	// Only generate data files for the selected test
+	single := *cfg
+	single.Tests = []config.Test{*test}
+	if err := workdir.Init(cfg.WorkDir.Path, cfg.WorkDir.MaxSize.Int64()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up work directory: %v\n", err)
+		return 2
+	}
+	if err := subproc.Configure(cfg.Subprocess); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	if err := testdata.EnsureTestDataFiles(&single); err != nil {
+		log.Printf("Warning: failed to ensure test data files: %v", err)
+	}
+
+	metricsCollector := metrics.NewCollector()
+	registerTests(metricsCollector, &single)
+	executors := buildExecutors(cfg, metricsCollector)
+
+	exec, ok := executors[test.ExecutorKey()]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Executor %q is not available (check the s3 configuration)\n", test.ExecutorKey())
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	result, runErr := exec.RunTest(ctx, test)
+	metricsCollector.RecordResult(result)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write result: %v\n", err)
+		}
+	} else if runErr != nil {
+		fmt.Fprintf(os.Stderr, "FAIL %s (%s) in %.2fs: %v\n", result.Test, result.Executor, result.DurationSeconds, runErr)
+	} else {
+		fmt.Fprintf(os.Stderr, "PASS %s (%s) in %.2fs\n", result.Test, result.Executor, result.DurationSeconds)
+	}
+
+	if runErr != nil {
+		return 1
+	}
+	return 0
+}
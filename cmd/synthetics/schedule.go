@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/robfig/cron/v3"
+)
+
+// scheduleRun is one expected firing of a test: it starts between Earliest
+// and Latest, depending on the jitter drawn
+type scheduleRun struct {
+	Earliest time.Time `json:"earliest"`
+	Latest   time.Time `json:"latest"`
+}
+
+// scheduleTest is the expected firings of one test in the preview window
+type scheduleTest struct {
+	Name             string        `json:"name"`
+	Schedule         string        `json:"schedule"`
+	MaxJitterSeconds float64       `json:"max_jitter_seconds"`
+	SkippedReason    string        `json:"skipped_reason,omitempty"` // Why the test never fires
+	Runs             []scheduleRun `json:"runs"`
+}
+
+// scheduleBucket counts the runs that may start in a time bucket
+type scheduleBucket struct {
+	Start time.Time `json:"start"`
+	Runs  int       `json:"runs"`
+}
+
+// schedulePreview is the output of the schedule command
+type schedulePreview struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Tests   []scheduleTest   `json:"tests"`
+	Buckets []scheduleBucket `json:"buckets"`
+}
+
+// scheduleCommand prints when each enabled test is expected to fire in a
+// window, with the range its jitter may delay each run by, and how many runs
+// start per bucket, to check how a config spreads load before deploying it.
+// when.days conditions are applied; other conditions depend on the probe and
+// are not.
+func scheduleCommand(args []string) int {
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	from := fs.String("from", "now", `Start of the window: "now" or an RFC 3339 time`)
+	window := fs.Duration("for", 24*time.Hour, "Length of the window")
+	bucket := fs.Duration("bucket", time.Hour, "Bucket size of the load summary")
+	jsonOutput := fs.Bool("json", false, "Write the preview as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics schedule [--from now|TIME] [--for 24h] [--bucket 1h] [--json] [--config PATH]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	start := time.Now()
+	if *from != "now" {
+		t, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --from %q: expected \"now\" or an RFC 3339 time\n", *from)
+			return 2
+		}
+		start = t
+	}
+	if *window <= 0 || *bucket <= 0 {
+		fmt.Fprintf(os.Stderr, "--for and --bucket must be positive\n")
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+
+	preview := previewSchedule(cfg, start, start.Add(*window), *bucket)
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(preview); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write schedule: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := printSchedule(preview, *bucket); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// previewSchedule computes the firings of the enabled tests between from and
+// to, and the runs that may start in each bucket
+func previewSchedule(cfg *config.Config, from, to time.Time, bucket time.Duration) schedulePreview {
+	preview := schedulePreview{From: from, To: to, Tests: []scheduleTest{}}
+	counts := make([]int, int((to.Sub(from)+bucket-1)/bucket))
+	for _, test := range cfg.Tests {
+		if !test.Enabled {
+			continue
+		}
+		st := scheduleTest{Name: test.Name, Schedule: test.Schedule, Runs: []scheduleRun{}}
+		sched, err := cron.ParseStandard(test.Schedule)
+		if err != nil {
+			st.SkippedReason = "invalid schedule"
+			preview.Tests = append(preview.Tests, st)
+			continue
+		}
+		if i := slices.IndexFunc(cfg.DisabledTags, test.HasTag); i >= 0 {
+			st.SkippedReason = "tag " + cfg.DisabledTags[i] + " is disabled"
+			preview.Tests = append(preview.Tests, st)
+			continue
+		}
+
+		// The same jitter the scheduler computes for the test
+		var maxJitter time.Duration
+		if jitter := test.GetTestJitter(cfg.Jitter); jitter.IsEnabled() {
+			interval, _ := config.ParseCronInterval(test.Schedule)
+			maxJitter, _ = jitter.ParseMaxJitter(interval)
+		}
+		st.MaxJitterSeconds = maxJitter.Seconds()
+
+		// A cron schedule can fire at from itself; @every schedules first fire
+		// one interval after the probe starts, taken to be from
+		first := from
+		if _, ok := sched.(cron.ConstantDelaySchedule); !ok {
+			first = from.Add(-time.Nanosecond)
+		}
+		for t := sched.Next(first); !t.IsZero() && t.Before(to); t = sched.Next(t) {
+			if !test.When.RunsOn(t.Weekday()) {
+				continue
+			}
+			st.Runs = append(st.Runs, scheduleRun{Earliest: t, Latest: t.Add(maxJitter)})
+			// A run counts in every bucket its jitter window reaches
+			first := int(t.Sub(from) / bucket)
+			last := min(int(t.Add(maxJitter).Sub(from)/bucket), len(counts)-1)
+			for i := first; i <= last; i++ {
+				counts[i]++
+			}
+		}
+		if len(st.Runs) == 0 {
+			st.SkippedReason = "no runs in the window"
+		}
+		preview.Tests = append(preview.Tests, st)
+	}
+	for i, n := range counts {
+		preview.Buckets = append(preview.Buckets, scheduleBucket{Start: from.Add(time.Duration(i) * bucket), Runs: n})
+	}
+	return preview
+}
+
+// printSchedule writes the preview as text: each test's firings, then the
+// load summary with a bar per bucket
+func printSchedule(preview schedulePreview, bucket time.Duration) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Schedule from %s to %s\n\n", preview.From.Format(time.RFC3339), preview.To.Format(time.RFC3339))
+	for _, st := range preview.Tests {
+		header := fmt.Sprintf("%s (%s", st.Name, st.Schedule)
+		if st.MaxJitterSeconds > 0 {
+			header += fmt.Sprintf(", jitter up to %v", time.Duration(st.MaxJitterSeconds*float64(time.Second)))
+		}
+		header += fmt.Sprintf("): %d runs", len(st.Runs))
+		if st.SkippedReason != "" {
+			header += ", " + st.SkippedReason
+		}
+		fmt.Fprintln(w, header)
+		for _, run := range st.Runs {
+			if run.Latest.Equal(run.Earliest) {
+				fmt.Fprintf(w, "  %s\n", run.Earliest.Format(time.RFC3339))
+			} else {
+				fmt.Fprintf(w, "  %s\t.. %s\n", run.Earliest.Format(time.RFC3339), run.Latest.Format(time.RFC3339))
+			}
+		}
+	}
+
+	peak := 0
+	for _, b := range preview.Buckets {
+		peak = max(peak, b.Runs)
+	}
+	fmt.Fprintf(w, "\nRuns starting per %v:\n", bucket)
+	for _, b := range preview.Buckets {
+		bar := ""
+		if peak > 0 {
+			bar = strings.Repeat("#", (b.Runs*40+peak-1)/peak)
+		}
+		fmt.Fprintf(w, "  %s\t%d\t%s\n", b.Start.Format(time.RFC3339), b.Runs, bar)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/executor"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/scheduler"
+	"github.com/ethanadams/synthetics/internal/testdata"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// startShadow loads a candidate config and schedules its tests next to the
+// active ones, on executors built from its settings. They record to their
+// own collector, served by the returned gatherer with shadow="true", and keep
+// no run state, events, or failure traces, so nothing but the shadow series
+// reacts to them. The candidate is reloaded when it changes, like the active
+// config. Their results are fed to cmp to compare them with the active tests.
+func startShadow(ctx context.Context, path string, cmp *metrics.ShadowComparison) (*scheduler.Scheduler, prometheus.Gatherer, error) {
+	cfg, remote, err := loadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := testdata.EnsureTestDataFiles(cfg); err != nil {
+		log.Printf("Warning: failed to ensure shadow test data files: %v", err)
+	}
+
+	mc, gatherer := metrics.NewShadow()
+	mc.CompareWith(cmp, true)
+	registerTests(mc, cfg)
+	mc.SetAvailabilityHalfLife(cfg.Metrics.AvailabilityHalfLifeDuration())
+	mc.SetMaxSeries(cfg.Metrics.MaxSeries)
+	state, err := scheduler.NewStateStore("")
+	if err != nil {
+		return nil, nil, err
+	}
+	sched := scheduler.New(cfg, buildExecutors(cfg, mc), mc, nil, state, nil)
+	if err := sched.Start(ctx); err != nil {
+		return nil, nil, err
+	}
+	go mc.RunExpiry(ctx)
+	log.Printf("Shadow config %s: %d tests, metrics labeled %s=\"true\"", path, len(cfg.Tests), metrics.ShadowLabel)
+
+	source := remote
+	if source == nil {
+		source = config.NewFileSource(path)
+	}
+	current := cfg
+	go source.Watch(ctx, func(newCfg *config.Config) {
+		if err := testdata.EnsureTestDataFiles(newCfg); err != nil {
+			log.Printf("Warning: failed to ensure shadow test data files: %v", err)
+		}
+		var newExecutors map[string]executor.TestExecutor
+		if executorSettingsChanged(current, newCfg) {
+			newExecutors = buildExecutors(newCfg, mc)
+		}
+		registerTests(mc, newCfg)
+		if err := sched.Reload(newCfg, newExecutors); err != nil {
+			log.Printf("Warning: failed to apply shadow config: %v", err)
+			return
+		}
+		current = newCfg
+	}, func(status string, err error) {})
+	return sched, gatherer, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/subproc"
+	"github.com/ethanadams/synthetics/internal/testdata"
+	"github.com/ethanadams/synthetics/internal/verify"
+	"github.com/ethanadams/synthetics/internal/workdir"
+)
+
+// verifyCommand runs the enabled tests with a tag several times and judges
+// them against availability and p95 thresholds, as a rollout gate. Logs go
+// to stderr and the verdict to stdout. Returns 0 if the verdict passes, 1 if
+// it fails, and 2 on usage or setup errors.
+func verifyCommand(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	configPath := fs.String("config", configPathFromEnv(), "Config file path or URL")
+	tag := fs.String("tag", "", "Tests to verify (required)")
+	runs := fs.Int("runs", verify.DefaultRuns, "Runs of each test")
+	interval := fs.Duration("interval", 0, "Pause between rounds of runs")
+	minAvailability := fs.Float64("min-availability", 100, "Minimum percent of passed runs per test")
+	maxP95 := fs.Duration("max-p95", 0, "Maximum p95 duration of passed runs per test (0 = no limit)")
+	jsonOutput := fs.Bool("json", false, "Write the verdict as JSON to stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: synthetics verify --tag TAG [--runs N] [--interval D] [--min-availability PCT] [--max-p95 D] [--json] [--config PATH]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *tag == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	opts := verify.Options{Runs: *runs, Interval: *interval, MinAvailability: *minAvailability, MaxP95: *maxP95}
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+		return 2
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 2
+	}
+	logging.SetLevel(cfg.Logging.Level)
+
+	selected := *cfg
+	selected.Tests = nil
+	for _, test := range cfg.Tests {
+		if test.Enabled && test.HasTag(*tag) {
+			selected.Tests = append(selected.Tests, test)
+		}
+	}
+	if len(selected.Tests) == 0 {
+		fmt.Fprintf(os.Stderr, "No enabled tests with tag %s\n", *tag)
+		return 2
+	}
+	if err := workdir.Init(cfg.WorkDir.Path, cfg.WorkDir.MaxSize.Int64()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up work directory: %v\n", err)
+		return 2
+	}
+	if err := subproc.Configure(cfg.Subprocess); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+	if err := testdata.EnsureTestDataFiles(&selected); err != nil {
+		log.Printf("Warning: failed to ensure test data files: %v", err)
+	}
+
+	metricsCollector := metrics.NewCollector()
+	registerTests(metricsCollector, &selected)
+	executors := buildExecutors(cfg, metricsCollector)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	verdict := verify.Run(ctx, *tag, selected.Tests, opts, func(ctx context.Context, test *config.Test) (*result.Result, error) {
+		exec, ok := executors[test.ExecutorKey()]
+		if !ok {
+			return nil, fmt.Errorf("executor %s is not available", test.ExecutorKey())
+		}
+		applicable, err := test.Applicable(time.Now(), cfg.S3.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("skipped: %w", err)
+		}
+		res, err := exec.RunTest(ctx, applicable)
+		metricsCollector.RecordResult(res)
+		return res, err
+	})
+
+	write := writeVerdictText
+	if *jsonOutput {
+		write = writeVerdictJSON
+	}
+	if err := write(os.Stdout, verdict); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write verdict: %v\n", err)
+		return 2
+	}
+	if !verdict.Pass {
+		return 1
+	}
+	return 0
+}
+
+func writeVerdictText(w io.Writer, v *verify.Verdict) error {
+	for _, t := range v.Tests {
+		status := "PASS"
+		if !t.Pass {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s %s: %d/%d runs passed (%.1f%%), p95 %.3fs", status, t.Test, t.Successes, t.Runs, t.AvailabilityPercent, t.P95Seconds)
+		if len(t.Reasons) > 0 {
+			fmt.Fprintf(w, ": %s", strings.Join(t.Reasons, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	verdict := "PASSED"
+	switch {
+	case v.Canceled:
+		verdict = "CANCELED"
+	case !v.Pass:
+		verdict = "FAILED"
+	}
+	_, err := fmt.Fprintf(w, "Verification of tag %s %s: %.1f%% of runs passed in %.2fs\n", v.Tag, verdict, v.AvailabilityPercent, v.DurationSeconds)
+	return err
+}
+
+func writeVerdictJSON(w io.Writer, v *verify.Verdict) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -4,58 +4,289 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/metrics"
 	"storj.io/uplink"
 )
 
+// reuseMetricName is the k6 counter incremented each time SharedClient hands
+// out an already open project
+const reuseMetricName = "storj_connection_reuses"
+
+// timeoutEnv names the environment variable holding the default deadline of
+// each client operation in milliseconds. The synthetics service sets it from
+// the step timeout; unset or 0 means no deadline beyond the VU context.
+const timeoutEnv = "STORJ_TIMEOUT_MS"
+
 func init() {
-	modules.Register("k6/x/storj", new(Storj))
+	modules.Register("k6/x/storj", new(RootModule))
+}
+
+// RootModule is the global module object, creating a Storj instance per VU.
+// It holds the projects SharedClient reuses across iterations and VUs.
+type RootModule struct {
+	mu       sync.Mutex
+	projects map[string]*sharedProject // Keyed by access grant
+	reuses   atomic.Int64
+}
+
+// sharedProject is a project opened once and kept open for the whole k6 run
+type sharedProject struct {
+	access  *uplink.Access
+	project *uplink.Project
 }
 
-// Storj is the k6 extension for Storj operations
-type Storj struct{}
+// Storj is the k6 extension for Storj operations, one per VU
+type Storj struct {
+	vu          modules.VU
+	root        *RootModule
+	reuseMetric *metrics.Metric // nil if the script registered the name with another type
+}
 
 // Client represents a Storj uplink client
 type Client struct {
+	vu      modules.VU
 	access  *uplink.Access
 	project *uplink.Project
+	shared  bool          // The project belongs to the module cache; Close leaves it open
+	timeout time.Duration // Default deadline of each operation; 0 means none
+}
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &Storj{}
+)
+
+// NewModuleInstance implements modules.Module
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	s := &Storj{vu: vu, root: r}
+	if env := vu.InitEnv(); env != nil && env.Registry != nil {
+		s.reuseMetric, _ = env.Registry.NewMetric(reuseMetricName, metrics.Counter)
+	}
+	return s
+}
+
+// Exports implements modules.Instance
+func (s *Storj) Exports() modules.Exports {
+	return modules.Exports{Default: s}
+}
+
+// vuContext returns the VU context, which k6 cancels when the run is aborted or
+// times out, so in-flight uplink operations stop with it instead of
+// overrunning the step timeout
+func vuContext(vu modules.VU) context.Context {
+	if ctx := vu.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// defaultTimeout returns the operation deadline from STORJ_TIMEOUT_MS
+func defaultTimeout() time.Duration {
+	ms, err := strconv.Atoi(os.Getenv(timeoutEnv))
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// timeoutArg returns the deadline of an optional trailing timeoutMs argument,
+// or fallback if it is missing or 0
+func timeoutArg(fallback time.Duration, timeoutMs []int) (time.Duration, error) {
+	if len(timeoutMs) == 0 || timeoutMs[0] == 0 {
+		return fallback, nil
+	}
+	if len(timeoutMs) > 1 || timeoutMs[0] < 0 {
+		return 0, errors.New("timeoutMs must be a single positive number of milliseconds")
+	}
+	return time.Duration(timeoutMs[0]) * time.Millisecond, nil
+}
+
+// withTimeout derives the context of one operation from the VU context, with
+// a deadline of timeout if it is set
+func withTimeout(vu modules.VU, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(vuContext(vu))
+	}
+	return context.WithTimeout(vuContext(vu), timeout)
+}
+
+// timeoutError names the operation and its deadline if err comes from the
+// deadline expiring rather than the VU context or the satellite, so scripts
+// can tell a hung connection from other failures
+func timeoutError(ctx context.Context, op string, timeout time.Duration, err error) error {
+	if err == nil || timeout <= 0 || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%s timed out after %v: %w", op, timeout, err)
+}
+
+// operation starts an operation of the client, returning its context and the
+// deadline it applies
+func (c *Client) operation(timeoutMs []int) (context.Context, context.CancelFunc, time.Duration, error) {
+	if c.project == nil {
+		return nil, nil, 0, errors.New("client not initialized")
+	}
+	timeout, err := timeoutArg(c.timeout, timeoutMs)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	ctx, cancel := withTimeout(c.vu, timeout)
+	return ctx, cancel, timeout, nil
 }
 
-// NewClient creates a new Storj client from an access grant
-func (s *Storj) NewClient(accessGrant string) (*Client, error) {
+// SetTimeout sets the default deadline of the client's operations in
+// milliseconds; 0 removes it
+func (c *Client) SetTimeout(timeoutMs int) error {
+	if timeoutMs < 0 {
+		return errors.New("timeoutMs must not be negative")
+	}
+	c.timeout = time.Duration(timeoutMs) * time.Millisecond
+	return nil
+}
+
+// NewClient creates a new Storj client from an access grant. Connections
+// identify themselves with STORJ_USER_AGENT, which the synthetics service sets
+// for each run. The optional timeoutMs is the default deadline of the
+// client's operations, replacing STORJ_TIMEOUT_MS.
+func (s *Storj) NewClient(accessGrant string, timeoutMs ...int) (*Client, error) {
 	if accessGrant == "" {
 		return nil, errors.New("access grant is required")
 	}
+	timeout, err := timeoutArg(defaultTimeout(), timeoutMs)
+	if err != nil {
+		return nil, err
+	}
 
 	access, err := uplink.ParseAccess(accessGrant)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := context.Background()
-	project, err := uplink.OpenProject(ctx, access)
+	ctx, cancel := withTimeout(s.vu, timeout)
+	defer cancel()
+	cfg := uplink.Config{UserAgent: os.Getenv("STORJ_USER_AGENT")}
+	project, err := cfg.OpenProject(ctx, access)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, "open project", timeout, err)
 	}
 
 	return &Client{
+		vu:      s.vu,
 		access:  access,
 		project: project,
+		timeout: timeout,
 	}, nil
 }
 
-// Upload uploads data to a Storj bucket with optional TTL
-// ttlSeconds: if > 0, object will expire after this many seconds
-func (c *Client) Upload(bucketName, key string, data []byte, ttlSeconds int) error {
-	if c.project == nil {
-		return errors.New("client not initialized")
+// SharedClient returns a client on a project cached per access grant for the
+// whole k6 run, so scripts running many iterations (or VUs) open the
+// satellite connection once instead of on every iteration. Closing a shared
+// client leaves the project open for the next one; it is closed when k6 exits.
+// The optional timeoutMs applies to this client only, as with NewClient.
+func (s *Storj) SharedClient(accessGrant string, timeoutMs ...int) (*Client, error) {
+	if accessGrant == "" {
+		return nil, errors.New("access grant is required")
+	}
+	timeout, err := timeoutArg(defaultTimeout(), timeoutMs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withTimeout(s.vu, timeout)
+	defer cancel()
+	shared, reused, err := s.root.project(ctx, accessGrant)
+	if err != nil {
+		return nil, timeoutError(ctx, "open project", timeout, err)
+	}
+	if reused {
+		s.root.reuses.Add(1)
+		s.recordReuse()
+	}
+
+	return &Client{
+		vu:      s.vu,
+		access:  shared.access,
+		project: shared.project,
+		shared:  true,
+		timeout: timeout,
+	}, nil
+}
+
+// ConnectionReuses returns how many times SharedClient has handed out an
+// already open project, across all VUs
+func (s *Storj) ConnectionReuses() int64 {
+	return s.root.reuses.Load()
+}
+
+// project returns the cached project for accessGrant, opening it on first use.
+// The lock is held while opening, so concurrent VUs share one open.
+func (r *RootModule) project(ctx context.Context, accessGrant string) (*sharedProject, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if shared, ok := r.projects[accessGrant]; ok {
+		return shared, true, nil
 	}
 
-	ctx := context.Background()
+	access, err := uplink.ParseAccess(accessGrant)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cfg := uplink.Config{UserAgent: os.Getenv("STORJ_USER_AGENT")}
+	project, err := cfg.OpenProject(ctx, access)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if r.projects == nil {
+		r.projects = make(map[string]*sharedProject)
+	}
+	shared := &sharedProject{access: access, project: project}
+	r.projects[accessGrant] = shared
+	return shared, false, nil
+}
+
+// recordReuse adds a sample to the storj_connection_reuses counter, tagged
+// like the VU's other samples. Reuses in the init context have no VU state
+// and are only counted by ConnectionReuses.
+func (s *Storj) recordReuse() {
+	state := s.vu.State()
+	if state == nil || s.reuseMetric == nil {
+		return
+	}
+	ctm := state.Tags.GetCurrentValues()
+	metrics.PushIfNotDone(vuContext(s.vu), state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: s.reuseMetric, Tags: ctm.Tags},
+		Time:       time.Now(),
+		Metadata:   ctm.Metadata,
+		Value:      1,
+	})
+}
+
+// Upload uploads data to a Storj bucket with optional TTL and custom metadata
+// ttlSeconds: if > 0, object will expire after this many seconds
+// metadata: optional custom metadata committed with the object
+// timeoutMs: optional deadline of the whole upload, replacing the client's
+func (c *Client) Upload(bucketName, key string, data []byte, ttlSeconds int, metadata map[string]string, timeoutMs ...int) error {
+	ctx, cancel, timeout, err := c.operation(timeoutMs)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return timeoutError(ctx, "upload", timeout, c.upload(ctx, bucketName, key, data, ttlSeconds, metadata))
+}
 
+func (c *Client) upload(ctx context.Context, bucketName, key string, data []byte, ttlSeconds int, metadata map[string]string) error {
 	// Ensure bucket exists
 	_, err := c.project.EnsureBucket(ctx, bucketName)
 	if err != nil {
@@ -83,41 +314,49 @@ func (c *Client) Upload(bucketName, key string, data []byte, ttlSeconds int) err
 		return err
 	}
 
+	if len(metadata) > 0 {
+		if err := upload.SetCustomMetadata(ctx, uplink.CustomMetadata(metadata)); err != nil {
+			return err
+		}
+	}
+
 	// Commit upload
 	return upload.Commit()
 }
 
-// Download downloads data from a Storj bucket
-func (c *Client) Download(bucketName, key string) ([]byte, error) {
-	if c.project == nil {
-		return nil, errors.New("client not initialized")
+// Download downloads data from a Storj bucket. The optional timeoutMs is the
+// deadline of the whole download, replacing the client's.
+func (c *Client) Download(bucketName, key string, timeoutMs ...int) ([]byte, error) {
+	ctx, cancel, timeout, err := c.operation(timeoutMs)
+	if err != nil {
+		return nil, err
 	}
-
-	ctx := context.Background()
+	defer cancel()
 
 	// Start download
 	download, err := c.project.DownloadObject(ctx, bucketName, key, nil)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, "download", timeout, err)
 	}
 	defer download.Close()
 
 	// Read all data
 	data, err := io.ReadAll(download)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, "download", timeout, err)
 	}
 
 	return data, nil
 }
 
-// List lists objects in a Storj bucket
-func (c *Client) List(bucketName string) ([]string, error) {
-	if c.project == nil {
-		return nil, errors.New("client not initialized")
+// List lists objects in a Storj bucket. The optional timeoutMs is the
+// deadline of the whole listing, replacing the client's.
+func (c *Client) List(bucketName string, timeoutMs ...int) ([]string, error) {
+	ctx, cancel, timeout, err := c.operation(timeoutMs)
+	if err != nil {
+		return nil, err
 	}
-
-	ctx := context.Background()
+	defer cancel()
 
 	// List objects
 	objects := c.project.ListObjects(ctx, bucketName, nil)
@@ -129,35 +368,37 @@ func (c *Client) List(bucketName string) ([]string, error) {
 	}
 
 	if err := objects.Err(); err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, "list", timeout, err)
 	}
 
 	return keys, nil
 }
 
-// Delete deletes an object from a Storj bucket
-func (c *Client) Delete(bucketName, key string) error {
-	if c.project == nil {
-		return errors.New("client not initialized")
+// Delete deletes an object from a Storj bucket. The optional timeoutMs
+// replaces the client's deadline.
+func (c *Client) Delete(bucketName, key string, timeoutMs ...int) error {
+	ctx, cancel, timeout, err := c.operation(timeoutMs)
+	if err != nil {
+		return err
 	}
+	defer cancel()
 
-	ctx := context.Background()
-
-	_, err := c.project.DeleteObject(ctx, bucketName, key)
-	return err
+	_, err = c.project.DeleteObject(ctx, bucketName, key)
+	return timeoutError(ctx, "delete", timeout, err)
 }
 
-// Stat gets object metadata
-func (c *Client) Stat(bucketName, key string) (map[string]interface{}, error) {
-	if c.project == nil {
-		return nil, errors.New("client not initialized")
+// Stat gets object metadata. The optional timeoutMs replaces the client's
+// deadline.
+func (c *Client) Stat(bucketName, key string, timeoutMs ...int) (map[string]interface{}, error) {
+	ctx, cancel, timeout, err := c.operation(timeoutMs)
+	if err != nil {
+		return nil, err
 	}
-
-	ctx := context.Background()
+	defer cancel()
 
 	object, err := c.project.StatObject(ctx, bucketName, key)
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, "stat", timeout, err)
 	}
 
 	return map[string]interface{}{
@@ -165,12 +406,13 @@ func (c *Client) Stat(bucketName, key string) (map[string]interface{}, error) {
 		"size":      object.System.ContentLength,
 		"created":   object.System.Created.Unix(),
 		"is_prefix": object.IsPrefix,
+		"metadata":  map[string]string(object.Custom),
 	}, nil
 }
 
-// Close closes the Storj project connection
+// Close closes the Storj project connection, unless it is shared
 func (c *Client) Close() error {
-	if c.project == nil {
+	if c.project == nil || c.shared {
 		return nil
 	}
 	return c.project.Close()
@@ -0,0 +1,179 @@
+// Package anomaly flags test runs whose duration strays from the test's own
+// rolling baseline, giving lightweight latency alerting without thresholds
+// tuned per test or an external detection system.
+package anomaly
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+)
+
+// minStddevRatio floors the standard deviation runs are measured against at
+// this fraction of the baseline, so a test with very steady durations isn't
+// flagged for deviations too small to matter
+const minStddevRatio = 0.05
+
+// Detector keeps a baseline per test from the durations of its successful
+// runs and flags the runs that deviate from it by more than
+// anomaly.threshold standard deviations. A nil *Detector does nothing.
+type Detector struct {
+	metrics *metrics.Collector
+	webhook *webhook
+
+	mu        sync.Mutex
+	cfg       config.AnomalyConfig
+	tenants   map[string]config.Tenant // Tenant of each tenant test
+	baselines map[string]*baseline
+	notified  map[string]time.Time // Last notification per test
+}
+
+// baseline is an exponentially weighted moving average of a test's run
+// durations and of their variance, so it follows gradual change while a
+// single slow run stands out
+type baseline struct {
+	mean, variance float64 // Seconds, seconds squared
+	runs           int
+}
+
+// observe adds a run duration and returns the mean and standard deviation
+// the run is compared with, from before it was added, and how many runs
+// they were built from
+func (b *baseline) observe(seconds, alpha float64) (mean, stddev float64, runs int) {
+	mean, stddev, runs = b.mean, math.Sqrt(b.variance), b.runs
+	if b.runs == 0 {
+		b.mean = seconds
+	} else {
+		diff := seconds - b.mean
+		incr := alpha * diff
+		b.mean += incr
+		b.variance = (1 - alpha) * (b.variance + diff*incr)
+	}
+	b.runs++
+	return mean, stddev, runs
+}
+
+// New creates a detector with the anomaly settings of cfg
+func New(cfg *config.Config, mc *metrics.Collector) *Detector {
+	return &Detector{
+		metrics:   mc,
+		webhook:   newWebhook(),
+		cfg:       cfg.Anomaly,
+		tenants:   testTenants(cfg),
+		baselines: make(map[string]*baseline),
+		notified:  make(map[string]time.Time),
+	}
+}
+
+// Reload applies a new configuration. Baselines are kept; see Forget.
+func (d *Detector) Reload(cfg *config.Config) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cfg = cfg.Anomaly
+	d.tenants = testTenants(cfg)
+}
+
+// testTenants returns the tenant of each test of cfg that belongs to one
+func testTenants(cfg *config.Config) map[string]config.Tenant {
+	tenants := make(map[string]config.Tenant)
+	for _, test := range cfg.Tests {
+		if tenant, ok := cfg.GetTenant(test.Tenant); ok {
+			tenants[test.Name] = tenant
+		}
+	}
+	return tenants
+}
+
+// Forget drops the baseline of a removed or changed test; a changed test's
+// durations start a new one
+func (d *Detector) Forget(test string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.baselines, test)
+	delete(d.notified, test)
+}
+
+// Observe compares a run with its test's baseline and adds it to the
+// baseline. Failed runs are left to the failure metrics and alerts; their
+// durations say little about latency.
+func (d *Detector) Observe(res *result.Result) {
+	if d == nil || res == nil || !res.Success {
+		return
+	}
+
+	d.mu.Lock()
+	cfg := d.cfg
+	tenant := d.tenants[res.Test]
+	if !cfg.Enabled {
+		d.mu.Unlock()
+		return
+	}
+	b := d.baselines[res.Test]
+	if b == nil {
+		b = &baseline{}
+		d.baselines[res.Test] = b
+	}
+	mean, stddev, runs := b.observe(res.DurationSeconds, cfg.GetAlpha())
+	d.mu.Unlock()
+
+	if runs < cfg.GetMinRuns() {
+		return
+	}
+	score := (res.DurationSeconds - mean) / max(stddev, minStddevRatio*mean)
+	d.metrics.RecordAnomalyScore(res.Test, score, time.Duration(mean*float64(time.Second)))
+	if math.Abs(score) < cfg.GetThreshold() {
+		return
+	}
+
+	n := Notification{
+		Test:            res.Test,
+		Tenant:          tenant.Name,
+		RunID:           res.RunID,
+		Executor:        res.Executor,
+		Direction:       metrics.AnomalySlow,
+		DurationSeconds: res.DurationSeconds,
+		BaselineSeconds: mean,
+		StddevSeconds:   stddev,
+		Score:           score,
+		Threshold:       cfg.GetThreshold(),
+		Time:            res.Start,
+	}
+	if score < 0 {
+		n.Direction = metrics.AnomalyFast
+	}
+	n.Text = n.summary()
+	d.metrics.RecordAnomaly(res.Test, n.Direction)
+	logging.With("test_name", res.Test, "executor", res.Executor, "ulid", res.RunID).Printf("Anomaly: %s", n.Text)
+
+	// A tenant's anomalies go to its own channel
+	url := cfg.Webhook
+	if tenant.AnomalyWebhook != "" {
+		url = tenant.AnomalyWebhook
+	}
+	if url != "" && d.shouldNotify(res.Test, cfg.NotifyIntervalDuration()) {
+		go d.webhook.send(url, n)
+	}
+}
+
+// shouldNotify reports whether a notification about the test is due, and if
+// so notes that one is sent now
+func (d *Detector) shouldNotify(test string, interval time.Duration) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if time.Since(d.notified[test]) < interval {
+		return false
+	}
+	d.notified[test] = time.Now()
+	return true
+}
@@ -0,0 +1,83 @@
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/version"
+)
+
+// webhookTimeout bounds each notification, so an unreachable receiver
+// doesn't pile up goroutines
+const webhookTimeout = 10 * time.Second
+
+// Notification is the JSON body POSTed to anomaly.webhook (or the test's
+// tenant's anomaly_webhook) for an anomalous run. Text makes it a valid Slack or Mattermost incoming webhook message.
+type Notification struct {
+	Test            string    `json:"test"`
+	Tenant          string    `json:"tenant,omitempty"` // Tenant of the test, if any
+	RunID           string    `json:"run_id"`
+	Executor        string    `json:"executor"`
+	Direction       string    `json:"direction"` // "slow" or "fast"
+	DurationSeconds float64   `json:"duration_seconds"`
+	BaselineSeconds float64   `json:"baseline_seconds"`
+	StddevSeconds   float64   `json:"stddev_seconds"`
+	Score           float64   `json:"score"` // Standard deviations from the baseline
+	Threshold       float64   `json:"threshold"`
+	Time            time.Time `json:"time"` // Start of the run
+	Text            string    `json:"text"`
+}
+
+// summary describes the anomaly in one line
+func (n *Notification) summary() string {
+	than := "slower"
+	if n.Direction == metrics.AnomalyFast {
+		than = "faster"
+	}
+	return fmt.Sprintf("test %s run %s took %.3fs, %.1f standard deviations %s than its %.3fs baseline",
+		n.Test, n.RunID, n.DurationSeconds, math.Abs(n.Score), than, n.BaselineSeconds)
+}
+
+// webhook posts notifications
+type webhook struct {
+	client *http.Client
+}
+
+func newWebhook() *webhook {
+	return &webhook{client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// send posts a notification, logging failures
+func (w *webhook) send(url string, n Notification) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		log.Printf("Warning: failed to encode anomaly notification: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		logging.With("test_name", n.Test).Printf("Warning: failed to notify anomaly of test %s: %v", n.Test, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "synthetics/"+version.Version)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		logging.With("test_name", n.Test).Printf("Warning: failed to notify anomaly of test %s: %v", n.Test, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logging.With("test_name", n.Test).Printf("Warning: failed to notify anomaly of test %s: webhook returned %s", n.Test, resp.Status)
+	}
+}
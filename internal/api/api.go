@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/results"
+	"github.com/ethanadams/synthetics/internal/scheduler"
+	"gopkg.in/yaml.v3"
+)
+
+// Server exposes the admin API for controlling the scheduler
+type Server struct {
+	ctx            context.Context // Bounds background work such as verifications
+	scheduler      *scheduler.Scheduler
+	verifications  verifications
+	shadow         *metrics.ShadowComparison // Nil unless a shadow config is running
+	results        *results.Store            // Nil unless the results store is enabled
+	webhookReplays webhookReplays
+}
+
+// New creates a new admin API server
+func New(ctx context.Context, sched *scheduler.Scheduler) *Server {
+	return &Server{ctx: ctx, scheduler: sched}
+}
+
+// WithShadow serves the comparison of shadow and active tests at
+// GET /api/v1/shadow
+func (s *Server) WithShadow(cmp *metrics.ShadowComparison) *Server {
+	s.shadow = cmp
+	return s
+}
+
+// WithResults serves the stored runs at GET /api/v1/results
+func (s *Server) WithResults(store *results.Store) *Server {
+	s.results = store
+	return s
+}
+
+// Register adds the admin API routes to the mux. The routes that change
+// state or start runs require api.token as a bearer token, and reject every
+// request while it is unset: they share the listener with /metrics, and
+// anyone who can scrape the probe must not be able to disable its tests or
+// make it generate load.
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/tags", s.handleListTags)
+	mux.HandleFunc("POST /api/v1/tags/{tag}/enable", s.authorized(s.handleEnableTag))
+	mux.HandleFunc("POST /api/v1/tags/{tag}/disable", s.authorized(s.handleDisableTag))
+	mux.HandleFunc("POST /api/v1/tags/{tag}/run", s.authorized(s.handleRunTag))
+	mux.HandleFunc("POST /api/v1/tests/{name}/run", s.authorized(s.handleRunTest))
+	mux.HandleFunc("POST /api/v1/verify/{tag}", s.authorized(s.handleStartVerify))
+	if s.scheduler.Config().API.Token == "" {
+		log.Printf("Warning: api.token is not set; the tag, run, and verify routes reject every request until a reload sets it")
+	}
+	mux.HandleFunc("GET /api/tests/{name}/last-failure", s.handleLastFailure)
+	mux.HandleFunc("POST /api/v1/webhook/{tag}", s.handleWebhook)
+	mux.HandleFunc("GET /api/v1/verify/{id}", s.handleGetVerify)
+	mux.HandleFunc("GET /api/config", s.handleConfig)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /api/v1/scheduler/dry-run", s.handleDryRun)
+	mux.HandleFunc("GET /api/traces", s.handleTraces)
+	mux.HandleFunc("GET /api/v1/shadow", s.handleShadow)
+	mux.HandleFunc("GET /api/v1/results", s.handleResults)
+	mux.HandleFunc("GET /status", s.handleStatus)
+}
+
+// authorized wraps a mutating route, requiring api.token as a bearer token.
+// The token is read on every request, so a reload sets or rotates it; a
+// reload that removes it closes the routes.
+func (s *Server) authorized(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := s.scheduler.Config().API.Token
+		want := "Bearer " + token
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+			log.Printf("Rejected %s %s from %s: missing or invalid bearer token", r.Method, r.URL.Path, r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// handleListTags returns all known tags with their state and tests
+func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.scheduler.Tags())
+}
+
+// handleEnableTag re-enables scheduled runs for a tag
+func (s *Server) handleEnableTag(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+	s.scheduler.EnableTag(tag)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tag": tag, "disabled": false})
+}
+
+// handleDisableTag skips scheduled runs for a tag
+func (s *Server) handleDisableTag(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+	s.scheduler.DisableTag(tag)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tag": tag, "disabled": true})
+}
+
+// handleRunTag triggers an immediate run of all enabled tests with a tag
+func (s *Server) handleRunTag(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+	triggered, err := s.scheduler.RunTag(tag)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"tag": tag, "triggered": triggered})
+}
+
+// handleRunTest runs a test now and responds with its result when it
+// finishes: 200 whether it passed or failed, 404 for an unknown test, and 409
+// if it was skipped. Canceling the request cancels the run.
+func (s *Server) handleRunTest(w http.ResponseWriter, r *http.Request) {
+	// A run may outlast the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to lift write deadline for test run: %v", err)
+	}
+
+	res, err := s.scheduler.RunNow(r.Context(), r.PathValue("name"))
+	switch {
+	case errors.Is(err, scheduler.ErrTestNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, scheduler.ErrTestSkipped):
+		writeError(w, http.StatusConflict, err)
+	case res == nil:
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleConfig returns the effective configuration (defaults applied,
+// environment expanded, secrets redacted) using the YAML field names
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	data, err := yaml.Marshal(s.scheduler.Config().Redacted())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// handleEvents returns scheduler events, oldest first. Supports the query
+// parameters test, type, since (RFC 3339), and limit.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := scheduler.EventFilter{Test: q.Get("test"), Type: q.Get("type"), Limit: 100}
+
+	if v := q.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since: %w", err))
+			return
+		}
+		filter.Since = since
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", v))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	writeJSON(w, http.StatusOK, s.scheduler.Events(filter))
+}
+
+// maxDryRunWindow bounds the window of a dry run, which lists every firing
+const maxDryRunWindow = 7 * 24 * time.Hour
+
+// handleDryRun evaluates which tests would run in the next window, without
+// running them, to answer why a test didn't or won't run. Supports the query
+// parameters for (a duration, default 1h, at most 7 days) and test.
+func (s *Server) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	window := time.Hour
+	if v := q.Get("for"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxDryRunWindow {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid for: %s (expected a duration up to %v)", v, maxDryRunWindow))
+			return
+		}
+		window = d
+	}
+
+	dry := s.scheduler.DryRun(time.Now(), window)
+	if name := q.Get("test"); name != "" {
+		i := slices.IndexFunc(dry.Tests, func(t scheduler.PlannedTest) bool { return t.Test == name })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", scheduler.ErrTestNotFound, name))
+			return
+		}
+		dry.Tests = dry.Tests[i : i+1]
+	}
+	writeJSON(w, http.StatusOK, dry)
+}
+
+// handleTraces returns recent network path traces, oldest first. Supports the
+// query parameters target and limit.
+func (s *Server) handleTraces(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 20
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", v))
+			return
+		}
+		limit = n
+	}
+	writeJSON(w, http.StatusOK, s.scheduler.Traces(q.Get("target"), limit))
+}
+
+// handleShadow compares the shadow tests with the active tests of the same
+// name over the comparison window
+func (s *Server) handleShadow(w http.ResponseWriter, r *http.Request) {
+	if s.shadow == nil {
+		writeError(w, http.StatusNotFound, errors.New("no shadow config is running"))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.shadow.Compare(time.Now()))
+}
+
+// handleResults returns stored runs with their steps, most recent first.
+// Supports the query parameters test, failed (true for failed runs only),
+// since and until (RFC 3339), and limit (default 100, at most 1000).
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	if s.results == nil {
+		writeError(w, http.StatusNotFound, errors.New("the results store is not enabled (results.path)"))
+		return
+	}
+	q := r.URL.Query()
+	filter := results.Filter{Test: q.Get("test"), Limit: results.DefaultLimit}
+
+	if v := q.Get("failed"); v != "" {
+		failed, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid failed: %s", v))
+			return
+		}
+		filter.Failed = failed
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %w", name, err))
+				return
+			}
+			*t = parsed
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > results.MaxLimit {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s (expected 1 to %d)", v, results.MaxLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	runs, err := s.results.Query(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write API response: %v", err)
+	}
+}
+
+// writeError writes an error as a JSON response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/netpath"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/results"
+)
+
+// lastFailure is the response of GET /api/tests/{name}/last-failure: what
+// failed in the test's most recent failed run, for incident triage
+type lastFailure struct {
+	Test                string             `json:"test"`
+	RunID               string             `json:"run_id"`
+	Time                time.Time          `json:"time"` // Start of the run
+	Executor            string             `json:"executor"`
+	Endpoint            string             `json:"endpoint,omitempty"`
+	Satellite           string             `json:"satellite,omitempty"`
+	FailedStep          string             `json:"failed_step,omitempty"` // Empty if the run failed outside a step
+	ErrorClass          string             `json:"error_class,omitempty"`
+	Error               string             `json:"error"`
+	RequestID           string             `json:"request_id,omitempty"` // Of the failed step, or the last step before it that had one
+	Phases              map[string]float64 `json:"phases,omitempty"`     // HTTP phase durations of the failed step, in seconds
+	DurationSeconds     float64            `json:"duration_seconds"`
+	ConsecutiveFailures int                `json:"consecutive_failures"`  // 0 if the test has passed since
+	LastSuccess         time.Time          `json:"last_success,omitzero"` // Zero if the test hasn't passed since its state was kept
+	Steps               []result.Step      `json:"steps"`
+	Links               failureLinks       `json:"links"`
+}
+
+// failureLinks point at the API resources with more detail about a failure
+type failureLinks struct {
+	Events  string   `json:"events"`            // The test's scheduler events
+	Results string   `json:"results,omitempty"` // The test's stored failed runs; set when the results store is enabled
+	Traces  []string `json:"traces,omitempty"`  // Path traces of the test's targets; set when traceroute.on_failure is enabled
+}
+
+// handleLastFailure returns the most recent failed run of a test: 404 for an
+// unknown test, or one that hasn't failed. The run is kept in the run state
+// (scheduler.state_file across restarts), falling back to the results store.
+func (s *Server) handleLastFailure(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	test, st, err := s.scheduler.LastFailure(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	run := st.LastFailedRun
+	if run == nil && s.results != nil {
+		runs, err := s.results.Query(r.Context(), results.Filter{Test: name, Failed: true, Limit: 1})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if len(runs) > 0 {
+			run = runs[0]
+		}
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("test %s has no recorded failure", name))
+		return
+	}
+	run = run.WithoutOutputs() // Step outputs can hold presigned URLs
+
+	out := lastFailure{
+		Test:            name,
+		RunID:           run.RunID,
+		Time:            run.Start,
+		Executor:        run.Executor,
+		Endpoint:        run.Endpoint,
+		Satellite:       run.Satellite,
+		FailedStep:      run.FailedStep,
+		ErrorClass:      run.ErrorClass,
+		Error:           run.Error,
+		DurationSeconds: run.DurationSeconds,
+		LastSuccess:     st.LastSuccess,
+		Steps:           run.Steps,
+		Links:           failureLinks{Events: "/api/events?" + url.Values{"test": {name}}.Encode()},
+	}
+	if st.LastStatus == "failure" {
+		out.ConsecutiveFailures = st.ConsecutiveFailures
+	}
+	for _, step := range run.Steps {
+		if step.RequestID != "" {
+			out.RequestID = step.RequestID
+		}
+		if step.Name == run.FailedStep && !step.Success {
+			out.Phases = step.Phases
+			break
+		}
+	}
+	if s.results != nil {
+		out.Links.Results = "/api/v1/results?" + url.Values{"test": {name}, "failed": {"true"}}.Encode()
+	}
+	cfg := s.scheduler.Config()
+	if cfg.Traceroute.Enabled && cfg.Traceroute.OnFailure {
+		for _, target := range netpath.TestTargets(cfg, test) {
+			out.Links.Traces = append(out.Links.Traces, "/api/traces?"+url.Values{"target": {target}}.Encode())
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
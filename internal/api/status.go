@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/scheduler"
+)
+
+// statusPage is the HTML rendering of /status
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"percent": func(f float64) string { return fmt.Sprintf("%.2f%%", f*100) },
+	"ago":     func(t time.Time) string { return time.Since(t).Round(time.Second).String() + " ago" },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>Synthetics Status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.4em 1em; text-align: left; border-bottom: 1px solid #ddd; }
+.ok { color: #1a7f37; } .degraded { color: #bf8700; } .down { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>Synthetics Status</h1>
+{{if .}}<table>
+<tr><th>Target</th><th>Type</th><th>State</th><th>Last check</th><th>24h availability</th><th>Failing tests</th></tr>
+{{range .}}<tr><td>{{.Target}}</td><td>{{.Type}}</td><td class="{{.State}}">{{.State}}</td><td>{{ago .LastCheck}}</td><td>{{percent .Availability24h}} ({{.Runs24h}} runs)</td><td>{{range $i, $t := .FailingTests}}{{if $i}}, {{end}}{{$t}}{{end}}</td></tr>
+{{end}}</table>{{else}}<p>No tests have run yet.</p>{{end}}
+</body>
+</html>
+`))
+
+// handleStatus summarizes each endpoint and satellite tests run against: JSON
+// by default, HTML for browsers or with ?format=html
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status := s.scheduler.Status()
+	format := r.URL.Query().Get("format")
+	if format == "html" || (format == "" && strings.Contains(r.Header.Get("Accept"), "text/html")) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusPage.Execute(w, status); err != nil {
+			log.Printf("Failed to write status page: %v", err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]scheduler.TargetStatus{"targets": status})
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/verify"
+	"github.com/oklog/ulid/v2"
+)
+
+// Verification states
+const (
+	verifyRunning = "running"
+	verifyPassed  = "passed"
+	verifyFailed  = "failed"
+)
+
+// maxVerifications is how many finished verifications are kept for polling
+const maxVerifications = 50
+
+// verification is a verify run started through the API
+type verification struct {
+	ID      string          `json:"id"`
+	Status  string          `json:"status"`
+	Tag     string          `json:"tag"`
+	Source  string          `json:"source,omitempty"`
+	Verdict *verify.Verdict `json:"verdict,omitempty"` // Set once finished
+}
+
+// verifications keeps recent verifications by ID
+type verifications struct {
+	mu    sync.Mutex
+	byID  map[string]*verification
+	order []string
+}
+
+func (v *verifications) add(ver *verification) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.byID == nil {
+		v.byID = make(map[string]*verification)
+	}
+	v.byID[ver.ID] = ver
+	v.order = append(v.order, ver.ID)
+	for len(v.order) > maxVerifications {
+		delete(v.byID, v.order[0])
+		v.order = v.order[1:]
+	}
+}
+
+func (v *verifications) get(id string) (verification, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	ver, ok := v.byID[id]
+	if !ok {
+		return verification{}, false
+	}
+	return *ver, true
+}
+
+func (v *verifications) finish(id string, verdict *verify.Verdict) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	ver, ok := v.byID[id]
+	if !ok {
+		return
+	}
+	ver.Verdict = verdict
+	ver.Status = verifyFailed
+	if verdict.Pass {
+		ver.Status = verifyPassed
+	}
+}
+
+// handleStartVerify starts verifying a test group in the background. Query
+// parameters: runs, interval, min_availability (percent), max_p95, source.
+// Poll GET /api/v1/verify/{id} for the verdict.
+func (s *Server) handleStartVerify(w http.ResponseWriter, r *http.Request) {
+	opts, err := verifyOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	tag := r.PathValue("tag")
+	tests := s.scheduler.TaggedTests(tag)
+	if len(tests) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no enabled tests with tag: %s", tag))
+		return
+	}
+
+	ver := &verification{ID: ulid.Make().String(), Status: verifyRunning, Tag: tag, Source: r.URL.Query().Get("source")}
+	s.verifications.add(ver)
+	trigger := "verify " + ver.ID
+	if ver.Source != "" {
+		trigger += " from " + ver.Source
+	}
+	log.Printf("Verifying tag %s (%d tests, %d runs each, id %s)", tag, len(tests), opts.Runs, ver.ID)
+	go func() {
+		verdict := verify.Run(s.ctx, tag, tests, opts, func(ctx context.Context, test *config.Test) (*result.Result, error) {
+			return s.scheduler.RunTest(ctx, test, trigger)
+		})
+		log.Printf("Verification %s of tag %s: pass=%t, availability %.1f%%", ver.ID, tag, verdict.Pass, verdict.AvailabilityPercent)
+		s.verifications.finish(ver.ID, verdict)
+	}()
+
+	w.Header().Set("Location", "/api/v1/verify/"+ver.ID)
+	writeJSON(w, http.StatusAccepted, ver)
+}
+
+// handleGetVerify returns a verification's status and, once finished, its verdict
+func (s *Server) handleGetVerify(w http.ResponseWriter, r *http.Request) {
+	ver, ok := s.verifications.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("verification not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, ver)
+}
+
+// verifyOptions parses verification options from query parameters
+func verifyOptions(r *http.Request) (verify.Options, error) {
+	q := r.URL.Query()
+	opts := verify.Options{MinAvailability: 100}
+	var err error
+	if v := q.Get("runs"); v != "" {
+		if opts.Runs, err = strconv.Atoi(v); err != nil {
+			return opts, fmt.Errorf("invalid runs: %s", v)
+		}
+	}
+	if v := q.Get("min_availability"); v != "" {
+		if opts.MinAvailability, err = strconv.ParseFloat(v, 64); err != nil {
+			return opts, fmt.Errorf("invalid min_availability: %s", v)
+		}
+	}
+	if v := q.Get("interval"); v != "" {
+		if opts.Interval, err = time.ParseDuration(v); err != nil {
+			return opts, fmt.Errorf("invalid interval: %s", v)
+		}
+	}
+	if v := q.Get("max_p95"); v != "" {
+		if opts.MaxP95, err = time.ParseDuration(v); err != nil {
+			return opts, fmt.Errorf("invalid max_p95: %s", v)
+		}
+	}
+	return opts, opts.Validate()
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxWebhookBody bounds the webhook request body
+const maxWebhookBody = 64 << 10
+
+// webhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+// signed payload (see webhookPayload)
+const webhookSignatureHeader = "X-Synthetics-Signature"
+
+// webhookTimestampHeader carries the Unix time in seconds the call was signed at
+const webhookTimestampHeader = "X-Synthetics-Timestamp"
+
+// webhookMaxSkew is how far a call's timestamp may be from the probe's clock.
+// Signatures are remembered until their timestamp goes stale, so each is
+// accepted once.
+const webhookMaxSkew = 5 * time.Minute
+
+// webhookReplays remembers the signatures of accepted calls until their
+// timestamps go stale
+type webhookReplays struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // Signature to the time it expires
+}
+
+// first reports whether signature hasn't been accepted before, remembering it
+// until expires
+func (r *webhookReplays) first(signature string, expires time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for sig, exp := range r.seen {
+		if now.After(exp) {
+			delete(r.seen, sig)
+		}
+	}
+	if _, ok := r.seen[signature]; ok {
+		return false
+	}
+	if r.seen == nil {
+		r.seen = make(map[string]time.Time)
+	}
+	r.seen[signature] = expires
+	return true
+}
+
+// webhookRequest is the optional JSON body of a webhook call
+type webhookRequest struct {
+	Source string `json:"source,omitempty"` // Caller, e.g. "deploy gateway-mt v1.2.3"; recorded on the runs' events
+}
+
+// handleWebhook runs the enabled tests with a tag on behalf of an external
+// caller, e.g. a deploy pipeline after a gateway rollout. The method, path,
+// timestamp, and body must be signed with webhook.secret; stale timestamps and
+// replayed signatures are rejected.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	secret := s.scheduler.Config().Webhook.Secret
+	if secret == "" {
+		writeError(w, http.StatusNotFound, errors.New("webhook is not configured"))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+	if len(body) > maxWebhookBody {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("body exceeds %d bytes", maxWebhookBody))
+		return
+	}
+	timestamp := r.Header.Get(webhookTimestampHeader)
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid %s", webhookTimestampHeader))
+		return
+	}
+	if skew := time.Since(time.Unix(signedAt, 0)); skew > webhookMaxSkew || skew < -webhookMaxSkew {
+		log.Printf("Rejected webhook from %s: timestamp %d is %v off", r.RemoteAddr, signedAt, skew.Round(time.Second))
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("%s is more than %v off", webhookTimestampHeader, webhookMaxSkew))
+		return
+	}
+	signature := r.Header.Get(webhookSignatureHeader)
+	if !validSignature(secret, webhookPayload(r.Method, r.URL.EscapedPath(), timestamp, body), signature) {
+		log.Printf("Rejected webhook from %s: invalid signature", r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid %s", webhookSignatureHeader))
+		return
+	}
+	if !s.webhookReplays.first(signature, time.Unix(signedAt, 0).Add(webhookMaxSkew)) {
+		log.Printf("Rejected webhook from %s: replayed signature", r.RemoteAddr)
+		writeError(w, http.StatusUnauthorized, errors.New("signature was already used"))
+		return
+	}
+
+	var req webhookRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+			return
+		}
+	}
+	source := strings.TrimSpace(req.Source)
+	if len(source) > 200 {
+		source = source[:200]
+	}
+
+	tag := r.PathValue("tag")
+	trigger := "webhook"
+	if source != "" {
+		trigger += " from " + source
+	}
+	trigger += " (tag " + tag + ")"
+	log.Printf("Webhook triggered tag %s (source: %q)", tag, source)
+
+	triggered, err := s.scheduler.RunTagFrom(tag, trigger)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"tag": tag, "source": source, "triggered": triggered})
+}
+
+// webhookPayload returns what a webhook call signs: the method, the escaped
+// path (which names the tag), and the timestamp, each followed by a newline,
+// then the body
+func webhookPayload(method, path, timestamp string, body []byte) []byte {
+	return append([]byte(method+"\n"+path+"\n"+timestamp+"\n"), body...)
+}
+
+// validSignature checks a "sha256=<hex>" HMAC-SHA256 signature of payload
+func validSignature(secret string, payload []byte, signature string) bool {
+	hexSig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(hexSig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -2,55 +2,949 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/robfig/cron/v3"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Satellite SatelliteConfig `yaml:"satellite"`
-	S3        S3Config        `yaml:"s3"`
-	Tests     []Test          `yaml:"tests"`
-	K6        K6Config        `yaml:"k6"`
-	Metrics   MetricsConfig   `yaml:"metrics"`
-	Logging   LoggingConfig   `yaml:"logging"`
-	Jitter    JitterConfig    `yaml:"jitter"` // Global jitter config (default: disabled)
+	Satellite  SatelliteConfig   `yaml:"satellite"`
+	Satellites []SatelliteConfig `yaml:"satellites,omitempty"` // Additional named satellites uplink tests can select
+	S3         S3Config          `yaml:"s3"`
+	S3Gateways []S3Config        `yaml:"s3_gateways,omitempty"` // Additional named S3 gateways gateway tests can select
+	Tests      []Test            `yaml:"tests"`
+	Fixtures   []Fixture         `yaml:"fixtures,omitempty"` // Shared objects for download-only tests
+	Tenants    []Tenant          `yaml:"tenants,omitempty"`  // Optional: teams' tests with their own credentials, buckets, and notifications
+	K6         K6Config          `yaml:"k6"`
+	Metrics    MetricsConfig     `yaml:"metrics"`
+	Logging    LoggingConfig     `yaml:"logging"`
+	Jitter     JitterConfig      `yaml:"jitter"` // Global jitter config (default: disabled)
+
+	DisabledTags []string `yaml:"disabled_tags,omitempty"` // Tests carrying any of these tags are not run
+
+	UserAgent string `yaml:"user_agent,omitempty"` // User-Agent template for executor requests (default: DefaultUserAgent)
+
+	Payload PayloadConfig `yaml:"payload,omitempty"` // How upload data is generated (default: crypto/rand)
+
+	Scheduler SchedulerConfig `yaml:"scheduler,omitempty"`
+
+	ExitPolicy ExitPolicy `yaml:"exit_policy,omitempty"` // Which results fail a --once run
+
+	Traceroute TracerouteConfig `yaml:"traceroute,omitempty"` // Optional: network path traces
+
+	Webhook WebhookConfig `yaml:"webhook,omitempty"` // Optional: signed inbound run triggers
+
+	API APIConfig `yaml:"api,omitempty"` // Optional: bearer token enabling the admin API's mutating routes
+
+	WorkDir WorkDirConfig `yaml:"work_dir,omitempty"` // Where run files are written
+
+	Subprocess SubprocessConfig `yaml:"subprocess,omitempty"` // Optional: resource limits of k6, curl, and other subprocesses
+
+	Profile string `yaml:"profile,omitempty"` // "standard" (default) or "lite" for constrained hardware; see profile.go
+
+	Shadow ShadowConfig `yaml:"shadow,omitempty"` // Optional: candidate config whose tests run alongside, labeled shadow="true"
+
+	Results ResultsConfig `yaml:"results,omitempty"` // Optional: SQLite store of every run and step
+
+	Anomaly AnomalyConfig `yaml:"anomaly,omitempty"` // Optional: flag runs far slower or faster than their test's baseline
+
+	Readiness ReadinessConfig `yaml:"readiness,omitempty"` // What /ready waits for
+
+	Mode       string           `yaml:"mode,omitempty"`       // "standalone" (default), "agent", or "aggregator"
+	Agent      AgentConfig      `yaml:"agent,omitempty"`      // Used in agent mode
+	Aggregator AggregatorConfig `yaml:"aggregator,omitempty"` // Used in aggregator mode
+}
+
+// Run modes
+const (
+	ModeStandalone = "standalone"
+	ModeAgent      = "agent"
+	ModeAggregator = "aggregator"
+)
+
+// DefaultUserAgent identifies synthetic traffic in gateway logs. Placeholders:
+// {version}, {run_id}, {test}, {executor}.
+const DefaultUserAgent = "synthetics/{version} run/{run_id} test/{test}"
+
+// GetUserAgent returns the User-Agent template (with default DefaultUserAgent)
+func (c *Config) GetUserAgent() string {
+	if c.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return c.UserAgent
+}
+
+// ExitPolicy decides which results affect the exit code of a --once run, so
+// CI can gate on the tests that matter
+type ExitPolicy struct {
+	IgnoreTags      []string `yaml:"ignore_tags,omitempty"`       // Failures of tests with any of these tags are reported but don't fail the run
+	MinPriority     *int     `yaml:"min_priority,omitempty"`      // Failures of tests with a lower priority are reported but don't fail the run
+	SlowAfter       string   `yaml:"slow_after,omitempty"`        // Passing tests slower than this are warnings (e.g. "30s")
+	WarningExitCode int      `yaml:"warning_exit_code,omitempty"` // Exit code with warnings but no failures (default: 0)
+}
+
+// Ignores returns true if failures of the test don't fail the run
+func (p *ExitPolicy) Ignores(test *Test) bool {
+	if p.MinPriority != nil && test.Priority < *p.MinPriority {
+		return true
+	}
+	for _, tag := range p.IgnoreTags {
+		if test.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// SlowAfterDuration returns the warning threshold (0 if unset)
+func (p *ExitPolicy) SlowAfterDuration() time.Duration {
+	d, _ := time.ParseDuration(p.SlowAfter)
+	return d
+}
+
+// validate checks the threshold and exit code
+func (p *ExitPolicy) validate() error {
+	if p.SlowAfter != "" {
+		if _, err := time.ParseDuration(p.SlowAfter); err != nil {
+			return fmt.Errorf("exit_policy: invalid slow_after %q: %w", p.SlowAfter, err)
+		}
+	}
+	if p.WarningExitCode < 0 || p.WarningExitCode > 125 {
+		return fmt.Errorf("exit_policy: warning_exit_code must be 0-125, got %d", p.WarningExitCode)
+	}
+	return nil
+}
+
+// PayloadConfig selects how upload data is generated
+type PayloadConfig struct {
+	Source  string `yaml:"source,omitempty"`  // "crypto" (default), "math", "zeros", "pattern", or "file"
+	Pattern string `yaml:"pattern,omitempty"` // Repeated string for "pattern" (default: "synthetics")
+	File    string `yaml:"file,omitempty"`    // File whose contents are repeated for "file" (up to 64MB)
+}
+
+// Payload sources
+const (
+	PayloadCrypto  = "crypto"  // crypto/rand: incompressible, slowest
+	PayloadMath    = "math"    // math/rand ChaCha8: incompressible, several times faster
+	PayloadZeros   = "zeros"   // All zero bytes: free, but trivially compressible
+	PayloadPattern = "pattern" // A repeated string
+	PayloadFile    = "file"    // A file's contents, repeated
+)
+
+// GetSource returns the payload source (with default "crypto")
+func (p *PayloadConfig) GetSource() string {
+	if p.Source == "" {
+		return PayloadCrypto
+	}
+	return p.Source
+}
+
+// GetPattern returns the repeated string of the pattern source
+func (p *PayloadConfig) GetPattern() string {
+	if p.Pattern == "" {
+		return "synthetics"
+	}
+	return p.Pattern
+}
+
+// validate checks the source and its options
+func (p *PayloadConfig) validate() error {
+	switch p.GetSource() {
+	case PayloadCrypto, PayloadMath, PayloadZeros, PayloadPattern:
+		return nil
+	case PayloadFile:
+		if p.File == "" {
+			return fmt.Errorf("payload source file requires file")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown payload source %q (expected crypto, math, zeros, pattern, or file)", p.Source)
+	}
+}
+
+// GetPayload returns the payload config of a step, falling back to the
+// global one
+func (c *Config) GetPayload(step *TestStep) PayloadConfig {
+	if step.Payload != nil {
+		return *step.Payload
+	}
+	return c.Payload
+}
+
+// SchedulerConfig holds scheduler settings
+type SchedulerConfig struct {
+	MaxConcurrent int            `yaml:"max_concurrent,omitempty"` // Max tests running at once; queued runs start by priority (0 = unlimited)
+	Events        EventLogConfig `yaml:"events,omitempty"`
+	StateFile     string         `yaml:"state_file,omitempty"` // Optional: JSON file persisting each test's last run across restarts
+
+	// Default concurrency_policy of tests that don't set one (default: "allow")
+	ConcurrencyPolicy string `yaml:"concurrency_policy,omitempty"`
+}
+
+// ShadowConfig runs the tests of a candidate config next to the active ones,
+// so new test definitions can be validated against production before they
+// are promoted
+type ShadowConfig struct {
+	Config string `yaml:"config,omitempty"` // Path or URL of the candidate config (reloaded like the active one)
+	Window string `yaml:"window,omitempty"` // Window shadow and active results are compared over (default: "1h")
+}
+
+// DefaultShadowWindow is the default window of shadow comparisons
+const DefaultShadowWindow = time.Hour
+
+// WindowDuration returns the comparison window (with default DefaultShadowWindow)
+func (s *ShadowConfig) WindowDuration() time.Duration {
+	d, err := time.ParseDuration(s.Window)
+	if err != nil || d <= 0 {
+		return DefaultShadowWindow
+	}
+	return d
+}
+
+// ResultsConfig enables the results store, which keeps every run with its
+// steps, errors, and HTTP phases for lookup after the fact
+type ResultsConfig struct {
+	Path      string `yaml:"path,omitempty"`      // SQLite database file; the store is disabled if empty
+	Retention string `yaml:"retention,omitempty"` // How long runs are kept (default: "720h")
+}
+
+// DefaultResultsRetention is the default age after which stored runs are deleted
+const DefaultResultsRetention = 30 * 24 * time.Hour
+
+// RetentionDuration returns how long runs are kept (with default DefaultResultsRetention)
+func (r *ResultsConfig) RetentionDuration() time.Duration {
+	d, err := time.ParseDuration(r.Retention)
+	if err != nil || d <= 0 {
+		return DefaultResultsRetention
+	}
+	return d
+}
+
+// ReadinessConfig sets what /ready waits for besides a started scheduler
+// with an executor for every enabled test
+type ReadinessConfig struct {
+	Connectivity bool   `yaml:"connectivity,omitempty"` // Wait until every S3 endpoint and satellite accepted a TCP connection once
+	Timeout      string `yaml:"timeout,omitempty"`      // Connect timeout of each attempt (default: "5s")
+}
+
+// DefaultReadinessTimeout is the default readiness.timeout
+const DefaultReadinessTimeout = 5 * time.Second
+
+// TimeoutDuration returns the connect timeout (default DefaultReadinessTimeout)
+func (r *ReadinessConfig) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(r.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultReadinessTimeout
+}
+
+// AnomalyConfig flags runs whose duration strays from their test's rolling
+// baseline: an EWMA of the durations of its successful runs, and of their
+// variance
+type AnomalyConfig struct {
+	Enabled        bool    `yaml:"enabled"`
+	Threshold      float64 `yaml:"threshold,omitempty"`       // Standard deviations from the baseline that flag a run (default: 3)
+	Alpha          float64 `yaml:"alpha,omitempty"`           // Weight of each run in the baseline, between 0 and 1 (default: 0.1)
+	MinRuns        int     `yaml:"min_runs,omitempty"`        // Successful runs that form the baseline before runs are flagged (default: 10)
+	Webhook        string  `yaml:"webhook,omitempty"`         // Optional: URL notified of each anomaly with a JSON POST
+	NotifyInterval string  `yaml:"notify_interval,omitempty"` // Minimum time between notifications about a test (default: "15m")
+}
+
+// Anomaly detection defaults
+const (
+	DefaultAnomalyThreshold      = 3.0
+	DefaultAnomalyAlpha          = 0.1
+	DefaultAnomalyMinRuns        = 10
+	DefaultAnomalyNotifyInterval = 15 * time.Minute
+)
+
+// GetThreshold returns the deviation that flags a run (with default DefaultAnomalyThreshold)
+func (a *AnomalyConfig) GetThreshold() float64 {
+	if a.Threshold <= 0 {
+		return DefaultAnomalyThreshold
+	}
+	return a.Threshold
+}
+
+// GetAlpha returns the weight of each run in the baseline (with default DefaultAnomalyAlpha)
+func (a *AnomalyConfig) GetAlpha() float64 {
+	if a.Alpha <= 0 || a.Alpha > 1 {
+		return DefaultAnomalyAlpha
+	}
+	return a.Alpha
+}
+
+// GetMinRuns returns the runs needed before flagging (with default DefaultAnomalyMinRuns)
+func (a *AnomalyConfig) GetMinRuns() int {
+	if a.MinRuns <= 0 {
+		return DefaultAnomalyMinRuns
+	}
+	return a.MinRuns
+}
+
+// NotifyIntervalDuration returns the minimum time between notifications about
+// a test (with default DefaultAnomalyNotifyInterval)
+func (a *AnomalyConfig) NotifyIntervalDuration() time.Duration {
+	d, err := time.ParseDuration(a.NotifyInterval)
+	if err != nil || d <= 0 {
+		return DefaultAnomalyNotifyInterval
+	}
+	return d
+}
+
+// validate checks the anomaly settings
+func (a *AnomalyConfig) validate() error {
+	if a.Threshold < 0 {
+		return fmt.Errorf("invalid threshold %v (expected a positive number of standard deviations)", a.Threshold)
+	}
+	if a.Alpha < 0 || a.Alpha > 1 {
+		return fmt.Errorf("invalid alpha %v (expected a weight between 0 and 1)", a.Alpha)
+	}
+	if a.MinRuns < 0 {
+		return fmt.Errorf("invalid min_runs %d", a.MinRuns)
+	}
+	if a.Webhook != "" {
+		if u, err := url.Parse(a.Webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook %q (expected an http or https URL)", a.Webhook)
+		}
+	}
+	if a.NotifyInterval != "" {
+		if d, err := time.ParseDuration(a.NotifyInterval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid notify_interval %q", a.NotifyInterval)
+		}
+	}
+	return nil
+}
+
+// EventLogConfig configures the scheduler event log
+type EventLogConfig struct {
+	Size int    `yaml:"size,omitempty"` // Events kept in memory (default: 1000)
+	File string `yaml:"file,omitempty"` // Optional: JSON Lines file events are appended to and reloaded from
+}
+
+// WebhookConfig enables POST /api/v1/webhook/{tag}, which runs a test group
+// when called with a body signed by the shared secret (HMAC-SHA256)
+type WebhookConfig struct {
+	Secret string `yaml:"secret,omitempty"` // Shared secret; the webhook is disabled if empty
+}
+
+// APIConfig protects the admin API routes that change state or start runs
+// (enabling and disabling tags, running tags and tests, verifications). They
+// are only served when Token is set, and require it as a bearer token.
+type APIConfig struct {
+	Token string `yaml:"token,omitempty"` // Bearer token of the mutating routes; they are not served if empty
+}
+
+// WorkDirConfig sets the directory all run files (k6 output, curl transfer
+// files, test data) are written under; see package workdir
+type WorkDirConfig struct {
+	Path         string   `yaml:"path,omitempty"`          // Default: workdir.DefaultDir ("/tmp/synthetics")
+	MaxSize      ByteSize `yaml:"max_size,omitempty"`      // Optional: runs that would grow the directory past this are skipped
+	CleanupAfter string   `yaml:"cleanup_after,omitempty"` // Remove orphaned temp files older than this (default: "1h")
+}
+
+// DefaultTempCleanupAfter is the default work_dir.cleanup_after
+const DefaultTempCleanupAfter = time.Hour
+
+// CleanupAfterDuration returns the age after which orphaned temp files are
+// removed (default DefaultTempCleanupAfter)
+func (w *WorkDirConfig) CleanupAfterDuration() time.Duration {
+	if d, err := time.ParseDuration(w.CleanupAfter); err == nil && d > 0 {
+		return d
+	}
+	return DefaultTempCleanupAfter
+}
+
+// SubprocessConfig limits the resources of each k6, curl, ping, and
+// mtr/traceroute subprocess, so a runaway script can't take down the probe;
+// see package subproc
+type SubprocessConfig struct {
+	MaxMemory    ByteSize     `yaml:"max_memory,omitempty"`     // Address space per subprocess (RLIMIT_AS; 0 = unlimited)
+	MaxCPUTime   string       `yaml:"max_cpu_time,omitempty"`   // CPU time per subprocess (RLIMIT_CPU, e.g. "5m"; empty = unlimited)
+	MaxOpenFiles int          `yaml:"max_open_files,omitempty"` // Open files per subprocess (RLIMIT_NOFILE; 0 = inherited)
+	Cgroup       CgroupConfig `yaml:"cgroup,omitempty"`         // Optional: place subprocesses in a cgroup
+	ReapInterval string       `yaml:"reap_interval,omitempty"`  // How often orphaned k6 processes are killed (default: "5m")
+}
+
+// DefaultReapInterval is how often orphaned k6 processes are looked for
+// unless subprocess.reap_interval is set
+const DefaultReapInterval = 5 * time.Minute
+
+// CgroupConfig places all subprocesses in one cgroup v2 group, which bounds
+// their combined memory and CPU
+type CgroupConfig struct {
+	Path      string   `yaml:"path,omitempty"`       // cgroup directory, created if missing (empty = off)
+	MemoryMax ByteSize `yaml:"memory_max,omitempty"` // Written to memory.max (0 = leave as is)
+	CPUs      float64  `yaml:"cpus,omitempty"`       // Written to cpu.max as a share of CPUs (0 = leave as is)
+}
+
+// MaxCPUTimeDuration returns the CPU time limit per subprocess (0 = unlimited)
+func (s *SubprocessConfig) MaxCPUTimeDuration() time.Duration {
+	if d, err := time.ParseDuration(s.MaxCPUTime); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// ReapIntervalDuration returns how often orphaned k6 processes are looked for
+func (s *SubprocessConfig) ReapIntervalDuration() time.Duration {
+	if d, err := time.ParseDuration(s.ReapInterval); err == nil && d > 0 {
+		return d
+	}
+	return DefaultReapInterval
+}
+
+// AgentConfig configures pushing results from a probe to an aggregator
+type AgentConfig struct {
+	AggregatorURL string `yaml:"aggregator_url"`
+	Probe         string `yaml:"probe,omitempty"`         // Probe name (default: hostname)
+	PushInterval  string `yaml:"push_interval,omitempty"` // Default: "30s"
+	Token         string `yaml:"token,omitempty"`         // Shared secret sent as a bearer token
+}
+
+// AggregatorConfig configures the central aggregator
+type AggregatorConfig struct {
+	Token      string `yaml:"token,omitempty"`       // Required bearer token for pushes
+	StaleAfter string `yaml:"stale_after,omitempty"` // Drop probes that have not pushed for this long from /metrics (default: "5m"); forget them after 12 times this
+}
+
+// PushIntervalDuration returns the push interval as a time.Duration
+func (a *AgentConfig) PushIntervalDuration() time.Duration {
+	d, err := time.ParseDuration(a.PushInterval)
+	if err != nil || d <= 0 {
+		return 30 * time.Second // default
+	}
+	return d
+}
+
+// StaleAfterDuration returns the probe staleness window as a time.Duration
+func (a *AggregatorConfig) StaleAfterDuration() time.Duration {
+	d, err := time.ParseDuration(a.StaleAfter)
+	if err != nil || d <= 0 {
+		return 5 * time.Minute // default
+	}
+	return d
+}
+
+// TracerouteConfig configures network path traces with mtr or traceroute
+type TracerouteConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Command     string   `yaml:"command,omitempty"`      // "mtr" (default) or "traceroute"
+	BinaryPath  string   `yaml:"binary_path,omitempty"`  // Default: the command, found in PATH
+	Count       int      `yaml:"count,omitempty"`        // Probes per hop (default: 5)
+	MaxHops     int      `yaml:"max_hops,omitempty"`     // Default: 30
+	Timeout     string   `yaml:"timeout,omitempty"`      // Per-trace timeout (default: "60s")
+	Interval    string   `yaml:"interval,omitempty"`     // Trace all targets this often (e.g. "15m"); empty disables periodic traces
+	OnFailure   bool     `yaml:"on_failure,omitempty"`   // Trace the hosts of a test that failed
+	MinInterval string   `yaml:"min_interval,omitempty"` // Minimum time between failure-triggered traces of a host (default: "5m")
+	Targets     []string `yaml:"targets,omitempty"`      // Hosts for periodic traces (default: S3 endpoint and satellite hosts)
+}
+
+// Traceroute commands
+const (
+	TraceCommandMTR        = "mtr"
+	TraceCommandTraceroute = "traceroute"
+)
+
+// GetCommand returns the trace command (with default "mtr")
+func (t *TracerouteConfig) GetCommand() string {
+	if t.Command == "" {
+		return TraceCommandMTR
+	}
+	return t.Command
+}
+
+// GetCount returns the number of probes per hop (with default 5)
+func (t *TracerouteConfig) GetCount() int {
+	if t.Count <= 0 {
+		return 5
+	}
+	return t.Count
+}
+
+// GetMaxHops returns the maximum path length (with default 30)
+func (t *TracerouteConfig) GetMaxHops() int {
+	if t.MaxHops <= 0 {
+		return 30
+	}
+	return t.MaxHops
+}
+
+// TimeoutDuration returns the per-trace timeout as a time.Duration
+func (t *TracerouteConfig) TimeoutDuration() time.Duration {
+	d, err := time.ParseDuration(t.Timeout)
+	if err != nil || d <= 0 {
+		return 60 * time.Second // default
+	}
+	return d
+}
+
+// IntervalDuration returns the periodic trace interval, or 0 if periodic traces are off
+func (t *TracerouteConfig) IntervalDuration() time.Duration {
+	d, err := time.ParseDuration(t.Interval)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// MinIntervalDuration returns the minimum time between failure-triggered traces of a host
+func (t *TracerouteConfig) MinIntervalDuration() time.Duration {
+	d, err := time.ParseDuration(t.MinInterval)
+	if err != nil || d <= 0 {
+		return 5 * time.Minute // default
+	}
+	return d
 }
 
 // JitterConfig holds jitter configuration
 type JitterConfig struct {
 	Enabled *bool  `yaml:"enabled,omitempty"` // nil = inherit from parent, false = disabled
 	Max     string `yaml:"max,omitempty"`     // Max jitter: duration ("30s") or percentage ("10%")
+
+	// Shrink jitter by the test's recent wait for a run slot, so runs still
+	// start within max of their schedule when the scheduler is busy
+	Compensate *bool `yaml:"compensate,omitempty"`
+}
+
+// SatelliteConfig holds Storj satellite configuration
+type SatelliteConfig struct {
+	Name        string `yaml:"name,omitempty"` // Selected by tests' satellite field; labels uplink metrics (default: "default")
+	AccessGrant string `yaml:"access_grant"`
+	Bucket      string `yaml:"bucket"` // Default bucket of tests using this satellite (default: satellite.bucket)
+}
+
+// DefaultSatellite names the top-level satellite unless it sets a name
+const DefaultSatellite = "default"
+
+// GetName returns the satellite's name (with default DefaultSatellite)
+func (s *SatelliteConfig) GetName() string {
+	if s.Name == "" {
+		return DefaultSatellite
+	}
+	return s.Name
+}
+
+// GetSatellite returns the named satellite; an empty name selects the
+// top-level one. Satellites without a bucket inherit satellite.bucket.
+func (c *Config) GetSatellite(name string) (SatelliteConfig, error) {
+	if name == "" || name == c.Satellite.GetName() {
+		return c.Satellite, nil
+	}
+	for _, sat := range c.Satellites {
+		if sat.Name == name {
+			if sat.Bucket == "" {
+				sat.Bucket = c.Satellite.Bucket
+			}
+			return sat, nil
+		}
+	}
+	return SatelliteConfig{}, fmt.Errorf("unknown satellite %q", name)
+}
+
+// AllSatellites returns every configured satellite with an access grant, the
+// top-level one first
+func (c *Config) AllSatellites() []SatelliteConfig {
+	var out []SatelliteConfig
+	if c.Satellite.AccessGrant != "" {
+		out = append(out, c.Satellite)
+	}
+	for _, sat := range c.Satellites {
+		if sat.Bucket == "" {
+			sat.Bucket = c.Satellite.Bucket
+		}
+		out = append(out, sat)
+	}
+	return out
+}
+
+// validateSatellites checks satellite names are set and unique
+func (c *Config) validateSatellites() error {
+	seen := map[string]bool{c.Satellite.GetName(): true}
+	for _, sat := range c.Satellites {
+		if sat.Name == "" {
+			return fmt.Errorf("satellites: every entry needs a name")
+		}
+		if seen[sat.Name] {
+			return fmt.Errorf("satellites: duplicate name %q", sat.Name)
+		}
+		if sat.AccessGrant == "" {
+			return fmt.Errorf("satellites: %s has no access_grant", sat.Name)
+		}
+		seen[sat.Name] = true
+	}
+	return nil
+}
+
+// S3Config holds S3 gateway configuration
+type S3Config struct {
+	Name      string `yaml:"name,omitempty"` // Selected by tests' gateway field (default: "default")
+	Endpoint  string `yaml:"endpoint"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	Region    string `yaml:"region"`
+
+	// Optional: session token of temporary (STS) credentials, sent as
+	// X-Amz-Security-Token. Short-lived credentials are rotated by updating
+	// the config, which reinitializes the executors.
+	SessionToken string `yaml:"session_token,omitempty"`
+
+	TLSCheck TLSCheckConfig `yaml:"tls_check,omitempty"` // Optional: deeper TLS validation (http-s3 executor)
+
+	// Optional: client certificate for mTLS-terminating proxies (http-s3 and curl-s3 executors)
+	ClientCert string `yaml:"client_cert,omitempty"` // PEM certificate path
+	ClientKey  string `yaml:"client_key,omitempty"`  // PEM private key path
+
+	Headers map[string]string `yaml:"headers,omitempty"` // Optional: extra headers sent with every request
+}
+
+// DefaultGateway names the top-level S3 gateway unless it sets a name
+const DefaultGateway = "default"
+
+// GetName returns the gateway's name (with default DefaultGateway)
+func (s *S3Config) GetName() string {
+	if s.Name == "" {
+		return DefaultGateway
+	}
+	return s.Name
+}
+
+// GetGateway returns the named S3 gateway; an empty name selects the
+// top-level s3 section. Named gateways inherit the credentials, region,
+// client certificate, and headers they don't set from the s3 section.
+func (c *Config) GetGateway(name string) (S3Config, error) {
+	if name == "" || name == c.S3.GetName() {
+		return c.S3, nil
+	}
+	for _, gw := range c.S3Gateways {
+		if gw.Name != name {
+			continue
+		}
+		if gw.AccessKey == "" && gw.SecretKey == "" {
+			gw.AccessKey, gw.SecretKey, gw.SessionToken = c.S3.AccessKey, c.S3.SecretKey, c.S3.SessionToken
+		}
+		if gw.Region == "" {
+			gw.Region = c.S3.Region
+		}
+		if gw.ClientCert == "" && gw.ClientKey == "" {
+			gw.ClientCert, gw.ClientKey = c.S3.ClientCert, c.S3.ClientKey
+		}
+		gw.Headers = MergeHeaders(c.S3.Headers, gw.Headers)
+		return gw, nil
+	}
+	return S3Config{}, fmt.Errorf("unknown gateway %q", name)
+}
+
+// validateGateways checks gateway names are set and unique, and that each
+// has an endpoint
+func (c *Config) validateGateways() error {
+	seen := map[string]bool{c.S3.GetName(): true}
+	for _, gw := range c.S3Gateways {
+		if gw.Name == "" {
+			return fmt.Errorf("s3_gateways: every entry needs a name")
+		}
+		if seen[gw.Name] {
+			return fmt.Errorf("s3_gateways: duplicate name %q", gw.Name)
+		}
+		if gw.Endpoint == "" {
+			return fmt.Errorf("s3_gateways: %s has no endpoint", gw.Name)
+		}
+		if err := validateHeaders(gw.Headers); err != nil {
+			return fmt.Errorf("s3_gateways: %s: %w", gw.Name, err)
+		}
+		seen[gw.Name] = true
+	}
+	return nil
+}
+
+// reservedHeaders are set by request signing and cannot be configured
+var reservedHeaders = []string{"Authorization", "Host", "X-Amz-Date", "X-Amz-Content-Sha256"}
+
+// validateHeaders rejects configured headers that signing controls
+func validateHeaders(headers map[string]string) error {
+	for name := range headers {
+		for _, reserved := range reservedHeaders {
+			if strings.EqualFold(name, reserved) {
+				return fmt.Errorf("header %s is set by request signing and cannot be configured", name)
+			}
+		}
+	}
+	return nil
+}
+
+// MergeHeaders returns base with override applied; header names are compared
+// case-insensitively
+func MergeHeaders(base, override map[string]string) map[string]string {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for name, value := range base {
+		merged[name] = value
+	}
+	for name, value := range override {
+		for existing := range merged {
+			if strings.EqualFold(existing, name) {
+				delete(merged, existing)
+			}
+		}
+		merged[name] = value
+	}
+	return merged
+}
+
+// TLSCheckConfig configures certificate chain, hostname, and revocation checks
+type TLSCheckConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	CAFile          string `yaml:"ca_file,omitempty"`          // PEM bundle of trusted roots (default: system roots)
+	CheckRevocation bool   `yaml:"check_revocation,omitempty"` // Check OCSP/CRL revocation status
+}
+
+// Test defines a synthetic test (1+ sequential steps)
+type Test struct {
+	Name      string        `yaml:"name"`
+	Schedule  string        `yaml:"schedule"`
+	Enabled   bool          `yaml:"enabled"`
+	Executor  string        `yaml:"executor"`            // Executor type: "uplink", "s3", "http-s3", "curl-s3", "compare", "bakeoff", "rtt", "canary", or "ttfb" (default: "uplink")
+	Bucket    *string       `yaml:"bucket,omitempty"`    // Optional: override global bucket
+	Satellite string        `yaml:"satellite,omitempty"` // Optional: named satellite for uplink tests (default: the top-level satellite)
+	Gateway   string        `yaml:"gateway,omitempty"`   // Optional: named S3 gateway for s3, http-s3, curl-s3, bakeoff, canary, and ttfb tests (default: the s3 section)
+	Filename  *string       `yaml:"filename"`            // Optional: custom filename
+	Jitter    *JitterConfig `yaml:"jitter,omitempty"`    // Optional: test-level jitter override
+	Tags      []string      `yaml:"tags,omitempty"`      // Optional: group labels (e.g. "critical", "large-files")
+	Metrics   string        `yaml:"metrics,omitempty"`   // Metric verbosity: "minimal", "standard", or "detailed" (default)
+	Priority  int           `yaml:"priority,omitempty"`  // Higher runs first when scheduler.max_concurrent is reached (default: 0)
+	Steps     []TestStep    `yaml:"steps"`               // Required: 1+ steps, unless workload is set
+
+	Workload *WorkloadConfig `yaml:"workload,omitempty"` // Optional: expand into a weighted operation mix instead of listing steps
+
+	// Optional: overall deadline across all steps (e.g. "4m"). Steps get at
+	// most their own timeout and never run past the test deadline.
+	Timeout string `yaml:"timeout,omitempty"`
+	Budget  string `yaml:"budget,omitempty"` // Step budgeting under a test timeout: "remaining" (default) or "proportional"
+
+	RetryOnFailure *RetryConfig `yaml:"retry_on_failure,omitempty"` // Optional: re-run the whole test after a failure
+
+	// What a scheduled run does while the test's previous run is still in
+	// flight: "allow" it to overlap, "forbid" (skip this run), or "replace"
+	// (cancel the previous run). Default: scheduler.concurrency_policy.
+	ConcurrencyPolicy string `yaml:"concurrency_policy,omitempty"`
+
+	When *When `yaml:"when,omitempty"` // Optional: only run on matching probes and days
+
+	Compare []CompareEndpoint `yaml:"compare,omitempty"` // Endpoints for the "compare" executor (2+)
+	Bakeoff []string          `yaml:"bakeoff,omitempty"` // Executors the "bakeoff" executor runs the steps through, in order (default: DefaultBakeoff)
+	RTT     *RTTConfig        `yaml:"rtt,omitempty"`     // Options for the "rtt" executor
+	Canary  *CanaryConfig     `yaml:"canary,omitempty"`  // Objects for the "canary" executor
+	TTFB    *TTFBConfig       `yaml:"ttfb,omitempty"`    // Object and SLA of the "ttfb" executor
+
+	// Optional: read the named fixture's object instead of uploading one.
+	// Only download steps are allowed.
+	Fixture string `yaml:"fixture,omitempty"`
+
+	FixtureUpload string `yaml:"-"` // Set on the generated test that uploads the named fixture
+	Tenant        string `yaml:"-"` // Set on the tests of the named tenant
+}
+
+// RTTConfig configures the "rtt" executor's round-trip time and packet loss probes
+type RTTConfig struct {
+	Method   string   `yaml:"method,omitempty"`   // "tcp" (default, TCP connect time) or "icmp" (ping subprocess)
+	Count    int      `yaml:"count,omitempty"`    // Probes per target (default: 5)
+	Interval string   `yaml:"interval,omitempty"` // Delay between probes (default: "200ms")
+	Timeout  string   `yaml:"timeout,omitempty"`  // Per-probe timeout (default: "2s")
+	Targets  []string `yaml:"targets,omitempty"`  // host:port (tcp) or host (icmp); default: S3 endpoint and satellite
+}
+
+// RTT probe methods
+const (
+	RTTMethodTCP  = "tcp"
+	RTTMethodICMP = "icmp"
+)
+
+// GetMethod returns the probe method (with default "tcp")
+func (r *RTTConfig) GetMethod() string {
+	if r == nil || r.Method == "" {
+		return RTTMethodTCP
+	}
+	return r.Method
+}
+
+// GetCount returns the number of probes per target (with default 5)
+func (r *RTTConfig) GetCount() int {
+	if r == nil || r.Count <= 0 {
+		return 5
+	}
+	return r.Count
+}
+
+// IntervalDuration returns the delay between probes as a time.Duration
+func (r *RTTConfig) IntervalDuration() time.Duration {
+	if r == nil {
+		return 200 * time.Millisecond
+	}
+	d, err := time.ParseDuration(r.Interval)
+	if err != nil || d < 0 {
+		return 200 * time.Millisecond // default
+	}
+	return d
+}
+
+// TimeoutDuration returns the per-probe timeout as a time.Duration
+func (r *RTTConfig) TimeoutDuration() time.Duration {
+	if r == nil {
+		return 2 * time.Second
+	}
+	d, err := time.ParseDuration(r.Timeout)
+	if err != nil || d <= 0 {
+		return 2 * time.Second // default
+	}
+	return d
+}
+
+// CanaryConfig configures the "canary" executor's long-lived objects
+type CanaryConfig struct {
+	Prefix string     `yaml:"prefix,omitempty"` // Object key prefix (default: "canary/")
+	Sizes  []ByteSize `yaml:"sizes,omitempty"`  // One canary object per size (default: 1KB, 1MB, 10MB)
+}
+
+// GetPrefix returns the object key prefix (with default "canary/")
+func (c *CanaryConfig) GetPrefix() string {
+	if c == nil || c.Prefix == "" {
+		return "canary/"
+	}
+	return c.Prefix
+}
+
+// GetSizes returns the canary object sizes (with default 1KB, 1MB, 10MB)
+func (c *CanaryConfig) GetSizes() []ByteSize {
+	if c == nil || len(c.Sizes) == 0 {
+		return []ByteSize{1 << 10, 1 << 20, 10 << 20}
+	}
+	return c.Sizes
+}
+
+// TTFBConfig configures the "ttfb" executor's first-byte SLA checks of a
+// small hot object
+type TTFBConfig struct {
+	Size      *ByteSize `yaml:"size,omitempty"`      // Object size (default: 4KB)
+	Key       string    `yaml:"key,omitempty"`       // Object key (default: "ttfb/<size>.bin")
+	Requests  int       `yaml:"requests,omitempty"`  // GETs per run, at most 100 (default: 5)
+	Threshold string    `yaml:"threshold,omitempty"` // SLA on the median time to first byte of a run (default: "100ms")
+}
+
+// GetSize returns the object size (with default 4KB)
+func (t *TTFBConfig) GetSize() ByteSize {
+	if t == nil || t.Size == nil || *t.Size <= 0 {
+		return 4 << 10
+	}
+	return *t.Size
+}
+
+// GetKey returns the object key (with default "ttfb/<size>.bin")
+func (t *TTFBConfig) GetKey() string {
+	if t == nil || t.Key == "" {
+		return "ttfb/" + t.GetSize().String() + ".bin"
+	}
+	return t.Key
+}
+
+// GetRequests returns the GETs per run (with default 5)
+func (t *TTFBConfig) GetRequests() int {
+	if t == nil || t.Requests <= 0 {
+		return 5
+	}
+	return t.Requests
+}
+
+// ThresholdDuration returns the SLA on a run's median time to first byte
+// (default 100ms)
+func (t *TTFBConfig) ThresholdDuration() time.Duration {
+	if t == nil {
+		return 100 * time.Millisecond
+	}
+	d, err := time.ParseDuration(t.Threshold)
+	if err != nil || d <= 0 {
+		return 100 * time.Millisecond // default
+	}
+	return d
+}
+
+// validate checks the threshold and request count
+func (t *TTFBConfig) validate() error {
+	if t == nil {
+		return nil
+	}
+	if t.Threshold != "" {
+		if d, err := time.ParseDuration(t.Threshold); err != nil || d <= 0 {
+			return fmt.Errorf("ttfb: invalid threshold %q", t.Threshold)
+		}
+	}
+	if t.Requests < 0 || t.Requests > 100 {
+		return fmt.Errorf("ttfb: requests must be between 1 and 100, or 0 for the default of 5")
+	}
+	return nil
+}
+
+// RetryConfig re-runs a failed test to tell transient blips from sustained outages
+type RetryConfig struct {
+	Count   int    `yaml:"count"`             // Retries after the first failure
+	Backoff string `yaml:"backoff,omitempty"` // Delay before the first retry, doubled for each further retry (default: "10s")
+}
+
+// MaxRetries bounds retry_on_failure.count
+const MaxRetries = 10
+
+// MaxRetryBackoff caps the doubled delay between retries
+const MaxRetryBackoff = time.Hour
+
+// validate checks the retry count and backoff
+func (r *RetryConfig) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Count < 0 || r.Count > MaxRetries {
+		return fmt.Errorf("retry_on_failure: count must be between 0 and %d", MaxRetries)
+	}
+	if r.Backoff != "" {
+		if d, err := time.ParseDuration(r.Backoff); err != nil || d <= 0 {
+			return fmt.Errorf("retry_on_failure: invalid backoff %q", r.Backoff)
+		}
+	}
+	return nil
 }
 
-// SatelliteConfig holds Storj satellite configuration
-type SatelliteConfig struct {
-	AccessGrant string `yaml:"access_grant"`
-	Bucket      string `yaml:"bucket"`
+// BackoffDuration returns the delay before the given retry (1-based), at
+// most MaxRetryBackoff
+func (r *RetryConfig) BackoffDuration(retry int) time.Duration {
+	d, err := time.ParseDuration(r.Backoff)
+	if err != nil || d <= 0 {
+		d = 10 * time.Second // default
+	}
+	for i := 1; i < retry && d < MaxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, MaxRetryBackoff)
 }
 
-// S3Config holds S3 gateway configuration
-type S3Config struct {
+// CompareEndpoint is one side of a multi-endpoint latency comparison.
+// Unset credentials and region are inherited from the global S3 config.
+type CompareEndpoint struct {
+	Name      string `yaml:"name"`
 	Endpoint  string `yaml:"endpoint"`
-	AccessKey string `yaml:"access_key"`
-	SecretKey string `yaml:"secret_key"`
-	Region    string `yaml:"region"`
-}
+	AccessKey string `yaml:"access_key,omitempty"`
+	SecretKey string `yaml:"secret_key,omitempty"`
+	Region    string `yaml:"region,omitempty"`
 
-// Test defines a synthetic test (1+ sequential steps)
-type Test struct {
-	Name     string        `yaml:"name"`
-	Schedule string        `yaml:"schedule"`
-	Enabled  bool          `yaml:"enabled"`
-	Executor string        `yaml:"executor"`         // Executor type: "uplink" or "s3" (default: "uplink")
-	Bucket   *string       `yaml:"bucket,omitempty"` // Optional: override global bucket
-	Filename *string       `yaml:"filename"`         // Optional: custom filename
-	Jitter   *JitterConfig `yaml:"jitter,omitempty"` // Optional: test-level jitter override
-	Steps    []TestStep    `yaml:"steps"`            // Required: 1+ steps
+	SessionToken string `yaml:"session_token,omitempty"` // Optional: with access_key, for temporary credentials
+
+	Headers map[string]string `yaml:"headers,omitempty"` // Optional: added to (or replacing) the s3: section's headers
 }
 
 // ByteSize represents a file size that can be specified as bytes or human-readable format
@@ -80,6 +974,21 @@ func (bs *ByteSize) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// MarshalYAML renders the size in human-readable format
+func (bs ByteSize) MarshalYAML() (interface{}, error) {
+	return bs.String(), nil
+}
+
+// Set parses a human-readable size, so a ByteSize can be used as a flag.Value
+func (bs *ByteSize) Set(s string) error {
+	size, err := parseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*bs = ByteSize(size)
+	return nil
+}
+
 // Int64 returns the byte size as int64
 func (bs ByteSize) Int64() int64 {
 	return int64(bs)
@@ -160,22 +1069,107 @@ func parseByteSize(s string) (int64, error) {
 // TestStep defines a single step within a test
 type TestStep struct {
 	Name    string `yaml:"name"`
+	ID      string `yaml:"id,omitempty"` // Optional: ID later steps reference this step's outputs by (default: name)
 	Script  string `yaml:"script"`
 	Timeout string `yaml:"timeout"`
 
+	// Optional: consecutive steps with the same group name run concurrently
+	// (s3, http-s3, and curl-s3); groups and other steps run one after the other
+	Parallel string `yaml:"parallel,omitempty"`
+
+	// Gateway step options; may reference earlier steps' outputs, e.g. "{{steps.upload.key}}"
+	Key string `yaml:"key,omitempty"` // Object key (default: the run's filename)
+	URL string `yaml:"url,omitempty"` // URL a fetch step GETs without signing
+
 	// Upload options
-	FileSize   *ByteSize `yaml:"file_size,omitempty"`   // Size (e.g., "5MB", "512KB", or bytes)
-	TTLSeconds *int      `yaml:"ttl_seconds,omitempty"` // Time-to-live in seconds
+	FileSize   *ByteSize      `yaml:"file_size,omitempty"`   // Size (e.g., "5MB", "512KB", or bytes)
+	Payload    *PayloadConfig `yaml:"payload,omitempty"`     // Optional: override the global payload source
+	TTLSeconds *int           `yaml:"ttl_seconds,omitempty"` // Time-to-live in seconds
+
+	// Upload and read-after-write options
+	Metadata map[string]string `yaml:"metadata,omitempty"` // User metadata written with the object (x-amz-meta-*, or custom metadata on uplink)
 
 	// Download/Delete options
 	FilePrefix *string `yaml:"file_prefix,omitempty"` // File prefix filter
 
+	// Download options
+	MinSpeed      *ByteSize `yaml:"min_speed,omitempty"`      // Fail as "stalled" below this many bytes per second (e.g. "10KB")
+	MinSpeedTime  string    `yaml:"min_speed_time,omitempty"` // Window the rate is measured over (default: "30s")
+	VerifyContent bool      `yaml:"verify_content,omitempty"` // Check the SHA-256 of the downloaded bytes against what the run uploaded
+
+	// Stat options
+	VerifyMetadata bool `yaml:"verify_metadata,omitempty"` // Check the object's size and user metadata against what the run uploaded
+
 	// Delete options
 	MaxAgeMinutes *int `yaml:"max_age_minutes,omitempty"` // Max age for deletion
 	MaxDelete     *int `yaml:"max_delete,omitempty"`      // Max files to delete
 
+	// Read-after-write options
+	PollInterval string `yaml:"poll_interval,omitempty"` // Delay between read attempts (default: "100ms")
+	PollMethod   string `yaml:"poll_method,omitempty"`   // Read request: "head" (default) or "get"
+
+	// Upload abort options
+	AbortMethod string `yaml:"abort_method,omitempty"` // How the upload is aborted: "cancel" (default) or "close"
+
+	// HEAD benchmark options
+	Requests *int `yaml:"requests,omitempty"` // HEAD requests per run (default: 20)
+
+	// Range seek options
+	Seeks     *int      `yaml:"seeks,omitempty"`      // Ranged reads per run (default: 10)
+	RangeSize *ByteSize `yaml:"range_size,omitempty"` // Bytes per ranged read (default: "64KB")
+
+	// Multipart upload options
+	PartSize    *ByteSize `yaml:"part_size,omitempty"`   // Size of each part but the last (default: "5MB", the S3 minimum)
+	Concurrency int       `yaml:"concurrency,omitempty"` // Parts uploaded at once (default: 1)
+
+	// List options
+	Prefix  string `yaml:"prefix,omitempty"`   // Key prefix listed (default: the whole bucket)
+	MaxKeys int    `yaml:"max_keys,omitempty"` // Keys returned at most (default: 1000, the S3 maximum)
+
+	// Presign options
+	Expires string `yaml:"expires,omitempty"` // How long the presigned URL is valid (default: "15m")
+
 	// Jitter options
 	Jitter *JitterConfig `yaml:"jitter,omitempty"` // Optional: step-level jitter
+
+	When *When `yaml:"when,omitempty"` // Optional: skip the step unless these conditions hold
+}
+
+// DiskNeed estimates the most work directory space a run of the test uses
+// at once. curl-s3 writes each upload and download (or each part being
+// uploaded) to a temp file removed after the step, so the steps of a
+// parallel group add up; the other executors stream, and k6 output is small.
+func (t *Test) DiskNeed() int64 {
+	if t.GetExecutor() != "curl-s3" {
+		return 0
+	}
+	const defaultSize = 1 << 20 // Executors' default file_size
+	var need int64
+	object := int64(defaultSize) // Size of the run's object, as last uploaded
+	for _, group := range t.StepGroups() {
+		var groupNeed int64
+		for _, i := range group {
+			step := t.Steps[i]
+			switch step.Name {
+			case "upload":
+				object = defaultSize
+				if step.FileSize != nil {
+					object = step.FileSize.Int64()
+				}
+				groupNeed += object
+			case "multipart-upload":
+				object = defaultSize
+				if step.FileSize != nil {
+					object = step.FileSize.Int64()
+				}
+				groupNeed += min(object, step.GetPartSize()*int64(step.GetConcurrency()))
+			case "download":
+				groupNeed += object
+			}
+		}
+		need = max(need, groupNeed)
+	}
+	return need
 }
 
 // GetExecutor returns the executor type (with default "uplink")
@@ -186,6 +1180,50 @@ func (t *Test) GetExecutor() string {
 	return t.Executor
 }
 
+// ExecutorKey returns the key of the test's executor among those built for
+// a config: the executor type, suffixed with "@" and the gateway's name for
+// tests on a named S3 gateway
+func (t *Test) ExecutorKey() string {
+	if t.Gateway == "" {
+		return t.GetExecutor()
+	}
+	return t.GetExecutor() + "@" + t.Gateway
+}
+
+// gatewayExecutors are the executor types that can run against a named S3
+// gateway
+var gatewayExecutors = map[string]bool{"s3": true, "http-s3": true, "curl-s3": true, "bakeoff": true, "canary": true, "ttfb": true}
+
+// DefaultBakeoff is the executors a bakeoff test runs its steps through,
+// in order, unless it lists its own
+var DefaultBakeoff = []string{"uplink", "s3", "http-s3", "curl-s3"}
+
+// GetBakeoff returns the executors of a bakeoff test (with default DefaultBakeoff)
+func (t *Test) GetBakeoff() []string {
+	if len(t.Bakeoff) == 0 {
+		return DefaultBakeoff
+	}
+	return t.Bakeoff
+}
+
+// validateBakeoff checks the executors of a bakeoff test
+func (t *Test) validateBakeoff() error {
+	if len(t.Bakeoff) > 0 && t.GetExecutor() != "bakeoff" {
+		return fmt.Errorf("bakeoff is only supported by the bakeoff executor")
+	}
+	seen := make(map[string]bool)
+	for _, name := range t.Bakeoff {
+		if !slices.Contains(DefaultBakeoff, name) {
+			return fmt.Errorf("bakeoff executor %q must be one of %s", name, strings.Join(DefaultBakeoff, ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("bakeoff executor %q is listed twice", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
 // GetBucket returns the bucket for this test (test-specific or global)
 func (t *Test) GetBucket(globalBucket string) string {
 	if t.Bucket != nil && *t.Bucket != "" {
@@ -202,11 +1240,92 @@ func (t *Test) GetFilename(ulid string) string {
 	return fmt.Sprintf("%s-%s.bin", t.Name, ulid)
 }
 
+// HasTag returns true if the test carries the given tag
+func (t *Test) HasTag(tag string) bool {
+	for _, tt := range t.Tags {
+		if tt == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // IsSingleStep returns true if test has exactly one step
 func (t *Test) IsSingleStep() bool {
 	return len(t.Steps) == 1
 }
 
+// Step budgeting modes under a test timeout
+const (
+	BudgetRemaining    = "remaining"    // Each step may use all time left before the deadline
+	BudgetProportional = "proportional" // Time left is shared by the remaining steps' timeouts
+)
+
+// validBudget checks a budget value; empty is the default
+func validBudget(budget string) error {
+	switch budget {
+	case "", BudgetRemaining, BudgetProportional:
+		return nil
+	}
+	return fmt.Errorf("invalid budget %q (expected remaining or proportional)", budget)
+}
+
+// Concurrency policies of scheduled runs that fire while the previous run of
+// the test is still in flight
+const (
+	ConcurrencyAllow   = "allow"   // Start the run alongside the previous one
+	ConcurrencyForbid  = "forbid"  // Skip the run
+	ConcurrencyReplace = "replace" // Cancel the previous run, then start
+)
+
+// validConcurrencyPolicy checks a concurrency_policy value; empty is the default
+func validConcurrencyPolicy(policy string) error {
+	switch policy {
+	case "", ConcurrencyAllow, ConcurrencyForbid, ConcurrencyReplace:
+		return nil
+	}
+	return fmt.Errorf("invalid concurrency_policy %q (expected allow, forbid, or replace)", policy)
+}
+
+// validMetricsVerbosity checks a test's metrics verbosity; empty is the
+// default (detailed). metrics.ParseVerbosity maps the values.
+func validMetricsVerbosity(verbosity string) error {
+	switch strings.ToLower(verbosity) {
+	case "", "minimal", "standard", "detailed":
+		return nil
+	}
+	return fmt.Errorf("invalid metrics verbosity %q (expected minimal, standard, or detailed)", verbosity)
+}
+
+// GetConcurrencyPolicy returns the test's concurrency policy, falling back
+// to the scheduler's default and then "allow"
+func (t *Test) GetConcurrencyPolicy(defaultPolicy string) string {
+	switch {
+	case t.ConcurrencyPolicy != "":
+		return t.ConcurrencyPolicy
+	case defaultPolicy != "":
+		return defaultPolicy
+	}
+	return ConcurrencyAllow
+}
+
+// TimeoutDuration returns the test deadline as a time.Duration, or 0 if none is set
+func (t *Test) TimeoutDuration() time.Duration {
+	d, err := time.ParseDuration(t.Timeout)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// GetBudget returns the step budgeting mode (with default "remaining")
+func (t *Test) GetBudget() string {
+	if t.Budget == "" {
+		return BudgetRemaining
+	}
+	return t.Budget
+}
+
 // TimeoutDuration returns the timeout as a time.Duration
 func (t *TestStep) TimeoutDuration() time.Duration {
 	d, err := time.ParseDuration(t.Timeout)
@@ -216,6 +1335,170 @@ func (t *TestStep) TimeoutDuration() time.Duration {
 	return d
 }
 
+// MinSpeedDuration returns the window over which min_speed is enforced
+func (t *TestStep) MinSpeedDuration() time.Duration {
+	d, err := time.ParseDuration(t.MinSpeedTime)
+	if err != nil || d <= 0 {
+		return 30 * time.Second // default
+	}
+	return d
+}
+
+// Read-after-write poll methods
+const (
+	PollHead = "head"
+	PollGet  = "get"
+)
+
+// PollIntervalDuration returns the delay between read-after-write attempts
+func (t *TestStep) PollIntervalDuration() time.Duration {
+	d, err := time.ParseDuration(t.PollInterval)
+	if err != nil || d <= 0 {
+		return 100 * time.Millisecond // default
+	}
+	return d
+}
+
+// GetPollMethod returns the read-after-write request method (with default "head")
+func (t *TestStep) GetPollMethod() string {
+	if t.PollMethod == "" {
+		return PollHead
+	}
+	return t.PollMethod
+}
+
+// Upload abort methods
+const (
+	AbortCancel = "cancel" // Cancel the request context
+	AbortClose  = "close"  // Fail the request body, closing the connection
+)
+
+// GetAbortMethod returns how upload-abort aborts the upload (with default "cancel")
+func (t *TestStep) GetAbortMethod() string {
+	if t.AbortMethod == "" {
+		return AbortCancel
+	}
+	return t.AbortMethod
+}
+
+// Multipart upload limits: the default (and S3 minimum) part size, and the
+// most parts an upload may have
+const (
+	DefaultPartSize = 5 << 20
+	MaxParts        = 10000
+)
+
+// GetPartSize returns the multipart-upload part size (default DefaultPartSize)
+func (t *TestStep) GetPartSize() int64 {
+	if t.PartSize == nil || *t.PartSize <= 0 {
+		return DefaultPartSize
+	}
+	return t.PartSize.Int64()
+}
+
+// GetConcurrency returns how many parts multipart-upload sends at once (default 1)
+func (t *TestStep) GetConcurrency() int {
+	return max(t.Concurrency, 1)
+}
+
+// MaxListKeys is the most keys one ListObjectsV2 request returns
+const MaxListKeys = 1000
+
+// GetMaxKeys returns the max-keys of a list step (default MaxListKeys)
+func (t *TestStep) GetMaxKeys() int {
+	if t.MaxKeys <= 0 {
+		return MaxListKeys
+	}
+	return t.MaxKeys
+}
+
+// validateList checks a list step's max_keys
+func (t *TestStep) validateList() error {
+	if t.MaxKeys < 0 || t.MaxKeys > MaxListKeys {
+		return fmt.Errorf("invalid max_keys %d (expected 1-%d)", t.MaxKeys, MaxListKeys)
+	}
+	return nil
+}
+
+// validateMultipart checks that a multipart-upload step's object fits in
+// MaxParts parts
+func (t *TestStep) validateMultipart() error {
+	if t.Name != "multipart-upload" || t.FileSize == nil {
+		return nil
+	}
+	if parts := (t.FileSize.Int64() + t.GetPartSize() - 1) / t.GetPartSize(); parts > MaxParts {
+		return fmt.Errorf("file_size %s needs %d parts of %d bytes (max %d); raise part_size", t.FileSize, parts, t.GetPartSize(), MaxParts)
+	}
+	return nil
+}
+
+// VerifiesContent reports whether a download or stat step of the test checks
+// the content the run uploaded
+func (t *Test) VerifiesContent() bool {
+	return slices.ContainsFunc(t.Steps, func(s TestStep) bool { return s.VerifyContent || s.VerifyMetadata })
+}
+
+// validateVerifyContent checks that verify_content is only set on download
+// steps of gateway tests, after a step that uploads the object
+func (t *Test) validateVerifyContent() error {
+	uploaded := false
+	for _, step := range t.Steps {
+		switch {
+		case step.Name == "upload" || step.Name == "multipart-upload" || step.Name == "read-after-write":
+			uploaded = true
+		case !step.VerifyContent:
+		case step.Name != "download":
+			return fmt.Errorf("step %s: verify_content is only supported on download steps", step.Name)
+		case t.GetExecutor() == "uplink":
+			return fmt.Errorf("step %s: verify_content is not supported by the uplink executor", step.Name)
+		case !uploaded:
+			return fmt.Errorf("step %s: verify_content needs an upload step earlier in the test", step.Name)
+		}
+	}
+	return nil
+}
+
+// RequestCount returns the number of head-bench requests (default 20)
+func (t *TestStep) RequestCount() int {
+	if t.Requests == nil || *t.Requests <= 0 {
+		return 20
+	}
+	return *t.Requests
+}
+
+// SeekCount returns the number of range-seek reads (default 10)
+func (t *TestStep) SeekCount() int {
+	if t.Seeks == nil || *t.Seeks <= 0 {
+		return 10
+	}
+	return *t.Seeks
+}
+
+// GetRangeSize returns the bytes of each range-seek read (default 64KB)
+func (t *TestStep) GetRangeSize() int64 {
+	if t.RangeSize == nil || *t.RangeSize <= 0 {
+		return 64 << 10
+	}
+	return t.RangeSize.Int64()
+}
+
+// validateRangeSeek checks that a range-seek step runs on an executor that
+// implements it
+func (t *Test) validateRangeSeek() error {
+	for _, step := range t.Steps {
+		if step.Name != "range-seek" {
+			continue
+		}
+		if e := t.GetExecutor(); e != "http-s3" && e != "compare" {
+			return fmt.Errorf("step %s: range-seek is not supported by the %s executor (expected http-s3 or compare)", step.Name, e)
+		}
+		if step.Seeks != nil && (*step.Seeks < 1 || *step.Seeks > 1000) {
+			return fmt.Errorf("step %s: seeks must be between 1 and 1000", step.Name)
+		}
+	}
+	return nil
+}
+
 // K6Config holds k6 binary configuration
 type K6Config struct {
 	BinaryPath   string `yaml:"binary_path"`
@@ -226,12 +1509,45 @@ type K6Config struct {
 type MetricsConfig struct {
 	Port int    `yaml:"port"`
 	Path string `yaml:"path"`
+
+	// Age out live gauge series (last durations, HTTP phases, ...) that a
+	// test hasn't updated for this many of its schedule intervals (0 = never)
+	StaleIntervals int `yaml:"stale_intervals,omitempty"`
+
+	// Half-life of a run's weight in synthetics_availability_ratio (default: 1h)
+	AvailabilityHalfLife string `yaml:"availability_half_life,omitempty"`
+
+	// Series (label combinations) each metric may have; later ones are
+	// dropped and counted (default: 10000)
+	MaxSeries int `yaml:"max_series,omitempty"`
+
+	// Optional: JSON file counters are saved to at shutdown and restored
+	// from at startup
+	SnapshotFile string `yaml:"snapshot_file,omitempty"`
+}
+
+// DefaultAvailabilityHalfLife is the default metrics.availability_half_life
+const DefaultAvailabilityHalfLife = time.Hour
+
+// AvailabilityHalfLifeDuration returns the availability half-life (default DefaultAvailabilityHalfLife)
+func (m *MetricsConfig) AvailabilityHalfLifeDuration() time.Duration {
+	if d, err := time.ParseDuration(m.AvailabilityHalfLife); err == nil && d > 0 {
+		return d
+	}
+	return DefaultAvailabilityHalfLife
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level  string `yaml:"level"`
-	Format string `yaml:"format"`
+	Level      string `yaml:"level"`
+	Format     string `yaml:"format"`
+	RunSummary *bool  `yaml:"run_summary,omitempty"` // nil = enabled
+}
+
+// RunSummaryEnabled returns whether a one-line JSON summary is logged for
+// every completed run
+func (l *LoggingConfig) RunSummaryEnabled() bool {
+	return l.RunSummary == nil || *l.RunSummary
 }
 
 // IsEnabled returns whether jitter is enabled
@@ -242,6 +1558,11 @@ func (j *JitterConfig) IsEnabled() bool {
 	return *j.Enabled
 }
 
+// Compensates returns true if jitter is reduced by queueing delay
+func (j *JitterConfig) Compensates() bool {
+	return j != nil && j.Compensate != nil && *j.Compensate
+}
+
 // GetEffectiveJitter returns the effective jitter config, merging with parent
 func (j *JitterConfig) GetEffectiveJitter(parent *JitterConfig) JitterConfig {
 	result := JitterConfig{}
@@ -250,6 +1571,7 @@ func (j *JitterConfig) GetEffectiveJitter(parent *JitterConfig) JitterConfig {
 	if parent != nil {
 		result.Enabled = parent.Enabled
 		result.Max = parent.Max
+		result.Compensate = parent.Compensate
 	}
 
 	// Override with current values if set
@@ -260,6 +1582,9 @@ func (j *JitterConfig) GetEffectiveJitter(parent *JitterConfig) JitterConfig {
 		if j.Max != "" {
 			result.Max = j.Max
 		}
+		if j.Compensate != nil {
+			result.Compensate = j.Compensate
+		}
 	}
 
 	return result
@@ -295,8 +1620,12 @@ func (j *JitterConfig) ParseMaxJitter(scheduleInterval time.Duration) (time.Dura
 }
 
 // ParseCronInterval estimates the interval between cron executions
-// Supports common patterns like "*/5 * * * *" (every 5 min), "0 * * * *" (hourly), etc.
+// Supports common patterns like "@every 6h", "*/5 * * * *" (every 5 min), "0 * * * *" (hourly), etc.
 func ParseCronInterval(schedule string) (time.Duration, error) {
+	if every, ok := strings.CutPrefix(schedule, "@every "); ok {
+		return time.ParseDuration(every)
+	}
+
 	parts := strings.Fields(schedule)
 	if len(parts) < 5 {
 		return 0, fmt.Errorf("invalid cron schedule: %s", schedule)
@@ -347,18 +1676,115 @@ func (s *TestStep) GetStepJitter(testJitter *JitterConfig) JitterConfig {
 	return s.Jitter.GetEffectiveJitter(testJitter)
 }
 
+// redactHeaders hides configured header values, which may carry tokens
+func redactHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string]string, len(headers))
+	for name := range headers {
+		out[name] = redacted
+	}
+	return out
+}
+
+// redacted replaces secret values that are set
+const redacted = "REDACTED"
+
+// Redacted returns a copy of the config with credentials and tokens replaced
+func (c *Config) Redacted() *Config {
+	out := *c
+
+	redact := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+	redact(&out.Satellite.AccessGrant)
+	out.Satellites = make([]SatelliteConfig, len(c.Satellites))
+	copy(out.Satellites, c.Satellites)
+	for i := range out.Satellites {
+		redact(&out.Satellites[i].AccessGrant)
+	}
+	redact(&out.S3.AccessKey)
+	redact(&out.S3.SecretKey)
+	redact(&out.S3.SessionToken)
+	redact(&out.Agent.Token)
+	redact(&out.Aggregator.Token)
+	redact(&out.Webhook.Secret)
+	redact(&out.API.Token)
+	redact(&out.Anomaly.Webhook) // Chat webhook URLs embed their token
+	out.S3.Headers = redactHeaders(c.S3.Headers)
+	out.S3Gateways = make([]S3Config, len(c.S3Gateways))
+	copy(out.S3Gateways, c.S3Gateways)
+	for i := range out.S3Gateways {
+		redact(&out.S3Gateways[i].AccessKey)
+		redact(&out.S3Gateways[i].SecretKey)
+		redact(&out.S3Gateways[i].SessionToken)
+		out.S3Gateways[i].Headers = redactHeaders(c.S3Gateways[i].Headers)
+	}
+
+	out.Tenants = make([]Tenant, len(c.Tenants))
+	for i, tenant := range c.Tenants {
+		if tenant.S3 != nil {
+			s3 := *tenant.S3
+			redact(&s3.AccessKey)
+			redact(&s3.SecretKey)
+			redact(&s3.SessionToken)
+			s3.Headers = redactHeaders(s3.Headers)
+			tenant.S3 = &s3
+		}
+		if tenant.Satellite != nil {
+			sat := *tenant.Satellite
+			redact(&sat.AccessGrant)
+			tenant.Satellite = &sat
+		}
+		redact(&tenant.AnomalyWebhook)
+		out.Tenants[i] = tenant
+	}
+
+	out.Tests = make([]Test, len(c.Tests))
+	for i, test := range c.Tests {
+		if len(test.Compare) > 0 {
+			compare := make([]CompareEndpoint, len(test.Compare))
+			copy(compare, test.Compare)
+			for j := range compare {
+				redact(&compare[j].AccessKey)
+				redact(&compare[j].SecretKey)
+				redact(&compare[j].SessionToken)
+				compare[j].Headers = redactHeaders(compare[j].Headers)
+			}
+			test.Compare = compare
+		}
+		out.Tests[i] = test
+	}
+	return &out
+}
+
 // Load reads and parses the configuration file
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return Parse(data)
+}
 
+// Parse parses configuration data and applies defaults
+func Parse(data []byte) (*Config, error) {
 	// Expand environment variables
 	expanded := os.ExpandEnv(string(data))
 
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(expanded), &doc); err != nil {
+		return nil, err
+	}
+	var key []byte
+	if err := decryptValues(&doc, &key); err != nil {
+		return nil, fmt.Errorf("encrypted value: %w", err)
+	}
 	var cfg Config
-	if err := yaml.Unmarshal([]byte(expanded), &cfg); err != nil {
+	if err := doc.Decode(&cfg); err != nil {
 		return nil, err
 	}
 
@@ -384,6 +1810,182 @@ func Load(path string) (*Config, error) {
 	if cfg.Logging.Format == "" {
 		cfg.Logging.Format = "json"
 	}
+	if cfg.Scheduler.Events.Size <= 0 {
+		cfg.Scheduler.Events.Size = 1000
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeStandalone
+	}
+	if err := cfg.applyProfile(); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveTenants(); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveWorkloads(); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveFixtures(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Payload.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.ExitPolicy.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateSatellites(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateGateways(); err != nil {
+		return nil, err
+	}
+	if cfg.Metrics.AvailabilityHalfLife != "" {
+		if d, err := time.ParseDuration(cfg.Metrics.AvailabilityHalfLife); err != nil || d <= 0 {
+			return nil, fmt.Errorf("metrics: invalid availability_half_life %q", cfg.Metrics.AvailabilityHalfLife)
+		}
+	}
+	if cfg.Readiness.Timeout != "" {
+		if d, err := time.ParseDuration(cfg.Readiness.Timeout); err != nil || d <= 0 {
+			return nil, fmt.Errorf("readiness: invalid timeout %q", cfg.Readiness.Timeout)
+		}
+	}
+	if cfg.Metrics.MaxSeries < 0 {
+		return nil, fmt.Errorf("metrics: max_series must not be negative")
+	}
+	if cfg.Shadow.Window != "" {
+		if d, err := time.ParseDuration(cfg.Shadow.Window); err != nil || d <= 0 {
+			return nil, fmt.Errorf("shadow: invalid window %q", cfg.Shadow.Window)
+		}
+	}
+	if err := cfg.Anomaly.validate(); err != nil {
+		return nil, fmt.Errorf("anomaly: %w", err)
+	}
+	if cfg.Results.Retention != "" {
+		if d, err := time.ParseDuration(cfg.Results.Retention); err != nil || d <= 0 {
+			return nil, fmt.Errorf("results: invalid retention %q", cfg.Results.Retention)
+		}
+	}
+	if cfg.Subprocess.MaxCPUTime != "" {
+		if d, err := time.ParseDuration(cfg.Subprocess.MaxCPUTime); err != nil || d < time.Second {
+			return nil, fmt.Errorf("subprocess: invalid max_cpu_time %q (expected a duration of at least 1s)", cfg.Subprocess.MaxCPUTime)
+		}
+	}
+	if cfg.Subprocess.ReapInterval != "" {
+		if d, err := time.ParseDuration(cfg.Subprocess.ReapInterval); err != nil || d <= 0 {
+			return nil, fmt.Errorf("subprocess: invalid reap_interval %q", cfg.Subprocess.ReapInterval)
+		}
+	}
+	if cfg.Subprocess.MaxMemory < 0 || cfg.Subprocess.MaxOpenFiles < 0 || cfg.Subprocess.Cgroup.MemoryMax < 0 || cfg.Subprocess.Cgroup.CPUs < 0 {
+		return nil, fmt.Errorf("subprocess: limits must not be negative")
+	}
+	if cfg.WorkDir.CleanupAfter != "" {
+		if d, err := time.ParseDuration(cfg.WorkDir.CleanupAfter); err != nil || d <= 0 {
+			return nil, fmt.Errorf("work_dir: invalid cleanup_after %q", cfg.WorkDir.CleanupAfter)
+		}
+	}
+	if cfg.Scheduler.MaxConcurrent < 0 {
+		return nil, fmt.Errorf("scheduler: max_concurrent must not be negative (0 = unlimited)")
+	}
+	if err := validConcurrencyPolicy(cfg.Scheduler.ConcurrencyPolicy); err != nil {
+		return nil, fmt.Errorf("scheduler: %w", err)
+	}
+	for _, test := range cfg.Tests {
+		if err := validConcurrencyPolicy(test.ConcurrencyPolicy); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if err := validMetricsVerbosity(test.Metrics); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if test.Timeout != "" {
+			if d, err := time.ParseDuration(test.Timeout); err != nil || d <= 0 {
+				return nil, fmt.Errorf("test %s: invalid timeout %q", test.Name, test.Timeout)
+			}
+		}
+		if err := validBudget(test.Budget); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if err := test.RetryOnFailure.validate(); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if test.Schedule != "" {
+			if _, err := cron.ParseStandard(test.Schedule); err != nil {
+				return nil, fmt.Errorf("test %s: invalid schedule %q: %w", test.Name, test.Schedule, err)
+			}
+		}
+		if test.Satellite != "" {
+			if _, err := cfg.GetSatellite(test.Satellite); err != nil {
+				return nil, fmt.Errorf("test %s: %w", test.Name, err)
+			}
+		}
+		if test.Gateway != "" {
+			if !gatewayExecutors[test.GetExecutor()] {
+				return nil, fmt.Errorf("test %s: gateway is not supported by the %s executor", test.Name, test.GetExecutor())
+			}
+			if _, err := cfg.GetGateway(test.Gateway); err != nil {
+				return nil, fmt.Errorf("test %s: %w", test.Name, err)
+			}
+		}
+		if err := test.When.validate(); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		for _, step := range test.Steps {
+			if step.Payload != nil {
+				if err := step.Payload.validate(); err != nil {
+					return nil, fmt.Errorf("test %s step %s: %w", test.Name, step.Name, err)
+				}
+			}
+			if err := step.When.validate(); err != nil {
+				return nil, fmt.Errorf("test %s step %s: %w", test.Name, step.Name, err)
+			}
+			if err := step.validateMultipart(); err != nil {
+				return nil, fmt.Errorf("test %s step %s: %w", test.Name, step.Name, err)
+			}
+			if err := step.validateList(); err != nil {
+				return nil, fmt.Errorf("test %s step %s: %w", test.Name, step.Name, err)
+			}
+		}
+		if err := test.validateVerifyContent(); err != nil {
+			return nil, fmt.Errorf("test %s %w", test.Name, err)
+		}
+		if err := test.validateMetadata(); err != nil {
+			return nil, fmt.Errorf("test %s %w", test.Name, err)
+		}
+		if err := test.validateBakeoff(); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if err := test.TTFB.validate(); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if err := test.validateRangeSeek(); err != nil {
+			return nil, fmt.Errorf("test %s %w", test.Name, err)
+		}
+		if err := test.validateParallel(); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if err := validateStepRefs(test.Steps); err != nil {
+			return nil, fmt.Errorf("test %s %w", test.Name, err)
+		}
+	}
+	if err := validateHeaders(cfg.S3.Headers); err != nil {
+		return nil, fmt.Errorf("s3: %w", err)
+	}
+	for _, test := range cfg.Tests {
+		for _, ep := range test.Compare {
+			if err := validateHeaders(ep.Headers); err != nil {
+				return nil, fmt.Errorf("test %s: compare endpoint %s: %w", test.Name, ep.Name, err)
+			}
+		}
+	}
+	if cfg.Mode == ModeAggregator && cfg.Aggregator.Token == "" {
+		// Anyone who can reach an open aggregator could push fake probes
+		return nil, fmt.Errorf("aggregator: token is required in aggregator mode")
+	}
+	if cfg.Mode == ModeAgent && cfg.Agent.Probe == "" {
+		if hostname, err := os.Hostname(); err == nil {
+			cfg.Agent.Probe = hostname
+		}
+	}
 
 	return &cfg, nil
 }
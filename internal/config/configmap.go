@@ -0,0 +1,252 @@
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// In-cluster service account files
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	defaultConfigKey  = "config.yaml"
+)
+
+// ConfigMapSource reads the config from a key of a Kubernetes ConfigMap using
+// the in-cluster service account, and watches it for changes.
+type ConfigMapSource struct {
+	apiServer string
+	namespace string
+	name      string
+	key       string
+	client    *http.Client
+
+	resourceVersion string
+}
+
+// configMap is the subset of a ConfigMap object used here
+type configMap struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Data map[string]string `json:"data"`
+}
+
+// watchEvent is a single event from the Kubernetes watch API
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// NewConfigMapSource creates a source for configmap://[namespace/]name[/key].
+// The namespace defaults to the pod's namespace and the key to config.yaml.
+func NewConfigMapSource(rawURL string) (*ConfigMapSource, error) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(rawURL, "configmap://"), "/"), "/")
+
+	src := &ConfigMapSource{key: defaultConfigKey}
+	switch len(parts) {
+	case 1:
+		src.name = parts[0]
+	case 2:
+		src.namespace, src.name = parts[0], parts[1]
+	case 3:
+		src.namespace, src.name, src.key = parts[0], parts[1], parts[2]
+	}
+	if src.name == "" || len(parts) > 3 {
+		return nil, fmt.Errorf("config URL must be configmap://[namespace/]name[/key], got %s", rawURL)
+	}
+
+	if src.namespace == "" {
+		ns, err := os.ReadFile(path.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return nil, fmt.Errorf("no namespace in %s and not running in a pod: %w", rawURL, err)
+		}
+		src.namespace = strings.TrimSpace(string(ns))
+	}
+
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("KUBERNETES_SERVICE_HOST/PORT not set (configmap:// requires running in a cluster)")
+	}
+	src.apiServer = "https://" + net.JoinHostPort(host, port)
+
+	caPEM, err := os.ReadFile(path.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in service account CA")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	src.client = &http.Client{Transport: transport} // No timeout: watch requests are long-lived
+
+	return src, nil
+}
+
+// String returns the ConfigMap reference
+func (c *ConfigMapSource) String() string {
+	return fmt.Sprintf("configmap://%s/%s/%s", c.namespace, c.name, c.key)
+}
+
+// Fetch reads the ConfigMap. changed is false if its resourceVersion has not moved.
+func (c *ConfigMapSource) Fetch(ctx context.Context) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/configmaps/%s", c.apiServer, url.PathEscape(c.namespace), url.PathEscape(c.name))
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, false, fmt.Errorf("failed to get ConfigMap %s/%s: status %d: %s", c.namespace, c.name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var cm configMap
+	if err := json.NewDecoder(resp.Body).Decode(&cm); err != nil {
+		return nil, false, fmt.Errorf("failed to decode ConfigMap: %w", err)
+	}
+	return c.update(&cm)
+}
+
+// update records the ConfigMap's resourceVersion and returns its config data
+func (c *ConfigMapSource) update(cm *configMap) ([]byte, bool, error) {
+	if cm.Metadata.ResourceVersion == c.resourceVersion {
+		return nil, false, nil
+	}
+	c.resourceVersion = cm.Metadata.ResourceVersion
+
+	data, ok := cm.Data[c.key]
+	if !ok {
+		return nil, false, fmt.Errorf("ConfigMap %s/%s has no key %q", c.namespace, c.name, c.key)
+	}
+	return []byte(data), true, nil
+}
+
+// Watch streams ConfigMap changes from the Kubernetes watch API and calls
+// onChange with each new, successfully parsed config. The watch is
+// re-established after errors; each new watch starts with the current object,
+// so updates missed while disconnected are still applied.
+func (c *ConfigMapSource) Watch(ctx context.Context, onChange func(*Config), onFailure func(status string, err error)) {
+	log.Printf("Watching %s for config changes", c)
+
+	apply := func(data []byte) {
+		cfg, err := Parse(data)
+		if err != nil {
+			log.Printf("Warning: invalid config in %s, keeping current config: %v", c, err)
+			onFailure(ReloadInvalid, err)
+			return
+		}
+		log.Printf("ConfigMap config changed (%s, resourceVersion %s)", c, c.resourceVersion)
+		onChange(cfg)
+	}
+
+	for {
+		err := c.watchOnce(ctx, apply, func(err error) { onFailure(ReloadInvalid, err) })
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Warning: ConfigMap watch interrupted: %v", err)
+			onFailure(ReloadFetchError, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// watchOnce runs a single watch request until it ends or fails. No
+// resourceVersion is sent, so the server first replays the current object as
+// ADDED; unchanged versions are ignored. A version without the config key is
+// passed to invalid.
+func (c *ConfigMapSource) watchOnce(ctx context.Context, apply func([]byte), invalid func(error)) error {
+	q := url.Values{}
+	q.Set("watch", "true")
+	q.Set("fieldSelector", "metadata.name="+c.name)
+	q.Set("timeoutSeconds", "300")
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/configmaps?%s", c.apiServer, url.PathEscape(c.namespace), q.Encode())
+
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("watch returned status %d", resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var event watchEvent
+		if err := dec.Decode(&event); err != nil {
+			if err == io.EOF {
+				return nil // Server-side timeout; reconnect
+			}
+			return err
+		}
+
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			var cm configMap
+			if err := json.Unmarshal(event.Object, &cm); err != nil {
+				return fmt.Errorf("failed to decode ConfigMap: %w", err)
+			}
+			data, changed, err := c.update(&cm)
+			if err != nil {
+				log.Printf("Warning: %v, keeping current config", err)
+				invalid(err)
+				continue
+			}
+			if changed {
+				apply(data)
+			}
+		case "DELETED":
+			log.Printf("Warning: ConfigMap %s/%s was deleted, keeping current config", c.namespace, c.name)
+		case "ERROR":
+			return fmt.Errorf("watch error: %s", string(event.Object))
+		}
+	}
+}
+
+// get performs an authenticated GET against the API server. The token is
+// re-read on every request because projected service account tokens rotate.
+func (c *ConfigMapSource) get(ctx context.Context, u string) (*http.Response, error) {
+	token, err := os.ReadFile(path.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Kubernetes API request failed: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileSettle is how long a config file must be quiet after a change before
+// it is read, so an editor's or ConfigMap's multi-step write is read whole
+const fileSettle = 500 * time.Millisecond
+
+// FileSource is a local config file, reloaded when the process receives
+// SIGHUP or the file changes
+type FileSource struct {
+	path string
+	sum  [sha256.Size]byte
+}
+
+// NewFileSource creates a source for a local config file
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (f *FileSource) String() string {
+	return f.path
+}
+
+// Fetch reads the file. changed is false if its contents are the same as at
+// the last fetch.
+func (f *FileSource) Fetch(ctx context.Context) ([]byte, bool, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256(data)
+	if bytes.Equal(sum[:], f.sum[:]) {
+		return data, false, nil
+	}
+	f.sum = sum
+	return data, true, nil
+}
+
+// Watch calls onChange with the config each time the process receives SIGHUP
+// and each time the file's contents change. The file's directory is watched
+// rather than the file, so replacing the file (editors' atomic saves, the
+// symlink swap of a mounted ConfigMap) is seen too. Read and parse errors are
+// logged and the current config is kept.
+func (f *FileSource) Watch(ctx context.Context, onChange func(*Config), onFailure func(status string, err error)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Changes made before the watch started are picked up by the first event
+	f.Fetch(ctx)
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		err = watcher.Add(filepath.Dir(f.path))
+	}
+	if err != nil {
+		log.Printf("Warning: cannot watch config %s, reloading on SIGHUP only: %v", f.path, err)
+	} else {
+		defer watcher.Close()
+		events, errs = watcher.Events, watcher.Errors
+		log.Printf("Watching config %s for changes (or send SIGHUP to reload)", f.path)
+	}
+
+	settle := time.NewTimer(0)
+	<-settle.C
+	for {
+		forced := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Printf("Received SIGHUP, reloading config %s", f.path)
+			forced = true
+		case event := <-events:
+			if event.Op != fsnotify.Chmod {
+				settle.Reset(fileSettle)
+			}
+			continue
+		case err := <-errs:
+			log.Printf("Warning: config watch error: %v", err)
+			continue
+		case <-settle.C:
+		}
+
+		data, changed, err := f.Fetch(ctx)
+		if err != nil {
+			log.Printf("Warning: failed to read config, keeping current config: %v", err)
+			onFailure(ReloadFetchError, err)
+			continue
+		}
+		if !changed && !forced {
+			continue
+		}
+		cfg, err := Parse(data)
+		if err != nil {
+			log.Printf("Warning: invalid config, keeping current config: %v", err)
+			onFailure(ReloadInvalid, err)
+			continue
+		}
+		log.Printf("Applying config %s", f.path)
+		onChange(cfg)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Fixture is an object uploaded once and shared by download-only tests, so
+// measuring download latency of large objects doesn't require uploading one
+// every run. Each fixture becomes a generated upload test that runs at
+// startup and every Refresh.
+type Fixture struct {
+	Name     string    `yaml:"name"`
+	Executor string    `yaml:"executor,omitempty"`  // Executor that uploads the object (default: "http-s3")
+	Bucket   *string   `yaml:"bucket,omitempty"`    // Optional: override global bucket
+	FileSize *ByteSize `yaml:"file_size,omitempty"` // Object size (default: 1MB)
+	Script   string    `yaml:"script,omitempty"`    // Upload script (uplink executor only)
+	Timeout  string    `yaml:"timeout,omitempty"`   // Upload timeout (default: step default)
+	Refresh  string    `yaml:"refresh,omitempty"`   // Re-upload interval (default: "6h")
+}
+
+// DefaultFixtureExecutor uploads fixtures that don't set an executor
+const DefaultFixtureExecutor = "http-s3"
+
+// GetExecutor returns the upload executor (with default "http-s3")
+func (f *Fixture) GetExecutor() string {
+	if f.Executor == "" {
+		return DefaultFixtureExecutor
+	}
+	return f.Executor
+}
+
+// RefreshDuration returns the re-upload interval (default 6h)
+func (f *Fixture) RefreshDuration() time.Duration {
+	if d, err := time.ParseDuration(f.Refresh); err == nil && d > 0 {
+		return d
+	}
+	return 6 * time.Hour
+}
+
+// FixtureTestName returns the name of a fixture's generated upload test
+func FixtureTestName(fixture string) string {
+	return "fixture-" + fixture
+}
+
+// Filename returns the fixture's object key
+func (f *Fixture) Filename() string {
+	return "fixture-" + f.Name + ".bin"
+}
+
+// uploadTest returns the generated test that uploads the fixture
+func (f *Fixture) uploadTest() Test {
+	filename := f.Filename()
+	return Test{
+		Name:          FixtureTestName(f.Name),
+		Schedule:      "@every " + f.RefreshDuration().String(),
+		Enabled:       true,
+		Executor:      f.GetExecutor(),
+		Bucket:        f.Bucket,
+		Filename:      &filename,
+		FixtureUpload: f.Name,
+		Steps: []TestStep{{
+			Name:     "upload",
+			Script:   f.Script,
+			Timeout:  f.Timeout,
+			FileSize: f.FileSize,
+		}},
+	}
+}
+
+// fixtureReadSteps are the steps a fixture test may run; anything else would
+// modify the shared object
+var fixtureReadSteps = map[string]bool{
+	"download":   true,
+	"stat":       true,
+	"range-seek": true,
+}
+
+// resolveFixtures points tests that use a fixture at its object and appends
+// the fixtures' upload tests
+func (c *Config) resolveFixtures() error {
+	fixtures := make(map[string]*Fixture, len(c.Fixtures))
+	for i := range c.Fixtures {
+		f := &c.Fixtures[i]
+		if f.Name == "" {
+			return fmt.Errorf("fixture %d has no name", i+1)
+		}
+		if _, dup := fixtures[f.Name]; dup {
+			return fmt.Errorf("duplicate fixture: %s", f.Name)
+		}
+		fixtures[f.Name] = f
+	}
+
+	for i := range c.Tests {
+		t := &c.Tests[i]
+		if t.Fixture == "" {
+			continue
+		}
+		f, ok := fixtures[t.Fixture]
+		if !ok {
+			return fmt.Errorf("test %s: unknown fixture %q", t.Name, t.Fixture)
+		}
+		if t.Filename != nil {
+			return fmt.Errorf("test %s: filename cannot be set with fixture", t.Name)
+		}
+		for _, step := range t.Steps {
+			if !fixtureReadSteps[step.Name] {
+				return fmt.Errorf("test %s: step %q would modify fixture %s (only download, stat, and range-seek are allowed)", t.Name, step.Name, f.Name)
+			}
+		}
+		filename := f.Filename()
+		t.Filename = &filename
+		if t.Bucket == nil {
+			t.Bucket = f.Bucket
+		}
+	}
+
+	for _, f := range c.Fixtures {
+		upload := f.uploadTest()
+		for _, t := range c.Tests {
+			if t.Name == upload.Name {
+				return fmt.Errorf("test %s conflicts with the upload test of fixture %s", t.Name, f.Name)
+			}
+		}
+		c.Tests = append(c.Tests, upload)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultMaxTestsPerMinute is how many tests may fire in the same minute
+// before Lint reports a collision
+const DefaultMaxTestsPerMinute = 5
+
+// lintStart is the start of the week Lint simulates schedules over: a Monday
+// at midnight UTC, so reports are the same from run to run. @every schedules
+// are taken to start then too, as all of them do when the probe starts.
+var lintStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// LoadReport estimates the gateway load of a config and the minutes in which
+// many tests fire together
+type LoadReport struct {
+	Tests           []TestLoad  `json:"tests"`
+	RunsPerHour     float64     `json:"runs_per_hour"`
+	RequestsPerHour float64     `json:"requests_per_hour"`
+	BytesPerHour    float64     `json:"bytes_per_hour"`
+	Collisions      []Collision `json:"collisions"`
+}
+
+// TestLoad is the estimated load of one enabled test
+type TestLoad struct {
+	Name            string  `json:"name"`
+	Schedule        string  `json:"schedule"`
+	RunsPerHour     float64 `json:"runs_per_hour"`
+	RequestsPerRun  int     `json:"requests_per_run"`
+	BytesPerRun     int64   `json:"bytes_per_run"`
+	RequestsPerHour float64 `json:"requests_per_hour"`
+	BytesPerHour    float64 `json:"bytes_per_hour"`
+}
+
+// Collision is a set of tests that fire in the same minute, without enough
+// jitter to spread them out. First is the first such minute of the simulated
+// week and Count the number of such minutes in it.
+type Collision struct {
+	Tests []string  `json:"tests"`
+	First time.Time `json:"first"`
+	Count int       `json:"count"`
+}
+
+// Lint estimates the requests and bytes per hour the enabled tests send to
+// the gateway, from each test's steps and how often its schedule fires over
+// a week, and finds the minutes in which more than maxPerMinute tests fire
+// together. Tests with a minute or more of jitter are spread out by it and
+// aren't counted in collisions; when conditions are ignored.
+func (c *Config) Lint(maxPerMinute int) *LoadReport {
+	const week = 7 * 24 * time.Hour
+	report := &LoadReport{Tests: []TestLoad{}, Collisions: []Collision{}}
+	byMinute := map[time.Time][]string{}
+	for _, test := range c.Tests {
+		if !test.Enabled {
+			continue
+		}
+		sched, err := cron.ParseStandard(test.Schedule)
+		if err != nil {
+			continue
+		}
+		var maxJitter time.Duration
+		if jitter := test.GetTestJitter(c.Jitter); jitter.IsEnabled() {
+			interval, _ := ParseCronInterval(test.Schedule)
+			maxJitter, _ = jitter.ParseMaxJitter(interval)
+		}
+
+		// A cron schedule can fire at the start itself; @every schedules
+		// first fire one interval after it
+		first := lintStart
+		if _, ok := sched.(cron.ConstantDelaySchedule); !ok {
+			first = lintStart.Add(-time.Nanosecond)
+		}
+		runs := 0
+		for t := sched.Next(first); t.Before(lintStart.Add(week)); t = sched.Next(t) {
+			runs++
+			if maxJitter < time.Minute {
+				minute := t.Truncate(time.Minute)
+				byMinute[minute] = append(byMinute[minute], test.Name)
+			}
+		}
+		requests, bytes := test.estimateRun()
+		load := TestLoad{
+			Name:           test.Name,
+			Schedule:       test.Schedule,
+			RunsPerHour:    float64(runs) / week.Hours(),
+			RequestsPerRun: requests,
+			BytesPerRun:    bytes,
+		}
+		load.RequestsPerHour = load.RunsPerHour * float64(requests)
+		load.BytesPerHour = load.RunsPerHour * float64(bytes)
+		report.Tests = append(report.Tests, load)
+		report.RunsPerHour += load.RunsPerHour
+		report.RequestsPerHour += load.RequestsPerHour
+		report.BytesPerHour += load.BytesPerHour
+	}
+
+	// Group the crowded minutes by the tests in them, so an hourly stampede
+	// is reported once rather than for each hour of the week
+	groups := map[string]*Collision{}
+	for minute, names := range byMinute {
+		if len(names) <= maxPerMinute {
+			continue
+		}
+		slices.Sort(names)
+		key := strings.Join(names, ",")
+		g, ok := groups[key]
+		if !ok {
+			g = &Collision{Tests: names, First: minute}
+			groups[key] = g
+		}
+		if minute.Before(g.First) {
+			g.First = minute
+		}
+		g.Count++
+	}
+	for _, g := range groups {
+		report.Collisions = append(report.Collisions, *g)
+	}
+	slices.SortFunc(report.Collisions, func(a, b Collision) int {
+		if n := len(b.Tests) - len(a.Tests); n != 0 {
+			return n
+		}
+		return a.First.Compare(b.First)
+	})
+	return report
+}
+
+// Warnings describes the collisions in the report
+func (r *LoadReport) Warnings() []string {
+	var warnings []string
+	for _, col := range r.Collisions {
+		warnings = append(warnings, fmt.Sprintf("%d tests fire at the same minute %d times a week (first %s): %s; add jitter or stagger their schedules",
+			len(col.Tests), col.Count, col.First.Format("Mon 15:04"), strings.Join(col.Tests, ", ")))
+	}
+	return warnings
+}
+
+// estimateRun estimates the gateway requests and bytes transferred by one run
+// of the test. Object sizes default to the executors' 1MB; failed runs,
+// retries, read-after-write polling, and clean-up listings are not counted.
+func (t *Test) estimateRun() (requests int, bytes int64) {
+	switch t.GetExecutor() {
+	case "rtt":
+		return 0, 0 // Probes the network path, not the gateway
+	case "canary":
+		for _, size := range t.Canary.GetSizes() {
+			requests++
+			bytes += size.Int64()
+		}
+		return requests, bytes
+	case "ttfb":
+		return t.TTFB.GetRequests(), int64(t.TTFB.GetRequests()) * t.TTFB.GetSize().Int64()
+	}
+
+	const defaultSize = 1 << 20
+	object := int64(defaultSize) // Size of the run's object, as last uploaded
+	for _, step := range t.Steps {
+		size := int64(defaultSize)
+		if step.FileSize != nil {
+			size = step.FileSize.Int64()
+		}
+		switch step.Name {
+		case "upload":
+			object = size
+			requests++
+			bytes += size
+		case "multipart-upload":
+			object = size
+			requests += int((size+step.GetPartSize()-1)/step.GetPartSize()) + 2 // Initiate, parts, complete
+			bytes += size
+		case "download", "fetch":
+			requests++
+			bytes += object
+		case "head-bench":
+			requests += step.RequestCount()
+		case "range-seek":
+			requests += step.SeekCount() + 1 // A HEAD for the object's size
+			bytes += int64(step.SeekCount()) * step.GetRangeSize()
+		case "presign":
+			// Signed locally
+		default:
+			requests++
+		}
+	}
+	if t.GetExecutor() == "compare" {
+		return requests * len(t.Compare), bytes * int64(len(t.Compare))
+	}
+	if t.GetExecutor() == "bakeoff" {
+		gateway := len(t.GetBakeoff())
+		if slices.Contains(t.GetBakeoff(), "uplink") {
+			gateway-- // Uplink goes to the satellite and storage nodes
+		}
+		return requests * gateway, bytes * int64(gateway)
+	}
+	return requests, bytes
+}
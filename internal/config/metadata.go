@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+)
+
+// metadataKey matches the user metadata keys steps may write. S3 lowercases
+// x-amz-meta-* names, so only lowercase keys read back as written.
+var metadataKey = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// UploadMetadata returns the user metadata an upload step writes to a
+// gateway: its metadata, and the TTL the gateway executors record as
+// ttl-seconds
+func (t *TestStep) UploadMetadata() map[string]string {
+	if len(t.Metadata) == 0 && (t.TTLSeconds == nil || *t.TTLSeconds <= 0) {
+		return nil
+	}
+	metadata := make(map[string]string, len(t.Metadata)+1)
+	maps.Copy(metadata, t.Metadata)
+	if t.TTLSeconds != nil && *t.TTLSeconds > 0 {
+		metadata["ttl-seconds"] = fmt.Sprintf("%d", *t.TTLSeconds)
+	}
+	return metadata
+}
+
+// validateMetadata checks that metadata is only set on steps that upload the
+// object with a single PUT, with keys that read back unchanged, and that
+// verify_metadata is only set on stat steps after a step that uploads the
+// object
+func (t *Test) validateMetadata() error {
+	uploaded := false
+	for _, step := range t.Steps {
+		if len(step.Metadata) > 0 {
+			if step.Name != "upload" && step.Name != "read-after-write" {
+				return fmt.Errorf("step %s: metadata is only supported on upload and read-after-write steps", step.Name)
+			}
+			for key := range step.Metadata {
+				if !metadataKey.MatchString(key) {
+					return fmt.Errorf("step %s: invalid metadata key %q (expected lowercase letters, digits, and dashes)", step.Name, key)
+				}
+				if key == "ttl-seconds" && t.GetExecutor() != "uplink" {
+					return fmt.Errorf("step %s: metadata key ttl-seconds is reserved for ttl_seconds", step.Name)
+				}
+			}
+		}
+		switch {
+		case step.Name == "upload" || step.Name == "multipart-upload" || step.Name == "read-after-write":
+			uploaded = true
+		case !step.VerifyMetadata:
+		case step.Name != "stat":
+			return fmt.Errorf("step %s: verify_metadata is only supported on stat steps", step.Name)
+		case !uploaded:
+			return fmt.Errorf("step %s: verify_metadata needs an upload step earlier in the test", step.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Presigned URL validity: the default, and the longest SigV4 allows
+const (
+	DefaultPresignExpiry = 15 * time.Minute
+	MaxPresignExpiry     = 7 * 24 * time.Hour
+)
+
+// stepRef matches a reference to an earlier step's output,
+// "{{steps.<id>.<output>}}" (e.g. "{{steps.presign.url}}")
+var stepRef = regexp.MustCompile(`\{\{\s*steps\.([A-Za-z0-9_-]+)\.([A-Za-z0-9_]+)\s*\}\}`)
+
+// StepOutputs holds the outputs of a run's completed steps by step ID.
+// Steps export values (the object key, ETag, a presigned URL, ...) that later
+// steps of the same run reference in their templated fields, so workflows
+// like "presign → fetch" are written in config rather than code.
+type StepOutputs map[string]map[string]string
+
+// GetID returns the ID later steps reference the step's outputs by (default:
+// its name). A later step with the same ID replaces the earlier outputs.
+func (t *TestStep) GetID() string {
+	if t.ID != "" {
+		return t.ID
+	}
+	return t.Name
+}
+
+// PresignExpiry returns how long a presigned URL is valid
+func (t *TestStep) PresignExpiry() time.Duration {
+	if d, err := time.ParseDuration(t.Expires); err == nil && d > 0 {
+		return d
+	}
+	return DefaultPresignExpiry
+}
+
+// templated returns the step fields that may reference step outputs
+func (t *TestStep) templated() []*string {
+	return []*string{&t.Key, &t.URL, &t.Prefix}
+}
+
+// Expand returns a copy of the step with its output references replaced by
+// the referenced values. A reference to an output the step didn't export is
+// an error rather than an empty string, which would silently address the
+// wrong object.
+func (o StepOutputs) Expand(step TestStep) (TestStep, error) {
+	var err error
+	for _, field := range step.templated() {
+		*field = stepRef.ReplaceAllStringFunc(*field, func(ref string) string {
+			m := stepRef.FindStringSubmatch(ref)
+			value, ok := o[m[1]][m[2]]
+			if !ok && err == nil {
+				err = fmt.Errorf("%s: step %s has no output %q", ref, m[1], m[2])
+			}
+			return value
+		})
+	}
+	return step, err
+}
+
+// validateStepRefs checks that every output reference names an earlier step
+func validateStepRefs(steps []TestStep) error {
+	earlier := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		for _, field := range step.templated() {
+			for _, m := range stepRef.FindAllStringSubmatch(*field, -1) {
+				if !earlier[m[1]] {
+					return fmt.Errorf("step %s: %s does not reference an earlier step", step.Name, m[0])
+				}
+			}
+		}
+		if step.Expires != "" {
+			if d, err := time.ParseDuration(step.Expires); err != nil || d <= 0 || d > MaxPresignExpiry {
+				return fmt.Errorf("step %s: invalid expires %q (expected a duration up to %v)", step.Name, step.Expires, MaxPresignExpiry)
+			}
+		}
+		earlier[step.GetID()] = true
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import "fmt"
+
+// parallelExecutors are the executor types that run parallel step groups
+var parallelExecutors = map[string]bool{"s3": true, "http-s3": true, "curl-s3": true}
+
+// objectWriters are the steps that create, replace, or remove their object.
+// Two steps of a parallel group may not target the same object if either
+// writes it, since their order would be a race.
+var objectWriters = map[string]bool{
+	"upload":           true,
+	"multipart-upload": true,
+	"read-after-write": true,
+	"upload-abort":     true,
+	"delete":           true,
+}
+
+// StepGroups returns the indexes of the test's steps in the groups they run
+// in, one group after the other: each run of consecutive steps with the same
+// parallel group runs concurrently, and every other step runs on its own
+func (t *Test) StepGroups() [][]int {
+	var groups [][]int
+	for i, step := range t.Steps {
+		last := len(groups) - 1
+		if step.Parallel != "" && last >= 0 && t.Steps[groups[last][0]].Parallel == step.Parallel {
+			groups[last] = append(groups[last], i)
+			continue
+		}
+		groups = append(groups, []int{i})
+	}
+	return groups
+}
+
+// validateParallel checks the test's parallel step groups: each is one run
+// of consecutive steps, its steps don't write an object another of them
+// uses, and they don't reference each other's outputs
+func (t *Test) validateParallel() error {
+	if !t.hasParallelSteps() {
+		return nil
+	}
+	if !parallelExecutors[t.GetExecutor()] {
+		return fmt.Errorf("parallel steps are not supported by the %s executor", t.GetExecutor())
+	}
+
+	done := make(map[string]bool) // Groups that have ended
+	for _, group := range t.StepGroups() {
+		name := t.Steps[group[0]].Parallel
+		if name == "" {
+			continue
+		}
+		if done[name] {
+			return fmt.Errorf("step %s: parallel group %s must be consecutive steps", t.Steps[group[0]].Name, name)
+		}
+		done[name] = true
+
+		ids := make(map[string]bool, len(group))
+		users := make(map[string]int, len(group)) // Steps using each object key ("" is the run's object)
+		for _, i := range group {
+			ids[t.Steps[i].GetID()] = true
+			users[t.Steps[i].Key]++
+		}
+		for _, i := range group {
+			step := t.Steps[i]
+			if objectWriters[step.Name] && users[step.Key] > 1 {
+				return fmt.Errorf("step %s: steps of parallel group %s use the object it writes; give them different keys", step.Name, name)
+			}
+			for _, field := range step.templated() {
+				for _, m := range stepRef.FindAllStringSubmatch(*field, -1) {
+					if ids[m[1]] {
+						return fmt.Errorf("step %s: %s references a step of its own parallel group %s", step.Name, m[0], name)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasParallelSteps reports whether any step of the test is in a parallel group
+func (t *Test) hasParallelSteps() bool {
+	for _, step := range t.Steps {
+		if step.Parallel != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"log"
+)
+
+// Probe profiles. The lite profile suits constrained hardware such as
+// Raspberry Pi field probes: it caps object sizes and concurrency and swaps
+// crypto/rand payloads for the faster math/rand source, so a test written for
+// a server-class probe doesn't exhaust the device's memory, CPU, or SD card.
+const (
+	ProfileStandard = "standard"
+	ProfileLite     = "lite"
+)
+
+// Caps of the lite profile
+const (
+	LiteMaxFileSize    ByteSize = 16 << 20 // Largest object a step uploads or a fixture holds
+	LiteMaxConcurrent           = 1        // Tests running at once
+	LiteMaxConcurrency          = 2        // Multipart parts uploaded at once
+)
+
+// GetProfile returns the probe profile (with default "standard")
+func (c *Config) GetProfile() string {
+	if c.Profile == "" {
+		return ProfileStandard
+	}
+	return c.Profile
+}
+
+// applyProfile validates the profile and applies its caps, logging each
+// value it lowers
+func (c *Config) applyProfile() error {
+	switch c.GetProfile() {
+	case ProfileStandard:
+		return nil
+	case ProfileLite:
+	default:
+		return fmt.Errorf("unknown profile %q (expected standard or lite)", c.Profile)
+	}
+
+	capped := func(what string, from, to any) {
+		log.Printf("Profile %s: %s capped from %v to %v", ProfileLite, what, from, to)
+	}
+	if c.Scheduler.MaxConcurrent == 0 || c.Scheduler.MaxConcurrent > LiteMaxConcurrent {
+		capped("scheduler.max_concurrent", c.Scheduler.MaxConcurrent, LiteMaxConcurrent)
+		c.Scheduler.MaxConcurrent = LiteMaxConcurrent
+	}
+	liteSource(&c.Payload, "payload")
+	for i := range c.Fixtures {
+		f := &c.Fixtures[i]
+		if f.FileSize != nil && *f.FileSize > LiteMaxFileSize {
+			capped("fixture "+f.Name+" file_size", *f.FileSize, LiteMaxFileSize)
+			*f.FileSize = LiteMaxFileSize
+		}
+	}
+	for i := range c.Tests {
+		test := &c.Tests[i]
+		for j := range test.Steps {
+			step := &test.Steps[j]
+			where := fmt.Sprintf("test %s step %s", test.Name, step.Name)
+			if step.FileSize != nil && *step.FileSize > LiteMaxFileSize {
+				capped(where+" file_size", *step.FileSize, LiteMaxFileSize)
+				*step.FileSize = LiteMaxFileSize
+			}
+			if step.Concurrency > LiteMaxConcurrency {
+				capped(where+" concurrency", step.Concurrency, LiteMaxConcurrency)
+				step.Concurrency = LiteMaxConcurrency
+			}
+			if step.Payload != nil {
+				payload := *step.Payload
+				liteSource(&payload, where+" payload")
+				step.Payload = &payload
+			}
+		}
+	}
+	return nil
+}
+
+// liteSource replaces the crypto/rand payload source with math/rand, which
+// is just as incompressible at a fraction of the CPU
+func liteSource(p *PayloadConfig, what string) {
+	if p.GetSource() == PayloadCrypto {
+		log.Printf("Profile %s: %s source %s replaced by %s", ProfileLite, what, PayloadCrypto, PayloadMath)
+		p.Source = PayloadMath
+	}
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// maxRemoteConfigBytes limits the size of a fetched config
+const maxRemoteConfigBytes = 4 << 20
+
+// Source is a config location that is watched for changes
+type Source interface {
+	// Fetch returns the config data, or changed=false if it has not changed since the last fetch
+	Fetch(ctx context.Context) (data []byte, changed bool, err error)
+
+	// Watch calls onChange with each new, successfully parsed config until
+	// ctx is cancelled, and onFailure with the status (ReloadFetchError or
+	// ReloadInvalid) of each change that could not be fetched or parsed
+	Watch(ctx context.Context, onChange func(*Config), onFailure func(status string, err error))
+
+	String() string
+}
+
+// Config load and reload outcomes
+const (
+	ReloadSuccess    = "success"
+	ReloadFetchError = "fetch_error" // The config could not be fetched
+	ReloadInvalid    = "invalid"     // The config failed to parse or validate
+	ReloadApplyError = "apply_error" // The scheduler rejected the new config
+)
+
+// IsRemote returns true if the config path is an https://, s3://, or configmap:// URL
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "s3://") ||
+		strings.HasPrefix(path, "configmap://")
+}
+
+// NewSource creates the source for a remote config URL. HTTPS and S3 sources
+// are polled every pollInterval; ConfigMaps are watched via the Kubernetes API.
+func NewSource(ctx context.Context, rawURL string, pollInterval time.Duration) (Source, error) {
+	if strings.HasPrefix(rawURL, "configmap://") {
+		return NewConfigMapSource(rawURL)
+	}
+	return NewRemoteSource(ctx, rawURL, pollInterval)
+}
+
+// LoadSource fetches and parses the config from a source
+func LoadSource(ctx context.Context, src Source) (*Config, error) {
+	data, _, err := src.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// RemoteSource fetches a config from an HTTPS or S3 URL, using conditional
+// requests so unchanged configs are not re-downloaded.
+type RemoteSource struct {
+	url      string
+	interval time.Duration
+
+	// HTTPS
+	client *http.Client
+
+	// S3
+	s3     *s3.Client
+	bucket string
+	key    string
+
+	etag         string
+	lastModified string
+}
+
+// NewRemoteSource creates a source for an https:// or s3://bucket/key URL.
+// S3 credentials and endpoint come from the standard AWS environment
+// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION, AWS_ENDPOINT_URL).
+func NewRemoteSource(ctx context.Context, rawURL string, pollInterval time.Duration) (*RemoteSource, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config URL: %w", err)
+	}
+
+	src := &RemoteSource{url: rawURL, interval: pollInterval}
+	switch u.Scheme {
+	case "https":
+		src.client = &http.Client{Timeout: 30 * time.Second}
+	case "s3":
+		src.bucket = u.Host
+		src.key = strings.TrimPrefix(u.Path, "/")
+		if src.bucket == "" || src.key == "" {
+			return nil, fmt.Errorf("config URL must be s3://bucket/key, got %s", rawURL)
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		if awsCfg.Region == "" {
+			awsCfg.Region = "us-east-1"
+		}
+		src.s3 = s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+			// S3-compatible gateways generally require path-style addressing
+			o.UsePathStyle = os.Getenv("AWS_ENDPOINT_URL") != "" || os.Getenv("AWS_ENDPOINT_URL_S3") != ""
+		})
+	default:
+		return nil, fmt.Errorf("unsupported config URL scheme %q (expected https or s3)", u.Scheme)
+	}
+	return src, nil
+}
+
+// String returns the source URL
+func (r *RemoteSource) String() string {
+	return r.url
+}
+
+// Fetch returns the config data, or changed=false if it has not changed since the last fetch
+func (r *RemoteSource) Fetch(ctx context.Context) (data []byte, changed bool, err error) {
+	if r.s3 != nil {
+		return r.fetchS3(ctx)
+	}
+	return r.fetchHTTP(ctx)
+}
+
+// fetchHTTP performs a conditional GET using ETag and Last-Modified
+func (r *RemoteSource) fetchHTTP(ctx context.Context) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if r.lastModified != "" {
+		req.Header.Set("If-Modified-Since", r.lastModified)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("failed to fetch config: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigBytes))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read config: %w", err)
+	}
+	r.etag = resp.Header.Get("ETag")
+	r.lastModified = resp.Header.Get("Last-Modified")
+	return data, true, nil
+}
+
+// fetchS3 performs a conditional GetObject using the ETag
+func (r *RemoteSource) fetchS3(ctx context.Context) ([]byte, bool, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key),
+	}
+	if r.etag != "" {
+		input.IfNoneMatch = aws.String(r.etag)
+	}
+
+	out, err := r.s3.GetObject(ctx, input)
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotModified {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxRemoteConfigBytes))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read config: %w", err)
+	}
+	r.etag = aws.ToString(out.ETag)
+	return data, true, nil
+}
+
+// Watch polls the source every interval and calls onChange with each new,
+// successfully parsed config. Fetch and parse errors are logged and the
+// current config is kept.
+func (r *RemoteSource) Watch(ctx context.Context, onChange func(*Config), onFailure func(status string, err error)) {
+	log.Printf("Polling remote config %s every %v", r.url, r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, changed, err := r.Fetch(ctx)
+		if err != nil {
+			log.Printf("Warning: config poll failed, keeping current config: %v", err)
+			onFailure(ReloadFetchError, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		cfg, err := Parse(data)
+		if err != nil {
+			log.Printf("Warning: invalid remote config, keeping current config: %v", err)
+			onFailure(ReloadInvalid, err)
+			continue
+		}
+		log.Printf("Remote config changed (%s)", r.url)
+		onChange(cfg)
+	}
+}
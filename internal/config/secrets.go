@@ -0,0 +1,116 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"gopkg.in/yaml.v3"
+)
+
+// Encrypted config values: a scalar written as
+// "ENC[xchacha20poly1305,<base64 nonce and ciphertext>]" is decrypted at load
+// with the key in CONFIG_KEY, or in the file named by CONFIG_KEY_FILE. Secrets
+// can then be committed with the config, between plaintext ${VAR} expansion
+// and a full secret manager. "synthetics encrypt" produces the values.
+const (
+	encPrefix = "ENC[xchacha20poly1305,"
+	encSuffix = "]"
+
+	KeyEnv     = "CONFIG_KEY"
+	KeyFileEnv = "CONFIG_KEY_FILE"
+)
+
+// GenerateKey returns a new random key, base64-encoded
+func GenerateKey() (string, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKey returns the key from CONFIG_KEY or CONFIG_KEY_FILE
+func LoadKey() ([]byte, error) {
+	encoded := os.Getenv(KeyEnv)
+	if path := os.Getenv(KeyFileEnv); encoded == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", KeyFileEnv, err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("neither %s nor %s is set", KeyEnv, KeyFileEnv)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid config key: expected %d base64-encoded bytes", chacha20poly1305.KeySize)
+	}
+	return key, nil
+}
+
+// Encrypt returns plaintext as an encrypted config value
+func Encrypt(key []byte, plaintext string) (string, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed) + encSuffix, nil
+}
+
+// decrypt returns the plaintext of an encrypted config value
+func decrypt(key []byte, value string) (string, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encPrefix), encSuffix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt (wrong key?)")
+	}
+	return string(plaintext), nil
+}
+
+// isEncrypted reports whether a scalar is an encrypted config value
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encPrefix) && strings.HasSuffix(value, encSuffix)
+}
+
+// decryptValues replaces every encrypted scalar in the document with its
+// plaintext. The key is only loaded if the document has encrypted values.
+func decryptValues(node *yaml.Node, key *[]byte) error {
+	if node.Kind == yaml.ScalarNode && isEncrypted(node.Value) {
+		if *key == nil {
+			k, err := LoadKey()
+			if err != nil {
+				return err
+			}
+			*key = k
+		}
+		plaintext, err := decrypt(*key, node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		// Resolve the plaintext's type as if it were written unquoted
+		node.Value, node.Tag, node.Style = plaintext, "", 0
+	}
+	for _, child := range node.Content {
+		if err := decryptValues(child, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Tenant groups the tests of one team, so a central deployment can host
+// synthetics for several teams. A tenant's tests are named
+// "<tenant>-<name>", run with its own gateway credentials, satellite, and
+// bucket, and their series carry a tenant label for routing alerts.
+type Tenant struct {
+	Name           string           `yaml:"name"`
+	S3             *S3Config        `yaml:"s3,omitempty"`              // Optional: the tenant's gateway credentials; the endpoint defaults to s3.endpoint. Without it, gateway tests use the s3 section.
+	Satellite      *SatelliteConfig `yaml:"satellite,omitempty"`       // Optional: the tenant's satellite for uplink tests. Without it, uplink tests use the satellite section.
+	Bucket         string           `yaml:"bucket,omitempty"`          // Optional: default bucket of the tenant's tests
+	AnomalyWebhook string           `yaml:"anomaly_webhook,omitempty"` // Optional: receives the tenant's anomaly notifications instead of anomaly.webhook
+	Tests          []Test           `yaml:"tests,omitempty"`           // Moved to the top-level tests when the config is parsed
+}
+
+// tenantName matches tenant names, which prefix test names and label series
+var tenantName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// TenantTestName returns the name a tenant's test runs under
+func TenantTestName(tenant, test string) string {
+	return tenant + "-" + test
+}
+
+// GetTenant returns the named tenant
+func (c *Config) GetTenant(name string) (Tenant, bool) {
+	for _, t := range c.Tenants {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tenant{}, false
+}
+
+// resolveTenants adds each tenant's gateway and satellite to the named ones,
+// and moves its tests to the top-level tests, renamed and pointed at them.
+//
+// A tenant without s3 runs its gateway tests with the s3 section, the
+// deployment's shared credentials, or a named gateway they select; likewise
+// without a satellite. Tenant tests can never select a gateway or satellite
+// named after another tenant, whether or not their own tenant has one.
+func (c *Config) resolveTenants() error {
+	names := make(map[string]bool, len(c.Tests))
+	for _, t := range c.Tests {
+		names[t.Name] = true
+	}
+	seen := make(map[string]bool, len(c.Tenants))
+	for i := range c.Tenants {
+		tenant := &c.Tenants[i]
+		if !tenantName.MatchString(tenant.Name) {
+			return fmt.Errorf("tenants: invalid name %q (expected lowercase letters, digits, and dashes)", tenant.Name)
+		}
+		if seen[tenant.Name] {
+			return fmt.Errorf("tenants: duplicate name %q", tenant.Name)
+		}
+		seen[tenant.Name] = true
+	}
+
+	for i := range c.Tenants {
+		tenant := &c.Tenants[i]
+		if tenant.S3 != nil {
+			// A tenant's s3 doesn't inherit the s3 section's keys, so the
+			// team's tests never mix its endpoint with the shared keys
+			if tenant.S3.AccessKey == "" || tenant.S3.SecretKey == "" {
+				return fmt.Errorf("tenant %s: s3 needs its own access_key and secret_key", tenant.Name)
+			}
+			if tenant.S3.Name != "" && tenant.S3.Name != tenant.Name {
+				return fmt.Errorf("tenant %s: s3 is named after the tenant", tenant.Name)
+			}
+			if _, err := c.GetGateway(tenant.Name); err == nil {
+				return fmt.Errorf("tenant %s: a gateway of that name already exists", tenant.Name)
+			}
+			gw := *tenant.S3
+			gw.Name = tenant.Name
+			if gw.Endpoint == "" {
+				gw.Endpoint = c.S3.Endpoint
+			}
+			c.S3Gateways = append(c.S3Gateways, gw)
+		}
+		if tenant.Satellite != nil {
+			if tenant.Satellite.AccessGrant == "" {
+				return fmt.Errorf("tenant %s: satellite has no access_grant", tenant.Name)
+			}
+			if tenant.Satellite.Name != "" && tenant.Satellite.Name != tenant.Name {
+				return fmt.Errorf("tenant %s: satellite is named after the tenant", tenant.Name)
+			}
+			if _, err := c.GetSatellite(tenant.Name); err == nil {
+				return fmt.Errorf("tenant %s: a satellite of that name already exists", tenant.Name)
+			}
+			sat := *tenant.Satellite
+			sat.Name = tenant.Name
+			if sat.Bucket == "" {
+				sat.Bucket = tenant.Bucket
+			}
+			c.Satellites = append(c.Satellites, sat)
+		}
+
+		for _, test := range tenant.Tests {
+			if test.Fixture != "" {
+				return fmt.Errorf("tenant %s test %s: fixtures are not supported in tenants", tenant.Name, test.Name)
+			}
+			if tenant.S3 != nil && test.Gateway != "" {
+				return fmt.Errorf("tenant %s test %s: gateway cannot be set when the tenant has s3", tenant.Name, test.Name)
+			}
+			if tenant.Satellite != nil && test.Satellite != "" {
+				return fmt.Errorf("tenant %s test %s: satellite cannot be set when the tenant has a satellite", tenant.Name, test.Name)
+			}
+			if seen[test.Gateway] && test.Gateway != tenant.Name {
+				return fmt.Errorf("tenant %s test %s: gateway %s is reserved for tenant %s", tenant.Name, test.Name, test.Gateway, test.Gateway)
+			}
+			if seen[test.Satellite] && test.Satellite != tenant.Name {
+				return fmt.Errorf("tenant %s test %s: satellite %s is reserved for tenant %s", tenant.Name, test.Name, test.Satellite, test.Satellite)
+			}
+			test.Name = TenantTestName(tenant.Name, test.Name)
+			if names[test.Name] {
+				return fmt.Errorf("tenant %s: test %s conflicts with another test", tenant.Name, test.Name)
+			}
+			names[test.Name] = true
+			test.Tenant = tenant.Name
+			if test.Bucket == nil && tenant.Bucket != "" {
+				bucket := tenant.Bucket
+				test.Bucket = &bucket
+			}
+			if tenant.S3 != nil && gatewayExecutors[test.GetExecutor()] {
+				test.Gateway = tenant.Name
+			}
+			if tenant.Satellite != nil {
+				test.Satellite = tenant.Name
+			}
+			c.Tests = append(c.Tests, test)
+		}
+		tenant.Tests = nil
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// When restricts a test or step to matching probes and days, so one config
+// can be shared across heterogeneous probe deployments. Every set condition
+// must hold. Conditions are evaluated each time the test fires, not at load.
+type When struct {
+	Env      map[string]string `yaml:"env,omitempty"`      // Variables that must be set; a non-empty value is a glob the value must match
+	Days     []string          `yaml:"days,omitempty"`     // Days to run on (probe local time): "mon".."sun", "weekdays", or "weekends"
+	Endpoint string            `yaml:"endpoint,omitempty"` // Glob the host of s3.endpoint must match, e.g. "*.eu1.storjshare.io"
+}
+
+// weekdaySets maps day names to the weekdays they cover
+var weekdaySets = map[string][]time.Weekday{
+	"sun":      {time.Sunday},
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+// validate checks day names and glob patterns
+func (w *When) validate() error {
+	if w == nil {
+		return nil
+	}
+	for _, day := range w.Days {
+		if _, ok := weekdaySets[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("when: unknown day %q (expected mon..sun, weekdays, or weekends)", day)
+		}
+	}
+	for name, pattern := range w.Env {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("when: env %s: invalid pattern %q", name, pattern)
+		}
+	}
+	if _, err := path.Match(w.Endpoint, ""); err != nil {
+		return fmt.Errorf("when: invalid endpoint pattern %q", w.Endpoint)
+	}
+	return nil
+}
+
+// Check returns an error describing the first condition that doesn't hold
+// at now for a probe using the given S3 endpoint, or nil if all hold
+func (w *When) Check(now time.Time, endpoint string) error {
+	if w == nil {
+		return nil
+	}
+	for name, pattern := range w.Env {
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return fmt.Errorf("env %s is not set", name)
+		}
+		if pattern != "" {
+			if matched, _ := path.Match(pattern, value); !matched {
+				return fmt.Errorf("env %s=%q does not match %q", name, value, pattern)
+			}
+		}
+	}
+	if len(w.Days) > 0 && !w.onDay(now.Weekday()) {
+		return fmt.Errorf("%s is not one of %s", strings.ToLower(now.Weekday().String()[:3]), strings.Join(w.Days, ", "))
+	}
+	if w.Endpoint != "" {
+		host := endpoint
+		if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+			host = u.Hostname()
+		}
+		if matched, _ := path.Match(w.Endpoint, host); !matched {
+			return fmt.Errorf("endpoint %q does not match %q", host, w.Endpoint)
+		}
+	}
+	return nil
+}
+
+// RunsOn reports whether the days condition, if any, allows running on day
+func (w *When) RunsOn(day time.Weekday) bool {
+	return w == nil || len(w.Days) == 0 || w.onDay(day)
+}
+
+func (w *When) onDay(day time.Weekday) bool {
+	for _, name := range w.Days {
+		for _, d := range weekdaySets[strings.ToLower(name)] {
+			if d == day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Applicable returns the test as it should run at now: nil if its own when
+// conditions don't hold, otherwise a copy without the steps whose conditions
+// don't hold. The returned error explains why the test doesn't run.
+func (t *Test) Applicable(now time.Time, endpoint string) (*Test, error) {
+	if err := t.When.Check(now, endpoint); err != nil {
+		return nil, err
+	}
+
+	applicable := *t
+	applicable.Steps = make([]TestStep, 0, len(t.Steps))
+	var unmet error
+	for _, step := range t.Steps {
+		if err := step.When.Check(now, endpoint); err != nil {
+			unmet = fmt.Errorf("step %s: %w", step.Name, err)
+			continue
+		}
+		applicable.Steps = append(applicable.Steps, step)
+	}
+	if len(applicable.Steps) == 0 && len(t.Steps) > 0 {
+		return nil, unmet
+	}
+	return &applicable, nil
+}
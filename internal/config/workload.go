@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// WorkloadConfig expands a test into a weighted mix of operations, so it
+// simulates a customer traffic mix instead of a strict
+// upload→download→delete. The run uploads its object, runs Operations steps
+// drawn from the mix, then deletes the object.
+type WorkloadConfig struct {
+	Profile    string         `yaml:"profile,omitempty"`    // "read-heavy", "write-heavy", or "metadata-heavy"; optional with weights
+	Weights    map[string]int `yaml:"weights,omitempty"`    // Optional: operation weights replacing the profile's (upload, download, stat, list)
+	Operations int            `yaml:"operations,omitempty"` // Steps drawn from the mix per run (default: 10)
+	FileSize   *ByteSize      `yaml:"file_size,omitempty"`  // Size of every upload (default: the executor's default)
+	Timeout    string         `yaml:"timeout,omitempty"`    // Timeout of every step (default: step default)
+}
+
+// WorkloadProfiles are the operation weights of the predefined workloads
+var WorkloadProfiles = map[string]map[string]int{
+	"read-heavy":     {"download": 90, "upload": 10},
+	"write-heavy":    {"upload": 70, "download": 20, "stat": 10},
+	"metadata-heavy": {"stat": 60, "list": 30, "download": 10},
+}
+
+// workloadOperations are the steps a workload mix may draw. delete is left
+// out: the reads after it would fail; the run's object is deleted last.
+var workloadOperations = map[string]bool{"upload": true, "download": true, "stat": true, "list": true}
+
+// workloadExecutors run every workload operation
+var workloadExecutors = map[string]bool{"s3": true, "http-s3": true, "curl-s3": true}
+
+// weights returns the operation weights of the workload
+func (w *WorkloadConfig) weights() (map[string]int, error) {
+	if len(w.Weights) > 0 {
+		for op, weight := range w.Weights {
+			if !workloadOperations[op] {
+				return nil, fmt.Errorf("workload: unknown operation %q in weights (expected upload, download, stat, or list)", op)
+			}
+			if weight < 0 {
+				return nil, fmt.Errorf("workload: weight of %s must not be negative", op)
+			}
+		}
+		return w.Weights, nil
+	}
+	if w.Profile == "" {
+		return nil, fmt.Errorf("workload: profile or weights is required")
+	}
+	weights, ok := WorkloadProfiles[w.Profile]
+	if !ok {
+		return nil, fmt.Errorf("workload: unknown profile %q (expected %s)", w.Profile, strings.Join(slices.Sorted(maps.Keys(WorkloadProfiles)), ", "))
+	}
+	return weights, nil
+}
+
+// steps returns the workload's step sequence: an upload, the operations of
+// the mix, and a delete. The mix is spread evenly (smooth weighted
+// round-robin), so every run issues the same sequence and each operation's
+// share matches its weight as closely as the count allows.
+func (w *WorkloadConfig) steps() ([]TestStep, error) {
+	weights, err := w.weights()
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	ops := slices.Sorted(maps.Keys(weights))
+	for _, op := range ops {
+		total += weights[op]
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("workload: weights must not all be zero")
+	}
+	n := w.Operations
+	if n == 0 {
+		n = 10
+	}
+	if n < 0 || n > 1000 {
+		return nil, fmt.Errorf("workload: operations must be between 1 and 1000")
+	}
+
+	step := func(name string) TestStep {
+		s := TestStep{Name: name, Timeout: w.Timeout}
+		if name == "upload" {
+			s.FileSize = w.FileSize
+		}
+		return s
+	}
+	steps := []TestStep{step("upload")}
+	current := make(map[string]int, len(ops))
+	for range n {
+		best := ""
+		for _, op := range ops {
+			current[op] += weights[op]
+			if best == "" || current[op] > current[best] {
+				best = op
+			}
+		}
+		current[best] -= total
+		steps = append(steps, step(best))
+	}
+	return append(steps, step("delete")), nil
+}
+
+// resolveWorkloads replaces the steps of tests with a workload by its
+// expanded sequence
+func (c *Config) resolveWorkloads() error {
+	for i := range c.Tests {
+		t := &c.Tests[i]
+		if t.Workload == nil {
+			continue
+		}
+		if len(t.Steps) > 0 {
+			return fmt.Errorf("test %s: steps cannot be set with workload", t.Name)
+		}
+		if !workloadExecutors[t.GetExecutor()] {
+			return fmt.Errorf("test %s: workload is not supported by the %s executor (expected s3, http-s3, or curl-s3)", t.Name, t.GetExecutor())
+		}
+		if t.Fixture != "" {
+			return fmt.Errorf("test %s: workload cannot be used with fixture", t.Name)
+		}
+		steps, err := t.Workload.steps()
+		if err != nil {
+			return fmt.Errorf("test %s: %w", t.Name, err)
+		}
+		t.Steps = steps
+	}
+	return nil
+}
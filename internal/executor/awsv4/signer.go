@@ -11,7 +11,9 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -26,14 +28,53 @@ const (
 
 // Credentials holds AWS credentials for signing requests.
 type Credentials struct {
-	AccessKey string
-	SecretKey string
-	Region    string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // Optional: token of temporary (STS) credentials, sent as X-Amz-Security-Token
+	Region       string
+}
+
+// setSecurityToken adds the session token of temporary credentials to the
+// request. As an x-amz-* header it is signed with the others.
+func setSecurityToken(req *http.Request, creds Credentials) {
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+}
+
+// Mode describes how a request was signed, so the probe's own signing cost
+// can be attributed
+type Mode struct {
+	KeyDerived    bool // The signing key was derived for this request instead of cached
+	PayloadSigned bool // The payload was hashed instead of sent as UNSIGNED-PAYLOAD
+}
+
+// Key returns "derived" or "cached"
+func (m Mode) Key() string {
+	if m.KeyDerived {
+		return "derived"
+	}
+	return "cached"
+}
+
+// Payload returns "signed" or "unsigned"
+func (m Mode) Payload() string {
+	if m.PayloadSigned {
+		return "signed"
+	}
+	return "unsigned"
 }
 
 // Signer caches the signing key for a day to avoid repeated HMAC computation.
+// It is safe for concurrent use.
 type Signer struct {
-	creds      Credentials
+	creds Credentials
+
+	// Observe, if set, is called with the mode and duration of every
+	// signature. Set it before the signer is used.
+	Observe func(Mode, time.Duration)
+
+	mu         sync.Mutex
 	signingKey []byte
 	dateStamp  string
 }
@@ -43,36 +84,110 @@ func NewSigner(creds Credentials) *Signer {
 	return &Signer{creds: creds}
 }
 
-// Sign signs a request using cached signing key when possible.
+// Sign signs a request with UNSIGNED-PAYLOAD using the cached signing key
+// when possible.
 func (s *Signer) Sign(req *http.Request) error {
-	now := time.Now().UTC()
+	return s.sign(req, nil)
+}
+
+// SignPayload signs a request including the SHA256 of its payload, using the
+// cached signing key when possible.
+func (s *Signer) SignPayload(req *http.Request, payload []byte) error {
+	return s.sign(req, payload)
+}
+
+func (s *Signer) sign(req *http.Request, payload []byte) error {
+	start := time.Now()
+	now := start.UTC()
 	dateStamp := now.Format(dateFormat)
 
-	// Refresh signing key if date changed
-	if s.dateStamp != dateStamp {
-		s.signingKey = deriveSigningKey(s.creds.SecretKey, dateStamp, s.creds.Region, serviceName)
-		s.dateStamp = dateStamp
+	signingKey, derived := s.key(dateStamp)
+
+	payloadHash := unsignedPayload
+	if payload != nil {
+		payloadHash = hashSHA256(payload)
 	}
 
 	amzDate := now.Format(timeFormat)
 	req.Header.Set("X-Amz-Date", amzDate)
 	req.Header.Set("Host", req.Host)
-	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	setSecurityToken(req, s.creds)
 
-	canonicalReq, signedHeaders := buildCanonicalRequest(req, unsignedPayload)
+	canonicalReq, signedHeaders := buildCanonicalRequest(req, payloadHash)
 	credentialScope := fmt.Sprintf("%s/%s/%s/%s", dateStamp, s.creds.Region, serviceName, terminationStr)
 	stringToSign := buildStringToSign(algorithm, amzDate, credentialScope, canonicalReq)
 
 	// Use cached signing key
-	signature := hex.EncodeToString(hmacSHA256(s.signingKey, []byte(stringToSign)))
+	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
 
 	authHeader := fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
 		algorithm, s.creds.AccessKey, credentialScope, signedHeaders, signature)
 	req.Header.Set("Authorization", authHeader)
 
+	if s.Observe != nil {
+		s.Observe(Mode{KeyDerived: derived, PayloadSigned: payload != nil}, time.Since(start))
+	}
 	return nil
 }
 
+// Presign returns the request's URL with a query string signature valid for
+// expires, using the cached signing key when possible. Only the host header
+// is signed, so any HTTP client can fetch the URL as is.
+func (s *Signer) Presign(req *http.Request, expires time.Duration) string {
+	start := time.Now()
+	now := start.UTC()
+	dateStamp := now.Format(dateFormat)
+	signingKey, derived := s.key(dateStamp)
+
+	amzDate := now.Format(timeFormat)
+	credentialScope := fmt.Sprintf("%s/%s/%s/%s", dateStamp, s.creds.Region, serviceName, terminationStr)
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", s.creds.AccessKey+"/"+credentialScope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if s.creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", s.creds.SessionToken)
+	}
+
+	canonicalURI := req.URL.Path
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalReq := strings.Join([]string{
+		req.Method,
+		canonicalURIEncode(canonicalURI),
+		EncodeQuery(query),
+		"host:" + req.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	stringToSign := buildStringToSign(algorithm, amzDate, credentialScope, canonicalReq)
+	query.Set("X-Amz-Signature", hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign))))
+
+	presigned := *req.URL
+	presigned.RawQuery = EncodeQuery(query)
+	if s.Observe != nil {
+		s.Observe(Mode{KeyDerived: derived}, time.Since(start))
+	}
+	return presigned.String()
+}
+
+// key returns the signing key for dateStamp, deriving and caching it if the
+// date changed, and whether it was derived
+func (s *Signer) key(dateStamp string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	derived := s.dateStamp != dateStamp
+	if derived {
+		s.signingKey = deriveSigningKey(s.creds.SecretKey, dateStamp, s.creds.Region, serviceName)
+		s.dateStamp = dateStamp
+	}
+	return s.signingKey, derived
+}
+
 // SignRequest signs an HTTP request using AWS Signature Version 4.
 // The payload can be nil for requests without a body, or the request body bytes.
 // For streaming uploads, pass nil and the request will use UNSIGNED-PAYLOAD.
@@ -94,6 +209,7 @@ func signRequestAtTimeUnsigned(req *http.Request, creds Credentials, t time.Time
 	req.Header.Set("X-Amz-Date", amzDate)
 	req.Header.Set("Host", req.Host)
 	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	setSecurityToken(req, creds)
 
 	canonicalReq, signedHeaders := buildCanonicalRequest(req, unsignedPayload)
 
@@ -124,6 +240,7 @@ func signRequestAtTime(req *http.Request, creds Credentials, payload []byte, t t
 		payloadHash = hashSHA256(payload)
 	}
 	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	setSecurityToken(req, creds)
 
 	// Build canonical request
 	canonicalReq, signedHeaders := buildCanonicalRequest(req, payloadHash)
@@ -188,6 +305,14 @@ func canonicalURIEncode(path string) string {
 
 // canonicalizeQueryString creates the canonical query string.
 func canonicalizeQueryString(values url.Values) string {
+	return EncodeQuery(values)
+}
+
+// EncodeQuery encodes query parameters in SigV4 canonical form: sorted by
+// key then value, with everything but unreserved characters percent-encoded
+// (spaces as %20, not +). Sending a query string in this form guarantees it
+// matches what was signed.
+func EncodeQuery(values url.Values) string {
 	if len(values) == 0 {
 		return ""
 	}
@@ -199,16 +324,36 @@ func canonicalizeQueryString(values url.Values) string {
 	}
 	sort.Strings(keys)
 
-	// Build canonical query string
+	// Build canonical query string, sorting repeated keys by value
 	var parts []string
 	for _, key := range keys {
-		for _, value := range values[key] {
-			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, value := range vals {
+			parts = append(parts, uriEncode(key)+"="+uriEncode(value))
 		}
 	}
 	return strings.Join(parts, "&")
 }
 
+// uriEncode percent-encodes every byte except the RFC 3986 unreserved
+// characters (A-Z, a-z, 0-9, '-', '_', '.', '~'), as SigV4 requires.
+func uriEncode(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0xF])
+	}
+	return b.String()
+}
+
 // canonicalizeHeaders creates the canonical headers and signed headers strings.
 func canonicalizeHeaders(headers http.Header, host string) (string, string) {
 	// Headers to sign (lowercase)
@@ -234,7 +379,8 @@ func canonicalizeHeaders(headers http.Header, host string) (string, string) {
 			// Get the header value (case-insensitive lookup)
 			for hName, hValues := range headers {
 				if strings.ToLower(hName) == name && len(hValues) > 0 {
-					value = strings.TrimSpace(hValues[0])
+					// Trimmed, with sequential spaces collapsed to one
+					value = strings.Join(strings.Fields(hValues[0]), " ")
 					break
 				}
 			}
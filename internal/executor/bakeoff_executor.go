@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+)
+
+const executorNameBakeoff = "bakeoff"
+
+// BakeoffExecutor runs a test's steps through several executors in turn
+// (uplink, AWS SDK, raw HTTP, curl) and compares their run durations, making
+// the overhead of each client a measurement of its own.
+type BakeoffExecutor struct {
+	config    *config.Config
+	metrics   *metrics.Collector
+	executors map[string]TestExecutor // Keyed like config.Test.ExecutorKey
+}
+
+// NewBakeoff creates a new bakeoff executor running tests through executors,
+// which must not be modified afterwards.
+func NewBakeoff(cfg *config.Config, mc *metrics.Collector, executors map[string]TestExecutor) *BakeoffExecutor {
+	return &BakeoffExecutor{
+		config:    cfg,
+		metrics:   mc,
+		executors: executors,
+	}
+}
+
+// RunTest runs the test through each of its bakeoff executors, one after the
+// other, and records how their durations compare. Every executor runs the
+// test as its own run, with its own object key, timeout, and metrics; the
+// bakeoff result collects their steps and one BakeoffEntry per executor.
+func (e *BakeoffExecutor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	run := runctx.New(test, executorNameBakeoff, e.config.Satellite.Bucket)
+	res := result.New(run)
+
+	names := test.GetBakeoff()
+	run.Log().Printf("Running bakeoff test: %s (%s)", test.Name, strings.Join(names, ", "))
+
+	// Step jitter would add random delays to the durations being compared
+	steps := make([]config.TestStep, len(test.Steps))
+	copy(steps, test.Steps)
+	for i := range steps {
+		steps[i].Jitter = nil
+	}
+
+	var firstErr error
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return res.Finish(fmt.Errorf("bakeoff test %s interrupted before executor %s: %w", test.Name, name, err))
+		}
+
+		sub := *test
+		sub.Executor = name
+		sub.Bakeoff = nil
+		sub.Steps = steps
+		if name == "uplink" {
+			sub.Gateway = "" // Uplink talks to the satellite, not a gateway
+		}
+
+		entry := result.BakeoffEntry{Executor: name}
+		var subRes *result.Result
+		exec, ok := e.executors[sub.ExecutorKey()]
+		var err error
+		if !ok {
+			err = fmt.Errorf("executor %s is not configured", sub.ExecutorKey())
+		} else {
+			subRes, err = exec.RunTest(ctx, &sub)
+		}
+		if subRes != nil {
+			if len(res.Steps) == 0 && len(subRes.Steps) > 0 {
+				res.SetupSeconds = subRes.StepsStart().Sub(res.Start).Seconds()
+			}
+			entry.RunID = subRes.RunID
+			entry.DurationSeconds = subRes.DurationSeconds
+			for _, step := range subRes.Steps {
+				if step.Executor == "" {
+					step.Executor = subRes.Executor
+				}
+				res.Steps = append(res.Steps, step)
+			}
+		}
+		entry.Success = err == nil
+		if err != nil {
+			entry.Error = err.Error()
+			run.Log().Printf("  Bakeoff executor %s failed: %v", name, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("executor %s: %w", name, err)
+			}
+		}
+		res.Bakeoff = append(res.Bakeoff, entry)
+	}
+
+	rankBakeoff(res.Bakeoff)
+	e.metrics.RecordBakeoff(run, res.Bakeoff)
+	for _, entry := range res.Bakeoff {
+		if entry.Success {
+			run.Log().Printf("  [%s] %.3fs (x%.2f)", entry.Executor, entry.DurationSeconds, entry.Relative)
+		}
+	}
+
+	if firstErr != nil {
+		return res.Finish(fmt.Errorf("bakeoff test %s failed: %w", test.Name, firstErr))
+	}
+	run.Log().Printf("Bakeoff test %s completed successfully", test.Name)
+	return res.Finish(nil)
+}
+
+// rankBakeoff sets the duration of each successful entry relative to the
+// fastest successful one
+func rankBakeoff(entries []result.BakeoffEntry) {
+	fastest := 0.0
+	for _, entry := range entries {
+		if entry.Success && entry.DurationSeconds > 0 && (fastest == 0 || entry.DurationSeconds < fastest) {
+			fastest = entry.DurationSeconds
+		}
+	}
+	if fastest == 0 {
+		return
+	}
+	for i := range entries {
+		if entries[i].Success {
+			entries[i].Relative = entries[i].DurationSeconds / fastest
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/s3err"
+)
+
+const executorNameCanary = "canary"
+
+// Canary check results
+const (
+	canaryOK      = "ok"
+	canarySeeded  = "seeded"  // Did not exist yet and was uploaded
+	canaryMissing = "missing" // Verified earlier by this process, now gone
+	canaryCorrupt = "corrupt" // Content differs from what was written
+	canaryError   = "error"   // Request failed
+)
+
+// CanaryExecutor maintains long-lived canary objects and verifies them on
+// every run, monitoring the durability of old objects rather than fresh
+// round trips. Canary content is derived from the object key, so any probe
+// can verify it without stored checksums. A canary that doesn't exist yet is
+// uploaded once.
+type CanaryExecutor struct {
+	config  *config.Config
+	metrics *metrics.Collector
+
+	mu     sync.Mutex
+	client *HttpS3Executor
+	seen   map[string]bool // Canaries this process has found intact, by bucket/key
+}
+
+// NewCanary creates a new canary executor.
+func NewCanary(cfg *config.Config, mc *metrics.Collector) *CanaryExecutor {
+	return &CanaryExecutor{
+		config:  cfg,
+		metrics: mc,
+		seen:    make(map[string]bool),
+	}
+}
+
+// httpClient returns the HTTP S3 executor used for requests, creating it on
+// first use so connections are reused across runs.
+func (e *CanaryExecutor) httpClient() (*HttpS3Executor, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client == nil {
+		c, err := NewHttpS3(e.config, e.metrics)
+		if err != nil {
+			return nil, err
+		}
+		c.name = executorNameCanary
+		e.client = c
+	}
+	return e.client, nil
+}
+
+func (e *CanaryExecutor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	run := runctx.New(test, executorNameCanary, e.config.Satellite.Bucket)
+	run.Endpoint, run.Region = e.config.S3.Endpoint, e.config.S3.Region
+	res := result.New(run)
+
+	// Bound all checks by the test timeout, if set
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	c, err := e.httpClient()
+	if err != nil {
+		return res.Finish(fmt.Errorf("canary test %s: %w", test.Name, err))
+	}
+	if err := c.ensureBucket(ctx, run); err != nil {
+		return res.Finish(fmt.Errorf("failed to ensure bucket %s exists: %w", run.Bucket, err))
+	}
+
+	sizes := test.Canary.GetSizes()
+	run.Log().Printf("Running canary test: %s (%d objects under %s%s)", test.Name, len(sizes), run.Bucket+"/", test.Canary.GetPrefix())
+
+	res.StepsStarting()
+	var firstErr error
+	for _, size := range sizes {
+		key := test.Canary.GetPrefix() + size.String() + ".bin"
+		sr, err := e.checkObject(ctx, c, run, key, size)
+		res.Steps = append(res.Steps, sr)
+		if err != nil && firstErr == nil {
+			res.FailedStep = sr.Name
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return res.Finish(fmt.Errorf("canary test %s failed: %w", test.Name, firstErr))
+	}
+	return res.Finish(nil)
+}
+
+// checkObject downloads one canary and compares it with its expected
+// content, uploading it first if it doesn't exist yet.
+func (e *CanaryExecutor) checkObject(ctx context.Context, c *HttpS3Executor, run *runctx.Run, key string, size config.ByteSize) (result.Step, error) {
+	sr := result.Step{Name: size.String()}
+	start := time.Now()
+	seenKey := run.Bucket + "/" + key
+
+	outcome, age, err := e.verify(ctx, c, run, key, size, &sr)
+	if outcome == canaryMissing {
+		if !e.wasSeen(seenKey) {
+			// First sight of this canary: write it once
+			outcome, err = canarySeeded, e.seed(ctx, c, run, key, size)
+			if err != nil {
+				outcome = canaryError
+			}
+		} else if serr := e.seed(ctx, c, run, key, size); serr != nil {
+			// Lost since it was verified: the run fails, and the canary is
+			// written again so later runs keep checking durability
+			run.Log().Printf("    Canary %s could not be re-seeded: %v", key, serr)
+		}
+	}
+	if outcome == canaryOK || outcome == canarySeeded {
+		e.markSeen(seenKey)
+	}
+	e.metrics.RecordCanaryCheck(run, key, outcome, age)
+
+	switch {
+	case err != nil:
+		run.Log().Printf("    Canary %s %s: %v", key, outcome, err)
+	case outcome == canarySeeded:
+		run.Log().Printf("    Canary %s seeded (%s)", key, size)
+	default:
+		run.Log().Debug("    Canary %s intact (%s, written %v ago)", key, size, age.Round(time.Second))
+	}
+	sr.Finish(start, err)
+	return sr, err
+}
+
+// verify downloads a canary and compares it with its expected content. A
+// missing object is reported as canaryMissing with a non-nil error.
+func (e *CanaryExecutor) verify(ctx context.Context, c *HttpS3Executor, run *runctx.Run, key string, size config.ByteSize, sr *result.Step) (string, time.Duration, error) {
+	req, err := c.newRequest(ctx, run, http.MethodGet, c.buildURL(run.Bucket, key), nil)
+	if err != nil {
+		return canaryError, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	signStart := time.Now()
+	if err := c.signer.Sign(req); err != nil {
+		return canaryError, 0, fmt.Errorf("failed to sign request: %w", err)
+	}
+	signDuration := time.Since(signStart)
+
+	tracer := newHTTPTimingTracer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.trace()))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		e.metrics.RecordStorjDownload(run, size.String(), time.Since(tracer.start), 0, false)
+		return canaryError, 0, fmt.Errorf("HTTP GET failed: %w", err)
+	}
+	defer resp.Body.Close()
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
+
+	if resp.StatusCode == http.StatusNotFound {
+		return canaryMissing, 0, &classifiedError{class: canaryMissing, msg: fmt.Sprintf("canary %s is missing", key)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		respErr := s3err.FromResponse(resp)
+		sr.RequestID = respErr.RequestID
+		e.metrics.RecordStorjDownload(run, size.String(), time.Since(tracer.start), 0, false)
+		return canaryError, 0, fmt.Errorf("HTTP GET returned %w", respErr)
+	}
+
+	var age time.Duration
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		age = time.Since(modified)
+	}
+
+	n, mismatch, err := compareCanary(resp.Body, newCanaryContent(key, size.Int64()))
+	timings := tracer.toMetrics(time.Now())
+	e.metrics.RecordHTTPTiming(run, "download", timings)
+	e.metrics.RecordHTTPTimingPhase(run, "download", "sign", signDuration)
+	describeStep(sr, timings, signDuration, resp.Header)
+	sr.Bytes = n
+	if age > 0 {
+		e.metrics.RecordCanaryTTFB(run, key, age, timings.TTFB)
+	}
+
+	if err != nil {
+		e.metrics.RecordStorjDownload(run, size.String(), timings.Total, n, false)
+		return canaryError, age, fmt.Errorf("failed to read HTTP response: %w", err)
+	}
+	if mismatch >= 0 {
+		e.metrics.RecordStorjDownload(run, size.String(), timings.Total, n, false)
+		return canaryCorrupt, age, &classifiedError{
+			class: canaryCorrupt,
+			msg:   fmt.Sprintf("canary %s is corrupt: read %d of %d bytes, first difference at offset %d", key, n, size.Int64(), mismatch),
+		}
+	}
+	e.metrics.RecordStorjDownload(run, size.String(), timings.Total, n, true)
+	return canaryOK, age, nil
+}
+
+// seed uploads a canary's expected content
+func (e *CanaryExecutor) seed(ctx context.Context, c *HttpS3Executor, run *runctx.Run, key string, size config.ByteSize) error {
+	req, err := c.newRequest(ctx, run, http.MethodPut, c.buildURL(run.Bucket, key), newCanaryContent(key, size.Int64()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size.Int64()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if err := c.signer.Sign(req); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		e.metrics.RecordStorjUpload(run, size.String(), time.Since(start), size.Int64(), false)
+		return fmt.Errorf("HTTP PUT failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		e.metrics.RecordStorjUpload(run, size.String(), time.Since(start), size.Int64(), false)
+		return fmt.Errorf("HTTP PUT returned %w", s3err.FromResponse(resp))
+	}
+	io.Copy(io.Discard, resp.Body)
+	e.metrics.RecordStorjUpload(run, size.String(), time.Since(start), size.Int64(), true)
+	return nil
+}
+
+func (e *CanaryExecutor) wasSeen(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.seen[key]
+}
+
+func (e *CanaryExecutor) markSeen(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.seen[key] = true
+}
+
+// canaryContent generates the deterministic content of a canary object: a
+// SHA-256 counter stream seeded by its key, so the expected bytes can be
+// regenerated anywhere without storing checksums.
+type canaryContent struct {
+	seed      [sha256.Size]byte
+	block     [sha256.Size]byte
+	off       int // Next unread byte of block
+	counter   uint64
+	remaining int64
+}
+
+func newCanaryContent(key string, size int64) *canaryContent {
+	return &canaryContent{
+		seed:      sha256.Sum256([]byte("synthetics-canary/" + key)),
+		off:       sha256.Size,
+		remaining: size,
+	}
+}
+
+func (c *canaryContent) Read(p []byte) (int, error) {
+	if c.remaining == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+
+	var in [sha256.Size + 8]byte
+	n := 0
+	for n < len(p) {
+		if c.off == len(c.block) {
+			copy(in[:], c.seed[:])
+			binary.BigEndian.PutUint64(in[sha256.Size:], c.counter)
+			c.block = sha256.Sum256(in[:])
+			c.counter++
+			c.off = 0
+		}
+		k := copy(p[n:], c.block[c.off:])
+		c.off += k
+		n += k
+	}
+	c.remaining -= int64(n)
+	return n, nil
+}
+
+// compareCanary reads body to the end, comparing it with the expected
+// content. It returns the bytes read and the offset of the first difference,
+// or -1 if body matches expected (so far as it was read).
+func compareCanary(body, expected io.Reader) (int64, int64, error) {
+	got := make([]byte, 32<<10)
+	want := make([]byte, 32<<10)
+	var n int64
+	mismatch := int64(-1)
+	for {
+		k, err := body.Read(got)
+		if k > 0 && mismatch < 0 {
+			w, _ := io.ReadFull(expected, want[:k])
+			if w != k || !bytes.Equal(got[:k], want[:k]) {
+				i := 0
+				for i < w && got[i] == want[i] {
+					i++
+				}
+				mismatch = n + int64(i)
+			}
+		}
+		n += int64(k)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, mismatch, err
+		}
+	}
+
+	// A short body differs where it ends
+	if mismatch < 0 {
+		if extra, _ := expected.Read(want[:1]); extra > 0 {
+			mismatch = n
+		}
+	}
+	return n, mismatch, nil
+}
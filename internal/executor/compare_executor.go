@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/jitter"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+)
+
+const executorNameCompare = "compare"
+
+// CompareExecutor runs the same HTTP S3 steps against several endpoints
+// back-to-back and exports the pairwise latency deltas.
+type CompareExecutor struct {
+	config  *config.Config
+	metrics *metrics.Collector
+
+	mu      sync.Mutex
+	clients map[string]*HttpS3Executor // Keyed by test name and endpoint name
+}
+
+// NewCompare creates a new compare executor.
+func NewCompare(cfg *config.Config, mc *metrics.Collector) *CompareExecutor {
+	return &CompareExecutor{
+		config:  cfg,
+		metrics: mc,
+		clients: make(map[string]*HttpS3Executor),
+	}
+}
+
+// client returns the HTTP S3 executor for an endpoint, creating it on first use
+// so connections are reused across runs.
+func (e *CompareExecutor) client(testName string, ep config.CompareEndpoint) (*HttpS3Executor, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := testName + "/" + ep.Name
+	if c, ok := e.clients[key]; ok {
+		return c, nil
+	}
+
+	cfg := *e.config
+	cfg.S3.Endpoint = ep.Endpoint
+	if ep.AccessKey != "" {
+		cfg.S3.AccessKey = ep.AccessKey
+		cfg.S3.SessionToken = ep.SessionToken // The global token belongs to the global key
+	}
+	if ep.SecretKey != "" {
+		cfg.S3.SecretKey = ep.SecretKey
+	}
+	if ep.Region != "" {
+		cfg.S3.Region = ep.Region
+	}
+	cfg.S3.Headers = config.MergeHeaders(cfg.S3.Headers, ep.Headers)
+
+	c, err := NewHttpS3(&cfg, e.metrics)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint %s: %w", ep.Name, err)
+	}
+	c.name = executorNameCompare + ":" + ep.Name
+	e.clients[key] = c
+	return c, nil
+}
+
+// RunTest executes each step against every endpoint in turn, then records
+// the latency delta for each pair of endpoints that completed the step.
+func (e *CompareExecutor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	// One run (same object key) on every endpoint so each performs the identical operation
+	run := runctx.New(test, executorNameCompare, e.config.Satellite.Bucket)
+	res := result.New(run)
+
+	if len(test.Compare) < 2 {
+		return res.Finish(fmt.Errorf("compare test %s requires at least 2 endpoints, got %d", test.Name, len(test.Compare)))
+	}
+
+	run.Log().Printf("Running compare test: %s (%d endpoints)", test.Name, len(test.Compare))
+
+	testStart := time.Now()
+
+	// Bound all steps by the test timeout, if set
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	release, err := run.Claim(ctx)
+	if err != nil {
+		return res.Finish(fmt.Errorf("test %s not started: %w", test.Name, err))
+	}
+	defer release()
+
+	clients := make([]*HttpS3Executor, len(test.Compare))
+	failed := make([]bool, len(test.Compare))
+	var firstErr error
+
+	for i, ep := range test.Compare {
+		c, err := e.client(test.Name, ep)
+		if err == nil {
+			err = c.ensureBucket(ctx, run)
+		}
+		if err != nil {
+			run.Log().Printf("  Compare endpoint %s unavailable: %v", ep.Name, err)
+			failed[i] = true
+			if firstErr == nil {
+				firstErr = fmt.Errorf("endpoint %s: %w", ep.Name, err)
+			}
+			continue
+		}
+		clients[i] = c
+	}
+
+	res.StepsStarting()
+	for s, step := range test.Steps {
+		// Apply step jitter once so every endpoint runs the step back-to-back
+		if step.Jitter != nil && step.Jitter.IsEnabled() {
+			maxJitter, _ := step.Jitter.ParseMaxJitter(0)
+			if maxJitter > 0 {
+				if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", test.Name, step.Name)); err != nil {
+					return res.Finish(fmt.Errorf("step jitter interrupted: %w", err))
+				}
+			}
+		}
+		stepCopy := step
+		stepCopy.Jitter = nil
+
+		// The step budget is shared by all endpoints
+		stepCtx, cancelStep := stepContext(ctx, test, []int{s})
+		durations := make([]time.Duration, len(test.Compare))
+		for i, ep := range test.Compare {
+			if failed[i] {
+				continue
+			}
+			stepStart := time.Now()
+			sr, err := clients[i].runStep(stepCtx, run.On(clients[i].name, clients[i].endpoint, clients[i].config.S3.Region), &stepCopy, false)
+			sr.Executor = clients[i].name
+			res.Steps = append(res.Steps, sr)
+			if err != nil {
+				err = budgetError(ctx, test, err)
+				run.StepLog(step.Name).Printf("  Compare endpoint %s failed at step %s: %v", ep.Name, step.Name, err)
+				failed[i] = true
+				if firstErr == nil {
+					firstErr = fmt.Errorf("endpoint %s failed at step %s: %w", ep.Name, step.Name, err)
+				}
+				continue
+			}
+			durations[i] = time.Since(stepStart)
+		}
+		cancelStep()
+
+		for a := 0; a < len(test.Compare); a++ {
+			for b := a + 1; b < len(test.Compare); b++ {
+				if failed[a] || failed[b] {
+					continue
+				}
+				delta := durations[b] - durations[a]
+				e.metrics.RecordCompareDelta(run, step.Name, test.Compare[a].Name, test.Compare[b].Name, delta)
+				run.StepLog(step.Name).Printf("  [%s] %s=%v %s=%v (delta %v)", step.Name,
+					test.Compare[a].Name, durations[a], test.Compare[b].Name, durations[b], delta)
+			}
+		}
+	}
+
+	duration := time.Since(testStart)
+	if firstErr != nil {
+		return res.Finish(fmt.Errorf("compare test %s failed: %w", test.Name, firstErr))
+	}
+
+	run.Log().Printf("Compare test %s completed successfully in %v", test.Name, duration)
+	return res.Finish(nil)
+}
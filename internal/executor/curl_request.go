@@ -0,0 +1,188 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/s3err"
+)
+
+// curlWriteFormat is the format string for curl -w to get timing info. It
+// starts on a new line so it can be split from a response body on stdout.
+// Format: http_code|time_namelookup|time_connect|time_appconnect|time_starttransfer|time_total|num_connects
+const curlWriteFormat = "\n%{http_code}|%{time_namelookup}|%{time_connect}|%{time_appconnect}|%{time_starttransfer}|%{time_total}|%{num_connects}"
+
+// CurlRequest describes one curl invocation. Args turns it into a command
+// line without running anything, so every operation builds its arguments the
+// same way.
+type CurlRequest struct {
+	Method      string
+	URL         string   // Full URL, including any signed query string
+	Headers     []string // "Name: value", including the signature headers
+	BodyFile    string   // File to send as the request body (--data-binary); empty for none
+	Output      string   // Where to write the response body; empty for stdout
+	DumpHeaders bool     // Write the response headers to stdout ahead of the body, into curlResponse.Header
+	WriteFormat string   // curl -w format; empty for curlWriteFormat
+	ExtraArgs   []string // Additional arguments, e.g. client certificates
+}
+
+// Args returns the curl arguments for the request
+func (r *CurlRequest) Args() []string {
+	args := []string{"-s", "-S"} // Silent but show errors
+	if r.Method == http.MethodHead {
+		args = append(args, "-I") // -X HEAD would wait for a body that never comes
+	} else {
+		args = append(args, "-X", r.Method)
+	}
+	if r.BodyFile != "" {
+		args = append(args, "--data-binary", "@"+r.BodyFile)
+	}
+	if r.Output != "" {
+		args = append(args, "-o", r.Output)
+	}
+	if r.DumpHeaders && r.Method != http.MethodHead {
+		args = append(args, "-D", "-") // -I writes them already
+	}
+
+	format := r.WriteFormat
+	if format == "" {
+		format = curlWriteFormat
+	}
+	args = append(args, "-w", format)
+	args = append(args, r.ExtraArgs...)
+	for _, h := range r.Headers {
+		args = append(args, "-H", h)
+	}
+	return append(args, r.URL)
+}
+
+// curlExitClasses are the error classes of curl exit codes worth telling
+// apart; other nonzero exits are classified as curl_<code>
+var curlExitClasses = map[int]string{
+	6:  "dns",               // Couldn't resolve host
+	7:  "connect",           // Failed to connect to host
+	28: result.ClassTimeout, // Operation timed out
+	35: result.ClassTLS,     // TLS handshake failed
+	52: "empty_reply",       // Server returned nothing
+	56: "recv",              // Failure receiving network data
+	60: result.ClassTLS,     // Peer certificate cannot be authenticated
+}
+
+// CurlError is a curl run that exited nonzero before producing a response
+type CurlError struct {
+	Method   string
+	ExitCode int
+	Stderr   string // curl's own message, e.g. "curl: (6) Could not resolve host: ..."
+}
+
+func (e *CurlError) Error() string {
+	msg := fmt.Sprintf("curl %s failed (exit %d)", e.Method, e.ExitCode)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// ErrorCode returns the error class of the exit code, which result.Classify
+// uses as the run's error_class
+func (e *CurlError) ErrorCode() string {
+	if class, ok := curlExitClasses[e.ExitCode]; ok {
+		return class
+	}
+	return fmt.Sprintf("curl_%d", e.ExitCode)
+}
+
+// curlResponse is the parsed result of a curl request
+type curlResponse struct {
+	StatusCode int         // 0 if no response was received
+	Body       []byte      // Response body when written to stdout (or read back from Output on error)
+	Header     http.Header // Response headers if the request dumped them
+	Timings    metrics.HTTPTimings
+}
+
+// check returns the S3 error in the response unless its status is one of ok
+func (r *curlResponse) check(ok ...int) *s3err.Error {
+	if slices.Contains(ok, r.StatusCode) {
+		return nil
+	}
+	return s3err.Parse(r.StatusCode, r.Body)
+}
+
+// splitCurlOutput separates a response body written to stdout from the
+// trailing curlWriteFormat line
+func splitCurlOutput(output []byte) (body []byte, writeOut string) {
+	i := bytes.LastIndexByte(output, '\n')
+	return output[:max(i, 0)], string(output[i+1:])
+}
+
+// splitCurlHeaders separates the response headers curl dumped ahead of the
+// body. Interim responses (100 Continue) dump a header block each; the last
+// block belongs to the final response.
+func splitCurlHeaders(output []byte) (http.Header, []byte) {
+	var header http.Header
+	for bytes.HasPrefix(output, []byte("HTTP/")) {
+		block, rest, found := bytes.Cut(output, []byte("\r\n\r\n"))
+		if !found {
+			break
+		}
+		tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(append(block, "\r\n\r\n"...))))
+		if _, err := tp.ReadLine(); err != nil { // Status line
+			break
+		}
+		mime, err := tp.ReadMIMEHeader()
+		if err != nil {
+			break
+		}
+		header, output = http.Header(mime), rest
+	}
+	return header, output
+}
+
+// parseCurlOutput parses curl -w output and returns status code and timings
+func parseCurlOutput(output string) (statusCode int, timings metrics.HTTPTimings, err error) {
+	parts := strings.Split(strings.TrimSpace(output), "|")
+	if len(parts) != 7 {
+		return 0, metrics.HTTPTimings{}, fmt.Errorf("unexpected curl output format: %s", output)
+	}
+
+	statusCode, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, metrics.HTTPTimings{}, fmt.Errorf("unexpected curl status code: %s", parts[0])
+	}
+
+	parseSeconds := func(s string) time.Duration {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(f * float64(time.Second))
+	}
+
+	dnsLookup := parseSeconds(parts[1])
+	tcpConnect := parseSeconds(parts[2])
+	tlsHandshake := parseSeconds(parts[3])
+	ttfb := parseSeconds(parts[4])
+	total := parseSeconds(parts[5])
+
+	// Curl times are cumulative, convert to individual phases
+	timings = metrics.HTTPTimings{
+		DNSLookup:    dnsLookup,
+		TCPConnect:   tcpConnect - dnsLookup,
+		TLSHandshake: tlsHandshake - tcpConnect,
+		TTFB:         ttfb - tlsHandshake,
+		Transfer:     total - ttfb,
+		Total:        total,
+		ConnReused:   parts[6] == "0", // No new connections were opened
+	}
+
+	return statusCode, timings, nil
+}
@@ -2,10 +2,11 @@ package executor
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"strconv"
@@ -17,49 +18,13 @@ import (
 	"github.com/ethanadams/synthetics/internal/jitter"
 	"github.com/ethanadams/synthetics/internal/logging"
 	"github.com/ethanadams/synthetics/internal/metrics"
-	"github.com/oklog/ulid/v2"
+	"github.com/ethanadams/synthetics/internal/payload"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/subproc"
+	"github.com/ethanadams/synthetics/internal/workdir"
 )
 
-// curlWriteFormat is the format string for curl -w to get timing info
-// Format: http_code|time_namelookup|time_connect|time_appconnect|time_starttransfer|time_total
-const curlWriteFormat = "%{http_code}|%{time_namelookup}|%{time_connect}|%{time_appconnect}|%{time_starttransfer}|%{time_total}"
-
-// parseCurlOutput parses curl -w output and returns status code and timings
-func parseCurlOutput(output string) (statusCode string, timings metrics.HTTPTimings, err error) {
-	parts := strings.Split(strings.TrimSpace(output), "|")
-	if len(parts) != 6 {
-		return "", metrics.HTTPTimings{}, fmt.Errorf("unexpected curl output format: %s", output)
-	}
-
-	statusCode = parts[0]
-
-	parseSeconds := func(s string) time.Duration {
-		f, err := strconv.ParseFloat(s, 64)
-		if err != nil {
-			return 0
-		}
-		return time.Duration(f * float64(time.Second))
-	}
-
-	dnsLookup := parseSeconds(parts[1])
-	tcpConnect := parseSeconds(parts[2])
-	tlsHandshake := parseSeconds(parts[3])
-	ttfb := parseSeconds(parts[4])
-	total := parseSeconds(parts[5])
-
-	// Curl times are cumulative, convert to individual phases
-	timings = metrics.HTTPTimings{
-		DNSLookup:    dnsLookup,
-		TCPConnect:   tcpConnect - dnsLookup,
-		TLSHandshake: tlsHandshake - tcpConnect,
-		TTFB:         ttfb - tlsHandshake,
-		Transfer:     total - ttfb,
-		Total:        total,
-	}
-
-	return statusCode, timings, nil
-}
-
 const executorNameCurlS3 = "curl-s3"
 
 // CurlS3Executor runs S3 tests using curl subprocess.
@@ -67,6 +32,7 @@ type CurlS3Executor struct {
 	curlPath string
 	endpoint string
 	signer   *awsv4.Signer // Cached signer for efficiency
+	tlsArgs  []string      // Client certificate args (--cert/--key) for mTLS
 	config   *config.Config
 	metrics  *metrics.Collector
 }
@@ -92,156 +58,189 @@ func NewCurlS3(cfg *config.Config, mc *metrics.Collector) (*CurlS3Executor, erro
 	}
 
 	creds := awsv4.Credentials{
-		AccessKey: cfg.S3.AccessKey,
-		SecretKey: cfg.S3.SecretKey,
-		Region:    region,
+		AccessKey:    cfg.S3.AccessKey,
+		SecretKey:    cfg.S3.SecretKey,
+		SessionToken: cfg.S3.SessionToken,
+		Region:       region,
+	}
+
+	var tlsArgs []string
+	if cfg.S3.ClientCert != "" || cfg.S3.ClientKey != "" {
+		if cfg.S3.ClientCert == "" || cfg.S3.ClientKey == "" {
+			return nil, fmt.Errorf("both client_cert and client_key are required for mTLS")
+		}
+		for _, path := range []string{cfg.S3.ClientCert, cfg.S3.ClientKey} {
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("client certificate file: %w", err)
+			}
+		}
+		tlsArgs = []string{"--cert", cfg.S3.ClientCert, "--key", cfg.S3.ClientKey}
+	}
+
+	signer := awsv4.NewSigner(creds) // Cached signer
+	signer.Observe = func(m awsv4.Mode, d time.Duration) {
+		mc.RecordSign(executorNameCurlS3, m.Key(), m.Payload(), d)
 	}
 
 	return &CurlS3Executor{
 		curlPath: curlPath,
 		endpoint: cfg.S3.Endpoint,
-		signer:   awsv4.NewSigner(creds), // Cached signer
+		signer:   signer,
+		tlsArgs:  tlsArgs,
 		config:   cfg,
 		metrics:  mc,
 	}, nil
 }
 
-// ensureBucket creates the bucket if it doesn't exist
-func (e *CurlS3Executor) ensureBucket(ctx context.Context, bucket string) error {
-	bucketURL := fmt.Sprintf("%s/%s", e.endpoint, bucket)
+// do runs a curl request and parses its status code, timings, and any
+// response body. A nonzero curl exit is returned as a *CurlError.
+func (e *CurlS3Executor) do(ctx context.Context, req *CurlRequest) (*curlResponse, error) {
+	done := e.metrics.TrackSubprocess("curl")
+	cmd := subproc.Command(ctx, e.curlPath, req.Args()...)
+	output, err := subproc.Output(cmd)
+	done(cmd.ProcessState)
+	if err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("curl %s failed: %w", req.Method, ctx.Err())
+		case errors.As(err, &exitErr):
+			return nil, &CurlError{Method: req.Method, ExitCode: exitErr.ExitCode(), Stderr: strings.TrimSpace(string(exitErr.Stderr))}
+		default:
+			return nil, fmt.Errorf("curl %s failed: %w", req.Method, err)
+		}
+	}
 
-	// Check if bucket exists by trying to HEAD it
-	headHeaders, _, err := e.signAndGetHeaders(http.MethodHead, bucketURL, 0)
+	body, writeOut := splitCurlOutput(output)
+	statusCode, timings, err := parseCurlOutput(writeOut)
 	if err != nil {
-		return fmt.Errorf("failed to sign HEAD request: %w", err)
+		return nil, fmt.Errorf("failed to parse curl output: %w", err)
+	}
+	var header http.Header
+	if req.DumpHeaders {
+		header, body = splitCurlHeaders(body)
 	}
 
-	headArgs := []string{"-s", "-S", "-I", "-o", "/dev/null", "-w", "%{http_code}"}
-	for _, h := range headHeaders {
-		headArgs = append(headArgs, "-H", h)
+	// Error bodies written to a file are read back so they can be parsed
+	if statusCode/100 != 2 && req.Output != "" && req.Output != os.DevNull {
+		body, _ = os.ReadFile(req.Output)
 	}
-	headArgs = append(headArgs, bucketURL)
+	return &curlResponse{StatusCode: statusCode, Body: body, Header: header, Timings: timings}, nil
+}
 
-	headCmd := exec.CommandContext(ctx, e.curlPath, headArgs...)
-	headOutput, err := headCmd.Output()
-	if err == nil && strings.TrimSpace(string(headOutput)) == "200" {
-		// Bucket exists
-		return nil
+// bucketStatus makes a signed request against the bucket and returns the status code
+func (e *CurlS3Executor) bucketStatus(ctx context.Context, run *runctx.Run, method, bucketURL string) (int, error) {
+	req, _, err := e.newRequest(run, method, bucketURL, nil, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sign %s request: %w", method, err)
 	}
+	req.Output = os.DevNull
 
-	// Try to create the bucket with PUT
-	putHeaders, _, err := e.signAndGetHeaders(http.MethodPut, bucketURL, 0)
+	resp, err := e.do(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to sign PUT request: %w", err)
+		return 0, err
 	}
+	return resp.StatusCode, nil
+}
 
-	putArgs := []string{"-s", "-S", "-X", "PUT", "-o", "/dev/null", "-w", "%{http_code}"}
-	for _, h := range putHeaders {
-		putArgs = append(putArgs, "-H", h)
+// ensureBucket creates the run's bucket if it doesn't exist
+func (e *CurlS3Executor) ensureBucket(ctx context.Context, run *runctx.Run) error {
+	bucket := run.Bucket
+	bucketURL := fmt.Sprintf("%s/%s", e.endpoint, bucket)
+
+	// Check if bucket exists by trying to HEAD it
+	status, err := e.bucketStatus(ctx, run, http.MethodHead, bucketURL)
+	if err == nil && status == http.StatusOK {
+		// Bucket exists
+		return nil
 	}
-	putArgs = append(putArgs, bucketURL)
 
-	putCmd := exec.CommandContext(ctx, e.curlPath, putArgs...)
-	putOutput, err := putCmd.Output()
+	// Try to create the bucket with PUT
+	status, err = e.bucketStatus(ctx, run, http.MethodPut, bucketURL)
 	if err != nil {
 		return fmt.Errorf("failed to create bucket: %w", err)
 	}
 
-	putStatus := strings.TrimSpace(string(putOutput))
-	if putStatus == "200" || putStatus == "201" {
-		log.Printf("    Created bucket: %s", bucket)
-	} else if putStatus != "409" {
+	if status == http.StatusOK || status == http.StatusCreated {
+		run.Log().Printf("    Created bucket: %s", bucket)
+	} else if status != http.StatusConflict {
 		// 409 Conflict usually means bucket already exists
-		log.Printf("    Note: CreateBucket returned status %s (may be ignorable if bucket exists)", putStatus)
+		run.Log().Printf("    Note: CreateBucket returned status %d (may be ignorable if bucket exists)", status)
 	}
 
 	// Verify bucket is now accessible
-	verifyHeaders, _, err := e.signAndGetHeaders(http.MethodHead, bucketURL, 0)
-	if err != nil {
-		return fmt.Errorf("failed to sign verify request: %w", err)
-	}
-
-	verifyArgs := []string{"-s", "-S", "-I", "-o", "/dev/null", "-w", "%{http_code}"}
-	for _, h := range verifyHeaders {
-		verifyArgs = append(verifyArgs, "-H", h)
-	}
-	verifyArgs = append(verifyArgs, bucketURL)
-
-	verifyCmd := exec.CommandContext(ctx, e.curlPath, verifyArgs...)
-	verifyOutput, err := verifyCmd.Output()
+	status, err = e.bucketStatus(ctx, run, http.MethodHead, bucketURL)
 	if err != nil {
 		return fmt.Errorf("bucket %s not accessible after creation attempt: %w", bucket, err)
 	}
-
-	verifyStatus := strings.TrimSpace(string(verifyOutput))
-	if verifyStatus != "200" {
-		return fmt.Errorf("bucket %s not accessible after creation attempt: status %s", bucket, verifyStatus)
+	if status != http.StatusOK {
+		return fmt.Errorf("bucket %s not accessible after creation attempt: status %d", bucket, status)
 	}
 
 	return nil
 }
 
 // RunTest executes a curl S3 test (handles single or multi-step).
-func (e *CurlS3Executor) RunTest(ctx context.Context, test *config.Test) error {
-	log.Printf("Running Curl S3 test: %s", test.Name)
+func (e *CurlS3Executor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	logging.With("test_name", test.Name, "executor", executorNameCurlS3).Printf("Running Curl S3 test: %s", test.Name)
 
 	testStart := time.Now()
 
-	// Generate ULID for this test run
-	entropy := ulid.Monotonic(rand.Reader, 0)
-	testULID := ulid.MustNew(ulid.Timestamp(testStart), entropy)
-	sharedFilename := test.GetFilename(testULID.String())
-	bucket := test.GetBucket(e.config.Satellite.Bucket)
+	// Bound all steps by the test timeout, if set
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	// Identify this run; overlapping runs of a fixed-filename test take turns
+	run := runctx.New(test, executorNameCurlS3, e.config.Satellite.Bucket)
+	run.Endpoint, run.Region = e.endpoint, e.config.S3.Region
+	res := result.New(run)
+	release, err := run.Claim(ctx)
+	if err != nil {
+		return res.Finish(fmt.Errorf("test %s not started: %w", test.Name, err))
+	}
+	defer release()
 
 	// Ensure bucket exists before running test
-	if err := e.ensureBucket(ctx, bucket); err != nil {
-		return fmt.Errorf("failed to ensure bucket %s exists: %w", bucket, err)
+	if err := e.ensureBucket(ctx, run); err != nil {
+		return res.Finish(fmt.Errorf("failed to ensure bucket %s exists: %w", run.Bucket, err))
 	}
 
 	isSingleStep := test.IsSingleStep()
 
 	if isSingleStep {
-		log.Printf("Curl S3 test %s using ULID: %s (filename: %s, bucket: %s)",
-			test.Name, testULID.String(), sharedFilename, bucket)
+		run.Log().Printf("Curl S3 test %s using ULID: %s (filename: %s, bucket: %s)",
+			test.Name, run.ID, run.Filename, run.Bucket)
 	} else {
-		log.Printf("Curl S3 test %s (%d steps) using ULID: %s (filename: %s, bucket: %s)",
-			test.Name, len(test.Steps), testULID.String(), sharedFilename, bucket)
+		run.Log().Printf("Curl S3 test %s (%d steps) using ULID: %s (filename: %s, bucket: %s)",
+			test.Name, len(test.Steps), run.ID, run.Filename, run.Bucket)
 	}
 
-	// Run each step sequentially
-	for i, step := range test.Steps {
-		if !isSingleStep {
-			log.Printf("  [%d/%d] Running: %s", i+1, len(test.Steps), step.Name)
-		}
+	res.StepsStarting()
 
-		if err := e.runStep(ctx, test.Name, &step, sharedFilename, bucket, isSingleStep); err != nil {
-			if !isSingleStep {
-				log.Printf("  [%d/%d] Failed: %s - %v", i+1, len(test.Steps), step.Name, err)
-			}
-			e.metrics.RecordTestRun(test.Name, step.Name, executorNameCurlS3, false, time.Since(testStart))
-			return fmt.Errorf("Curl S3 test %s failed at step %s: %w", test.Name, step.Name, err)
-		}
-
-		if !isSingleStep {
-			log.Printf("  [%d/%d] Completed: %s", i+1, len(test.Steps), step.Name)
-		}
+	// Run the steps in order, passing outputs to the steps after
+	if failed, err := runSteps(ctx, test, run, res, e.runStep); err != nil {
+		res.FailedStep = failed
+		return res.Finish(fmt.Errorf("Curl S3 test %s failed at step %s: %w", test.Name, failed, err))
 	}
 
 	duration := time.Since(testStart)
-	log.Printf("Curl S3 test %s completed successfully in %v", test.Name, duration)
-	e.metrics.RecordTestRun(test.Name, "", executorNameCurlS3, true, duration)
+	run.Log().Printf("Curl S3 test %s completed successfully in %v", test.Name, duration)
 
-	return nil
+	return res.Finish(nil)
 }
 
 // runStep executes a single curl S3 test step.
-func (e *CurlS3Executor) runStep(ctx context.Context, testName string, step *config.TestStep, filename, bucket string, isSingleStep bool) error {
+func (e *CurlS3Executor) runStep(ctx context.Context, run *runctx.Run, step *config.TestStep, isSingleStep bool) (result.Step, error) {
+	sr := result.Step{Name: step.Name}
+
 	// Apply step-level jitter if configured
 	if step.Jitter != nil && step.Jitter.IsEnabled() {
 		maxJitter, _ := step.Jitter.ParseMaxJitter(0) // Steps use duration only, not percentage
 		if maxJitter > 0 {
-			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", testName, step.Name)); err != nil {
-				return fmt.Errorf("step jitter interrupted: %w", err)
+			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", run.Test, step.Name)); err != nil {
+				err = fmt.Errorf("step jitter interrupted: %w", err)
+				sr.Finish(time.Now(), err)
+				return sr, err
 			}
 		}
 	}
@@ -263,25 +262,29 @@ func (e *CurlS3Executor) runStep(ctx context.Context, testName string, step *con
 	var err error
 	switch step.Name {
 	case "upload":
-		err = e.uploadObject(ctx, testName, bucket, filename, step)
+		err = e.uploadObject(ctx, run, step, &sr)
 	case "download":
-		err = e.downloadObject(ctx, testName, bucket, filename)
+		err = e.downloadObject(ctx, run, step, &sr)
 	case "delete":
-		err = e.deleteObject(ctx, testName, bucket, filename, fileSizeLabel)
+		err = e.deleteObject(ctx, run, fileSizeLabel, &sr)
+	case "list":
+		err = e.listObjects(ctx, run, step, &sr)
+	case "stat":
+		err = e.statObject(ctx, run, step, &sr)
+	case "multipart-upload":
+		err = e.multipartUpload(ctx, run, step, &sr)
 	default:
 		err = fmt.Errorf("unknown Curl S3 operation: %s", step.Name)
 	}
 
-	duration := time.Since(stepStart)
-
 	if err != nil {
-		log.Printf("    Curl S3 step %s failed: %v", step.Name, err)
-		e.metrics.RecordTestRun(testName, step.Name, executorNameCurlS3, false, duration)
-		return fmt.Errorf("step execution failed: %w", err)
+		run.StepLog(step.Name).Printf("    Curl S3 step %s failed: %v", step.Name, err)
+		sr.Finish(stepStart, err)
+		return sr, fmt.Errorf("step execution failed: %w", err)
 	}
 
-	e.metrics.RecordTestRun(testName, step.Name, executorNameCurlS3, true, duration)
-	return nil
+	sr.Finish(stepStart, nil)
+	return sr, nil
 }
 
 // buildURL constructs the S3 object URL using path-style addressing.
@@ -289,13 +292,24 @@ func (e *CurlS3Executor) buildURL(bucket, key string) string {
 	return fmt.Sprintf("%s/%s/%s", e.endpoint, bucket, key)
 }
 
-// signAndGetHeaders creates a signed request and extracts headers for curl.
-// Uses cached signer for efficiency. Returns headers and sign duration.
-func (e *CurlS3Executor) signAndGetHeaders(method, url string, contentLength int64) ([]string, time.Duration, error) {
-	req, err := http.NewRequest(method, url, nil)
+// newRequest creates a signed curl request made on behalf of the run,
+// identified by the run's User-Agent and carrying the configured headers.
+// Query parameters (e.g. list or
+// multipart options) are encoded in SigV4 canonical form and signed, so the
+// query curl sends matches the signature. Uses cached signer for efficiency.
+// Returns the request and sign duration.
+func (e *CurlS3Executor) newRequest(run *runctx.Run, method, rawURL string, query url.Values, contentLength int64) (*CurlRequest, time.Duration, error) {
+	if len(query) > 0 {
+		rawURL += "?" + awsv4.EncodeQuery(query)
+	}
+	req, err := http.NewRequest(method, rawURL, nil)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("User-Agent", run.UserAgent(e.config.GetUserAgent()))
+	for name, value := range e.config.S3.Headers {
+		req.Header.Set(name, value)
+	}
 
 	if contentLength > 0 {
 		req.ContentLength = contentLength
@@ -310,18 +324,18 @@ func (e *CurlS3Executor) signAndGetHeaders(method, url string, contentLength int
 	signDuration := time.Since(signStart)
 
 	// Extract headers for curl
-	var headers []string
+	cr := &CurlRequest{Method: method, URL: rawURL, ExtraArgs: e.tlsArgs}
 	for name, values := range req.Header {
 		for _, value := range values {
-			headers = append(headers, fmt.Sprintf("%s: %s", name, value))
+			cr.Headers = append(cr.Headers, fmt.Sprintf("%s: %s", name, value))
 		}
 	}
 
-	return headers, signDuration, nil
+	return cr, signDuration, nil
 }
 
 // uploadObject uploads a file to S3 using curl.
-func (e *CurlS3Executor) uploadObject(ctx context.Context, testName, bucket, filename string, step *config.TestStep) error {
+func (e *CurlS3Executor) uploadObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
 	var fileSize int64 = 1024 * 1024 // Default 1MB
 	fileSizeLabel := "1MB"
 	if step.FileSize != nil {
@@ -329,211 +343,379 @@ func (e *CurlS3Executor) uploadObject(ctx context.Context, testName, bucket, fil
 		fileSizeLabel = step.FileSize.String()
 	}
 
-	// Generate random data and write to temp file
-	data := make([]byte, fileSize)
-	if _, err := rand.Read(data); err != nil {
-		return fmt.Errorf("failed to generate random data: %w", err)
+	src, err := payload.New(e.config.GetPayload(step))
+	if err != nil {
+		return err
 	}
 
-	// Write to temp file for curl to upload
-	tmpFile, err := os.CreateTemp("", "curl-upload-*")
+	// Write payload data to a temp file for curl to upload. The content is
+	// hashed as it is written if a later step verifies it.
+	tmpFile, sum, err := spillPayload(run, src, fileSize, "curl-upload-*")
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return err
 	}
 	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
-
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("failed to write temp file: %w", err)
-	}
 	tmpFile.Close()
+	defer os.Remove(tmpPath)
 
-	url := e.buildURL(bucket, filename)
-
-	// Get signed headers (uses UNSIGNED-PAYLOAD for efficiency)
-	headers, signDuration, err := e.signAndGetHeaders(http.MethodPut, url, fileSize)
+	// Get signed request (uses UNSIGNED-PAYLOAD for efficiency)
+	req, signDuration, err := e.newRequest(run, http.MethodPut, e.buildURL(run.Bucket, run.Filename), nil, fileSize)
 	if err != nil {
 		return fmt.Errorf("failed to sign request: %w", err)
 	}
+	req.BodyFile = tmpPath // Response body (empty unless an S3 error) goes to stdout
 
-	// Add TTL metadata if specified
-	if step.TTLSeconds != nil && *step.TTLSeconds > 0 {
-		headers = append(headers, fmt.Sprintf("X-Amz-Meta-Ttl-Seconds: %d", *step.TTLSeconds))
-	}
-
-	// Build curl command with timing output
-	args := []string{
-		"-s", "-S", // Silent but show errors
-		"-X", "PUT",
-		"--data-binary", "@" + tmpPath,
-		"-w", curlWriteFormat,
-		"-o", "/dev/null", // Discard response body
-	}
-	for _, h := range headers {
-		args = append(args, "-H", h)
-	}
-	args = append(args, url)
-
-	cmd := exec.CommandContext(ctx, e.curlPath, args...)
-	output, err := cmd.Output()
-
-	if err != nil {
-		e.metrics.RecordStorjUpload(testName, executorNameCurlS3, bucket, fileSizeLabel, 0, fileSize, false)
-		return fmt.Errorf("curl PUT failed: %w", err)
+	// Add user metadata, with TTL as ttl-seconds if specified
+	metadata := step.UploadMetadata()
+	for key, value := range metadata {
+		req.Headers = append(req.Headers, metaPrefix+key+": "+value)
 	}
 
-	// Parse output for status code and timings
-	statusCode, timings, err := parseCurlOutput(string(output))
+	resp, err := e.do(ctx, req)
 	if err != nil {
-		e.metrics.RecordStorjUpload(testName, executorNameCurlS3, bucket, fileSizeLabel, 0, fileSize, false)
-		return fmt.Errorf("failed to parse curl output: %w", err)
+		e.metrics.RecordStorjUpload(run, fileSizeLabel, 0, fileSize, false)
+		return err
 	}
+	sr.StatusCode = resp.StatusCode
+	timings := resp.Timings
 
 	// Record granular timing metrics
-	e.metrics.RecordHTTPTiming(testName, "upload", executorNameCurlS3, timings)
-	e.metrics.RecordHTTPTimingPhase(testName, "upload", executorNameCurlS3, "sign", signDuration)
+	e.metrics.RecordHTTPTiming(run, "upload", timings)
+	e.metrics.RecordHTTPTimingPhase(run, "upload", "sign", signDuration)
+	describeStep(sr, timings, signDuration, nil)
 
-	if statusCode != "200" && statusCode != "201" {
-		e.metrics.RecordStorjUpload(testName, executorNameCurlS3, bucket, fileSizeLabel, timings.Total, fileSize, false)
-		return fmt.Errorf("curl PUT returned status %s", statusCode)
+	if respErr := resp.check(http.StatusOK, http.StatusCreated); respErr != nil {
+		sr.RequestID = respErr.RequestID
+		e.metrics.RecordStorjUpload(run, fileSizeLabel, timings.Total, fileSize, false)
+		return fmt.Errorf("curl PUT returned %w", respErr)
 	}
 
 	if step.TTLSeconds != nil && *step.TTLSeconds > 0 {
-		logging.Debug("    Curl S3 uploaded %s (%d bytes) with TTL %ds in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
-			filename, fileSize, *step.TTLSeconds, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+		run.StepLog(step.Name).Debug("    Curl S3 uploaded %s (%d bytes) with TTL %ds in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+			run.Filename, fileSize, *step.TTLSeconds, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
 	} else {
-		logging.Debug("    Curl S3 uploaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
-			filename, fileSize, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+		run.StepLog(step.Name).Debug("    Curl S3 uploaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+			run.Filename, fileSize, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+	}
+	sr.Bytes = fileSize
+	e.metrics.RecordStorjUpload(run, fileSizeLabel, timings.Total, fileSize, true)
+	if sum != nil {
+		run.RecordContent(&runctx.Content{Size: fileSize, Sums: [][]byte{sum}, Metadata: metadata})
 	}
-	e.metrics.RecordStorjUpload(testName, executorNameCurlS3, bucket, fileSizeLabel, timings.Total, fileSize, true)
 
 	return nil
 }
 
-// downloadObject downloads a file from S3 using curl.
-func (e *CurlS3Executor) downloadObject(ctx context.Context, testName, bucket, filename string) error {
-	url := e.buildURL(bucket, filename)
+// downloadObject downloads a file from S3 using curl. With verify_content
+// set, the downloaded file is checked against what the run uploaded.
+func (e *CurlS3Executor) downloadObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	var verifier *contentVerifier
+	if step.VerifyContent {
+		if verifier = newContentVerifier(run); verifier == nil {
+			return errNotUploaded(run)
+		}
+	}
 
-	// Get signed headers
-	headers, signDuration, err := e.signAndGetHeaders(http.MethodGet, url, 0)
+	// Get signed request
+	req, signDuration, err := e.newRequest(run, http.MethodGet, e.buildURL(run.Bucket, run.Filename), nil, 0)
 	if err != nil {
 		return fmt.Errorf("failed to sign request: %w", err)
 	}
 
 	// Create temp file for download
-	tmpFile, err := os.CreateTemp("", "curl-download-*")
+	tmpFile, err := os.CreateTemp(workdir.TempDir(), "curl-download-*")
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 	tmpPath := tmpFile.Name()
 	tmpFile.Close()
 	defer os.Remove(tmpPath)
+	req.Output = tmpPath
 
-	// Build curl command with timing output
-	args := []string{
-		"-s", "-S",
-		"-X", "GET",
-		"-o", tmpPath,
-		"-w", curlWriteFormat,
-	}
-	for _, h := range headers {
-		args = append(args, "-H", h)
-	}
-	args = append(args, url)
-
-	cmd := exec.CommandContext(ctx, e.curlPath, args...)
-	output, err := cmd.Output()
-
-	if err != nil {
-		e.metrics.RecordStorjDownload(testName, executorNameCurlS3, bucket, "", 0, 0, false)
-		return fmt.Errorf("curl GET failed: %w", err)
-	}
-
-	// Parse output for status code and timings
-	statusCode, timings, err := parseCurlOutput(string(output))
+	resp, err := e.do(ctx, req)
 	if err != nil {
-		e.metrics.RecordStorjDownload(testName, executorNameCurlS3, bucket, "", 0, 0, false)
-		return fmt.Errorf("failed to parse curl output: %w", err)
+		e.metrics.RecordStorjDownload(run, "", 0, 0, false)
+		return err
 	}
+	sr.StatusCode = resp.StatusCode
+	timings := resp.Timings
 
 	// Record granular timing metrics
-	e.metrics.RecordHTTPTiming(testName, "download", executorNameCurlS3, timings)
-	e.metrics.RecordHTTPTimingPhase(testName, "download", executorNameCurlS3, "sign", signDuration)
+	e.metrics.RecordHTTPTiming(run, "download", timings)
+	e.metrics.RecordHTTPTimingPhase(run, "download", "sign", signDuration)
+	describeStep(sr, timings, signDuration, nil)
 
-	if statusCode != "200" {
-		e.metrics.RecordStorjDownload(testName, executorNameCurlS3, bucket, "", timings.Total, 0, false)
-		return fmt.Errorf("curl GET returned status %s", statusCode)
+	if respErr := resp.check(http.StatusOK); respErr != nil {
+		sr.RequestID = respErr.RequestID
+		e.metrics.RecordStorjDownload(run, "", timings.Total, 0, false)
+		return fmt.Errorf("curl GET returned %w", respErr)
 	}
 
 	// Get downloaded file size
 	fileInfo, err := os.Stat(tmpPath)
 	if err != nil {
-		e.metrics.RecordStorjDownload(testName, executorNameCurlS3, bucket, "", timings.Total, 0, false)
+		e.metrics.RecordStorjDownload(run, "", timings.Total, 0, false)
 		return fmt.Errorf("failed to stat downloaded file: %w", err)
 	}
 	bytesRead := fileInfo.Size()
 
-	logging.Debug("    Curl S3 downloaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v, transfer=%v)",
-		filename, bytesRead, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB, timings.Transfer)
-	e.metrics.RecordStorjDownload(testName, executorNameCurlS3, bucket, "", timings.Total, bytesRead, true)
+	run.StepLog(step.Name).Debug("    Curl S3 downloaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v, transfer=%v)",
+		run.Filename, bytesRead, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB, timings.Transfer)
+	sr.Bytes = bytesRead
+	e.metrics.RecordStorjDownload(run, "", timings.Total, bytesRead, true)
 
+	if verifier != nil {
+		f, err := os.Open(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read downloaded file: %w", err)
+		}
+		defer f.Close()
+		if _, err := io.Copy(verifier, f); err != nil {
+			return fmt.Errorf("failed to read downloaded file: %w", err)
+		}
+		return verifier.verify(e.metrics, run)
+	}
 	return nil
 }
 
-// deleteObject deletes a file from S3 using curl.
-func (e *CurlS3Executor) deleteObject(ctx context.Context, testName, bucket, filename, fileSizeLabel string) error {
-	url := e.buildURL(bucket, filename)
+// listObjects lists one page of the bucket with a curl ListObjectsV2
+// request.
+func (e *CurlS3Executor) listObjects(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	req, signDuration, err := e.newRequest(run, http.MethodGet, fmt.Sprintf("%s/%s", e.endpoint, run.Bucket), listQuery(step), 0)
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
 
-	// Get signed headers
-	headers, signDuration, err := e.signAndGetHeaders(http.MethodDelete, url, 0)
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		e.metrics.RecordStorjList(run, 0, 0, false)
+		return err
+	}
+	sr.StatusCode = resp.StatusCode
+	timings := resp.Timings
+
+	e.metrics.RecordHTTPTiming(run, "list", timings)
+	e.metrics.RecordHTTPTimingPhase(run, "list", "sign", signDuration)
+	describeStep(sr, timings, signDuration, nil)
+
+	if respErr := resp.check(http.StatusOK); respErr != nil {
+		sr.RequestID = respErr.RequestID
+		e.metrics.RecordStorjList(run, 0, 0, false)
+		return fmt.Errorf("curl GET returned %w", respErr)
+	}
+	count, err := parseListObjects(resp.Body)
+	if err != nil {
+		e.metrics.RecordStorjList(run, 0, 0, false)
+		return err
+	}
+	sr.SetOutput("objects", strconv.Itoa(count))
+
+	run.StepLog(step.Name).Debug("    Curl S3 listed %d objects in %s in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+		count, run.Bucket, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+	e.metrics.RecordStorjList(run, timings.Total, count, true)
+	return nil
+}
+
+// statObject reads the object's size and user metadata with a HEAD request.
+// With verify_metadata set, they are checked against what the run uploaded.
+func (e *CurlS3Executor) statObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	req, signDuration, err := e.newRequest(run, http.MethodHead, e.buildURL(run.Bucket, run.Filename), nil, 0)
 	if err != nil {
 		return fmt.Errorf("failed to sign request: %w", err)
 	}
+	req.DumpHeaders = true
+
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		e.metrics.RecordStorjStat(run, 0, false)
+		return err
+	}
+	sr.StatusCode = resp.StatusCode
+	timings := resp.Timings
+
+	e.metrics.RecordHTTPTiming(run, "stat", timings)
+	e.metrics.RecordHTTPTimingPhase(run, "stat", "sign", signDuration)
+	describeStep(sr, timings, signDuration, resp.Header)
 
-	// Build curl command with timing output
-	args := []string{
-		"-s", "-S",
-		"-X", "DELETE",
-		"-w", curlWriteFormat,
-		"-o", "/dev/null",
+	if respErr := resp.check(http.StatusOK); respErr != nil {
+		if respErr.RequestID != "" {
+			sr.RequestID = respErr.RequestID
+		}
+		e.metrics.RecordStorjStat(run, timings.Total, false)
+		return fmt.Errorf("curl HEAD returned %w", respErr)
 	}
-	for _, h := range headers {
-		args = append(args, "-H", h)
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		e.metrics.RecordStorjStat(run, timings.Total, false)
+		return fmt.Errorf("curl HEAD returned no valid Content-Length: %q", resp.Header.Get("Content-Length"))
 	}
-	args = append(args, url)
+	sr.SetOutput("size", strconv.FormatInt(size, 10))
+	metadata := userMetadata(resp.Header)
 
-	cmd := exec.CommandContext(ctx, e.curlPath, args...)
-	output, err := cmd.Output()
+	run.StepLog(step.Name).Debug("    Curl S3 stat %s (%d bytes, %d metadata keys) in %v (sign=%v, ttfb=%v)",
+		run.Filename, size, len(metadata), timings.Total, signDuration, timings.TTFB)
+	e.metrics.RecordStorjStat(run, timings.Total, true)
 
+	if step.VerifyMetadata {
+		return verifyStat(e.metrics, run, size, metadata)
+	}
+	return nil
+}
+
+// deleteObject deletes a file from S3 using curl.
+func (e *CurlS3Executor) deleteObject(ctx context.Context, run *runctx.Run, fileSizeLabel string, sr *result.Step) error {
+	// Get signed request
+	req, signDuration, err := e.newRequest(run, http.MethodDelete, e.buildURL(run.Bucket, run.Filename), nil, 0)
 	if err != nil {
-		e.metrics.RecordStorjDelete(testName, executorNameCurlS3, bucket, fileSizeLabel, 0, 0, false)
-		return fmt.Errorf("curl DELETE failed: %w", err)
+		return fmt.Errorf("failed to sign request: %w", err)
 	}
 
-	// Parse output for status code and timings
-	statusCode, timings, err := parseCurlOutput(string(output))
+	resp, err := e.do(ctx, req)
 	if err != nil {
-		e.metrics.RecordStorjDelete(testName, executorNameCurlS3, bucket, fileSizeLabel, 0, 0, false)
-		return fmt.Errorf("failed to parse curl output: %w", err)
+		e.metrics.RecordStorjDelete(run, fileSizeLabel, 0, 0, false)
+		return err
 	}
+	sr.StatusCode = resp.StatusCode
+	timings := resp.Timings
 
 	// Record granular timing metrics
-	e.metrics.RecordHTTPTiming(testName, "delete", executorNameCurlS3, timings)
-	e.metrics.RecordHTTPTimingPhase(testName, "delete", executorNameCurlS3, "sign", signDuration)
+	e.metrics.RecordHTTPTiming(run, "delete", timings)
+	e.metrics.RecordHTTPTimingPhase(run, "delete", "sign", signDuration)
+	describeStep(sr, timings, signDuration, nil)
 
 	// Check HTTP status code (204 No Content is expected for DELETE)
-	if statusCode != "200" && statusCode != "204" {
-		e.metrics.RecordStorjDelete(testName, executorNameCurlS3, bucket, fileSizeLabel, 0, 0, false)
-		return fmt.Errorf("curl DELETE returned status %s", statusCode)
+	if respErr := resp.check(http.StatusOK, http.StatusNoContent); respErr != nil {
+		sr.RequestID = respErr.RequestID
+		e.metrics.RecordStorjDelete(run, fileSizeLabel, 0, 0, false)
+		return fmt.Errorf("curl DELETE returned %w", respErr)
 	}
 
-	logging.Debug("    Curl S3 deleted %s in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
-		filename, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
-	e.metrics.RecordStorjDelete(testName, executorNameCurlS3, bucket, fileSizeLabel, timings.Total, 1, true)
+	run.Log().Debug("    Curl S3 deleted %s in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+		run.Filename, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+	e.metrics.RecordStorjDelete(run, fileSizeLabel, timings.Total, 1, true)
 
 	return nil
 }
 
+// multipartUpload uploads the object in parts with curl multipart requests
+func (e *CurlS3Executor) multipartUpload(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	src, err := payload.New(e.config.GetPayload(step))
+	if err != nil {
+		return err
+	}
+	return multipartUpload(ctx, e, e.metrics, run, step, src, sr)
+}
+
+// writeTempBody writes a request body to a temp file for curl to send; the
+// caller removes it
+func writeTempBody(data []byte) (string, error) {
+	tmpFile, err := os.CreateTemp(workdir.TempDir(), "curl-upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	return tmpFile.Name(), nil
+}
+
+// initiateMultipart starts a multipart upload of the run's object
+func (e *CurlS3Executor) initiateMultipart(ctx context.Context, run *runctx.Run) (string, error) {
+	req, _, err := e.newRequest(run, http.MethodPost, e.buildURL(run.Bucket, run.Filename), url.Values{"uploads": {""}}, 0)
+	if err != nil {
+		return "", err
+	}
+	req.Headers = append(req.Headers, "Content-Length: 0") // curl sends none for a bodyless POST
+
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if respErr := resp.check(http.StatusOK); respErr != nil {
+		return "", fmt.Errorf("curl POST returned %w", respErr)
+	}
+	return parseInitiateMultipart(resp.Body)
+}
+
+// uploadPart uploads one part and returns its ETag
+func (e *CurlS3Executor) uploadPart(ctx context.Context, run *runctx.Run, uploadID string, number int, data *payload.Buffer) (string, error) {
+	bodyPath, err := writeTempBody(data.B)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(bodyPath)
+
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	req, _, err := e.newRequest(run, http.MethodPut, e.buildURL(run.Bucket, run.Filename), query, int64(len(data.B)))
+	if err != nil {
+		return "", err
+	}
+	req.BodyFile = bodyPath
+	req.DumpHeaders = true
+
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if respErr := resp.check(http.StatusOK); respErr != nil {
+		return "", fmt.Errorf("curl PUT returned %w", respErr)
+	}
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+// completeMultipart assembles the uploaded parts into the object
+func (e *CurlS3Executor) completeMultipart(ctx context.Context, run *runctx.Run, uploadID string, etags []string, sr *result.Step) error {
+	parts := completeMultipartBody(etags)
+	bodyPath, err := writeTempBody(parts)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(bodyPath)
+
+	req, _, err := e.newRequest(run, http.MethodPost, e.buildURL(run.Bucket, run.Filename), url.Values{"uploadId": {uploadID}}, int64(len(parts)))
+	if err != nil {
+		return err
+	}
+	req.BodyFile = bodyPath
+	req.DumpHeaders = true
+
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	sr.StatusCode = resp.StatusCode
+	sr.RequestID = resp.Header.Get("X-Amz-Request-Id")
+	if respErr := resp.check(http.StatusOK); respErr != nil {
+		return fmt.Errorf("curl POST returned %w", respErr)
+	}
+	etag, err := parseCompleteMultipart(resp.StatusCode, resp.Body)
+	if err != nil {
+		return err
+	}
+	sr.SetOutput("etag", etag)
+	return nil
+}
+
+// abortMultipart discards a multipart upload and its parts
+func (e *CurlS3Executor) abortMultipart(ctx context.Context, run *runctx.Run, uploadID string) error {
+	req, _, err := e.newRequest(run, http.MethodDelete, e.buildURL(run.Bucket, run.Filename), url.Values{"uploadId": {uploadID}}, 0)
+	if err != nil {
+		return err
+	}
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	if respErr := resp.check(http.StatusOK, http.StatusNoContent); respErr != nil {
+		return fmt.Errorf("curl DELETE returned %w", respErr)
+	}
+	return nil
+}
+
 // Ensure CurlS3Executor implements TestExecutor
 var _ TestExecutor = (*CurlS3Executor)(nil)
@@ -2,11 +2,157 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
 )
 
-// TestExecutor defines the interface for test execution
+// TestExecutor defines the interface for test execution. RunTest always
+// returns a Result describing the run; the error is non-nil if it failed.
 type TestExecutor interface {
-	RunTest(ctx context.Context, test *config.Test) error
+	RunTest(ctx context.Context, test *config.Test) (*result.Result, error)
+}
+
+// withTestDeadline bounds the whole test run by the test's timeout, if set
+func withTestDeadline(ctx context.Context, test *config.Test) (context.Context, context.CancelFunc) {
+	timeout := test.TimeoutDuration()
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// stepContext returns the context for a group of a test's steps, which run
+// concurrently (one step, unless it is a parallel group). Steps never outlive
+// the test deadline; in proportional mode, the time left is further divided
+// between the group and the steps after it by their configured timeouts, so a
+// slow early step cannot starve the steps after it of their share. Parallel
+// groups count as their longest step, since their steps run side by side.
+func stepContext(ctx context.Context, test *config.Test, group []int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || test.GetBudget() != config.BudgetProportional {
+		return context.WithCancel(ctx)
+	}
+
+	groupTimeout := longestTimeout(test, group)
+	total := groupTimeout
+	for _, later := range test.StepGroups() {
+		if later[0] > group[len(group)-1] {
+			total += longestTimeout(test, later)
+		}
+	}
+	if total <= 0 {
+		return context.WithCancel(ctx) // Nothing to share the time left by
+	}
+	budget := time.Duration(float64(time.Until(deadline)) * float64(groupTimeout) / float64(total))
+	if budget >= groupTimeout {
+		return context.WithCancel(ctx)
+	}
+
+	name := test.Steps[group[0]].Name
+	if len(group) > 1 {
+		name = "parallel group " + test.Steps[group[0]].Parallel
+	}
+	logging.With("test_name", test.Name, "step", name).Debug("  Step %s/%s budgeted %v of its %v timeout", test.Name, name, budget.Round(time.Millisecond), groupTimeout)
+	return context.WithTimeout(ctx, budget)
+}
+
+// longestTimeout returns the longest timeout of a group of the test's steps
+func longestTimeout(test *config.Test, group []int) time.Duration {
+	var longest time.Duration
+	for _, i := range group {
+		longest = max(longest, test.Steps[i].TimeoutDuration())
+	}
+	return longest
+}
+
+// classifiedError is a check failure with its own error class, such as a
+// corrupt canary or a partial object left by an aborted upload
+type classifiedError struct {
+	class string
+	msg   string
+}
+
+func (e *classifiedError) Error() string {
+	return e.msg
+}
+
+// ErrorCode returns the error class, which result.Classify uses as the run's
+// error_class
+func (e *classifiedError) ErrorCode() string {
+	return e.class
+}
+
+// describeStep copies an HTTP request's phase timings, request ID, and ETag
+// into the step result
+func describeStep(sr *result.Step, timings metrics.HTTPTimings, signDuration time.Duration, header http.Header) {
+	sr.Phases = timings.Phases()
+	if signDuration > 0 {
+		sr.Phases["sign"] = signDuration.Seconds()
+	}
+	if header != nil {
+		sr.RequestID = header.Get("X-Amz-Request-Id")
+		sr.SetOutput("etag", strings.Trim(header.Get("ETag"), `"`))
+	}
+}
+
+// metaPrefix is the header prefix of S3 user metadata
+const metaPrefix = "X-Amz-Meta-"
+
+// userMetadata returns the user metadata in S3 response headers, with
+// lowercase keys
+func userMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for name, values := range header {
+		if key, ok := strings.CutPrefix(http.CanonicalHeaderKey(name), metaPrefix); ok && len(values) > 0 {
+			metadata[strings.ToLower(key)] = values[0]
+		}
+	}
+	return metadata
+}
+
+// readProbe makes one read attempt, returning whether the object was readable
+type readProbe func(ctx context.Context) (bool, error)
+
+// pollReadable repeats probe every interval until it reports the object
+// readable or ctx is done. It returns the time until the first successful
+// read and the number of attempts made.
+func pollReadable(ctx context.Context, interval time.Duration, probe readProbe) (time.Duration, int, error) {
+	start := time.Now()
+	attempts := 0
+	var lastErr error
+	for {
+		attempts++
+		ok, err := probe(ctx)
+		if ok {
+			return time.Since(start), attempts, nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return time.Since(start), attempts, fmt.Errorf("object not readable after %d attempts (last: %v): %w", attempts, lastErr, ctx.Err())
+			}
+			return time.Since(start), attempts, fmt.Errorf("object not readable after %d attempts: %w", attempts, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// budgetError annotates a step error caused by the test deadline
+func budgetError(ctx context.Context, test *config.Test, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("test timeout %s exceeded: %w", test.Timeout, err)
+	}
+	return err
 }
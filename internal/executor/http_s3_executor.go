@@ -3,13 +3,16 @@ package executor
 import (
 	"bytes"
 	"context"
-	"crypto/rand"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethanadams/synthetics/internal/config"
@@ -17,20 +20,26 @@ import (
 	"github.com/ethanadams/synthetics/internal/jitter"
 	"github.com/ethanadams/synthetics/internal/logging"
 	"github.com/ethanadams/synthetics/internal/metrics"
-	"github.com/oklog/ulid/v2"
+	"github.com/ethanadams/synthetics/internal/payload"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/s3err"
+	"github.com/ethanadams/synthetics/internal/tlscheck"
 )
 
 // httpTimingTracer captures detailed HTTP timing using httptrace
 type httpTimingTracer struct {
-	start            time.Time
-	dnsStart         time.Time
-	dnsDone          time.Time
-	connectStart     time.Time
-	connectDone      time.Time
-	tlsStart         time.Time
-	tlsDone          time.Time
-	firstByteTime    time.Time
-	wroteRequest     time.Time
+	start         time.Time
+	dnsStart      time.Time
+	dnsDone       time.Time
+	connectStart  time.Time
+	connectDone   time.Time
+	tlsStart      time.Time
+	tlsDone       time.Time
+	firstByteTime time.Time
+	wroteRequest  time.Time
+	tlsState      *tls.ConnectionState // Set when a TLS handshake completed on a new connection
+	connReused    bool
 }
 
 func newHTTPTimingTracer() *httpTimingTracer {
@@ -39,12 +48,18 @@ func newHTTPTimingTracer() *httpTimingTracer {
 
 func (t *httpTimingTracer) trace() *httptrace.ClientTrace {
 	return &httptrace.ClientTrace{
-		DNSStart:             func(_ httptrace.DNSStartInfo) { t.dnsStart = time.Now() },
-		DNSDone:              func(_ httptrace.DNSDoneInfo) { t.dnsDone = time.Now() },
-		ConnectStart:         func(_, _ string) { t.connectStart = time.Now() },
-		ConnectDone:          func(_, _ string, _ error) { t.connectDone = time.Now() },
-		TLSHandshakeStart:    func() { t.tlsStart = time.Now() },
-		TLSHandshakeDone:     func(_ tls.ConnectionState, _ error) { t.tlsDone = time.Now() },
+		DNSStart:          func(_ httptrace.DNSStartInfo) { t.dnsStart = time.Now() },
+		DNSDone:           func(_ httptrace.DNSDoneInfo) { t.dnsDone = time.Now() },
+		ConnectStart:      func(_, _ string) { t.connectStart = time.Now() },
+		ConnectDone:       func(_, _ string, _ error) { t.connectDone = time.Now() },
+		TLSHandshakeStart: func() { t.tlsStart = time.Now() },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			t.tlsDone = time.Now()
+			if err == nil {
+				t.tlsState = &state
+			}
+		},
+		GotConn:              func(info httptrace.GotConnInfo) { t.connReused = info.Reused },
 		WroteRequest:         func(_ httptrace.WroteRequestInfo) { t.wroteRequest = time.Now() },
 		GotFirstResponseByte: func() { t.firstByteTime = time.Now() },
 	}
@@ -52,7 +67,8 @@ func (t *httpTimingTracer) trace() *httptrace.ClientTrace {
 
 func (t *httpTimingTracer) toMetrics(transferDone time.Time) metrics.HTTPTimings {
 	timings := metrics.HTTPTimings{
-		Total: transferDone.Sub(t.start),
+		Total:      transferDone.Sub(t.start),
+		ConnReused: t.connReused,
 	}
 
 	if !t.dnsStart.IsZero() && !t.dnsDone.IsZero() {
@@ -74,10 +90,24 @@ func (t *httpTimingTracer) toMetrics(transferDone time.Time) metrics.HTTPTimings
 	return timings
 }
 
+// tlsInfo returns the negotiated TLS parameters if a handshake occurred
+func (t *httpTimingTracer) tlsInfo() (metrics.TLSInfo, bool) {
+	if t.tlsState == nil {
+		return metrics.TLSInfo{}, false
+	}
+	return metrics.TLSInfo{
+		Version:     tls.VersionName(t.tlsState.Version),
+		CipherSuite: tls.CipherSuiteName(t.tlsState.CipherSuite),
+		ALPN:        t.tlsState.NegotiatedProtocol,
+		Resumed:     t.tlsState.DidResume,
+	}, true
+}
+
 const executorNameHttpS3 = "http-s3"
 
 // HttpS3Executor runs S3 tests using raw HTTP requests (no AWS SDK).
 type HttpS3Executor struct {
+	name     string // Executor label for metrics
 	client   *http.Client
 	endpoint string
 	signer   *awsv4.Signer // Cached signer for efficiency
@@ -100,27 +130,47 @@ func NewHttpS3(cfg *config.Config, mc *metrics.Collector) (*HttpS3Executor, erro
 	}
 
 	creds := awsv4.Credentials{
-		AccessKey: cfg.S3.AccessKey,
-		SecretKey: cfg.S3.SecretKey,
-		Region:    region,
+		AccessKey:    cfg.S3.AccessKey,
+		SecretKey:    cfg.S3.SecretKey,
+		SessionToken: cfg.S3.SessionToken,
+		Region:       region,
 	}
 
-	return &HttpS3Executor{
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.S3.ClientCert != "" || cfg.S3.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.S3.ClientCert, cfg.S3.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		transport.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	e := &HttpS3Executor{
 		client: &http.Client{
-			Timeout: 5 * time.Minute, // Default timeout, overridden per-request
+			Transport: transport,
+			Timeout:   5 * time.Minute, // Default timeout, overridden per-request
 		},
+		name:     executorNameHttpS3,
 		endpoint: cfg.S3.Endpoint,
 		signer:   awsv4.NewSigner(creds), // Cached signer
 		config:   cfg,
 		metrics:  mc,
-	}, nil
+	}
+	// Attribute signing cost to the executor's label, which compare and
+	// canary set after construction
+	e.signer.Observe = func(m awsv4.Mode, d time.Duration) {
+		mc.RecordSign(e.name, m.Key(), m.Payload(), d)
+	}
+	return e, nil
 }
 
-// ensureBucket creates the bucket if it doesn't exist
-func (e *HttpS3Executor) ensureBucket(ctx context.Context, bucket string) error {
+// ensureBucket creates the run's bucket if it doesn't exist
+func (e *HttpS3Executor) ensureBucket(ctx context.Context, run *runctx.Run) error {
+	bucket := run.Bucket
+
 	// Check if bucket exists by trying to HEAD it
 	headURL := fmt.Sprintf("%s/%s", e.endpoint, bucket)
-	headReq, err := http.NewRequestWithContext(ctx, http.MethodHead, headURL, nil)
+	headReq, err := e.newRequest(ctx, run, http.MethodHead, headURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create HEAD request: %w", err)
 	}
@@ -139,7 +189,7 @@ func (e *HttpS3Executor) ensureBucket(ctx context.Context, bucket string) error
 
 	// Try to create the bucket with PUT
 	putURL := fmt.Sprintf("%s/%s", e.endpoint, bucket)
-	putReq, err := http.NewRequestWithContext(ctx, http.MethodPut, putURL, nil)
+	putReq, err := e.newRequest(ctx, run, http.MethodPut, putURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create PUT request: %w", err)
 	}
@@ -154,14 +204,14 @@ func (e *HttpS3Executor) ensureBucket(ctx context.Context, bucket string) error
 	putResp.Body.Close()
 
 	if putResp.StatusCode == http.StatusOK || putResp.StatusCode == http.StatusCreated {
-		log.Printf("    Created bucket: %s", bucket)
+		run.Log().Printf("    Created bucket: %s", bucket)
 	} else if putResp.StatusCode != http.StatusConflict {
 		// 409 Conflict usually means bucket already exists, which is fine
-		log.Printf("    Note: CreateBucket returned status %d (may be ignorable if bucket exists)", putResp.StatusCode)
+		run.Log().Printf("    Note: CreateBucket returned status %d (may be ignorable if bucket exists)", putResp.StatusCode)
 	}
 
 	// Verify bucket is now accessible
-	verifyReq, err := http.NewRequestWithContext(ctx, http.MethodHead, headURL, nil)
+	verifyReq, err := e.newRequest(ctx, run, http.MethodHead, headURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create verify request: %w", err)
 	}
@@ -183,66 +233,101 @@ func (e *HttpS3Executor) ensureBucket(ctx context.Context, bucket string) error
 }
 
 // RunTest executes an HTTP S3 test (handles single or multi-step).
-func (e *HttpS3Executor) RunTest(ctx context.Context, test *config.Test) error {
-	log.Printf("Running HTTP S3 test: %s", test.Name)
+func (e *HttpS3Executor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	logging.With("test_name", test.Name, "executor", e.name).Printf("Running HTTP S3 test: %s", test.Name)
 
 	testStart := time.Now()
 
-	// Generate ULID for this test run
-	entropy := ulid.Monotonic(rand.Reader, 0)
-	testULID := ulid.MustNew(ulid.Timestamp(testStart), entropy)
-	sharedFilename := test.GetFilename(testULID.String())
-	bucket := test.GetBucket(e.config.Satellite.Bucket)
+	// Bound all steps by the test timeout, if set
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	// Identify this run; overlapping runs of a fixed-filename test take turns
+	run := runctx.New(test, e.name, e.config.Satellite.Bucket)
+	run.Endpoint, run.Region = e.endpoint, e.config.S3.Region
+	res := result.New(run)
+	release, err := run.Claim(ctx)
+	if err != nil {
+		return res.Finish(fmt.Errorf("test %s not started: %w", test.Name, err))
+	}
+	defer release()
+
+	// Validate the certificate chain before issuing any requests
+	if e.config.S3.TLSCheck.Enabled {
+		if err := e.checkTLS(ctx, run); err != nil {
+			return res.Finish(err)
+		}
+	}
 
 	// Ensure bucket exists before running test
-	if err := e.ensureBucket(ctx, bucket); err != nil {
-		return fmt.Errorf("failed to ensure bucket %s exists: %w", bucket, err)
+	if err := e.ensureBucket(ctx, run); err != nil {
+		return res.Finish(fmt.Errorf("failed to ensure bucket %s exists: %w", run.Bucket, err))
 	}
 
 	isSingleStep := test.IsSingleStep()
 
 	if isSingleStep {
-		log.Printf("HTTP S3 test %s using ULID: %s (filename: %s, bucket: %s)",
-			test.Name, testULID.String(), sharedFilename, bucket)
+		run.Log().Printf("HTTP S3 test %s using ULID: %s (filename: %s, bucket: %s)",
+			test.Name, run.ID, run.Filename, run.Bucket)
 	} else {
-		log.Printf("HTTP S3 test %s (%d steps) using ULID: %s (filename: %s, bucket: %s)",
-			test.Name, len(test.Steps), testULID.String(), sharedFilename, bucket)
+		run.Log().Printf("HTTP S3 test %s (%d steps) using ULID: %s (filename: %s, bucket: %s)",
+			test.Name, len(test.Steps), run.ID, run.Filename, run.Bucket)
 	}
 
-	// Run each step sequentially
-	for i, step := range test.Steps {
-		if !isSingleStep {
-			log.Printf("  [%d/%d] Running: %s", i+1, len(test.Steps), step.Name)
-		}
+	res.StepsStarting()
 
-		if err := e.runStep(ctx, test.Name, &step, sharedFilename, bucket, isSingleStep); err != nil {
-			if !isSingleStep {
-				log.Printf("  [%d/%d] Failed: %s - %v", i+1, len(test.Steps), step.Name, err)
-			}
-			e.metrics.RecordTestRun(test.Name, step.Name, executorNameHttpS3, false, time.Since(testStart))
-			return fmt.Errorf("HTTP S3 test %s failed at step %s: %w", test.Name, step.Name, err)
-		}
-
-		if !isSingleStep {
-			log.Printf("  [%d/%d] Completed: %s", i+1, len(test.Steps), step.Name)
-		}
+	// Run the steps in order, passing outputs to the steps after
+	if failed, err := runSteps(ctx, test, run, res, e.runStep); err != nil {
+		res.FailedStep = failed
+		return res.Finish(fmt.Errorf("HTTP S3 test %s failed at step %s: %w", test.Name, failed, err))
 	}
 
 	duration := time.Since(testStart)
-	log.Printf("HTTP S3 test %s completed successfully in %v", test.Name, duration)
-	e.metrics.RecordTestRun(test.Name, "", executorNameHttpS3, true, duration)
+	run.Log().Printf("HTTP S3 test %s completed successfully in %v", test.Name, duration)
+
+	return res.Finish(nil)
+}
+
+// checkTLS validates the endpoint certificate chain, hostname, and revocation status.
+// Failures are reported with their own class rather than as generic connection errors.
+func (e *HttpS3Executor) checkTLS(ctx context.Context, run *runctx.Run) error {
+	opts := tlscheck.Options{
+		RootsFile:       e.config.S3.TLSCheck.CAFile,
+		CheckRevocation: e.config.S3.TLSCheck.CheckRevocation,
+	}
 
+	result, err := tlscheck.Check(ctx, e.endpoint, opts)
+	if err != nil {
+		var checkErr *tlscheck.Error
+		class := tlscheck.ClassDial
+		if errors.As(err, &checkErr) {
+			class = checkErr.Class
+		}
+		e.metrics.RecordTLSCheck(run, class)
+		run.Log().Printf("    HTTP S3 TLS check failed for %s: %v", e.endpoint, err)
+		return err
+	}
+
+	e.metrics.RecordTLSCheck(run, result.Class)
+	if result.Class == tlscheck.ClassRevocationUnavailable {
+		run.Log().Printf("    Warning: revocation status unavailable for %s", e.endpoint)
+	}
+	run.Log().Debug("    HTTP S3 TLS check passed for %s (expires %s)", e.endpoint, result.NotAfter.Format(time.RFC3339))
 	return nil
 }
 
 // runStep executes a single HTTP S3 test step.
-func (e *HttpS3Executor) runStep(ctx context.Context, testName string, step *config.TestStep, filename, bucket string, isSingleStep bool) error {
+func (e *HttpS3Executor) runStep(ctx context.Context, run *runctx.Run, step *config.TestStep, isSingleStep bool) (result.Step, error) {
+	sr := result.Step{Name: step.Name}
+
 	// Apply step-level jitter if configured
 	if step.Jitter != nil && step.Jitter.IsEnabled() {
 		maxJitter, _ := step.Jitter.ParseMaxJitter(0) // Steps use duration only, not percentage
 		if maxJitter > 0 {
-			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", testName, step.Name)); err != nil {
-				return fmt.Errorf("step jitter interrupted: %w", err)
+			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", run.Test, step.Name)); err != nil {
+				err = fmt.Errorf("step jitter interrupted: %w", err)
+				sr.Finish(time.Now(), err)
+				return sr, err
 			}
 		}
 	}
@@ -264,25 +349,56 @@ func (e *HttpS3Executor) runStep(ctx context.Context, testName string, step *con
 	var err error
 	switch step.Name {
 	case "upload":
-		err = e.uploadObject(ctx, testName, bucket, filename, step)
+		err = e.uploadObject(ctx, run, step, &sr)
 	case "download":
-		err = e.downloadObject(ctx, testName, bucket, filename)
+		err = e.downloadObject(ctx, run, step, &sr)
 	case "delete":
-		err = e.deleteObject(ctx, testName, bucket, filename, fileSizeLabel)
+		err = e.deleteObject(ctx, run, fileSizeLabel, &sr)
+	case "list":
+		err = e.listObjects(ctx, run, step, &sr)
+	case "stat":
+		err = e.statObject(ctx, run, step, &sr)
+	case "read-after-write":
+		err = e.readAfterWrite(ctx, run, step, &sr)
+	case "upload-abort":
+		err = e.uploadAbort(ctx, run, step, &sr)
+	case "head-bench":
+		err = e.headBench(ctx, run, step, &sr)
+	case "range-seek":
+		err = e.rangeSeek(ctx, run, step, &sr)
+	case "presign":
+		err = e.presignObject(ctx, run, step, &sr)
+	case "fetch":
+		err = e.fetchURL(ctx, run, step, &sr)
+	case "multipart-upload":
+		err = e.multipartUpload(ctx, run, step, &sr)
 	default:
 		err = fmt.Errorf("unknown HTTP S3 operation: %s", step.Name)
 	}
 
-	duration := time.Since(stepStart)
-
 	if err != nil {
-		log.Printf("    HTTP S3 step %s failed: %v", step.Name, err)
-		e.metrics.RecordTestRun(testName, step.Name, executorNameHttpS3, false, duration)
-		return fmt.Errorf("step execution failed: %w", err)
+		run.StepLog(step.Name).Printf("    HTTP S3 step %s failed: %v", step.Name, err)
+		sr.Finish(stepStart, err)
+		return sr, fmt.Errorf("step execution failed: %w", err)
 	}
 
-	e.metrics.RecordTestRun(testName, step.Name, executorNameHttpS3, true, duration)
-	return nil
+	sr.Finish(stepStart, nil)
+	return sr, nil
+}
+
+// newRequest creates a request made on behalf of the run, identified by the
+// run's User-Agent and carrying the configured headers. Headers are set
+// before signing, so x-amz-* headers are signed.
+func (e *HttpS3Executor) newRequest(ctx context.Context, run *runctx.Run, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", run.UserAgent(e.config.GetUserAgent()))
+	for name, value := range e.config.S3.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
 }
 
 // buildURL constructs the S3 object URL using path-style addressing.
@@ -291,7 +407,7 @@ func (e *HttpS3Executor) buildURL(bucket, key string) string {
 }
 
 // uploadObject uploads a file to S3 using HTTP PUT.
-func (e *HttpS3Executor) uploadObject(ctx context.Context, testName, bucket, filename string, step *config.TestStep) error {
+func (e *HttpS3Executor) uploadObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
 	var fileSize int64 = 1024 * 1024 // Default 1MB
 	fileSizeLabel := "1MB"
 	if step.FileSize != nil {
@@ -299,25 +415,33 @@ func (e *HttpS3Executor) uploadObject(ctx context.Context, testName, bucket, fil
 		fileSizeLabel = step.FileSize.String()
 	}
 
-	// Generate random data
-	data := make([]byte, fileSize)
-	if _, err := rand.Read(data); err != nil {
-		return fmt.Errorf("failed to generate random data: %w", err)
+	// Generate payload data into a pooled buffer, or a temp file for large
+	// objects
+	src, err := payload.New(e.config.GetPayload(step))
+	if err != nil {
+		return err
+	}
+	data, err := newUploadBody(run, src, fileSize)
+	if err != nil {
+		return err
 	}
+	defer data.Release()
 
 	// Build request
-	url := e.buildURL(bucket, filename)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(data))
+	url := e.buildURL(run.Bucket, run.Filename)
+	req, err := e.newRequest(ctx, run, http.MethodPut, url, data.Reader())
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.ContentLength = fileSize
+	req.GetBody = func() (io.ReadCloser, error) { return data.Reader(), nil }
 	req.Header.Set("Content-Type", "application/octet-stream")
 
-	// Add TTL metadata if specified
-	if step.TTLSeconds != nil && *step.TTLSeconds > 0 {
-		req.Header.Set("X-Amz-Meta-Ttl-Seconds", fmt.Sprintf("%d", *step.TTLSeconds))
+	// Add user metadata, with TTL as ttl-seconds if specified
+	metadata := step.UploadMetadata()
+	for key, value := range metadata {
+		req.Header.Set(metaPrefix+key, value)
 	}
 
 	// Sign the request (uses cached signing key) - measure signing time
@@ -334,44 +458,71 @@ func (e *HttpS3Executor) uploadObject(ctx context.Context, testName, bucket, fil
 	// Execute request
 	resp, err := e.client.Do(req)
 	if err != nil {
-		e.metrics.RecordStorjUpload(testName, executorNameHttpS3, bucket, fileSizeLabel, time.Since(tracer.start), fileSize, false)
+		e.metrics.RecordStorjUpload(run, fileSizeLabel, time.Since(tracer.start), fileSize, false)
 		return fmt.Errorf("HTTP PUT failed: %w", err)
 	}
 	defer resp.Body.Close()
+	sr.StatusCode = resp.StatusCode
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
 
-	// Read response body to complete timing
+	// Read response body to complete timing, parsing it if it is an S3 error
+	var respErr error
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respErr = s3err.FromResponse(resp)
+	}
 	io.Copy(io.Discard, resp.Body)
 	transferDone := time.Now()
 
 	// Record granular timing metrics
 	timings := tracer.toMetrics(transferDone)
-	e.metrics.RecordHTTPTiming(testName, "upload", executorNameHttpS3, timings)
-	e.metrics.RecordHTTPTimingPhase(testName, "upload", executorNameHttpS3, "sign", signDuration)
+	e.metrics.RecordHTTPTiming(run, "upload", timings)
+	if info, ok := tracer.tlsInfo(); ok {
+		e.metrics.RecordTLSConnection(run, info)
+	}
+	e.metrics.RecordHTTPTimingPhase(run, "upload", "sign", signDuration)
+	describeStep(sr, timings, signDuration, resp.Header)
 
 	// Check response
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		e.metrics.RecordStorjUpload(testName, executorNameHttpS3, bucket, fileSizeLabel, timings.Total, fileSize, false)
-		return fmt.Errorf("HTTP PUT returned status %d", resp.StatusCode)
+	if respErr != nil {
+		e.metrics.RecordStorjUpload(run, fileSizeLabel, timings.Total, fileSize, false)
+		return fmt.Errorf("HTTP PUT returned %w", respErr)
 	}
 
 	// Log with TTL info if specified
 	if step.TTLSeconds != nil && *step.TTLSeconds > 0 {
-		logging.Debug("    HTTP S3 uploaded %s (%d bytes) with TTL %ds in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
-			filename, fileSize, *step.TTLSeconds, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+		run.StepLog(step.Name).Debug("    HTTP S3 uploaded %s (%d bytes) with TTL %ds in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+			run.Filename, fileSize, *step.TTLSeconds, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
 	} else {
-		logging.Debug("    HTTP S3 uploaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
-			filename, fileSize, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+		run.StepLog(step.Name).Debug("    HTTP S3 uploaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+			run.Filename, fileSize, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
 	}
-	e.metrics.RecordStorjUpload(testName, executorNameHttpS3, bucket, fileSizeLabel, timings.Total, fileSize, true)
+	sr.Bytes = fileSize
+	e.metrics.RecordStorjUpload(run, fileSizeLabel, timings.Total, fileSize, true)
+	data.recordUpload(run, metadata)
 
 	return nil
 }
 
-// downloadObject downloads a file from S3 using HTTP GET.
-func (e *HttpS3Executor) downloadObject(ctx context.Context, testName, bucket, filename string) error {
+// downloadObject downloads a file from S3 using HTTP GET. With min_speed set,
+// a body transfer slower than that over min_speed_time fails as "stalled";
+// with verify_content set, the bytes are checked against what the run
+// uploaded.
+func (e *HttpS3Executor) downloadObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	var dst io.Writer = io.Discard
+	var verifier *contentVerifier
+	if step.VerifyContent {
+		if verifier = newContentVerifier(run); verifier == nil {
+			return errNotUploaded(run)
+		}
+		dst = verifier
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Build request
-	url := e.buildURL(bucket, filename)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	url := e.buildURL(run.Bucket, run.Filename)
+	req, err := e.newRequest(ctx, run, http.MethodGet, url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -390,44 +541,537 @@ func (e *HttpS3Executor) downloadObject(ctx context.Context, testName, bucket, f
 	// Execute request
 	resp, err := e.client.Do(req)
 	if err != nil {
-		e.metrics.RecordStorjDownload(testName, executorNameHttpS3, bucket, "", time.Since(tracer.start), 0, false)
+		e.metrics.RecordStorjDownload(run, "", time.Since(tracer.start), 0, false)
 		return fmt.Errorf("HTTP GET failed: %w", err)
 	}
 	defer resp.Body.Close()
+	sr.StatusCode = resp.StatusCode
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
 
 	// Check response
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		e.metrics.RecordStorjDownload(testName, executorNameHttpS3, bucket, "", time.Since(tracer.start), 0, false)
-		return fmt.Errorf("HTTP GET returned status %d: %s", resp.StatusCode, string(body))
+		respErr := s3err.FromResponse(resp)
+		sr.RequestID = respErr.RequestID
+		e.metrics.RecordStorjDownload(run, "", time.Since(tracer.start), 0, false)
+		return fmt.Errorf("HTTP GET returned %w", respErr)
 	}
 
 	// Read the data to measure actual download time
-	bytesRead, err := io.Copy(io.Discard, resp.Body)
+	var body io.Reader = resp.Body
+	var watch *stallWatch
+	if step.MinSpeed != nil && *step.MinSpeed > 0 {
+		watch = watchStall(resp.Body, step.MinSpeed.Int64(), step.MinSpeedDuration(), cancel)
+		body = watch
+	}
+	bytesRead, err := io.Copy(dst, body)
 	transferDone := time.Now()
+	if watch != nil {
+		watch.Stop()
+	}
 
 	// Record granular timing metrics
 	timings := tracer.toMetrics(transferDone)
-	e.metrics.RecordHTTPTiming(testName, "download", executorNameHttpS3, timings)
-	e.metrics.RecordHTTPTimingPhase(testName, "download", executorNameHttpS3, "sign", signDuration)
+	e.metrics.RecordHTTPTiming(run, "download", timings)
+	if info, ok := tracer.tlsInfo(); ok {
+		e.metrics.RecordTLSConnection(run, info)
+	}
+	e.metrics.RecordHTTPTimingPhase(run, "download", "sign", signDuration)
+	describeStep(sr, timings, signDuration, resp.Header)
 
 	if err != nil {
-		e.metrics.RecordStorjDownload(testName, executorNameHttpS3, bucket, "", timings.Total, bytesRead, false)
+		e.metrics.RecordStorjDownload(run, "", timings.Total, bytesRead, false)
+		if watch != nil && watch.Stalled() {
+			return &classifiedError{
+				class: "stalled",
+				msg:   fmt.Sprintf("download stalled: under %s/s for %v after %d bytes", step.MinSpeed, step.MinSpeedDuration(), bytesRead),
+			}
+		}
 		return fmt.Errorf("failed to read HTTP response: %w", err)
 	}
 
-	logging.Debug("    HTTP S3 downloaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v, transfer=%v)",
-		filename, bytesRead, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB, timings.Transfer)
-	e.metrics.RecordStorjDownload(testName, executorNameHttpS3, bucket, "", timings.Total, bytesRead, true)
+	run.StepLog(step.Name).Debug("    HTTP S3 downloaded %s (%d bytes) in %v (sign=%v, dns=%v, tls=%v, ttfb=%v, transfer=%v)",
+		run.Filename, bytesRead, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB, timings.Transfer)
+	sr.Bytes = bytesRead
+	e.metrics.RecordStorjDownload(run, "", timings.Total, bytesRead, true)
 
+	if verifier != nil {
+		return verifier.verify(e.metrics, run)
+	}
+	return nil
+}
+
+// readAfterWrite uploads the object, then polls it with HEAD or GET until it
+// is readable, recording the delay as the gateway's read-after-write latency.
+func (e *HttpS3Executor) readAfterWrite(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	method := step.GetPollMethod()
+	var httpMethod string
+	switch method {
+	case config.PollHead:
+		httpMethod = http.MethodHead
+	case config.PollGet:
+		httpMethod = http.MethodGet
+	default:
+		return fmt.Errorf("unknown poll_method %q (expected head or get)", method)
+	}
+
+	if err := e.uploadObject(ctx, run, step, sr); err != nil {
+		return err
+	}
+
+	delay, attempts, err := pollReadable(ctx, step.PollIntervalDuration(), func(ctx context.Context) (bool, error) {
+		return e.probeObject(ctx, run, httpMethod)
+	})
+	e.metrics.RecordReadAfterWrite(run, method, delay, err == nil)
+	if err != nil {
+		return err
+	}
+
+	if sr.Phases == nil {
+		sr.Phases = make(map[string]float64)
+	}
+	sr.Phases["readable"] = delay.Seconds()
+	run.StepLog(step.Name).Debug("    HTTP S3 %s readable after %v (%d %s attempts)", run.Filename, delay, attempts, httpMethod)
+	return nil
+}
+
+// errUploadAborted fails the body of an upload-abort request
+var errUploadAborted = errors.New("upload aborted by the probe")
+
+// abortReader yields the first limit bytes of r, then aborts: it cancels
+// the request context if cancel is set and fails the read either way
+type abortReader struct {
+	r      io.Reader
+	limit  int64
+	off    int64
+	cancel context.CancelFunc
+}
+
+func (r *abortReader) Read(p []byte) (int, error) {
+	if r.off >= r.limit {
+		if r.cancel != nil {
+			r.cancel()
+		}
+		return 0, errUploadAborted
+	}
+	n, err := r.r.Read(p[:min(int64(len(p)), r.limit-r.off)])
+	r.off += int64(n)
+	return n, err
+}
+
+// uploadAbort starts an upload, aborts it halfway through the body, and then
+// verifies that no partial object became visible. A visible object fails the
+// step with error class "partial_object" and is deleted.
+func (e *HttpS3Executor) uploadAbort(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	method := step.GetAbortMethod()
+	if method != config.AbortCancel && method != config.AbortClose {
+		return fmt.Errorf("unknown abort_method %q (expected cancel or close)", method)
+	}
+
+	var fileSize int64 = 1024 * 1024 // Default 1MB
+	if step.FileSize != nil {
+		fileSize = step.FileSize.Int64()
+	}
+	src, err := payload.New(e.config.GetPayload(step))
+	if err != nil {
+		return err
+	}
+
+	// Only half of the payload is sent, so it is generated as it is read
+	uploadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	body := &abortReader{r: payload.Reader(src, fileSize), limit: fileSize / 2}
+	if method == config.AbortCancel {
+		body.cancel = cancel
+	}
+
+	req, err := e.newRequest(uploadCtx, run, http.MethodPut, e.buildURL(run.Bucket, run.Filename), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = fileSize // Declare the full size so the gateway expects the rest
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if err := e.signer.Sign(req); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := e.client.Do(req)
+	if err == nil {
+		// The gateway answered before the body was complete
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
+			return fmt.Errorf("upload of %s succeeded after sending %d of %d bytes", run.Filename, body.off, fileSize)
+		}
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("upload-abort interrupted: %w", ctx.Err())
+	}
+	sr.Bytes = int64(body.off)
+	run.StepLog(step.Name).Debug("    HTTP S3 aborted upload of %s after %d of %d bytes (%s)", run.Filename, body.off, fileSize, method)
+
+	visible, err := e.probeObject(ctx, run, http.MethodHead)
+	var respErr *s3err.Error
+	if !visible && errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if !visible {
+		return fmt.Errorf("failed to check for a partial object: %w", err)
+	}
+
+	// Don't leave the partial object behind for the next run
+	if err := e.deleteObject(ctx, run, "", &result.Step{}); err != nil {
+		run.StepLog(step.Name).Printf("    HTTP S3 failed to delete partial object %s: %v", run.Filename, err)
+	}
+	return &classifiedError{
+		class: "partial_object",
+		msg:   fmt.Sprintf("object %s is visible after its upload was aborted at %d of %d bytes", run.Filename, body.off, fileSize),
+	}
+}
+
+// headBench issues authenticated HEAD requests for the bucket back-to-back
+// and records their latency distribution and rate. The requests are
+// sequential, so they share one keep-alive connection and measure auth and
+// metadata overhead without any data transfer.
+func (e *HttpS3Executor) headBench(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	n := step.RequestCount()
+	url := fmt.Sprintf("%s/%s", e.endpoint, run.Bucket)
+	latencies := make([]time.Duration, 0, n)
+	newConns := 0
+
+	start := time.Now()
+	for range n {
+		req, err := e.newRequest(ctx, run, http.MethodHead, url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		if err := e.signer.Sign(req); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
+
+		tracer := newHTTPTimingTracer()
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.trace()))
+		resp, err := e.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("HTTP HEAD %d/%d failed: %w", len(latencies)+1, n, err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		sr.StatusCode = resp.StatusCode
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("HTTP HEAD %d/%d returned %w", len(latencies)+1, n, s3err.FromResponse(resp))
+		}
+
+		latencies = append(latencies, time.Since(tracer.start))
+		if !tracer.connReused {
+			newConns++
+		}
+	}
+	elapsed := time.Since(start)
+	e.metrics.RecordHeadBench(run, latencies, elapsed)
+
+	slices.Sort(latencies)
+	sr.Phases = map[string]float64{
+		"p50": latencyPercentile(latencies, 50).Seconds(),
+		"p90": latencyPercentile(latencies, 90).Seconds(),
+		"p99": latencyPercentile(latencies, 99).Seconds(),
+		"max": latencies[len(latencies)-1].Seconds(),
+	}
+	run.StepLog(step.Name).Printf("    HTTP S3 head-bench: %d requests in %v (%.1f req/s, p50 %v, p99 %v, %d new connections)",
+		n, elapsed.Round(time.Millisecond), float64(n)/elapsed.Seconds(),
+		latencyPercentile(latencies, 50).Round(time.Microsecond), latencyPercentile(latencies, 99).Round(time.Microsecond), newConns)
+	return nil
+}
+
+// presignObject exports a presigned GET URL for the object as the step's
+// "url" output, for a later fetch step. It makes no requests.
+func (e *HttpS3Executor) presignObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.buildURL(run.Bucket, run.Filename), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	sr.SetOutput("url", e.signer.Presign(req, step.PresignExpiry()))
+	run.StepLog(step.Name).Debug("    HTTP S3 presigned %s for %v", run.Filename, step.PresignExpiry())
+	return nil
+}
+
+// fetchURL GETs the step's url without signing it, as a browser or CDN
+// would fetch a presigned URL. Only the User-Agent is set: x-amz-* headers
+// must be signed, so the configured S3 headers are not sent.
+func (e *HttpS3Executor) fetchURL(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	if step.URL == "" {
+		return fmt.Errorf("fetch step requires url")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, step.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", run.UserAgent(e.config.GetUserAgent()))
+
+	tracer := newHTTPTimingTracer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.trace()))
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP GET failed: %w", err)
+	}
+	defer resp.Body.Close()
+	sr.StatusCode = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		respErr := s3err.FromResponse(resp)
+		sr.RequestID = respErr.RequestID
+		return fmt.Errorf("HTTP GET returned %w", respErr)
+	}
+
+	bytesRead, err := io.Copy(io.Discard, resp.Body)
+	timings := tracer.toMetrics(time.Now())
+	e.metrics.RecordHTTPTiming(run, "fetch", timings)
+	describeStep(sr, timings, 0, resp.Header)
+	if err != nil {
+		return fmt.Errorf("failed to read HTTP response: %w", err)
+	}
+
+	run.StepLog(step.Name).Debug("    HTTP S3 fetched %s (%d bytes) in %v (dns=%v, tls=%v, ttfb=%v, transfer=%v)",
+		req.URL.Host+req.URL.Path, bytesRead, timings.Total, timings.DNSLookup, timings.TLSHandshake, timings.TTFB, timings.Transfer)
+	sr.Bytes = bytesRead
+	return nil
+}
+
+// multipartUpload uploads the object in parts with raw multipart requests
+func (e *HttpS3Executor) multipartUpload(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	src, err := payload.New(e.config.GetPayload(step))
+	if err != nil {
+		return err
+	}
+	return multipartUpload(ctx, e, e.metrics, run, step, src, sr)
+}
+
+// objectRequest makes one signed request for the run's object with the
+// given query and returns the response status, headers, and body. newBody
+// returns a fresh reader of the size byte request body (nil for none).
+// Non-2xx responses are returned as an *s3err.Error.
+func (e *HttpS3Executor) objectRequest(ctx context.Context, run *runctx.Run, method string, query url.Values, newBody func() io.Reader, size int64) (int, http.Header, []byte, error) {
+	var body io.Reader
+	if newBody != nil {
+		body = newBody()
+	}
+	req, err := e.newRequest(ctx, run, method, e.buildURL(run.Bucket, run.Filename)+"?"+awsv4.EncodeQuery(query), body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if newBody != nil {
+		req.ContentLength = size
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(newBody()), nil }
+	}
+	if err := e.signer.Sign(req); err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("HTTP %s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, resp.Header, nil, fmt.Errorf("HTTP %s returned %w", method, s3err.FromResponse(resp))
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, resp.Header, nil, fmt.Errorf("failed to read HTTP response: %w", err)
+	}
+	return resp.StatusCode, resp.Header, respBody, nil
+}
+
+// initiateMultipart starts a multipart upload of the run's object
+func (e *HttpS3Executor) initiateMultipart(ctx context.Context, run *runctx.Run) (string, error) {
+	_, _, body, err := e.objectRequest(ctx, run, http.MethodPost, url.Values{"uploads": {""}}, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	return parseInitiateMultipart(body)
+}
+
+// uploadPart uploads one part and returns its ETag
+func (e *HttpS3Executor) uploadPart(ctx context.Context, run *runctx.Run, uploadID string, number int, data *payload.Buffer) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	_, header, _, err := e.objectRequest(ctx, run, http.MethodPut, query, func() io.Reader { return data.Reader() }, int64(len(data.B)))
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(header.Get("ETag"), `"`), nil
+}
+
+// completeMultipart assembles the uploaded parts into the object
+func (e *HttpS3Executor) completeMultipart(ctx context.Context, run *runctx.Run, uploadID string, etags []string, sr *result.Step) error {
+	parts := completeMultipartBody(etags)
+	status, header, body, err := e.objectRequest(ctx, run, http.MethodPost, url.Values{"uploadId": {uploadID}},
+		func() io.Reader { return bytes.NewReader(parts) }, int64(len(parts)))
+	if header != nil {
+		sr.StatusCode = status
+		sr.RequestID = header.Get("X-Amz-Request-Id")
+	}
+	if err != nil {
+		return err
+	}
+	etag, err := parseCompleteMultipart(status, body)
+	if err != nil {
+		return err
+	}
+	sr.SetOutput("etag", etag)
+	return nil
+}
+
+// abortMultipart discards a multipart upload and its parts
+func (e *HttpS3Executor) abortMultipart(ctx context.Context, run *runctx.Run, uploadID string) error {
+	_, _, _, err := e.objectRequest(ctx, run, http.MethodDelete, url.Values{"uploadId": {uploadID}}, nil, 0)
+	return err
+}
+
+// latencyPercentile returns the p-th percentile (nearest rank) of sorted
+// latencies
+func latencyPercentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p + 99) / 100
+	return sorted[max(i, 1)-1]
+}
+
+// probeObject makes one read request for the object, returning whether it succeeded
+func (e *HttpS3Executor) probeObject(ctx context.Context, run *runctx.Run, method string) (bool, error) {
+	req, err := e.newRequest(ctx, run, method, e.buildURL(run.Bucket, run.Filename), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := e.signer.Sign(req); err != nil {
+		return false, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("HTTP %s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %s returned %w", method, s3err.FromResponse(resp))
+	}
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return false, fmt.Errorf("failed to read HTTP response: %w", err)
+	}
+	return true, nil
+}
+
+// statObject reads the object's size and user metadata with an HTTP HEAD
+// request. With verify_metadata set, they are checked against what the run
+// uploaded.
+func (e *HttpS3Executor) statObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	req, err := e.newRequest(ctx, run, http.MethodHead, e.buildURL(run.Bucket, run.Filename), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	signStart := time.Now()
+	if err := e.signer.Sign(req); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	signDuration := time.Since(signStart)
+
+	tracer := newHTTPTimingTracer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.trace()))
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.metrics.RecordStorjStat(run, 0, false)
+		return fmt.Errorf("HTTP HEAD failed: %w", err)
+	}
+	defer resp.Body.Close()
+	sr.StatusCode = resp.StatusCode
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
+	io.Copy(io.Discard, resp.Body)
+
+	timings := tracer.toMetrics(time.Now())
+	e.metrics.RecordHTTPTiming(run, "stat", timings)
+	e.metrics.RecordHTTPTimingPhase(run, "stat", "sign", signDuration)
+	describeStep(sr, timings, signDuration, resp.Header)
+
+	if resp.StatusCode != http.StatusOK {
+		e.metrics.RecordStorjStat(run, timings.Total, false)
+		return fmt.Errorf("HTTP HEAD returned %w", s3err.FromResponse(resp))
+	}
+	sr.SetOutput("size", strconv.FormatInt(resp.ContentLength, 10))
+	metadata := userMetadata(resp.Header)
+
+	run.StepLog(step.Name).Debug("    HTTP S3 stat %s (%d bytes, %d metadata keys) in %v (sign=%v, ttfb=%v)",
+		run.Filename, resp.ContentLength, len(metadata), timings.Total, signDuration, timings.TTFB)
+	e.metrics.RecordStorjStat(run, timings.Total, true)
+
+	if step.VerifyMetadata {
+		return verifyStat(e.metrics, run, resp.ContentLength, metadata)
+	}
+	return nil
+}
+
+// listObjects lists one page of the bucket with an HTTP ListObjectsV2
+// request.
+func (e *HttpS3Executor) listObjects(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	url := fmt.Sprintf("%s/%s?%s", e.endpoint, run.Bucket, awsv4.EncodeQuery(listQuery(step)))
+	req, err := e.newRequest(ctx, run, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	signStart := time.Now()
+	if err := e.signer.Sign(req); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	signDuration := time.Since(signStart)
+
+	tracer := newHTTPTimingTracer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.trace()))
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.metrics.RecordStorjList(run, 0, 0, false)
+		return fmt.Errorf("HTTP GET failed: %w", err)
+	}
+	defer resp.Body.Close()
+	sr.StatusCode = resp.StatusCode
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
+
+	var respErr error
+	var body []byte
+	if resp.StatusCode != http.StatusOK {
+		respErr = s3err.FromResponse(resp)
+	} else if body, err = io.ReadAll(resp.Body); err != nil {
+		respErr = fmt.Errorf("failed to read HTTP response: %w", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	transferDone := time.Now()
+
+	timings := tracer.toMetrics(transferDone)
+	e.metrics.RecordHTTPTiming(run, "list", timings)
+	if info, ok := tracer.tlsInfo(); ok {
+		e.metrics.RecordTLSConnection(run, info)
+	}
+	e.metrics.RecordHTTPTimingPhase(run, "list", "sign", signDuration)
+	describeStep(sr, timings, signDuration, resp.Header)
+
+	var count int
+	if respErr == nil {
+		count, respErr = parseListObjects(body)
+	}
+	if respErr != nil {
+		e.metrics.RecordStorjList(run, 0, 0, false)
+		return fmt.Errorf("HTTP GET returned %w", respErr)
+	}
+	sr.SetOutput("objects", strconv.Itoa(count))
+
+	run.StepLog(step.Name).Debug("    HTTP S3 listed %d objects in %s in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+		count, run.Bucket, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+	e.metrics.RecordStorjList(run, timings.Total, count, true)
 	return nil
 }
 
 // deleteObject deletes a file from S3 using HTTP DELETE.
-func (e *HttpS3Executor) deleteObject(ctx context.Context, testName, bucket, filename, fileSizeLabel string) error {
+func (e *HttpS3Executor) deleteObject(ctx context.Context, run *runctx.Run, fileSizeLabel string, sr *result.Step) error {
 	// Build request
-	url := e.buildURL(bucket, filename)
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	url := e.buildURL(run.Bucket, run.Filename)
+	req, err := e.newRequest(ctx, run, http.MethodDelete, url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -446,29 +1090,40 @@ func (e *HttpS3Executor) deleteObject(ctx context.Context, testName, bucket, fil
 	// Execute request
 	resp, err := e.client.Do(req)
 	if err != nil {
-		e.metrics.RecordStorjDelete(testName, executorNameHttpS3, bucket, fileSizeLabel, 0, 0, false)
+		e.metrics.RecordStorjDelete(run, fileSizeLabel, 0, 0, false)
 		return fmt.Errorf("HTTP DELETE failed: %w", err)
 	}
 	defer resp.Body.Close()
+	sr.StatusCode = resp.StatusCode
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
 
-	// Read response body to complete timing
+	// Read response body to complete timing, parsing it if it is an S3 error
+	// (204 No Content is the expected success response for DELETE)
+	var respErr error
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respErr = s3err.FromResponse(resp)
+	}
 	io.Copy(io.Discard, resp.Body)
 	transferDone := time.Now()
 
 	// Record granular timing metrics
 	timings := tracer.toMetrics(transferDone)
-	e.metrics.RecordHTTPTiming(testName, "delete", executorNameHttpS3, timings)
-	e.metrics.RecordHTTPTimingPhase(testName, "delete", executorNameHttpS3, "sign", signDuration)
+	e.metrics.RecordHTTPTiming(run, "delete", timings)
+	if info, ok := tracer.tlsInfo(); ok {
+		e.metrics.RecordTLSConnection(run, info)
+	}
+	e.metrics.RecordHTTPTimingPhase(run, "delete", "sign", signDuration)
+	describeStep(sr, timings, signDuration, resp.Header)
 
-	// Check response (204 No Content is the expected success response for DELETE)
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		e.metrics.RecordStorjDelete(testName, executorNameHttpS3, bucket, fileSizeLabel, 0, 0, false)
-		return fmt.Errorf("HTTP DELETE returned status %d", resp.StatusCode)
+	// Check response
+	if respErr != nil {
+		e.metrics.RecordStorjDelete(run, fileSizeLabel, 0, 0, false)
+		return fmt.Errorf("HTTP DELETE returned %w", respErr)
 	}
 
-	logging.Debug("    HTTP S3 deleted %s in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
-		filename, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
-	e.metrics.RecordStorjDelete(testName, executorNameHttpS3, bucket, fileSizeLabel, timings.Total, 1, true)
+	run.Log().Debug("    HTTP S3 deleted %s in %v (sign=%v, dns=%v, tls=%v, ttfb=%v)",
+		run.Filename, timings.Total, signDuration, timings.DNSLookup, timings.TLSHandshake, timings.TTFB)
+	e.metrics.RecordStorjDelete(run, fileSizeLabel, timings.Total, 1, true)
 
 	return nil
 }
@@ -0,0 +1,193 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/k6output"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/runctx"
+)
+
+// runK6 runs a k6 command writing its JSON output to stdout ("--out json=-")
+// and passes each metric point to fn while k6 runs, logging parse errors to
+// logger. It returns k6's console output (stderr).
+func runK6(cmd *exec.Cmd, logger logging.Logger, fn func(k6output.MetricPoint)) ([]byte, error) {
+	var output bytes.Buffer
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = &output
+
+	parsed := make(chan struct{})
+	go func() {
+		defer close(parsed)
+		if _, err := k6output.Stream(pr, fn); err != nil {
+			logger.Printf("    Warning: failed to parse k6 output: %v", err)
+		}
+		io.Copy(io.Discard, pr) // Never leave k6 blocked on a full pipe
+	}()
+
+	err := cmd.Run()
+	pw.Close()
+	<-parsed
+	return output.Bytes(), err
+}
+
+// k6Recorder records the metrics of a k6 step's points as they are parsed.
+// Deletes and stat mismatches are recorded point by point; an upload,
+// download, or stat combines its duration, bytes, and success points, so it
+// is recorded by finish.
+type k6Recorder struct {
+	metrics       *metrics.Collector
+	run           *runctx.Run
+	fileSizeLabel string
+
+	points   int
+	names    map[string]bool // Metrics seen, for debug logging
+	upload   k6Transfer
+	download k6Transfer
+	stat     k6Transfer
+	deletes  int      // Sum of storj_delete_count_total
+	mismatch []string // Fields a stat with verify_metadata found changed
+}
+
+// k6Transfer is the first duration, bytes, and success point of an upload,
+// download, or stat
+type k6Transfer struct {
+	duration                          time.Duration
+	bytes                             int64
+	success                           bool
+	hasDuration, hasBytes, hasSuccess bool
+}
+
+func newK6Recorder(mc *metrics.Collector, run *runctx.Run, fileSizeLabel string) *k6Recorder {
+	return &k6Recorder{
+		metrics:       mc,
+		run:           run,
+		fileSizeLabel: fileSizeLabel,
+		names:         make(map[string]bool),
+	}
+}
+
+// add records a metric point
+func (r *k6Recorder) add(point k6output.MetricPoint) {
+	r.points++
+	r.names[point.Metric] = true
+
+	switch point.Metric {
+	case "storj_upload_duration_ms":
+		r.upload.setDuration(point.Value)
+	case "storj_upload_bytes_total":
+		r.upload.setBytes(point.Value)
+	case "storj_upload_success":
+		r.upload.setSuccess(point.Value)
+	case "storj_download_duration_ms":
+		r.download.setDuration(point.Value)
+	case "storj_download_bytes_total":
+		r.download.setBytes(point.Value)
+	case "storj_download_success":
+		r.download.setSuccess(point.Value)
+	case "storj_delete_duration_ms":
+		duration := time.Duration(point.Value) * time.Millisecond
+		r.run.Log().Debug("    Uplink delete duration from k6: %v (raw value: %v)", duration, point.Value)
+		r.metrics.RecordStorjDelete(r.run, r.fileSizeLabel, duration, 1, true)
+	case "storj_delete_success":
+		if point.Value <= 0 {
+			// Record failure (no duration, count=0); each point is one delete attempt
+			r.metrics.RecordStorjDelete(r.run, r.fileSizeLabel, 0, 1, false)
+		}
+	case "storj_delete_count_total":
+		r.deletes += int(point.Value)
+	case "storj_stat_duration_ms":
+		r.stat.setDuration(point.Value)
+	case "storj_stat_success":
+		r.stat.setSuccess(point.Value)
+	case "storj_stat_size_match":
+		r.statMatch("size", point.Value)
+	case "storj_stat_metadata_match":
+		r.statMatch("metadata", point.Value)
+	}
+}
+
+// statMatch records a stat's size or metadata check
+func (r *k6Recorder) statMatch(field string, rate float64) {
+	if rate <= 0 {
+		r.metrics.RecordMetadataMismatch(r.run, field)
+		r.mismatch = append(r.mismatch, field)
+	}
+}
+
+// verified returns the error of a stat whose size or metadata differs from
+// what the run uploaded; the script logs the differences
+func (r *k6Recorder) verified() error {
+	if len(r.mismatch) == 0 {
+		return nil
+	}
+	return &classifiedError{
+		class: "metadata_mismatch",
+		msg:   fmt.Sprintf("metadata verification failed for %s: %s differs from what was uploaded", r.run.Filename, strings.Join(r.mismatch, " and ")),
+	}
+}
+
+// finish records the upload, download, and delete count of the step once its
+// output has ended, and returns the bytes transferred
+func (r *k6Recorder) finish() int64 {
+	names := make([]string, 0, len(r.names))
+	for name := range r.names {
+		names = append(names, name)
+	}
+	r.run.Log().Debug("    Parsed %d metric points, found metric types: %v", r.points, names)
+
+	// Record in single calls, so the histogram gets both duration and bytes-derived fileSize
+	if r.upload.recorded() {
+		r.run.Log().Debug("    Uplink upload duration from k6: %v", r.upload.duration)
+		r.metrics.RecordStorjUpload(r.run, r.fileSizeLabel, r.upload.duration, r.upload.bytes, r.upload.ok())
+	}
+	if r.download.recorded() {
+		r.run.Log().Debug("    Uplink download duration from k6: %v", r.download.duration)
+		r.metrics.RecordStorjDownload(r.run, r.fileSizeLabel, r.download.duration, r.download.bytes, r.download.ok())
+	}
+	if r.stat.hasDuration || r.stat.hasSuccess {
+		r.metrics.RecordStorjStat(r.run, r.stat.duration, r.stat.ok())
+	}
+	if r.deletes > 0 {
+		// For count-only metrics, pass empty fileSize and 0 duration
+		r.metrics.RecordStorjDelete(r.run, "", 0, r.deletes, true)
+	}
+
+	r.run.Log().Printf("Parsed %d metric points from run %s", r.points, r.run)
+	return r.upload.bytes + r.download.bytes
+}
+
+func (t *k6Transfer) setDuration(ms float64) {
+	if !t.hasDuration {
+		t.duration, t.hasDuration = time.Duration(ms)*time.Millisecond, true
+	}
+}
+
+func (t *k6Transfer) setBytes(bytes float64) {
+	if !t.hasBytes {
+		t.bytes, t.hasBytes = int64(bytes), true
+	}
+}
+
+func (t *k6Transfer) setSuccess(rate float64) {
+	if !t.hasSuccess {
+		t.success, t.hasSuccess = rate > 0, true
+	}
+}
+
+// recorded reports whether the transfer has anything to record
+func (t *k6Transfer) recorded() bool {
+	return t.duration > 0 || t.bytes > 0
+}
+
+// ok reports whether the transfer succeeded; without a success point, it did
+func (t *k6Transfer) ok() bool {
+	return !t.hasSuccess || t.success
+}
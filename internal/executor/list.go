@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/ethanadams/synthetics/internal/config"
+)
+
+// listQuery returns the ListObjectsV2 query of a list step
+func listQuery(step *config.TestStep) url.Values {
+	query := url.Values{
+		"list-type": {"2"},
+		"max-keys":  {strconv.Itoa(step.GetMaxKeys())},
+	}
+	if step.Prefix != "" {
+		query.Set("prefix", step.Prefix)
+	}
+	return query
+}
+
+// parseListObjects returns the number of objects in a ListObjectsV2
+// response
+func parseListObjects(body []byte) (int, error) {
+	var doc struct {
+		XMLName  xml.Name
+		Contents []struct {
+			Key string `xml:"Key"`
+		} `xml:"Contents"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil || doc.XMLName.Local != "ListBucketResult" {
+		return 0, fmt.Errorf("malformed ListObjectsV2 response")
+	}
+	return len(doc.Contents), nil
+}
@@ -0,0 +1,228 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/payload"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/s3err"
+)
+
+// abortTimeout bounds aborting a failed multipart upload, which runs even if
+// the step's context is done
+const abortTimeout = 30 * time.Second
+
+// multipartUploader makes the requests of a multipart upload; each S3
+// executor implements it with its own client, so the gateway's multipart
+// behavior is measured the same way through the SDK, raw HTTP, and curl
+type multipartUploader interface {
+	initiateMultipart(ctx context.Context, run *runctx.Run) (uploadID string, err error)
+	uploadPart(ctx context.Context, run *runctx.Run, uploadID string, number int, data *payload.Buffer) (etag string, err error)
+	completeMultipart(ctx context.Context, run *runctx.Run, uploadID string, etags []string, sr *result.Step) error
+	abortMultipart(ctx context.Context, run *runctx.Run, uploadID string) error
+}
+
+// multipartPart is one part of a multipart upload
+type multipartPart struct {
+	Number int   // 1-based part number
+	Offset int64 // Offset of the part in the object
+	Size   int64
+}
+
+// planParts splits an object of size bytes into parts of partSize bytes;
+// the last part holds the remainder
+func planParts(size, partSize int64) []multipartPart {
+	parts := make([]multipartPart, 0, (size+partSize-1)/partSize)
+	for off := int64(0); off < size || len(parts) == 0; off += partSize {
+		parts = append(parts, multipartPart{Number: len(parts) + 1, Offset: off, Size: min(partSize, size-off)})
+	}
+	return parts
+}
+
+// multipartUpload uploads the step's object in parts of part_size bytes,
+// concurrency parts at a time. Initiate, each part, and complete are timed
+// separately; a failed upload is aborted so its parts don't linger.
+func multipartUpload(ctx context.Context, u multipartUploader, mc *metrics.Collector, run *runctx.Run, step *config.TestStep, src payload.Source, sr *result.Step) error {
+	var fileSize int64 = 1024 * 1024 // Default 1MB
+	fileSizeLabel := "1MB"
+	if step.FileSize != nil {
+		fileSize = step.FileSize.Int64()
+		fileSizeLabel = step.FileSize.String()
+	}
+	parts := planParts(fileSize, step.GetPartSize())
+
+	start := time.Now()
+	uploadID, err := u.initiateMultipart(ctx, run)
+	mc.RecordMultipart(run, metrics.MultipartInitiate, time.Since(start), err == nil)
+	if err != nil {
+		mc.RecordStorjUpload(run, fileSizeLabel, time.Since(start), fileSize, false)
+		return fmt.Errorf("InitiateMultipartUpload failed: %w", err)
+	}
+	initiated := time.Now()
+	sr.SetOutput("upload_id", uploadID)
+
+	// Parts are hashed separately if a later step verifies the content,
+	// since they finish in any order
+	var sums [][]byte
+	if run.VerifiesContent() {
+		sums = make([][]byte, len(parts))
+	}
+	etags, err := uploadParts(ctx, parts, step.GetConcurrency(), func(ctx context.Context, part multipartPart) (string, error) {
+		data, err := payload.NewBufferAt(src, part.Offset, part.Size)
+		if err != nil {
+			return "", err
+		}
+		defer data.Release()
+		partStart := time.Now()
+		etag, err := u.uploadPart(ctx, run, uploadID, part.Number, data)
+		mc.RecordMultipart(run, metrics.MultipartUploadPart, time.Since(partStart), err == nil)
+		if err == nil && sums != nil {
+			sum := sha256.Sum256(data.B)
+			sums[part.Number-1] = sum[:]
+		}
+		return etag, err
+	})
+	partsDone := time.Now()
+	if err != nil {
+		err = fmt.Errorf("UploadPart failed: %w", err)
+	} else {
+		err = u.completeMultipart(ctx, run, uploadID, etags, sr)
+		mc.RecordMultipart(run, metrics.MultipartComplete, time.Since(partsDone), err == nil)
+		if err != nil {
+			err = fmt.Errorf("CompleteMultipartUpload failed: %w", err)
+		}
+	}
+	duration := time.Since(start)
+	sr.Phases = map[string]float64{
+		"initiate": initiated.Sub(start).Seconds(),
+		"parts":    partsDone.Sub(initiated).Seconds(),
+		"complete": time.Since(partsDone).Seconds(),
+	}
+
+	if err != nil {
+		mc.RecordStorjUpload(run, fileSizeLabel, duration, fileSize, false)
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), abortTimeout)
+		defer cancel()
+		abortStart := time.Now()
+		abortErr := u.abortMultipart(abortCtx, run, uploadID)
+		mc.RecordMultipart(run, metrics.MultipartAbort, time.Since(abortStart), abortErr == nil)
+		if abortErr != nil {
+			run.StepLog(step.Name).Debug("    Failed to abort multipart upload %s of %s: %v", uploadID, run.Filename, abortErr)
+		}
+		return err
+	}
+
+	run.StepLog(step.Name).Debug("    Multipart uploaded %s (%d bytes, %d parts of %d, concurrency %d) in %v (initiate=%v, parts=%v, complete=%v)",
+		run.Filename, fileSize, len(parts), step.GetPartSize(), step.GetConcurrency(), duration,
+		initiated.Sub(start), partsDone.Sub(initiated), time.Since(partsDone))
+	sr.SetOutput("parts", fmt.Sprint(len(parts)))
+	sr.Bytes = fileSize
+	mc.RecordStorjUpload(run, fileSizeLabel, duration, fileSize, true)
+	if sums != nil {
+		run.RecordContent(&runctx.Content{Size: fileSize, PartSize: step.GetPartSize(), Sums: sums})
+	}
+	return nil
+}
+
+// uploadParts uploads the parts, up to concurrency at once, and returns
+// their ETags in part order. The first failure cancels the parts in flight.
+func uploadParts(ctx context.Context, parts []multipartPart, concurrency int, upload func(context.Context, multipartPart) (string, error)) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	etags := make([]string, len(parts))
+	slots := make(chan struct{}, concurrency)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for _, part := range parts {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			etag, err := upload(ctx, part)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("part %d: %w", part.Number, err)
+					cancel()
+				})
+				return
+			}
+			etags[part.Number-1] = etag
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return etags, nil
+}
+
+// completeMultipartBody returns the CompleteMultipartUpload request body
+// listing the parts' ETags
+func completeMultipartBody(etags []string) []byte {
+	type completedPart struct {
+		PartNumber int
+		ETag       string
+	}
+	doc := struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{}
+	for i, etag := range etags {
+		doc.Parts = append(doc.Parts, completedPart{PartNumber: i + 1, ETag: `"` + etag + `"`})
+	}
+	body, _ := xml.Marshal(doc)
+	return body
+}
+
+// parseInitiateMultipart returns the upload ID of an
+// InitiateMultipartUpload response
+func parseInitiateMultipart(body []byte) (string, error) {
+	var doc struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil || doc.UploadID == "" {
+		return "", fmt.Errorf("malformed InitiateMultipartUpload response")
+	}
+	return doc.UploadID, nil
+}
+
+// parseCompleteMultipart returns the object ETag of a
+// CompleteMultipartUpload response. S3 can report a failed completion in the
+// body of a 200 response, which is returned as an *s3err.Error.
+func parseCompleteMultipart(statusCode int, body []byte) (string, error) {
+	var doc struct {
+		XMLName xml.Name
+		ETag    string `xml:"ETag"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("malformed CompleteMultipartUpload response")
+	}
+	if doc.XMLName.Local == "Error" {
+		return "", s3err.Parse(statusCode, body)
+	}
+	return strings.Trim(doc.ETag, `"`), nil
+}
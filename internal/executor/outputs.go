@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+)
+
+// stepRunner runs one step of a gateway test (each executor's runStep)
+type stepRunner func(ctx context.Context, run *runctx.Run, step *config.TestStep, isSingleStep bool) (result.Step, error)
+
+// runStepWithOutputs runs a step with its references to earlier steps'
+// outputs expanded, then records its own outputs for the steps after it. A
+// step that sets key runs on a copy of run addressing that object.
+func runStepWithOutputs(ctx context.Context, runStep stepRunner, outputs config.StepOutputs, run *runctx.Run, step config.TestStep, isSingleStep bool) (result.Step, error) {
+	step, err := outputs.Expand(step)
+	if err != nil {
+		err = fmt.Errorf("step outputs: %w", err)
+		sr := result.Step{Name: step.Name}
+		sr.Finish(time.Now(), err)
+		return sr, err
+	}
+	if step.Key != "" {
+		keyed := *run
+		keyed.Filename = step.Key
+		run = &keyed
+	}
+
+	sr, err := runStep(ctx, run, &step, isSingleStep)
+	sr.SetOutput("bucket", run.Bucket)
+	sr.SetOutput("key", run.Filename)
+	sr.SetOutput("request_id", sr.RequestID)
+	if sr.Bytes > 0 {
+		sr.SetOutput("bytes", strconv.FormatInt(sr.Bytes, 10))
+	}
+	outputs[step.GetID()] = sr.Outputs
+	return sr, err
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/s3err"
+)
+
+// rangeSeek reads range_size bytes at seeks random offsets of the object, one
+// after the other, as a video player seeking through a large object would.
+// A HEAD request first finds the object's size. Each read is timed to its
+// last byte and must return exactly the requested range.
+func (e *HttpS3Executor) rangeSeek(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	url := e.buildURL(run.Bucket, run.Filename)
+	size, err := e.objectSize(ctx, run, url)
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		return fmt.Errorf("object %s is empty", run.Filename)
+	}
+	rangeSize := min(step.GetRangeSize(), size)
+
+	n := step.SeekCount()
+	latencies := make([]time.Duration, 0, n)
+	for i := range n {
+		off := rand.Int64N(size - rangeSize + 1)
+		req, err := e.newRequest(ctx, run, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+rangeSize-1))
+		if err := e.signer.Sign(req); err != nil {
+			return fmt.Errorf("failed to sign request: %w", err)
+		}
+
+		start := time.Now()
+		resp, err := e.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("ranged GET %d/%d at offset %d failed: %w", i+1, n, off, err)
+		}
+		read, err := io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		latency := time.Since(start)
+		if resp.StatusCode != http.StatusPartialContent {
+			if resp.StatusCode == http.StatusOK {
+				return fmt.Errorf("ranged GET %d/%d returned the whole object: the gateway ignored the Range header", i+1, n)
+			}
+			respErr := s3err.FromResponse(resp)
+			sr.RequestID = respErr.RequestID
+			return fmt.Errorf("ranged GET %d/%d at offset %d returned %w", i+1, n, off, respErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read ranged GET %d/%d: %w", i+1, n, err)
+		}
+		if read != rangeSize {
+			return fmt.Errorf("ranged GET %d/%d at offset %d returned %d bytes, requested %d", i+1, n, off, read, rangeSize)
+		}
+		sr.RequestID = resp.Header.Get("X-Amz-Request-Id")
+		sr.Bytes += read
+		latencies = append(latencies, latency)
+	}
+	e.metrics.RecordRangeSeek(run, latencies)
+
+	sorted := slices.Sorted(slices.Values(latencies))
+	sr.Phases = map[string]float64{
+		"p50": latencyPercentile(sorted, 50).Seconds(),
+		"p90": latencyPercentile(sorted, 90).Seconds(),
+		"p99": latencyPercentile(sorted, 99).Seconds(),
+		"max": sorted[len(sorted)-1].Seconds(),
+	}
+	run.StepLog(step.Name).Printf("    HTTP S3 range-seek: %d reads of %d bytes in a %d-byte object (p50 %v, max %v)",
+		n, rangeSize, size, latencyPercentile(sorted, 50).Round(time.Microsecond), sorted[len(sorted)-1].Round(time.Microsecond))
+	return nil
+}
+
+// objectSize returns the size of the object at url from a HEAD request
+func (e *HttpS3Executor) objectSize(ctx context.Context, run *runctx.Run, url string) (int64, error) {
+	req, err := e.newRequest(ctx, run, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := e.signer.Sign(req); err != nil {
+		return 0, fmt.Errorf("failed to sign request: %w", err)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("HTTP HEAD failed: %w", err)
+	}
+	defer resp.Body.Close()
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP HEAD returned %w", s3err.FromResponse(resp))
+	}
+	if resp.ContentLength < 0 {
+		return 0, fmt.Errorf("HTTP HEAD returned no Content-Length for %s", run.Filename)
+	}
+	return resp.ContentLength, nil
+}
@@ -0,0 +1,209 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/netpath"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/subproc"
+)
+
+const executorNameRTT = "rtt"
+
+// RTTExecutor measures round-trip time and packet loss to the gateway and
+// satellite hosts. It is cheap enough to run every minute, giving a network
+// baseline to normalize S3 operation latencies against.
+type RTTExecutor struct {
+	config  *config.Config
+	metrics *metrics.Collector
+}
+
+// NewRTT creates a new RTT executor.
+func NewRTT(cfg *config.Config, mc *metrics.Collector) *RTTExecutor {
+	return &RTTExecutor{
+		config:  cfg,
+		metrics: mc,
+	}
+}
+
+func (e *RTTExecutor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	run := runctx.New(test, executorNameRTT, e.config.Satellite.Bucket)
+	res := result.New(run)
+
+	// Bound all probes by the test timeout, if set
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	method := test.RTT.GetMethod()
+	if method != config.RTTMethodTCP && method != config.RTTMethodICMP {
+		return res.Finish(fmt.Errorf("rtt test %s: unknown method %q (expected tcp or icmp)", test.Name, method))
+	}
+	targets := e.targets(test, method)
+	if len(targets) == 0 {
+		return res.Finish(fmt.Errorf("rtt test %s has no targets (set rtt.targets, s3.endpoint, or satellite.access_grant)", test.Name))
+	}
+
+	run.Log().Printf("Running RTT test: %s (%d %s probes to %v)", test.Name, test.RTT.GetCount(), method, targets)
+
+	res.StepsStarting()
+	var firstErr error
+	for _, target := range targets {
+		sr, err := e.probeTarget(ctx, run, test.RTT, method, target)
+		res.Steps = append(res.Steps, sr)
+		if err != nil && firstErr == nil {
+			res.FailedStep = target
+			firstErr = err
+		}
+	}
+
+	if firstErr != nil {
+		return res.Finish(fmt.Errorf("rtt test %s failed: %w", test.Name, firstErr))
+	}
+	return res.Finish(nil)
+}
+
+// targets returns the configured targets, or the S3 endpoint and every
+// satellite address. ICMP targets are hosts without ports.
+func (e *RTTExecutor) targets(test *config.Test, method string) []string {
+	var targets []string
+	if test.RTT != nil && len(test.RTT.Targets) > 0 {
+		targets = test.RTT.Targets
+	} else {
+		addrs := []string{netpath.EndpointAddr(e.config.S3.Endpoint)}
+		for _, sat := range e.config.AllSatellites() {
+			addrs = append(addrs, netpath.SatelliteAddr(sat.AccessGrant))
+		}
+		for _, addr := range addrs {
+			if addr != "" && !slices.Contains(targets, addr) {
+				targets = append(targets, addr)
+			}
+		}
+	}
+
+	if method == config.RTTMethodICMP {
+		hosts := make([]string, len(targets))
+		for i, target := range targets {
+			hosts[i] = netpath.Host(target)
+		}
+		return hosts
+	}
+	return targets
+}
+
+// probeTarget sends the configured number of probes to one target. The step
+// fails only if every probe was lost.
+func (e *RTTExecutor) probeTarget(ctx context.Context, run *runctx.Run, rc *config.RTTConfig, method, target string) (result.Step, error) {
+	sr := result.Step{Name: target}
+	start := time.Now()
+
+	var rtts []time.Duration
+	var sent int
+	var err error
+	if method == config.RTTMethodICMP {
+		rtts, sent, err = e.ping(ctx, rc, target)
+	} else {
+		rtts, sent, err = tcpConnect(ctx, rc, target)
+	}
+	e.metrics.RecordRTT(run, target, method, rtts, sent)
+
+	if len(rtts) > 0 {
+		best, worst, sum := rtts[0], rtts[0], time.Duration(0)
+		for _, d := range rtts {
+			best, worst = min(best, d), max(worst, d)
+			sum += d
+		}
+		avg := sum / time.Duration(len(rtts))
+		sr.Phases = map[string]float64{
+			"rtt_min": best.Seconds(),
+			"rtt_avg": avg.Seconds(),
+			"rtt_max": worst.Seconds(),
+		}
+		run.Log().Printf("    RTT %s (%s): %d/%d replies, min/avg/max %v/%v/%v",
+			target, method, len(rtts), sent, best.Round(time.Microsecond), avg.Round(time.Microsecond), worst.Round(time.Microsecond))
+	}
+
+	if err == nil && len(rtts) == 0 {
+		err = fmt.Errorf("%s: all %d probes lost", target, sent)
+	}
+	if err != nil {
+		run.Log().Printf("    RTT %s (%s) failed: %v", target, method, err)
+	}
+	sr.Finish(start, err)
+	return sr, err
+}
+
+// tcpConnect measures TCP connect time to a host:port. Failed connects count as lost.
+func tcpConnect(ctx context.Context, rc *config.RTTConfig, target string) ([]time.Duration, int, error) {
+	var rtts []time.Duration
+	var lastErr error
+	dialer := net.Dialer{Timeout: rc.TimeoutDuration()}
+
+	count := rc.GetCount()
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return rtts, i, ctx.Err()
+			case <-time.After(rc.IntervalDuration()):
+			}
+		}
+
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", target)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		rtts = append(rtts, time.Since(start))
+		conn.Close()
+	}
+
+	if len(rtts) == 0 && lastErr != nil {
+		return rtts, count, fmt.Errorf("%s: all %d probes lost: %w", target, count, lastErr)
+	}
+	return rtts, count, nil
+}
+
+// pingReply matches the round-trip time of each reply in ping output (iputils and busybox)
+var pingReply = regexp.MustCompile(`time[=<]([\d.]+) ms`)
+
+// ping runs the ping binary against a host. Replies are counted from the
+// output, so lost probes are the difference from the count sent.
+func (e *RTTExecutor) ping(ctx context.Context, rc *config.RTTConfig, host string) ([]time.Duration, int, error) {
+	count := rc.GetCount()
+	args := []string{"-n", "-c", strconv.Itoa(count), "-W", strconv.Itoa(max(1, int(rc.TimeoutDuration().Seconds())))}
+	if interval := rc.IntervalDuration(); interval > 0 {
+		args = append(args, "-i", strconv.FormatFloat(interval.Seconds(), 'f', 3, 64))
+	}
+
+	done := e.metrics.TrackSubprocess("ping")
+	cmd := subproc.Command(ctx, "ping", append(args, host)...)
+	out, err := subproc.Output(cmd)
+	done(cmd.ProcessState)
+
+	var rtts []time.Duration
+	for _, m := range pingReply.FindAllSubmatch(out, -1) {
+		ms, perr := strconv.ParseFloat(string(m[1]), 64)
+		if perr == nil {
+			rtts = append(rtts, time.Duration(ms*float64(time.Millisecond)))
+		}
+	}
+
+	// ping exits 1 when some replies were lost; only report other failures
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return rtts, count, fmt.Errorf("ping %s failed: %w", host, err)
+	}
+	return rtts, count, nil
+}
@@ -1,22 +1,29 @@
 package executor
 
 import (
-	"bytes"
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/ethanadams/synthetics/internal/config"
 	"github.com/ethanadams/synthetics/internal/jitter"
+	"github.com/ethanadams/synthetics/internal/logging"
 	"github.com/ethanadams/synthetics/internal/metrics"
-	"github.com/oklog/ulid/v2"
+	"github.com/ethanadams/synthetics/internal/payload"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
 )
 
 // S3Executor runs S3 gateway tests using AWS SDK
@@ -29,7 +36,7 @@ type S3Executor struct {
 // NewS3 creates a new S3 executor
 func NewS3(cfg *config.Config, mc *metrics.Collector) (*S3Executor, error) {
 	// Create AWS config with custom endpoint
-	awsCfg, err := awsConfig(cfg.S3.Endpoint, cfg.S3.AccessKey, cfg.S3.SecretKey, cfg.S3.Region)
+	awsCfg, err := awsConfig(cfg.S3.Endpoint, cfg.S3.AccessKey, cfg.S3.SecretKey, cfg.S3.SessionToken, cfg.S3.Region)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AWS config: %w", err)
 	}
@@ -47,7 +54,7 @@ func NewS3(cfg *config.Config, mc *metrics.Collector) (*S3Executor, error) {
 }
 
 // awsConfig creates AWS config with custom credentials and endpoint
-func awsConfig(endpoint, accessKey, secretKey, region string) (aws.Config, error) {
+func awsConfig(endpoint, accessKey, secretKey, sessionToken, region string) (aws.Config, error) {
 	customResolver := aws.EndpointResolverWithOptionsFunc(func(service, regionID string, options ...interface{}) (aws.Endpoint, error) {
 		return aws.Endpoint{
 			URL:               endpoint,
@@ -58,7 +65,7 @@ func awsConfig(endpoint, accessKey, secretKey, region string) (aws.Config, error
 
 	return awsconfig.LoadDefaultConfig(context.Background(),
 		awsconfig.WithRegion(region),
-		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, sessionToken)),
 		awsconfig.WithEndpointResolverWithOptions(customResolver),
 		// Disable automatic checksum calculation for Storj compatibility
 		// AWS SDK v2 1.73.0+ calculates CRC32 checksums by default which breaks compatibility with Storj
@@ -68,12 +75,42 @@ func awsConfig(endpoint, accessKey, secretKey, region string) (aws.Config, error
 	)
 }
 
-// ensureBucket creates the bucket if it doesn't exist
-func (e *S3Executor) ensureBucket(ctx context.Context, bucket string) error {
+// recordResponse records the gateway identity headers, status code, request
+// ID, and ETag from an SDK response
+func (e *S3Executor) recordResponse(run *runctx.Run, metadata middleware.Metadata, sr *result.Step) {
+	if id, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		sr.RequestID = id
+	}
+	resp, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response)
+	if !ok || resp == nil {
+		return
+	}
+	sr.StatusCode = resp.StatusCode
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
+	sr.SetOutput("etag", strings.Trim(resp.Header.Get("ETag"), `"`))
+}
+
+// requestOptions returns an operation option that sends the run's User-Agent
+// in place of the SDK's, plus the configured headers. Headers are added
+// before the SDK signs the request, so x-amz-* headers are signed.
+func (e *S3Executor) requestOptions(run *runctx.Run) func(*s3.Options) {
+	ua := run.UserAgent(e.config.GetUserAgent())
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue("User-Agent", ua))
+		for name, value := range e.config.S3.Headers {
+			o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue(name, value))
+		}
+	}
+}
+
+// ensureBucket creates the run's bucket if it doesn't exist
+func (e *S3Executor) ensureBucket(ctx context.Context, run *runctx.Run) error {
+	bucket := run.Bucket
+
 	// Check if bucket exists by trying to head it
 	_, err := e.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
 		Bucket: aws.String(bucket),
-	})
+	}, e.requestOptions(run))
 	if err == nil {
 		// Bucket exists
 		return nil
@@ -82,19 +119,19 @@ func (e *S3Executor) ensureBucket(ctx context.Context, bucket string) error {
 	// Try to create the bucket
 	_, err = e.s3Client.CreateBucket(ctx, &s3.CreateBucketInput{
 		Bucket: aws.String(bucket),
-	})
+	}, e.requestOptions(run))
 	if err != nil {
 		// Ignore "bucket already exists" errors (race condition or different error format)
 		// Some S3-compatible services return different error codes
-		log.Printf("    Note: CreateBucket returned: %v (may be ignorable if bucket exists)", err)
+		run.Log().Printf("    Note: CreateBucket returned: %v (may be ignorable if bucket exists)", err)
 	} else {
-		log.Printf("    Created bucket: %s", bucket)
+		run.Log().Printf("    Created bucket: %s", bucket)
 	}
 
 	// Verify bucket is now accessible
 	_, err = e.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
 		Bucket: aws.String(bucket),
-	})
+	}, e.requestOptions(run))
 	if err != nil {
 		return fmt.Errorf("bucket %s not accessible after creation attempt: %w", bucket, err)
 	}
@@ -102,68 +139,103 @@ func (e *S3Executor) ensureBucket(ctx context.Context, bucket string) error {
 	return nil
 }
 
+// HeadBucket checks that the credentials can access the bucket without creating it
+func (e *S3Executor) HeadBucket(ctx context.Context, bucket string) error {
+	_, err := e.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	return err
+}
+
+// ObjectInfo is an object found by ListObjects
+type ObjectInfo struct {
+	Key  string
+	Size int64
+}
+
+// ListObjects lists up to limit objects (0: all) in the bucket under prefix
+func (e *S3Executor) ListObjects(ctx context.Context, bucket, prefix string, limit int) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	pages := s3.NewListObjectsV2Paginator(e.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, ObjectInfo{Key: aws.ToString(obj.Key), Size: aws.ToInt64(obj.Size)})
+			if limit > 0 && len(objects) == limit {
+				return objects, nil
+			}
+		}
+	}
+	return objects, nil
+}
+
 // RunTest executes an S3 test (handles single or multi-step)
-func (e *S3Executor) RunTest(ctx context.Context, test *config.Test) error {
-	log.Printf("Running S3 test: %s", test.Name)
+func (e *S3Executor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	logging.With("test_name", test.Name, "executor", "s3").Printf("Running S3 test: %s", test.Name)
 
 	testStart := time.Now()
 
-	// Generate ULID for this test run
-	entropy := ulid.Monotonic(rand.Reader, 0)
-	testULID := ulid.MustNew(ulid.Timestamp(testStart), entropy)
-	sharedFilename := test.GetFilename(testULID.String())
-	bucket := test.GetBucket(e.config.Satellite.Bucket)
+	// Bound all steps by the test timeout, if set
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	// Identify this run; overlapping runs of a fixed-filename test take turns
+	run := runctx.New(test, "s3", e.config.Satellite.Bucket)
+	run.Endpoint, run.Region = e.config.S3.Endpoint, e.config.S3.Region
+	res := result.New(run)
+	release, err := run.Claim(ctx)
+	if err != nil {
+		return res.Finish(fmt.Errorf("test %s not started: %w", test.Name, err))
+	}
+	defer release()
 
 	// Ensure bucket exists before running test
-	if err := e.ensureBucket(ctx, bucket); err != nil {
-		return fmt.Errorf("failed to ensure bucket %s exists: %w", bucket, err)
+	if err := e.ensureBucket(ctx, run); err != nil {
+		return res.Finish(fmt.Errorf("failed to ensure bucket %s exists: %w", run.Bucket, err))
 	}
 
 	isSingleStep := test.IsSingleStep()
 
 	if isSingleStep {
-		log.Printf("S3 test %s using ULID: %s (filename: %s, bucket: %s)",
-			test.Name, testULID.String(), sharedFilename, bucket)
+		run.Log().Printf("S3 test %s using ULID: %s (filename: %s, bucket: %s)",
+			test.Name, run.ID, run.Filename, run.Bucket)
 	} else {
-		log.Printf("S3 test %s (%d steps) using ULID: %s (filename: %s, bucket: %s)",
-			test.Name, len(test.Steps), testULID.String(), sharedFilename, bucket)
+		run.Log().Printf("S3 test %s (%d steps) using ULID: %s (filename: %s, bucket: %s)",
+			test.Name, len(test.Steps), run.ID, run.Filename, run.Bucket)
 	}
 
-	// Run each step sequentially
-	for i, step := range test.Steps {
-		if !isSingleStep {
-			log.Printf("  [%d/%d] Running: %s", i+1, len(test.Steps), step.Name)
-		}
-
-		if err := e.runStep(ctx, test.Name, &step, sharedFilename, bucket, isSingleStep); err != nil {
-			if !isSingleStep {
-				log.Printf("  [%d/%d] Failed: %s - %v", i+1, len(test.Steps), step.Name, err)
-			}
-			e.metrics.RecordTestRun(test.Name, step.Name, "s3", false, time.Since(testStart))
-			return fmt.Errorf("S3 test %s failed at step %s: %w", test.Name, step.Name, err)
-		}
+	res.StepsStarting()
 
-		if !isSingleStep {
-			log.Printf("  [%d/%d] Completed: %s", i+1, len(test.Steps), step.Name)
-		}
+	// Run the steps in order, passing outputs to the steps after
+	if failed, err := runSteps(ctx, test, run, res, e.runStep); err != nil {
+		res.FailedStep = failed
+		return res.Finish(fmt.Errorf("S3 test %s failed at step %s: %w", test.Name, failed, err))
 	}
 
 	duration := time.Since(testStart)
-	log.Printf("S3 test %s completed successfully in %v", test.Name, duration)
-	// For overall test run, use empty action (represents entire test)
-	e.metrics.RecordTestRun(test.Name, "", "s3", true, duration)
+	run.Log().Printf("S3 test %s completed successfully in %v", test.Name, duration)
 
-	return nil
+	return res.Finish(nil)
 }
 
 // runStep executes a single S3 test step
-func (e *S3Executor) runStep(ctx context.Context, testName string, step *config.TestStep, filename, bucket string, isSingleStep bool) error {
+func (e *S3Executor) runStep(ctx context.Context, run *runctx.Run, step *config.TestStep, isSingleStep bool) (result.Step, error) {
+	sr := result.Step{Name: step.Name}
+
 	// Apply step-level jitter if configured
 	if step.Jitter != nil && step.Jitter.IsEnabled() {
 		maxJitter, _ := step.Jitter.ParseMaxJitter(0) // Steps use duration only, not percentage
 		if maxJitter > 0 {
-			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", testName, step.Name)); err != nil {
-				return fmt.Errorf("step jitter interrupted: %w", err)
+			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", run.Test, step.Name)); err != nil {
+				err = fmt.Errorf("step jitter interrupted: %w", err)
+				sr.Finish(time.Now(), err)
+				return sr, err
 			}
 		}
 	}
@@ -185,99 +257,126 @@ func (e *S3Executor) runStep(ctx context.Context, testName string, step *config.
 	var err error
 	switch step.Name {
 	case "upload":
-		err = e.uploadObject(ctx, testName, bucket, filename, step)
+		err = e.uploadObject(ctx, run, step, &sr)
 	case "download":
-		err = e.downloadObject(ctx, testName, bucket, filename)
+		err = e.downloadObject(ctx, run, step, &sr)
 	case "delete":
-		err = e.deleteObject(ctx, testName, bucket, filename, fileSizeLabel)
+		err = e.deleteObject(ctx, run, fileSizeLabel, &sr)
+	case "list":
+		err = e.listObjects(ctx, run, step, &sr)
+	case "stat":
+		err = e.statObject(ctx, run, step, &sr)
+	case "read-after-write":
+		err = e.readAfterWrite(ctx, run, step, &sr)
+	case "multipart-upload":
+		err = e.multipartUpload(ctx, run, step, &sr)
 	default:
 		err = fmt.Errorf("unknown S3 operation: %s", step.Name)
 	}
 
-	duration := time.Since(stepStart)
-
 	if err != nil {
-		log.Printf("    S3 step %s failed: %v", step.Name, err)
-		e.metrics.RecordTestRun(testName, step.Name, "s3", false, duration)
-		return fmt.Errorf("step execution failed: %w", err)
+		run.StepLog(step.Name).Printf("    S3 step %s failed: %v", step.Name, err)
+		var respErr *smithyhttp.ResponseError
+		if errors.As(err, &respErr) {
+			sr.StatusCode = respErr.HTTPStatusCode()
+		}
+		sr.Finish(stepStart, err)
+		return sr, fmt.Errorf("step execution failed: %w", err)
 	}
 
-	e.metrics.RecordTestRun(testName, step.Name, "s3", true, duration)
-	return nil
+	sr.Finish(stepStart, nil)
+	return sr, nil
 }
 
 // uploadObject uploads a file to S3
-func (e *S3Executor) uploadObject(ctx context.Context, testName, bucket, filename string, step *config.TestStep) error {
+func (e *S3Executor) uploadObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
 	var fileSize int64 = 1024 * 1024 // Default 1MB
-	fileSizeLabel := "1MB"            // Default label
+	fileSizeLabel := "1MB"           // Default label
 	if step.FileSize != nil {
 		fileSize = step.FileSize.Int64()
 		fileSizeLabel = step.FileSize.String()
 	}
 
-	// Generate random data
-	data := make([]byte, fileSize)
-	if _, err := rand.Read(data); err != nil {
-		return fmt.Errorf("failed to generate random data: %w", err)
+	// Generate payload data into a pooled buffer, or a temp file for large
+	// objects. The SDK closes its request body before PutObject returns, so
+	// releasing it afterwards is safe.
+	src, err := payload.New(e.config.GetPayload(step))
+	if err != nil {
+		return err
 	}
+	data, err := newUploadBody(run, src, fileSize)
+	if err != nil {
+		return err
+	}
+	defer data.Release()
 
 	start := time.Now()
 
 	// Prepare PutObject input
 	putInput := &s3.PutObjectInput{
-		Bucket:        aws.String(bucket),
-		Key:           aws.String(filename),
-		Body:          bytes.NewReader(data),
+		Bucket:        aws.String(run.Bucket),
+		Key:           aws.String(run.Filename),
+		Body:          data.Reader(),
 		ContentLength: aws.Int64(fileSize),
 	}
 
-	// Add TTL via metadata if specified
+	// Add user metadata, with TTL as ttl-seconds if specified
 	// Note: Storj S3 gateway doesn't support Expires header for object deletion
-	// TTL must be set at upload time via uplink SDK, not S3 API
-	if step.TTLSeconds != nil && *step.TTLSeconds > 0 {
-		// Store TTL in metadata for reference (actual TTL only works with uplink executor)
-		if putInput.Metadata == nil {
-			putInput.Metadata = make(map[string]string)
-		}
-		putInput.Metadata["ttl-seconds"] = fmt.Sprintf("%d", *step.TTLSeconds)
-	}
+	// TTL must be set at upload time via uplink SDK, not S3 API, so it is only
+	// stored for reference (actual TTL only works with uplink executor)
+	metadata := step.UploadMetadata()
+	putInput.Metadata = metadata
 
 	// Upload to S3
-	_, err := e.s3Client.PutObject(ctx, putInput)
+	putOutput, err := e.s3Client.PutObject(ctx, putInput, e.requestOptions(run))
 
 	duration := time.Since(start)
 
 	if err != nil {
-		e.metrics.RecordStorjUpload(testName, "s3", bucket, fileSizeLabel, duration, fileSize, false)
+		e.metrics.RecordStorjUpload(run, fileSizeLabel, duration, fileSize, false)
 		return fmt.Errorf("S3 PutObject failed: %w", err)
 	}
+	e.recordResponse(run, putOutput.ResultMetadata, sr)
 
 	// Log with TTL info if specified
 	if step.TTLSeconds != nil && *step.TTLSeconds > 0 {
-		log.Printf("    S3 uploaded %s (%d bytes) with TTL %ds in %v", filename, fileSize, *step.TTLSeconds, duration)
+		run.StepLog(step.Name).Printf("    S3 uploaded %s (%d bytes) with TTL %ds in %v", run.Filename, fileSize, *step.TTLSeconds, duration)
 	} else {
-		log.Printf("    S3 uploaded %s (%d bytes) in %v", filename, fileSize, duration)
+		run.StepLog(step.Name).Printf("    S3 uploaded %s (%d bytes) in %v", run.Filename, fileSize, duration)
 	}
-	e.metrics.RecordStorjUpload(testName, "s3", bucket, fileSizeLabel, duration, fileSize, true)
+	sr.Bytes = fileSize
+	e.metrics.RecordStorjUpload(run, fileSizeLabel, duration, fileSize, true)
+	data.recordUpload(run, metadata)
 
 	return nil
 }
 
-// downloadObject downloads a file from S3
-func (e *S3Executor) downloadObject(ctx context.Context, testName, bucket, filename string) error {
+// downloadObject downloads a file from S3. With verify_content set, the
+// bytes are checked against what the run uploaded.
+func (e *S3Executor) downloadObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	var dst io.Writer = io.Discard
+	var verifier *contentVerifier
+	if step.VerifyContent {
+		if verifier = newContentVerifier(run); verifier == nil {
+			return errNotUploaded(run)
+		}
+		dst = verifier
+	}
+
 	start := time.Now()
 
 	// Download from S3
 	result, err := e.s3Client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(filename),
-	})
+		Bucket: aws.String(run.Bucket),
+		Key:    aws.String(run.Filename),
+	}, e.requestOptions(run))
 
 	if err != nil {
-		e.metrics.RecordStorjDownload(testName, "s3", bucket, "", time.Since(start), 0, false)
+		e.metrics.RecordStorjDownload(run, "", time.Since(start), 0, false)
 		return fmt.Errorf("S3 GetObject failed: %w", err)
 	}
 	defer result.Body.Close()
+	e.recordResponse(run, result.ResultMetadata, sr)
 
 	// Log content length from response headers for debugging
 	var expectedSize int64
@@ -286,44 +385,211 @@ func (e *S3Executor) downloadObject(ctx context.Context, testName, bucket, filen
 	}
 
 	// Read the data to measure actual download time
-	bytesRead, err := io.Copy(io.Discard, result.Body)
+	bytesRead, err := io.Copy(dst, result.Body)
 	duration := time.Since(start)
 
 	if err != nil {
-		e.metrics.RecordStorjDownload(testName, "s3", bucket, "", duration, bytesRead, false)
+		e.metrics.RecordStorjDownload(run, "", duration, bytesRead, false)
 		return fmt.Errorf("failed to read S3 object: %w", err)
 	}
 
 	// Warn if bytes read doesn't match expected size
 	if expectedSize > 0 && bytesRead != expectedSize {
-		log.Printf("    WARNING: S3 download size mismatch for %s: expected %d bytes, got %d bytes", filename, expectedSize, bytesRead)
+		run.StepLog(step.Name).Printf("    WARNING: S3 download size mismatch for %s: expected %d bytes, got %d bytes", run.Filename, expectedSize, bytesRead)
+	}
+
+	run.StepLog(step.Name).Printf("    S3 downloaded %s (%d bytes, expected %d) in %v", run.Filename, bytesRead, expectedSize, duration)
+	sr.Bytes = bytesRead
+	e.metrics.RecordStorjDownload(run, "", duration, bytesRead, true)
+
+	if verifier != nil {
+		return verifier.verify(e.metrics, run)
+	}
+	return nil
+}
+
+// readAfterWrite uploads the object, then polls it with HeadObject or
+// GetObject until it is readable, recording the delay as the gateway's
+// read-after-write latency.
+func (e *S3Executor) readAfterWrite(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	method := step.GetPollMethod()
+	if method != config.PollHead && method != config.PollGet {
+		return fmt.Errorf("unknown poll_method %q (expected head or get)", method)
 	}
 
-	log.Printf("    S3 downloaded %s (%d bytes, expected %d) in %v", filename, bytesRead, expectedSize, duration)
-	e.metrics.RecordStorjDownload(testName, "s3", bucket, "", duration, bytesRead, true)
+	if err := e.uploadObject(ctx, run, step, sr); err != nil {
+		return err
+	}
+
+	delay, attempts, err := pollReadable(ctx, step.PollIntervalDuration(), func(ctx context.Context) (bool, error) {
+		if method == config.PollHead {
+			_, err := e.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+				Bucket: aws.String(run.Bucket),
+				Key:    aws.String(run.Filename),
+			}, e.requestOptions(run))
+			return err == nil, err
+		}
+		out, err := e.s3Client.GetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(run.Bucket),
+			Key:    aws.String(run.Filename),
+		}, e.requestOptions(run))
+		if err != nil {
+			return false, err
+		}
+		defer out.Body.Close()
+		_, err = io.Copy(io.Discard, out.Body)
+		return err == nil, err
+	})
+	e.metrics.RecordReadAfterWrite(run, method, delay, err == nil)
+	if err != nil {
+		return err
+	}
 
+	sr.Phases = map[string]float64{"readable": delay.Seconds()}
+	run.StepLog(step.Name).Printf("    S3 %s readable after %v (%d %s attempts)", run.Filename, delay, attempts, method)
+	return nil
+}
+
+// multipartUpload uploads the object in parts with the SDK's multipart API
+func (e *S3Executor) multipartUpload(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	src, err := payload.New(e.config.GetPayload(step))
+	if err != nil {
+		return err
+	}
+	return multipartUpload(ctx, e, e.metrics, run, step, src, sr)
+}
+
+// initiateMultipart starts a multipart upload of the run's object
+func (e *S3Executor) initiateMultipart(ctx context.Context, run *runctx.Run) (string, error) {
+	out, err := e.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(run.Bucket),
+		Key:    aws.String(run.Filename),
+	}, e.requestOptions(run))
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.UploadId), nil
+}
+
+// uploadPart uploads one part and returns its ETag. The SDK closes the body
+// before UploadPart returns, so the caller may release data afterwards.
+func (e *S3Executor) uploadPart(ctx context.Context, run *runctx.Run, uploadID string, number int, data *payload.Buffer) (string, error) {
+	out, err := e.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(run.Bucket),
+		Key:           aws.String(run.Filename),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(int32(number)),
+		Body:          data.Reader(),
+		ContentLength: aws.Int64(int64(len(data.B))),
+	}, e.requestOptions(run))
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(aws.ToString(out.ETag), `"`), nil
+}
+
+// completeMultipart assembles the uploaded parts into the object
+func (e *S3Executor) completeMultipart(ctx context.Context, run *runctx.Run, uploadID string, etags []string, sr *result.Step) error {
+	parts := make([]types.CompletedPart, len(etags))
+	for i, etag := range etags {
+		parts[i] = types.CompletedPart{ETag: aws.String(`"` + etag + `"`), PartNumber: aws.Int32(int32(i + 1))}
+	}
+	out, err := e.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(run.Bucket),
+		Key:             aws.String(run.Filename),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	}, e.requestOptions(run))
+	if err != nil {
+		return err
+	}
+	e.recordResponse(run, out.ResultMetadata, sr)
+	sr.SetOutput("etag", strings.Trim(aws.ToString(out.ETag), `"`))
+	return nil
+}
+
+// abortMultipart discards a multipart upload and its parts
+func (e *S3Executor) abortMultipart(ctx context.Context, run *runctx.Run, uploadID string) error {
+	_, err := e.s3Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(run.Bucket),
+		Key:      aws.String(run.Filename),
+		UploadId: aws.String(uploadID),
+	}, e.requestOptions(run))
+	return err
+}
+
+// listObjects lists one page of the bucket with ListObjectsV2
+func (e *S3Executor) listObjects(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	start := time.Now()
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(run.Bucket),
+		MaxKeys: aws.Int32(int32(step.GetMaxKeys())),
+	}
+	if step.Prefix != "" {
+		input.Prefix = aws.String(step.Prefix)
+	}
+	out, err := e.s3Client.ListObjectsV2(ctx, input, e.requestOptions(run))
+	duration := time.Since(start)
+
+	if err != nil {
+		e.metrics.RecordStorjList(run, 0, 0, false)
+		return fmt.Errorf("S3 ListObjectsV2 failed: %w", err)
+	}
+	e.recordResponse(run, out.ResultMetadata, sr)
+	sr.SetOutput("objects", strconv.Itoa(len(out.Contents)))
+
+	run.StepLog(step.Name).Printf("    S3 listed %d objects in %s in %v", len(out.Contents), run.Bucket, duration)
+	e.metrics.RecordStorjList(run, duration, len(out.Contents), true)
+	return nil
+}
+
+// statObject reads the object's size and user metadata with HeadObject. With
+// verify_metadata set, they are checked against what the run uploaded.
+func (e *S3Executor) statObject(ctx context.Context, run *runctx.Run, step *config.TestStep, sr *result.Step) error {
+	start := time.Now()
+	out, err := e.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(run.Bucket),
+		Key:    aws.String(run.Filename),
+	}, e.requestOptions(run))
+	duration := time.Since(start)
+
+	if err != nil {
+		e.metrics.RecordStorjStat(run, 0, false)
+		return fmt.Errorf("S3 HeadObject failed: %w", err)
+	}
+	e.recordResponse(run, out.ResultMetadata, sr)
+	size := aws.ToInt64(out.ContentLength)
+	sr.SetOutput("size", strconv.FormatInt(size, 10))
+
+	run.StepLog(step.Name).Printf("    S3 stat %s (%d bytes, %d metadata keys) in %v", run.Filename, size, len(out.Metadata), duration)
+	e.metrics.RecordStorjStat(run, duration, true)
+
+	if step.VerifyMetadata {
+		return verifyStat(e.metrics, run, size, out.Metadata)
+	}
 	return nil
 }
 
 // deleteObject deletes a file from S3
-func (e *S3Executor) deleteObject(ctx context.Context, testName, bucket, filename, fileSizeLabel string) error {
+func (e *S3Executor) deleteObject(ctx context.Context, run *runctx.Run, fileSizeLabel string, sr *result.Step) error {
 	start := time.Now()
 
 	// Delete from S3
-	_, err := e.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(filename),
-	})
+	deleteOutput, err := e.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(run.Bucket),
+		Key:    aws.String(run.Filename),
+	}, e.requestOptions(run))
 
 	duration := time.Since(start)
 
 	if err != nil {
-		e.metrics.RecordStorjDelete(testName, "s3", bucket, fileSizeLabel, 0, 0, false)
+		e.metrics.RecordStorjDelete(run, fileSizeLabel, 0, 0, false)
 		return fmt.Errorf("S3 DeleteObject failed: %w", err)
 	}
+	e.recordResponse(run, deleteOutput.ResultMetadata, sr)
 
-	log.Printf("    S3 deleted %s in %v", filename, duration)
-	e.metrics.RecordStorjDelete(testName, "s3", bucket, fileSizeLabel, duration, 1, true)
+	run.Log().Printf("    S3 deleted %s in %v", run.Filename, duration)
+	e.metrics.RecordStorjDelete(run, fileSizeLabel, duration, 1, true)
 
 	return nil
 }
@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// stallWatch fails a transfer whose rate stays below a minimum, like curl's
+// --speed-limit/--speed-time, so a stalled transfer is reported as such
+// instead of waiting out the step timeout
+type stallWatch struct {
+	r       io.Reader
+	n       atomic.Int64
+	stalled atomic.Bool
+	done    chan struct{}
+}
+
+// watchStall wraps r and calls cancel if fewer than minRate bytes per second
+// are read from it over a window of period. Stop must be called when the
+// transfer ends.
+func watchStall(r io.Reader, minRate int64, period time.Duration, cancel context.CancelFunc) *stallWatch {
+	w := &stallWatch{r: r, done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		var last int64
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				n := w.n.Load()
+				if float64(n-last) < float64(minRate)*period.Seconds() {
+					w.stalled.Store(true)
+					cancel()
+					return
+				}
+				last = n
+			}
+		}
+	}()
+	return w
+}
+
+func (w *stallWatch) Read(p []byte) (int, error) {
+	n, err := w.r.Read(p)
+	w.n.Add(int64(n))
+	return n, err
+}
+
+// Stop ends the watch
+func (w *stallWatch) Stop() {
+	close(w.done)
+}
+
+// Stalled reports whether the watch canceled the transfer
+func (w *stallWatch) Stalled() bool {
+	return w.stalled.Load()
+}
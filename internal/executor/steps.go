@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+)
+
+// runSteps runs a gateway test's steps in order, passing outputs to the
+// steps after them. The steps of a parallel group run concurrently, sharing
+// the group's step context, and the group ends when all of them have; a
+// group with a failed step stops the run. Step results are added to res in
+// step order. It returns the first step that failed, if any, with its error.
+func runSteps(ctx context.Context, test *config.Test, run *runctx.Run, res *result.Result, runStep stepRunner) (string, error) {
+	isSingleStep := test.IsSingleStep()
+	total := len(test.Steps)
+	outputs := make(config.StepOutputs, total)
+
+	for _, group := range test.StepGroups() {
+		parallel := ""
+		if len(group) > 1 {
+			parallel = fmt.Sprintf(" (parallel group %s)", test.Steps[group[0]].Parallel)
+		}
+		if !isSingleStep {
+			for _, i := range group {
+				run.StepLog(test.Steps[i].Name).Printf("  [%d/%d] Running: %s%s", i+1, total, test.Steps[i].Name, parallel)
+			}
+		}
+
+		srs := make([]result.Step, len(group))
+		errs := make([]error, len(group))
+		stepCtx, cancelStep := stepContext(ctx, test, group)
+		if len(group) == 1 {
+			srs[0], errs[0] = runStepWithOutputs(stepCtx, runStep, outputs, run, test.Steps[group[0]], isSingleStep)
+		} else {
+			// Each step records its outputs in its own copy, merged in step
+			// order once the group is done
+			groupOutputs := make([]config.StepOutputs, len(group))
+			var wg sync.WaitGroup
+			for g, i := range group {
+				groupOutputs[g] = maps.Clone(outputs)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					srs[g], errs[g] = runStepWithOutputs(stepCtx, runStep, groupOutputs[g], run, test.Steps[i], isSingleStep)
+				}()
+			}
+			wg.Wait()
+			for g, i := range group {
+				id := test.Steps[i].GetID()
+				if out, ok := groupOutputs[g][id]; ok {
+					outputs[id] = out
+				}
+			}
+		}
+		cancelStep()
+
+		failed := ""
+		var firstErr error
+		var alsoFailed []string
+		for g, i := range group {
+			step := test.Steps[i]
+			res.Steps = append(res.Steps, srs[g])
+			if errs[g] == nil {
+				if !isSingleStep {
+					run.StepLog(step.Name).Printf("  [%d/%d] Completed: %s", i+1, total, step.Name)
+				}
+				continue
+			}
+			err := budgetError(ctx, test, errs[g])
+			if !isSingleStep {
+				run.StepLog(step.Name).Printf("  [%d/%d] Failed: %s - %v", i+1, total, step.Name, err)
+			}
+			if firstErr == nil {
+				failed, firstErr = step.Name, err
+			} else {
+				alsoFailed = append(alsoFailed, step.Name)
+			}
+		}
+		if len(alsoFailed) > 0 {
+			firstErr = fmt.Errorf("%w (also failed in parallel: %s)", firstErr, strings.Join(alsoFailed, ", "))
+		}
+		if firstErr != nil {
+			return failed, firstErr
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+)
+
+// TestRunStepsProportionalParallelGroup checks that a parallel group shares
+// one proportional budget: its steps run side by side, so each is budgeted
+// by the group's longest timeout and the steps after it, not as if its
+// siblings ran first.
+func TestRunStepsProportionalParallelGroup(t *testing.T) {
+	test := &config.Test{
+		Name:   "budget",
+		Budget: config.BudgetProportional,
+		Steps: []config.TestStep{
+			{Name: "upload", Timeout: "10s"},
+			{Name: "download", Timeout: "20s", Parallel: "reads"},
+			{Name: "stat", Timeout: "5s", Parallel: "reads"},
+			{Name: "list", Timeout: "20s", Parallel: "reads"},
+			{Name: "delete", Timeout: "10s"},
+		},
+	}
+	// Counting the group as its longest step, the timeouts sum to 40s
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	budgets := make(map[string]time.Duration)
+	runStep := func(ctx context.Context, run *runctx.Run, step *config.TestStep, isSingleStep bool) (result.Step, error) {
+		deadline, _ := ctx.Deadline()
+		mu.Lock()
+		budgets[step.Name] = time.Until(deadline)
+		mu.Unlock()
+		return result.Step{Name: step.Name, Success: true}, nil
+	}
+
+	run := &runctx.Run{ID: "run", Test: test.Name, Start: time.Now()}
+	res := result.New(run)
+	if failed, err := runSteps(ctx, test, run, res, runStep); err != nil {
+		t.Fatalf("runSteps failed at %s: %v", failed, err)
+	}
+
+	// Steps return at once, so each group shares the full 4s with the steps
+	// after it. Budgeted one by one, download would get 20/55 of it, stat
+	// 5/35, and list 20/30.
+	want := map[string]time.Duration{
+		"upload":   time.Second,         // 10/40
+		"download": 8 * time.Second / 3, // 20/30
+		"stat":     8 * time.Second / 3, // The group's budget
+		"list":     8 * time.Second / 3,
+		"delete":   4 * time.Second, // 10/10
+	}
+	for name, budget := range want {
+		if got := budgets[name]; got < budget-100*time.Millisecond || got > budget {
+			t.Errorf("step %s budgeted %v, want %v", name, got, budget)
+		}
+	}
+}
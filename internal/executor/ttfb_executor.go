@@ -0,0 +1,197 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/s3err"
+)
+
+const executorNameTTFB = "ttfb"
+
+// TTFBExecutor checks the time to first byte of a small hot object against a
+// tight threshold. Small-object latency is dominated by request handling,
+// metadata lookups, and caching rather than throughput, so it is measured
+// on its own: the object is uploaded once and read several times per run
+// over kept-alive connections, and the run fails if its median time to first
+// byte exceeds the threshold.
+type TTFBExecutor struct {
+	config  *config.Config
+	metrics *metrics.Collector
+
+	mu     sync.Mutex
+	client *HttpS3Executor
+}
+
+// NewTTFB creates a new TTFB executor.
+func NewTTFB(cfg *config.Config, mc *metrics.Collector) *TTFBExecutor {
+	return &TTFBExecutor{config: cfg, metrics: mc}
+}
+
+// httpClient returns the HTTP S3 executor used for requests, creating it on
+// first use so connections are reused across runs.
+func (e *TTFBExecutor) httpClient() (*HttpS3Executor, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client == nil {
+		c, err := NewHttpS3(e.config, e.metrics)
+		if err != nil {
+			return nil, err
+		}
+		c.name = executorNameTTFB
+		e.client = c
+	}
+	return e.client, nil
+}
+
+func (e *TTFBExecutor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	run := runctx.New(test, executorNameTTFB, e.config.Satellite.Bucket)
+	run.Endpoint, run.Region = e.config.S3.Endpoint, e.config.S3.Region
+	res := result.New(run)
+
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	c, err := e.httpClient()
+	if err != nil {
+		return res.Finish(fmt.Errorf("ttfb test %s: %w", test.Name, err))
+	}
+	if err := c.ensureBucket(ctx, run); err != nil {
+		return res.Finish(fmt.Errorf("failed to ensure bucket %s exists: %w", run.Bucket, err))
+	}
+
+	key, size := test.TTFB.GetKey(), test.TTFB.GetSize()
+	threshold := test.TTFB.ThresholdDuration()
+	run.Log().Printf("Running TTFB test: %s (%s/%s, threshold %v)", test.Name, run.Bucket, key, threshold)
+
+	res.StepsStarting()
+	sr := result.Step{Name: "ttfb"}
+	start := time.Now()
+	err = e.check(ctx, c, run, key, size, test.TTFB.GetRequests(), threshold, &sr)
+	sr.Finish(start, err)
+	res.Steps = append(res.Steps, sr)
+	if err != nil {
+		res.FailedStep = sr.Name
+		return res.Finish(fmt.Errorf("ttfb test %s failed: %w", test.Name, err))
+	}
+	return res.Finish(nil)
+}
+
+// check reads the object n times, uploading it first if it doesn't exist,
+// and compares the median time to first byte with the threshold
+func (e *TTFBExecutor) check(ctx context.Context, c *HttpS3Executor, run *runctx.Run, key string, size config.ByteSize, n int, threshold time.Duration, sr *result.Step) error {
+	ttfbs := make([]time.Duration, 0, n)
+	seeded := false
+	for len(ttfbs) < n {
+		ttfb, found, err := e.get(ctx, c, run, key, size, sr)
+		if err != nil {
+			return err
+		}
+		if !found {
+			switch {
+			case len(ttfbs) > 0:
+				return fmt.Errorf("object %s disappeared after %d reads", key, len(ttfbs))
+			case seeded:
+				return fmt.Errorf("object %s is not readable after its upload", key)
+			}
+			if err := e.seed(ctx, c, run, key, size); err != nil {
+				return fmt.Errorf("failed to upload %s: %w", key, err)
+			}
+			seeded = true
+			run.Log().Printf("    TTFB object %s uploaded (%s)", key, size)
+			continue
+		}
+		ttfbs = append(ttfbs, ttfb)
+	}
+	e.metrics.RecordTTFB(run, ttfbs, threshold)
+
+	slices.Sort(ttfbs)
+	median := latencyPercentile(ttfbs, 50)
+	sr.Phases = map[string]float64{
+		"p50": median.Seconds(),
+		"max": ttfbs[len(ttfbs)-1].Seconds(),
+	}
+	if median > threshold {
+		return &classifiedError{
+			class: "ttfb_sla",
+			msg:   fmt.Sprintf("median time to first byte %v of %d reads exceeds %v", median.Round(time.Microsecond), n, threshold),
+		}
+	}
+	run.Log().Debug("    TTFB %s: median %v, max %v over %d reads", key, median.Round(time.Microsecond), ttfbs[len(ttfbs)-1].Round(time.Microsecond), n)
+	return nil
+}
+
+// get reads the object once and returns its time to first byte. found is
+// false if the object doesn't exist.
+func (e *TTFBExecutor) get(ctx context.Context, c *HttpS3Executor, run *runctx.Run, key string, size config.ByteSize, sr *result.Step) (ttfb time.Duration, found bool, err error) {
+	req, err := c.newRequest(ctx, run, http.MethodGet, c.buildURL(run.Bucket, key), nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := c.signer.Sign(req); err != nil {
+		return 0, false, fmt.Errorf("failed to sign request: %w", err)
+	}
+	tracer := newHTTPTimingTracer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.trace()))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, false, fmt.Errorf("HTTP GET failed: %w", err)
+	}
+	defer resp.Body.Close()
+	e.metrics.RecordServerIdentity(run, metrics.ServerIdentityFromHeader(resp.Header))
+	if resp.StatusCode == http.StatusNotFound {
+		io.Copy(io.Discard, resp.Body)
+		return 0, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		respErr := s3err.FromResponse(resp)
+		sr.RequestID = respErr.RequestID
+		return 0, false, fmt.Errorf("HTTP GET returned %w", respErr)
+	}
+
+	n, err := io.Copy(io.Discard, resp.Body)
+	timings := tracer.toMetrics(time.Now())
+	sr.Bytes += n
+	sr.RequestID = resp.Header.Get("X-Amz-Request-Id")
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read HTTP response: %w", err)
+	}
+	if n != size.Int64() {
+		return 0, false, fmt.Errorf("object %s has %d bytes, expected %d", key, n, size.Int64())
+	}
+	return timings.TTFB, true, nil
+}
+
+// seed uploads the object
+func (e *TTFBExecutor) seed(ctx context.Context, c *HttpS3Executor, run *runctx.Run, key string, size config.ByteSize) error {
+	req, err := c.newRequest(ctx, run, http.MethodPut, c.buildURL(run.Bucket, key), newCanaryContent(key, size.Int64()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size.Int64()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if err := c.signer.Sign(req); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP PUT failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("HTTP PUT returned %w", s3err.FromResponse(resp))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -2,20 +2,20 @@ package executor
 
 import (
 	"context"
-	"crypto/rand"
+	"encoding/json"
 	"fmt"
-	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"github.com/ethanadams/synthetics/internal/config"
 	"github.com/ethanadams/synthetics/internal/jitter"
-	"github.com/ethanadams/synthetics/internal/k6output"
 	"github.com/ethanadams/synthetics/internal/logging"
 	"github.com/ethanadams/synthetics/internal/metrics"
-	"github.com/oklog/ulid/v2"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/subproc"
 )
 
 // UplinkExecutor runs Uplink tests via k6 with xk6-storj extension
@@ -25,6 +25,20 @@ type UplinkExecutor struct {
 	metrics  *metrics.Collector
 }
 
+// uplinkTimeoutGrace is how much earlier than the step timeout the uplink
+// operations of a k6 script time out, so the script records the failure and
+// its latency before the step's deadline kills k6
+const uplinkTimeoutGrace = time.Second
+
+// uplinkOperationTimeout returns the default deadline of each uplink
+// operation of a step, passed to xk6-storj as STORJ_TIMEOUT_MS
+func uplinkOperationTimeout(stepTimeout time.Duration) time.Duration {
+	if stepTimeout > 2*uplinkTimeoutGrace {
+		return stepTimeout - uplinkTimeoutGrace
+	}
+	return stepTimeout
+}
+
 // NewUplink creates a new Uplink executor
 func NewUplink(cfg *config.Config, mc *metrics.Collector) *UplinkExecutor {
 	return &UplinkExecutor{
@@ -34,62 +48,94 @@ func NewUplink(cfg *config.Config, mc *metrics.Collector) *UplinkExecutor {
 	}
 }
 
+// K6Pattern matches the command line of the k6 runs of an UplinkExecutor
+// using the k6 binary, directly or through the limits wrapper
+func K6Pattern(binary string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[/ ])` + regexp.QuoteMeta(filepath.Base(binary)) + ` run `)
+}
+
 // RunTest executes a synthetic test (handles single or multi-step)
-func (e *UplinkExecutor) RunTest(ctx context.Context, test *config.Test) error {
-	log.Printf("Running test: %s", test.Name)
+func (e *UplinkExecutor) RunTest(ctx context.Context, test *config.Test) (*result.Result, error) {
+	logging.With("test_name", test.Name, "executor", "uplink").Printf("Running test: %s", test.Name)
 
 	testStart := time.Now()
 
-	// Generate ULID for this test run (for filename uniqueness)
-	entropy := ulid.Monotonic(rand.Reader, 0)
-	testULID := ulid.MustNew(ulid.Timestamp(testStart), entropy)
-	sharedFilename := test.GetFilename(testULID.String())
-	bucket := test.GetBucket(e.config.Satellite.Bucket)
+	// Bound all steps by the test timeout, if set
+	ctx, cancel := withTestDeadline(ctx, test)
+	defer cancel()
+
+	// Identify this run; overlapping runs of a fixed-filename test take turns.
+	// Satellites are read at startup, so a reloaded test may name one this
+	// executor doesn't know yet.
+	sat, satErr := e.config.GetSatellite(test.Satellite)
+	if satErr != nil {
+		sat = config.SatelliteConfig{Name: test.Satellite, Bucket: e.config.Satellite.Bucket}
+	}
+	run := runctx.New(test, "uplink", sat.Bucket)
+	run.Satellite = sat.GetName()
+	res := result.New(run)
+	if satErr != nil {
+		return res.Finish(fmt.Errorf("test %s not started: %w (satellites require a restart)", test.Name, satErr))
+	}
+	release, err := run.Claim(ctx)
+	if err != nil {
+		return res.Finish(fmt.Errorf("test %s not started: %w", test.Name, err))
+	}
+	defer release()
 
 	isSingleStep := test.IsSingleStep()
 
 	if isSingleStep {
-		log.Printf("Test %s using ULID: %s (filename: %s)", test.Name, testULID.String(), sharedFilename)
+		run.Log().Printf("Test %s using ULID: %s (filename: %s)", test.Name, run.ID, run.Filename)
 	} else {
-		log.Printf("Test %s (%d steps) using ULID: %s (filename: %s)",
-			test.Name, len(test.Steps), testULID.String(), sharedFilename)
+		run.Log().Printf("Test %s (%d steps) using ULID: %s (filename: %s)",
+			test.Name, len(test.Steps), run.ID, run.Filename)
 	}
 
+	res.StepsStarting()
+
 	// Run each step sequentially
 	for i, step := range test.Steps {
 		if !isSingleStep {
-			log.Printf("  [%d/%d] Running: %s", i+1, len(test.Steps), step.Name)
+			run.StepLog(step.Name).Printf("  [%d/%d] Running: %s", i+1, len(test.Steps), step.Name)
 		}
 
-		if err := e.runStep(ctx, test.Name, &step, sharedFilename, testULID.String(), bucket, isSingleStep); err != nil {
+		stepCtx, cancelStep := stepContext(ctx, test, []int{i})
+		sr, err := e.runStep(stepCtx, run, &sat, &step, isSingleStep)
+		res.Steps = append(res.Steps, sr)
+		cancelStep()
+		if err != nil {
+			err = budgetError(ctx, test, err)
 			if !isSingleStep {
-				log.Printf("  [%d/%d] Failed: %s - %v", i+1, len(test.Steps), step.Name, err)
+				run.StepLog(step.Name).Printf("  [%d/%d] Failed: %s - %v", i+1, len(test.Steps), step.Name, err)
 			}
-			e.metrics.RecordTestRun(test.Name, step.Name, "uplink", false, time.Since(testStart))
-			return fmt.Errorf("test %s failed at step %s: %w", test.Name, step.Name, err)
+			res.FailedStep = step.Name
+			return res.Finish(fmt.Errorf("test %s failed at step %s: %w", test.Name, step.Name, err))
 		}
 
 		if !isSingleStep {
-			log.Printf("  [%d/%d] Completed: %s", i+1, len(test.Steps), step.Name)
+			run.StepLog(step.Name).Printf("  [%d/%d] Completed: %s", i+1, len(test.Steps), step.Name)
 		}
 	}
 
 	duration := time.Since(testStart)
-	log.Printf("Test %s completed successfully in %v", test.Name, duration)
-	// For overall test run, use empty action (represents entire test)
-	e.metrics.RecordTestRun(test.Name, "", "uplink", true, duration)
+	run.Log().Printf("Test %s completed successfully in %v", test.Name, duration)
 
-	return nil
+	return res.Finish(nil)
 }
 
 // runStep executes a single test step
-func (e *UplinkExecutor) runStep(ctx context.Context, testName string, step *config.TestStep, sharedFilename, testULID, bucket string, isSingleStep bool) error {
+func (e *UplinkExecutor) runStep(ctx context.Context, run *runctx.Run, sat *config.SatelliteConfig, step *config.TestStep, isSingleStep bool) (result.Step, error) {
+	sr := result.Step{Name: step.Name}
+
 	// Apply step-level jitter if configured
 	if step.Jitter != nil && step.Jitter.IsEnabled() {
 		maxJitter, _ := step.Jitter.ParseMaxJitter(0) // Steps use duration only, not percentage
 		if maxJitter > 0 {
-			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", testName, step.Name)); err != nil {
-				return fmt.Errorf("step jitter interrupted: %w", err)
+			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("step %s/%s", run.Test, step.Name)); err != nil {
+				err = fmt.Errorf("step jitter interrupted: %w", err)
+				sr.Finish(time.Now(), err)
+				return sr, err
 			}
 		}
 	}
@@ -102,10 +148,6 @@ func (e *UplinkExecutor) runStep(ctx context.Context, testName string, step *con
 		fileSizeLabel = step.FileSize.String()
 	}
 
-	// Create temporary file for k6 output
-	outputFile := filepath.Join(os.TempDir(), fmt.Sprintf("k6-output-%s-%s-%d.json", testName, step.Name, time.Now().Unix()))
-	defer os.Remove(outputFile)
-
 	// Set timeout
 	timeout := step.TimeoutDuration()
 	ctx, cancel := context.WithTimeout(ctx, timeout)
@@ -114,21 +156,23 @@ func (e *UplinkExecutor) runStep(ctx context.Context, testName string, step *con
 	// Build k6 command
 	args := []string{
 		"run",
-		"--out", fmt.Sprintf("json=%s", outputFile),
+		"--out", "json=-", // Metric points on stdout, parsed as k6 writes them
 		"--summary-mode=disabled", // Disable end-of-test summary
 		"--no-usage-report",       // No usage reporting
 		"--quiet",                 // Suppress verbose output
 	}
 
-	cmd := exec.CommandContext(ctx, e.k6Binary, append(args, step.Script)...)
+	cmd := subproc.Command(ctx, e.k6Binary, append(args, step.Script)...)
 
 	// Start with base environment - ALWAYS include test metadata
 	env := append(os.Environ(),
-		fmt.Sprintf("STORJ_ACCESS_GRANT=%s", e.config.Satellite.AccessGrant),
-		fmt.Sprintf("STORJ_BUCKET=%s", bucket),
-		fmt.Sprintf("TEST_NAME=%s", testName),
-		fmt.Sprintf("SHARED_FILE=%s", sharedFilename),
-		fmt.Sprintf("TEST_ULID=%s", testULID),
+		fmt.Sprintf("STORJ_ACCESS_GRANT=%s", sat.AccessGrant),
+		fmt.Sprintf("STORJ_BUCKET=%s", run.Bucket),
+		fmt.Sprintf("TEST_NAME=%s", run.Test),
+		fmt.Sprintf("SHARED_FILE=%s", run.Filename),
+		fmt.Sprintf("TEST_ULID=%s", run.ID),
+		fmt.Sprintf("STORJ_USER_AGENT=%s", run.UserAgent(e.config.GetUserAgent())),
+		fmt.Sprintf("STORJ_TIMEOUT_MS=%d", uplinkOperationTimeout(timeout).Milliseconds()),
 	)
 
 	// Add step-specific configuration as environment variables
@@ -147,134 +191,62 @@ func (e *UplinkExecutor) runStep(ctx context.Context, testName string, step *con
 	if step.MaxDelete != nil {
 		env = append(env, fmt.Sprintf("MAX_DELETE=%d", *step.MaxDelete))
 	}
+	if len(step.Metadata) > 0 {
+		metadata, _ := json.Marshal(step.Metadata)
+		env = append(env, fmt.Sprintf("METADATA=%s", metadata))
+	}
+	if step.VerifyMetadata {
+		// The stat script compares what it reads with what the run uploaded
+		want, ok := run.UploadedContent()
+		if !ok {
+			err := fmt.Errorf("verify_metadata: %s was not uploaded earlier in this run", run.Filename)
+			sr.Finish(stepStart, err)
+			return sr, err
+		}
+		expected := want.Metadata
+		if expected == nil {
+			expected = map[string]string{} // Marshaled as {}, not null
+		}
+		metadata, _ := json.Marshal(expected)
+		env = append(env, fmt.Sprintf("EXPECT_SIZE=%d", want.Size), fmt.Sprintf("EXPECT_METADATA=%s", metadata))
+	}
 
 	cmd.Env = env
-
-	// Run the test
-	output, err := cmd.CombinedOutput()
-	duration := time.Since(stepStart)
-
+	release := subproc.Mark(cmd, run.ID)
+
+	// Run the test, recording its metric points as k6 writes them
+	rec := newK6Recorder(e.metrics, run, fileSizeLabel)
+	done := e.metrics.TrackSubprocess("k6")
+	output, err := runK6(cmd, run.StepLog(step.Name), rec.add)
+	done(cmd.ProcessState)
+	release()
 	if err != nil {
-		log.Printf("    Step %s failed: %v", step.Name, err)
+		run.StepLog(step.Name).Printf("    Step %s failed: %v", step.Name, err)
 		if len(output) > 0 {
-			log.Printf("    Output: %s", string(output))
+			run.StepLog(step.Name).Printf("    Output: %s", string(output))
 		}
 
 		// Record metrics
-		e.metrics.RecordTestRun(testName, step.Name, "uplink", false, duration)
-		return fmt.Errorf("step execution failed: %w", err)
+		sr.Finish(stepStart, err)
+		return sr, fmt.Errorf("step execution failed: %w", err)
 	}
 
 	// Log k6 console output if present
 	if len(output) > 0 {
-		log.Printf("    k6 output: %s", string(output))
+		run.StepLog(step.Name).Printf("    k6 output: %s", string(output))
 	}
 
-	// Parse k6 output and update metrics
-	if err := e.parseAndRecordMetrics(outputFile, testName, bucket, fileSizeLabel); err != nil {
-		log.Printf("    Warning: failed to parse k6 output: %v", err)
+	sr.Bytes += rec.finish()
+	if step.Name == "upload" && rec.upload.bytes > 0 {
+		run.RecordContent(&runctx.Content{Size: rec.upload.bytes, Metadata: step.Metadata})
 	}
-
-	e.metrics.RecordTestRun(testName, step.Name, "uplink", true, duration)
-
-	return nil
-}
-
-// parseAndRecordMetrics parses k6 JSON output and records metrics
-func (e *UplinkExecutor) parseAndRecordMetrics(outputFile, testName, bucket, fileSizeLabel string) error {
-	points, err := k6output.ParseJSONOutput(outputFile)
-	if err != nil {
-		return err
-	}
-
-	// Group metrics by name
-	grouped := k6output.GroupMetricsByName(points)
-
-	// Log what metrics were found
-	logging.Debug("    Parsed %d metric points, found metric types: %v", len(points), func() []string {
-		keys := make([]string, 0, len(grouped))
-		for k := range grouped {
-			keys = append(keys, k)
-		}
-		return keys
-	}())
-
-	// Collect upload metrics (duration and bytes) to combine in single call
-	var uploadDuration time.Duration
-	var uploadBytes int64
-	var uploadSuccess = true
-
-	if uploadPoints, ok := grouped["storj_upload_duration_ms"]; ok && len(uploadPoints) > 0 {
-		uploadDuration = time.Duration(uploadPoints[0].Value) * time.Millisecond
-		logging.Debug("    Uplink upload duration from k6: %v (raw value: %v)", uploadDuration, uploadPoints[0].Value)
-	}
-	if uploadBytesPoints, ok := grouped["storj_upload_bytes_total"]; ok && len(uploadBytesPoints) > 0 {
-		uploadBytes = int64(uploadBytesPoints[0].Value)
-	}
-	if uploadSuccessPoints, ok := grouped["storj_upload_success"]; ok && len(uploadSuccessPoints) > 0 {
-		uploadSuccess = uploadSuccessPoints[0].Value > 0
-	}
-
-	// Record upload metrics in single call (so histogram gets both duration and bytes-derived fileSize)
-	if uploadDuration > 0 || uploadBytes > 0 {
-		e.metrics.RecordStorjUpload(testName, "uplink", bucket, fileSizeLabel, uploadDuration, uploadBytes, uploadSuccess)
-	}
-
-	// Collect download metrics (duration and bytes) to combine in single call
-	var downloadDuration time.Duration
-	var downloadBytes int64
-	var downloadSuccess = true
-
-	if downloadPoints, ok := grouped["storj_download_duration_ms"]; ok && len(downloadPoints) > 0 {
-		downloadDuration = time.Duration(downloadPoints[0].Value) * time.Millisecond
-		logging.Debug("    Uplink download duration from k6: %v (raw value: %v)", downloadDuration, downloadPoints[0].Value)
-	}
-	if downloadBytesPoints, ok := grouped["storj_download_bytes_total"]; ok && len(downloadBytesPoints) > 0 {
-		downloadBytes = int64(downloadBytesPoints[0].Value)
-	}
-	if downloadSuccessPoints, ok := grouped["storj_download_success"]; ok && len(downloadSuccessPoints) > 0 {
-		downloadSuccess = downloadSuccessPoints[0].Value > 0
-	}
-
-	// Record download metrics in single call (so histogram gets both duration and bytes-derived fileSize)
-	if downloadDuration > 0 || downloadBytes > 0 {
-		e.metrics.RecordStorjDownload(testName, "uplink", bucket, fileSizeLabel, downloadDuration, downloadBytes, downloadSuccess)
-	}
-
-	// Process delete duration metrics
-	if deletePoints, ok := grouped["storj_delete_duration_ms"]; ok {
-		for _, point := range deletePoints {
-			duration := time.Duration(point.Value) * time.Millisecond
-			logging.Debug("    Uplink delete duration from k6: %v (raw value: %v)", duration, point.Value)
-			e.metrics.RecordStorjDelete(testName, "uplink", bucket, fileSizeLabel, duration, 1, true)
-		}
-	}
-
-	// Process delete success metrics
-	if deleteSuccessPoints, ok := grouped["storj_delete_success"]; ok {
-		for _, point := range deleteSuccessPoints {
-			success := point.Value > 0
-			count := 1 // Each point represents one delete attempt
-			if !success {
-				// Record failure (no duration, count=0)
-				e.metrics.RecordStorjDelete(testName, "uplink", bucket, fileSizeLabel, 0, count, success)
-			}
-		}
-	}
-
-	// Process delete count metrics
-	if deleteCountPoints, ok := grouped["storj_delete_count_total"]; ok {
-		totalDeletes := 0
-		for _, point := range deleteCountPoints {
-			totalDeletes += int(point.Value)
-		}
-		if totalDeletes > 0 {
-			// For count-only metrics, pass empty fileSize and 0 duration
-			e.metrics.RecordStorjDelete(testName, "uplink", bucket, "", 0, totalDeletes, true)
-		}
+	if err := rec.verified(); err != nil {
+		run.StepLog(step.Name).Printf("    Step %s failed: %v", step.Name, err)
+		sr.Finish(stepStart, err)
+		return sr, err
 	}
 
-	log.Printf("Parsed %d metric points from test %s", len(points), testName)
+	sr.Finish(stepStart, nil)
 
-	return nil
+	return sr, nil
 }
@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/ethanadams/synthetics/internal/payload"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/workdir"
+)
+
+// spillThreshold is the object size above which a single-PUT upload is
+// generated into a temp file instead of memory, so multi-GB objects (and
+// their content verification) fit on probes with a few hundred MB of RAM
+const spillThreshold = 64 << 20
+
+// uploadBody is the data of a single-PUT upload: a pooled buffer, or a temp
+// file past spillThreshold. The data is generated before the request, so
+// generating it is never part of the measured transfer.
+type uploadBody struct {
+	size int64
+	buf  *payload.Buffer
+	file *os.File
+	sum  []byte // SHA-256 of the file's data, if the run verifies content
+}
+
+// newUploadBody generates size bytes from src, in memory or in a temp file
+// depending on the size. A file is hashed as it is written if the run
+// verifies content, so its data is never read back into memory.
+func newUploadBody(run *runctx.Run, src payload.Source, size int64) (*uploadBody, error) {
+	if size <= spillThreshold {
+		buf, err := payload.NewBuffer(src, size)
+		if err != nil {
+			return nil, err
+		}
+		return &uploadBody{size: size, buf: buf}, nil
+	}
+	file, sum, err := spillPayload(run, src, size, "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	return &uploadBody{size: size, file: file, sum: sum}, nil
+}
+
+// spillPayload writes size bytes from src to a new temp file, hashing them
+// if the run verifies content. The caller closes and removes the file.
+func spillPayload(run *runctx.Run, src payload.Source, size int64, pattern string) (*os.File, []byte, error) {
+	file, err := os.CreateTemp(workdir.TempDir(), pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	var dst io.Writer = file
+	var h hash.Hash
+	if run.VerifiesContent() {
+		h = sha256.New()
+		dst = io.MultiWriter(file, h)
+	}
+	if _, err := io.Copy(dst, payload.Reader(src, size)); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if h == nil {
+		return file, nil, nil
+	}
+	return file, h.Sum(nil), nil
+}
+
+// Reader returns a new reader of the whole body, e.g. for a request and
+// each of its retries
+func (b *uploadBody) Reader() io.ReadSeekCloser {
+	if b.buf != nil {
+		return b.buf.Reader()
+	}
+	return sectionReadCloser{io.NewSectionReader(b.file, 0, b.size)}
+}
+
+// Release frees the buffer or removes the temp file. The body must not be
+// used afterwards.
+func (b *uploadBody) Release() {
+	if b.buf != nil {
+		b.buf.Release()
+		return
+	}
+	b.file.Close()
+	os.Remove(b.file.Name())
+}
+
+// recordUpload records the uploaded content if the run verifies it
+func (b *uploadBody) recordUpload(run *runctx.Run, metadata map[string]string) {
+	if b.buf != nil {
+		recordUpload(run, b.buf.B, metadata)
+		return
+	}
+	if b.sum != nil {
+		run.RecordContent(&runctx.Content{Size: b.size, Sums: [][]byte{b.sum}, Metadata: metadata})
+	}
+}
+
+// sectionReadCloser is a request body reading part of a file the caller
+// closes; closing the body leaves the file open for retries
+type sectionReadCloser struct {
+	*io.SectionReader
+}
+
+func (sectionReadCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/runctx"
+)
+
+// recordUpload records the SHA-256 and user metadata of an object uploaded
+// in a single PUT, if the run verifies content
+func recordUpload(run *runctx.Run, data []byte, metadata map[string]string) {
+	if !run.VerifiesContent() {
+		return
+	}
+	sum := sha256.Sum256(data)
+	run.RecordContent(&runctx.Content{Size: int64(len(data)), Sums: [][]byte{sum[:]}, Metadata: metadata})
+}
+
+// contentVerifier hashes downloaded bytes and compares them with the content
+// the run uploaded. A multipart upload is hashed part by part, so its parts
+// can be hashed as they are uploaded, in any order.
+type contentVerifier struct {
+	want      *runctx.Content
+	h         hash.Hash
+	size      int64
+	parts     int   // Parts hashed so far
+	partBytes int64 // Bytes of the current part hashed so far
+	bad       int   // First part that differs, or -1
+}
+
+// newContentVerifier returns a verifier of the run's object, or nil if the
+// run didn't upload it
+func newContentVerifier(run *runctx.Run) *contentVerifier {
+	want, ok := run.UploadedContent()
+	if !ok {
+		return nil
+	}
+	return &contentVerifier{want: want, h: sha256.New(), bad: -1}
+}
+
+func (v *contentVerifier) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		chunk := p
+		if v.want.PartSize > 0 && int64(len(chunk)) > v.want.PartSize-v.partBytes {
+			chunk = chunk[:v.want.PartSize-v.partBytes]
+		}
+		v.h.Write(chunk)
+		v.size += int64(len(chunk))
+		v.partBytes += int64(len(chunk))
+		p = p[len(chunk):]
+		if v.partBytes == v.want.PartSize {
+			v.endPart()
+		}
+	}
+	return n, nil
+}
+
+// endPart compares the hash of the current part
+func (v *contentVerifier) endPart() {
+	if v.bad < 0 && (v.parts >= len(v.want.Sums) || !bytes.Equal(v.h.Sum(nil), v.want.Sums[v.parts])) {
+		v.bad = v.parts
+	}
+	v.parts++
+	v.partBytes = 0
+	v.h.Reset()
+}
+
+// verify checks the downloaded bytes written so far. A mismatch fails the
+// step as "corrupt" and counts in synth_verification_failures_total.
+func (v *contentVerifier) verify(mc *metrics.Collector, run *runctx.Run) error {
+	if v.partBytes > 0 || v.parts == 0 {
+		v.endPart()
+	}
+	var msg string
+	switch {
+	case v.size != v.want.Size:
+		msg = fmt.Sprintf("content verification failed: downloaded %d bytes, uploaded %d", v.size, v.want.Size)
+	case v.bad >= 0 && v.want.PartSize > 0:
+		msg = fmt.Sprintf("content verification failed: SHA-256 of part %d (offset %d) differs from the uploaded part", v.bad+1, int64(v.bad)*v.want.PartSize)
+	case v.bad >= 0:
+		msg = "content verification failed: SHA-256 differs from the uploaded object"
+	default:
+		run.Log().Debug("    Verified content of %s (%d bytes)", run.Filename, v.size)
+		return nil
+	}
+	mc.RecordVerificationFailure(run)
+	return &classifiedError{class: canaryCorrupt, msg: msg}
+}
+
+// verifyStat checks the size and user metadata a stat step read against what
+// the run uploaded. Metadata keys are compared case-insensitively, since
+// gateways may change their case. A mismatch fails the step as
+// "metadata_mismatch" and counts in synth_metadata_mismatches_total.
+func verifyStat(mc *metrics.Collector, run *runctx.Run, size int64, metadata map[string]string) error {
+	want, ok := run.UploadedContent()
+	if !ok {
+		return fmt.Errorf("verify_metadata: %s was not uploaded earlier in this run", run.Filename)
+	}
+
+	var diffs []string
+	if size != want.Size {
+		mc.RecordMetadataMismatch(run, "size")
+		diffs = append(diffs, fmt.Sprintf("size %d, uploaded %d", size, want.Size))
+	}
+	got, uploaded := lowerKeys(metadata), lowerKeys(want.Metadata)
+	var keys []string
+	for key, value := range uploaded {
+		if v, ok := got[key]; !ok || v != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range got {
+		if _, ok := uploaded[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		slices.Sort(keys)
+		mc.RecordMetadataMismatch(run, "metadata")
+		for _, key := range keys {
+			diffs = append(diffs, fmt.Sprintf("%s %q, uploaded %q", key, got[key], uploaded[key]))
+		}
+	}
+	if len(diffs) == 0 {
+		run.Log().Debug("    Verified size and %d metadata keys of %s", len(uploaded), run.Filename)
+		return nil
+	}
+	return &classifiedError{
+		class: "metadata_mismatch",
+		msg:   fmt.Sprintf("metadata verification failed for %s: %s", run.Filename, strings.Join(diffs, "; ")),
+	}
+}
+
+// lowerKeys returns metadata with its keys lowercased
+func lowerKeys(metadata map[string]string) map[string]string {
+	lower := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		lower[strings.ToLower(key)] = value
+	}
+	return lower
+}
+
+// errNotUploaded fails a download step with verify_content whose object the
+// run didn't upload, e.g. because the step's key names another object
+func errNotUploaded(run *runctx.Run) error {
+	return fmt.Errorf("verify_content: %s was not uploaded earlier in this run", run.Filename)
+}
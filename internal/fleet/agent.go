@@ -0,0 +1,188 @@
+// Package fleet implements distributed probe mode: agents run tests and push
+// their metrics and finished runs to a central aggregator, which serves the
+// metrics merged with a probe label, the fleet's run history, and a
+// dashboard.
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// metricPrefix selects the families pushed by agents. Process and runtime
+// metrics stay local so they don't collide with the aggregator's own.
+const metricPrefix = "synth"
+
+// Pusher periodically pushes an agent's metrics to the aggregator
+type Pusher struct {
+	url      string
+	probe    string
+	token    string
+	interval time.Duration
+	gatherer prometheus.Gatherer
+	client   *http.Client
+
+	runs      func(since time.Time) []Run // Nil unless WithRuns was called
+	runCursor time.Time                   // Time of the last run pushed
+}
+
+// NewPusher creates a pusher for the given agent configuration
+func NewPusher(cfg config.AgentConfig, gatherer prometheus.Gatherer) (*Pusher, error) {
+	if cfg.AggregatorURL == "" {
+		return nil, fmt.Errorf("agent.aggregator_url is required in agent mode")
+	}
+	if cfg.Probe == "" {
+		return nil, fmt.Errorf("agent.probe is required in agent mode")
+	}
+
+	interval := cfg.PushIntervalDuration()
+	return &Pusher{
+		url:      strings.TrimSuffix(cfg.AggregatorURL, "/") + "/api/v1/push/" + url.PathEscape(cfg.Probe),
+		probe:    cfg.Probe,
+		token:    cfg.Token,
+		interval: interval,
+		gatherer: gatherer,
+		client:   &http.Client{Timeout: interval},
+	}, nil
+}
+
+// WithRuns also pushes the runs finished since the previous push, as
+// returned by runs, for the aggregator's run history
+func (p *Pusher) WithRuns(runs func(since time.Time) []Run) *Pusher {
+	p.runs = runs
+	p.runCursor = time.Now()
+	return p
+}
+
+// Run pushes on every interval until ctx is cancelled, with a final push on shutdown
+func (p *Pusher) Run(ctx context.Context) {
+	log.Printf("Pushing metrics to aggregator %s as probe %s every %v", p.url, p.probe, p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Best-effort final push so the aggregator sees the last results
+			finalCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := p.push(finalCtx); err != nil {
+				log.Printf("Warning: final push to aggregator failed: %v", err)
+			}
+			if err := p.pushRuns(finalCtx); err != nil {
+				log.Printf("Warning: final push of runs to aggregator failed: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := p.push(ctx); err != nil {
+				log.Printf("Warning: push to aggregator failed: %v", err)
+			}
+			if err := p.pushRuns(ctx); err != nil {
+				log.Printf("Warning: push of runs to aggregator failed: %v", err)
+			}
+		}
+	}
+}
+
+// push gathers the synthetics metric families and sends them in text exposition format
+func (p *Pusher) push(ctx context.Context) error {
+	families, err := p.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for _, mf := range families {
+		if !strings.HasPrefix(mf.GetName(), metricPrefix) {
+			continue
+		}
+		if _, err := expfmt.MetricFamilyToText(&buf, mf); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", mf.GetName(), err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("aggregator returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	logging.Debug("Pushed %d bytes of metrics to aggregator", req.ContentLength)
+	return nil
+}
+
+// pushRuns sends the runs finished since the last successful push as JSON.
+// Runs that fail to push are sent again with the next push.
+func (p *Pusher) pushRuns(ctx context.Context) error {
+	if p.runs == nil {
+		return nil
+	}
+	var runs []Run
+	for _, run := range p.runs(p.runCursor) {
+		if run.Time.After(p.runCursor) {
+			runs = append(runs, run)
+		}
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+	if len(runs) > maxPushRuns {
+		runs = runs[len(runs)-maxPushRuns:]
+	}
+	body, err := json.Marshal(runs)
+	if err != nil {
+		return fmt.Errorf("failed to encode runs: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/runs", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("aggregator returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	p.runCursor = runs[len(runs)-1].Time
+	logging.Debug("Pushed %d runs to aggregator", len(runs))
+	return nil
+}
@@ -0,0 +1,252 @@
+package fleet
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+)
+
+// probeLabel is added to every metric pushed by an agent
+const probeLabel = "probe"
+
+// maxPushBytes limits the size of a single push
+const maxPushBytes = 16 << 20
+
+// EvictStaleIntervals is how many stale_after windows a probe may go without
+// pushing before the aggregator forgets it and its run history, so renamed
+// and decommissioned probes don't accumulate
+const EvictStaleIntervals = 12
+
+// Aggregator receives metrics and finished runs from agents. It serves the
+// metrics merged with a probe label (it implements prometheus.Gatherer), and
+// the runs as the fleet's run history and dashboard. The history is kept in
+// memory.
+type Aggregator struct {
+	token      string
+	staleAfter time.Duration
+
+	mu      sync.RWMutex
+	probes  map[string]*probeState
+	history map[string][]Run // Finished runs by probe, oldest first
+}
+
+// probeState holds the most recent push from a probe
+type probeState struct {
+	families map[string]*dto.MetricFamily
+	lastPush time.Time
+	addr     string
+}
+
+// ProbeStatus describes a probe known to the aggregator
+type ProbeStatus struct {
+	Probe    string    `json:"probe"`
+	LastPush time.Time `json:"last_push"`
+	Address  string    `json:"address"`
+	Families int       `json:"families"`
+	Stale    bool      `json:"stale"`
+}
+
+// NewAggregator creates a new aggregator
+func NewAggregator(token string, staleAfter time.Duration) *Aggregator {
+	return &Aggregator{
+		token:      token,
+		staleAfter: staleAfter,
+		probes:     make(map[string]*probeState),
+		history:    make(map[string][]Run),
+	}
+}
+
+// Register adds the aggregator API routes to the mux
+func (a *Aggregator) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/push/{probe}", a.handlePush)
+	mux.HandleFunc("POST /api/v1/push/{probe}/runs", a.handlePushRuns)
+	mux.HandleFunc("GET /api/v1/probes", a.handleListProbes)
+	mux.HandleFunc("GET /api/v1/runs", a.handleListRuns)
+	mux.HandleFunc("GET /dashboard", a.handleDashboard)
+}
+
+// handlePush replaces the stored metrics for a probe
+func (a *Aggregator) handlePush(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	probe := r.PathValue("probe")
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(http.MaxBytesReader(w, r.Body, maxPushBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid metrics: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	if _, ok := a.probes[probe]; !ok {
+		log.Printf("Probe %s connected from %s", probe, r.RemoteAddr)
+	}
+	a.probes[probe] = &probeState{
+		families: families,
+		lastPush: time.Now(),
+		addr:     r.RemoteAddr,
+	}
+	a.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListProbes returns all probes with their last push time
+func (a *Aggregator) handleListProbes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a.Probes()); err != nil {
+		log.Printf("Failed to write API response: %v", err)
+	}
+}
+
+// authorized checks the bearer token. Without a token configured, every
+// push is refused.
+func (a *Aggregator) authorized(r *http.Request) bool {
+	if a.token == "" {
+		return false
+	}
+	want := "Bearer " + a.token
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) == 1
+}
+
+// Evict forgets the probes that have not pushed for EvictStaleIntervals
+// times stale_after, with their run history, and the history of probes that
+// never pushed metrics
+func (a *Aggregator) Evict(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for probe, p := range a.probes {
+		if now.Sub(p.lastPush) > EvictStaleIntervals*a.staleAfter {
+			delete(a.probes, probe)
+			log.Printf("Probe %s forgotten: no push since %s", probe, p.lastPush.Format(time.RFC3339))
+		}
+	}
+	for probe := range a.history {
+		if _, ok := a.probes[probe]; !ok {
+			delete(a.history, probe)
+		}
+	}
+}
+
+// RunEviction evicts forgotten probes every stale_after until ctx is done
+func (a *Aggregator) RunEviction(ctx context.Context) {
+	ticker := time.NewTicker(a.staleAfter)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.Evict(now)
+		}
+	}
+}
+
+// Probes returns the status of all known probes, sorted by name
+func (a *Aggregator) Probes() []ProbeStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]ProbeStatus, 0, len(a.probes))
+	for name, p := range a.probes {
+		result = append(result, ProbeStatus{
+			Probe:    name,
+			LastPush: p.lastPush,
+			Address:  p.addr,
+			Families: len(p.families),
+			Stale:    now.Sub(p.lastPush) > a.staleAfter,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Probe < result[j].Probe })
+	return result
+}
+
+// Gather merges the metrics of all non-stale probes, adding a probe label.
+// A synth_probe_last_push_timestamp_seconds gauge is exported for every known probe.
+func (a *Aggregator) Gather() ([]*dto.MetricFamily, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	now := time.Now()
+	merged := make(map[string]*dto.MetricFamily)
+
+	lastPush := &dto.MetricFamily{
+		Name: proto.String("synth_probe_last_push_timestamp_seconds"),
+		Help: proto.String("Unix time of the most recent metrics push from each probe"),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+
+	for probe, p := range a.probes {
+		lastPush.Metric = append(lastPush.Metric, &dto.Metric{
+			Label: []*dto.LabelPair{{Name: proto.String(probeLabel), Value: proto.String(probe)}},
+			Gauge: &dto.Gauge{Value: proto.Float64(float64(p.lastPush.UnixNano()) / 1e9)},
+		})
+
+		if now.Sub(p.lastPush) > a.staleAfter {
+			continue
+		}
+
+		for name, mf := range p.families {
+			out, ok := merged[name]
+			if !ok {
+				out = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+				merged[name] = out
+			}
+			if out.GetType() != mf.GetType() {
+				log.Printf("Warning: probe %s sent %s as %s, expected %s; skipping", probe, name, mf.GetType(), out.GetType())
+				continue
+			}
+			for _, m := range mf.Metric {
+				out.Metric = append(out.Metric, withProbe(m, probe))
+			}
+		}
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(merged)+1)
+	if len(lastPush.Metric) > 0 {
+		families = append(families, lastPush)
+	}
+	for _, mf := range merged {
+		families = append(families, mf)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+	return families, nil
+}
+
+// withProbe returns a copy of m labelled with the probe name (replacing any existing probe label)
+func withProbe(m *dto.Metric, probe string) *dto.Metric {
+	labels := make([]*dto.LabelPair, 0, len(m.Label)+1)
+	for _, lp := range m.Label {
+		if lp.GetName() != probeLabel {
+			labels = append(labels, lp)
+		}
+	}
+	labels = append(labels, &dto.LabelPair{Name: proto.String(probeLabel), Value: proto.String(probe)})
+	sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+	return &dto.Metric{
+		Label:       labels,
+		Gauge:       m.Gauge,
+		Counter:     m.Counter,
+		Summary:     m.Summary,
+		Untyped:     m.Untyped,
+		Histogram:   m.Histogram,
+		TimestampMs: m.TimestampMs,
+	}
+}
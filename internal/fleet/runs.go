@@ -0,0 +1,212 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxPushRuns limits the runs sent in a single push
+const maxPushRuns = 1000
+
+// maxProbeRuns is how many runs the aggregator keeps per probe
+const maxProbeRuns = 1000
+
+// Run is a finished run of a test on a probe, as pushed by its agent
+type Run struct {
+	Probe           string    `json:"probe"` // Set by the aggregator from the push URL
+	Test            string    `json:"test"`
+	RunID           string    `json:"run_id,omitempty"`
+	Time            time.Time `json:"time"`
+	Success         bool      `json:"success"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Trigger         string    `json:"trigger,omitempty"` // Empty for scheduled runs
+	Error           string    `json:"error,omitempty"`
+}
+
+// RunFilter selects runs from the history. Zero values match everything.
+type RunFilter struct {
+	Probe  string
+	Test   string
+	Failed bool // Only failed runs
+	Since  time.Time
+	Limit  int // Most recent runs to return
+}
+
+// handlePushRuns appends a probe's finished runs to the history
+func (a *Aggregator) handlePushRuns(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	probe := r.PathValue("probe")
+	var runs []Run
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushBytes)).Decode(&runs); err != nil {
+		http.Error(w, fmt.Sprintf("invalid runs: %v", err), http.StatusBadRequest)
+		return
+	}
+	a.AddRuns(probe, runs)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddRuns appends runs of a probe to the history, keeping the most recent
+// maxProbeRuns
+func (a *Aggregator) AddRuns(probe string, runs []Run) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	history := a.history[probe]
+	for _, run := range runs {
+		run.Probe = probe
+		history = append(history, run)
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Time.Before(history[j].Time) })
+	if len(history) > maxProbeRuns {
+		history = append([]Run(nil), history[len(history)-maxProbeRuns:]...)
+	}
+	a.history[probe] = history
+}
+
+// Runs returns the runs matching f across the fleet, oldest first
+func (a *Aggregator) Runs(f RunFilter) []Run {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	out := []Run{}
+	for probe, history := range a.history {
+		if f.Probe != "" && probe != f.Probe {
+			continue
+		}
+		for _, run := range history {
+			if f.Test != "" && run.Test != f.Test {
+				continue
+			}
+			if f.Failed && run.Success {
+				continue
+			}
+			if !f.Since.IsZero() && run.Time.Before(f.Since) {
+				continue
+			}
+			out = append(out, run)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+// handleListRuns returns the fleet's run history, oldest first. Supports the
+// query parameters probe, test, failed, since (RFC 3339), and limit.
+func (a *Aggregator) handleListRuns(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := RunFilter{Probe: q.Get("probe"), Test: q.Get("test"), Limit: 100}
+	if v := q.Get("failed"); v != "" {
+		failed, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid failed: %v", err), http.StatusBadRequest)
+			return
+		}
+		filter.Failed = failed
+	}
+	if v := q.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a.Runs(filter)); err != nil {
+		log.Printf("Failed to write API response: %v", err)
+	}
+}
+
+// dashboardProbe is a probe's row group on the dashboard
+type dashboardProbe struct {
+	ProbeStatus
+	Tests []Run // Latest run of each test, by name
+}
+
+// dashboard is the data of the dashboard page
+type dashboard struct {
+	Probes   []dashboardProbe
+	Failures []Run // Most recent failed runs, newest first
+}
+
+// dashboardPage is the HTML rendering of /dashboard
+var dashboardPage = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ago":     func(t time.Time) string { return time.Since(t).Round(time.Second).String() + " ago" },
+	"seconds": func(s float64) string { return strconv.FormatFloat(s, 'f', 2, 64) + "s" },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<title>Synthetics Fleet</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 0.4em 1em; text-align: left; border-bottom: 1px solid #ddd; }
+.ok { color: #1a7f37; } .stale { color: #bf8700; } .failed { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>Synthetics Fleet</h1>
+{{if .Probes}}<table>
+<tr><th>Probe</th><th>Last push</th><th>Test</th><th>Last run</th><th>Result</th><th>Duration</th></tr>
+{{range .Probes}}{{$p := .}}{{if .Tests}}{{range $i, $t := .Tests}}<tr>{{if not $i}}<td rowspan="{{len $p.Tests}}">{{$p.Probe}}</td><td rowspan="{{len $p.Tests}}" class="{{if $p.Stale}}stale{{else}}ok{{end}}">{{ago $p.LastPush}}</td>{{end}}<td>{{$t.Test}}</td><td>{{ago $t.Time}}</td><td class="{{if $t.Success}}ok{{else}}failed{{end}}">{{if $t.Success}}passed{{else}}failed{{end}}</td><td>{{seconds $t.DurationSeconds}}</td></tr>
+{{end}}{{else}}<tr><td>{{.Probe}}</td><td class="{{if .Stale}}stale{{else}}ok{{end}}">{{ago .LastPush}}</td><td colspan="4">No runs pushed yet</td></tr>
+{{end}}{{end}}</table>{{else}}<p>No probes have pushed yet.</p>{{end}}
+<h2>Recent failures</h2>
+{{if .Failures}}<table>
+<tr><th>Time</th><th>Probe</th><th>Test</th><th>Run</th><th>Error</th></tr>
+{{range .Failures}}<tr><td>{{ago .Time}}</td><td>{{.Probe}}</td><td>{{.Test}}</td><td>{{.RunID}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{else}}<p>No failures.</p>{{end}}
+</body>
+</html>
+`))
+
+// handleDashboard renders each probe's freshness and the latest run of each
+// of its tests, with the fleet's most recent failures
+func (a *Aggregator) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	var d dashboard
+	for _, status := range a.Probes() {
+		latest := map[string]Run{}
+		for _, run := range a.Runs(RunFilter{Probe: status.Probe}) {
+			latest[run.Test] = run
+		}
+		p := dashboardProbe{ProbeStatus: status}
+		for _, run := range latest {
+			p.Tests = append(p.Tests, run)
+		}
+		sort.Slice(p.Tests, func(i, j int) bool { return p.Tests[i].Test < p.Tests[j].Test })
+		d.Probes = append(d.Probes, p)
+	}
+	failures := a.Runs(RunFilter{Failed: true, Limit: 20})
+	for i := len(failures) - 1; i >= 0; i-- {
+		d.Failures = append(d.Failures, failures[i])
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardPage.Execute(w, d); err != nil {
+		log.Printf("Failed to write dashboard: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package k6output
 import (
 	"bufio"
 	"encoding/json"
+	"io"
 	"os"
 	"time"
 )
@@ -22,6 +23,69 @@ type MetricPoint struct {
 	Tags   map[string]string
 }
 
+// pointLine is the part of a k6 JSON output line that Stream decodes
+type pointLine struct {
+	Type   string `json:"type"`
+	Metric string `json:"metric"`
+	Data   struct {
+		Time  string         `json:"time"`
+		Value float64        `json:"value"`
+		Tags  map[string]any `json:"tags"`
+	} `json:"data"`
+}
+
+// Stream reads k6 JSON output (one JSON object per line, e.g. from
+// "--out json=-" while k6 runs) and calls fn with each metric point as it is
+// read, so a long output is never held in memory. Lines aren't limited in
+// length; lines that aren't valid JSON are skipped. It returns the number of
+// points read.
+func Stream(r io.Reader, fn func(MetricPoint)) (int, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	points := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if point, ok := parseLine(line); ok {
+				fn(point)
+				points++
+			}
+		}
+		if err == io.EOF {
+			return points, nil
+		}
+		if err != nil {
+			return points, err
+		}
+	}
+}
+
+// parseLine returns the metric point of a k6 JSON output line, if it is one
+func parseLine(line []byte) (MetricPoint, bool) {
+	var metric pointLine
+	if err := json.Unmarshal(line, &metric); err != nil {
+		return MetricPoint{}, false
+	}
+	// We're interested in "Point" type metrics
+	if metric.Type != "Point" {
+		return MetricPoint{}, false
+	}
+
+	point := MetricPoint{
+		Metric: metric.Metric,
+		Value:  metric.Data.Value,
+		Tags:   make(map[string]string, len(metric.Data.Tags)),
+	}
+	if t, err := time.Parse(time.RFC3339Nano, metric.Data.Time); err == nil {
+		point.Time = t
+	}
+	for k, v := range metric.Data.Tags {
+		if strVal, ok := v.(string); ok {
+			point.Tags[k] = strVal
+		}
+	}
+	return point, true
+}
+
 // ParseJSONOutput parses k6 JSON output file and extracts metric points
 func ParseJSONOutput(path string) ([]MetricPoint, error) {
 	file, err := os.Open(path)
@@ -31,52 +95,11 @@ func ParseJSONOutput(path string) ([]MetricPoint, error) {
 	defer file.Close()
 
 	var points []MetricPoint
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		var metric K6Metric
-		if err := json.Unmarshal(scanner.Bytes(), &metric); err != nil {
-			// Skip lines that aren't valid JSON
-			continue
-		}
-
-		// We're interested in "Point" type metrics
-		if metric.Type == "Point" {
-			point := MetricPoint{
-				Metric: metric.Metric,
-				Tags:   make(map[string]string),
-			}
-
-			// Extract time
-			if timeValue, ok := metric.Data["time"].(string); ok {
-				t, err := time.Parse(time.RFC3339Nano, timeValue)
-				if err == nil {
-					point.Time = t
-				}
-			}
-
-			// Extract value
-			if value, ok := metric.Data["value"].(float64); ok {
-				point.Value = value
-			}
-
-			// Extract tags
-			if tags, ok := metric.Data["tags"].(map[string]interface{}); ok {
-				for k, v := range tags {
-					if strVal, ok := v.(string); ok {
-						point.Tags[k] = strVal
-					}
-				}
-			}
-
-			points = append(points, point)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
+	if _, err := Stream(file, func(point MetricPoint) {
+		points = append(points, point)
+	}); err != nil {
 		return nil, err
 	}
-
 	return points, nil
 }
 
@@ -1,63 +1,174 @@
+// Package logging writes the probe's log lines through log/slog: as JSON
+// objects for log pipelines (logging.format: json), or as text lines in the
+// standard log format. Lines about a run carry its test_name, executor, ulid,
+// bucket, and step as fields; see Logger.
 package logging
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
-// Level represents the logging level
-type Level int
-
+// Log formats
 const (
-	LevelDebug Level = iota
-	LevelInfo
-	LevelWarn
-	LevelError
+	FormatJSON = "json"
+	FormatText = "text"
 )
 
-var currentLevel = LevelInfo
+// level is the minimum level of the Debug, Info, Warn, and Error lines.
+// Lines of the standard log package are always written, as before levels.
+var level = new(slog.LevelVar)
+
+// handler writes every line; see Setup
+var handler atomic.Pointer[slog.Handler]
+
+func init() {
+	setHandler(newTextHandler(os.Stderr))
+}
+
+// setHandler makes h write every line, including the standard log package's
+func setHandler(h slog.Handler) {
+	handler.Store(&h)
+	log.SetFlags(0)
+	log.SetOutput(stdWriter{})
+}
+
+// Setup sets the level and format ("json" or "text") of the log. JSON lines
+// have time, level, and msg keys, followed by the line's fields.
+func Setup(lvl, format string) {
+	opts := &slog.HandlerOptions{ReplaceAttr: trimMessage}
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		setHandler(slog.NewJSONHandler(os.Stderr, opts))
+	default:
+		setHandler(newTextHandler(os.Stderr))
+	}
+	SetLevel(lvl)
+}
+
+// trimMessage drops the indentation text lines nest step details with
+func trimMessage(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.MessageKey {
+		a.Value = slog.StringValue(strings.TrimLeft(a.Value.String(), " "))
+	}
+	return a
+}
 
 // SetLevel sets the global logging level from a string
-func SetLevel(level string) {
-	switch strings.ToLower(level) {
+func SetLevel(lvl string) {
+	switch strings.ToLower(lvl) {
 	case "debug":
-		currentLevel = LevelDebug
+		level.Set(slog.LevelDebug)
 	case "info":
-		currentLevel = LevelInfo
+		level.Set(slog.LevelInfo)
 	case "warn", "warning":
-		currentLevel = LevelWarn
+		level.Set(slog.LevelWarn)
 	case "error":
-		currentLevel = LevelError
+		level.Set(slog.LevelError)
 	default:
-		currentLevel = LevelInfo
+		level.Set(slog.LevelInfo)
 	}
-	log.Printf("Log level set to: %s", strings.ToLower(level))
+	log.Printf("Log level set to: %s", strings.ToLower(lvl))
+}
+
+// Logger writes lines with fields. The zero Logger has none.
+type Logger struct {
+	args []any // Key-value pairs, as for slog.Logger.With
+}
+
+// With returns a logger adding fields, given as key-value pairs, to its lines
+func With(args ...any) Logger {
+	return Logger{args: args}
+}
+
+// With returns a logger adding more fields to the logger's lines
+func (l Logger) With(args ...any) Logger {
+	return Logger{args: append(slices.Clip(l.args), args...)}
+}
+
+// Printf logs a message like log.Printf, whatever the level: at WARN level
+// if it starts with "Warning:", otherwise at INFO level
+func (l Logger) Printf(format string, v ...any) {
+	msg := fmt.Sprintf(format, v...)
+	l.handle(levelOf(msg), msg)
 }
 
 // Debug logs a message at DEBUG level
-func Debug(format string, v ...interface{}) {
-	if currentLevel <= LevelDebug {
-		log.Printf(format, v...)
+func (l Logger) Debug(format string, v ...any) {
+	l.write(slog.LevelDebug, fmt.Sprintf(format, v...))
+}
+
+// Info logs a message at INFO level
+func (l Logger) Info(format string, v ...any) {
+	l.write(slog.LevelInfo, fmt.Sprintf(format, v...))
+}
+
+// Warn logs a message at WARN level
+func (l Logger) Warn(format string, v ...any) {
+	l.write(slog.LevelWarn, fmt.Sprintf(format, v...))
+}
+
+// Error logs a message at ERROR level
+func (l Logger) Error(format string, v ...any) {
+	l.write(slog.LevelError, fmt.Sprintf(format, v...))
+}
+
+// write logs a message with the logger's fields, if its level is enabled
+func (l Logger) write(lvl slog.Level, msg string) {
+	if lvl < level.Level() {
+		return
+	}
+	l.handle(lvl, msg)
+}
+
+// handle logs a message with the logger's fields
+func (l Logger) handle(lvl slog.Level, msg string) {
+	r := slog.NewRecord(time.Now(), lvl, msg, 0)
+	r.Add(l.args...)
+	_ = (*handler.Load()).Handle(context.Background(), r)
+}
+
+// levelOf returns the level of a log.Printf message
+func levelOf(msg string) slog.Level {
+	trimmed := strings.TrimLeft(msg, " ")
+	if strings.HasPrefix(trimmed, "Warning:") || strings.HasPrefix(trimmed, "WARNING:") {
+		return slog.LevelWarn
 	}
+	return slog.LevelInfo
+}
+
+// Debug logs a message at DEBUG level
+func Debug(format string, v ...interface{}) {
+	Logger{}.Debug(format, v...)
 }
 
 // Info logs a message at INFO level
 func Info(format string, v ...interface{}) {
-	if currentLevel <= LevelInfo {
-		log.Printf(format, v...)
-	}
+	Logger{}.Info(format, v...)
 }
 
 // Warn logs a message at WARN level
 func Warn(format string, v ...interface{}) {
-	if currentLevel <= LevelWarn {
-		log.Printf(format, v...)
-	}
+	Logger{}.Warn(format, v...)
 }
 
 // Error logs a message at ERROR level
 func Error(format string, v ...interface{}) {
-	if currentLevel <= LevelError {
-		log.Printf(format, v...)
-	}
+	Logger{}.Error(format, v...)
+}
+
+// stdWriter writes the standard log package's lines through the handler
+type stdWriter struct{}
+
+func (stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	Logger{}.handle(levelOf(msg), msg)
+	return len(p), nil
 }
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"math"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/result"
+)
+
+// Target types of synthetics_availability_ratio
+const (
+	targetEndpoint  = "endpoint"
+	targetSatellite = "satellite"
+)
+
+type availabilityTarget struct {
+	kind, name string
+}
+
+// availability is a success ratio in which each run's weight halves every
+// half-life, so the ratio reflects the last few half-lives of runs across all
+// of a target's tests, however often each runs. Decay scales both counts
+// alike, so the ratio only changes when a run is recorded.
+type availability struct {
+	success, total float64
+	updated        time.Time
+}
+
+func (a *availability) observe(now time.Time, success bool, halfLife time.Duration) float64 {
+	if !a.updated.IsZero() {
+		decay := math.Exp2(-now.Sub(a.updated).Seconds() / halfLife.Seconds())
+		a.success *= decay
+		a.total *= decay
+	}
+	a.updated = now
+	a.total++
+	if success {
+		a.success++
+	}
+	return a.success / a.total
+}
+
+// SetAvailabilityHalfLife sets how fast past runs stop counting towards
+// synthetics_availability_ratio
+func (c *Collector) SetAvailabilityHalfLife(halfLife time.Duration) {
+	c.availMu.Lock()
+	defer c.availMu.Unlock()
+	c.availHalfLife = halfLife
+}
+
+// recordAvailability rolls a run up into the availability of its endpoint
+// and satellite. Runs against several endpoints (compare) or none (rtt)
+// aren't rolled up.
+func (c *Collector) recordAvailability(res *result.Result) {
+	c.availMu.Lock()
+	defer c.availMu.Unlock()
+	for _, target := range []availabilityTarget{{targetEndpoint, res.Endpoint}, {targetSatellite, res.Satellite}} {
+		if target.name == "" {
+			continue
+		}
+		a := c.avail[target]
+		if a == nil {
+			a = &availability{}
+			c.avail[target] = a
+		}
+		c.gauge(c.availabilityRatio, target.kind, target.name).Set(a.observe(time.Now(), res.Success, c.availHalfLife))
+	}
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMaxSeries is the default number of label combinations (series) a
+// metric of a Collector may have
+const DefaultMaxSeries = 10000
+
+// Metrics for series past a metric's limit, which record nothing. They are
+// never registered.
+var (
+	discardCounter  = prometheus.NewCounter(prometheus.CounterOpts{Name: "discarded"})
+	discardGauge    = prometheus.NewGauge(prometheus.GaugeOpts{Name: "discarded"})
+	discardObserver = prometheus.ObserverFunc(func(float64) {})
+)
+
+// seriesGuard bounds the series of each metric, so a label value that varies
+// by run (e.g. a templated bucket or filename) can't grow Prometheus' memory
+// without limit. Series past the limit are dropped and counted.
+type seriesGuard struct {
+	mu      sync.Mutex
+	limit   int
+	series  map[prometheus.Collector]map[string]struct{} // Label values joined by labelSep
+	names   map[prometheus.Collector]string
+	dropped *prometheus.CounterVec
+}
+
+func newSeriesGuard(dropped *prometheus.CounterVec) *seriesGuard {
+	return &seriesGuard{
+		limit:   DefaultMaxSeries,
+		series:  make(map[prometheus.Collector]map[string]struct{}),
+		names:   make(map[prometheus.Collector]string),
+		dropped: dropped,
+	}
+}
+
+// admit reports whether a series of vec may be recorded: it exists already
+// or the metric is below its limit. The first series dropped of a metric is
+// logged.
+func (g *seriesGuard) admit(vec prometheus.Collector, labels []string) bool {
+	key := strings.Join(labels, labelSep)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	series := g.series[vec]
+	if series == nil {
+		series = make(map[string]struct{})
+		g.series[vec] = series
+	}
+	if _, ok := series[key]; ok {
+		return true
+	}
+	if len(series) < g.limit {
+		series[key] = struct{}{}
+		return true
+	}
+
+	name, warned := g.names[vec]
+	if !warned {
+		name = metricName(vec)
+		g.names[vec] = name
+		log.Printf("Warning: metric %s reached its limit of %d series, dropping new label combinations (first: %s)",
+			name, g.limit, strings.Join(labels, ", "))
+	}
+	g.dropped.WithLabelValues(name).Inc()
+	return false
+}
+
+// forget notes that a series of vec was deleted
+func (g *seriesGuard) forget(vec prometheus.Collector, labels ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.series[vec], strings.Join(labels, labelSep))
+}
+
+// forgetTest notes that the series of a test were deleted from vec, whose
+// first label is test_name
+func (g *seriesGuard) forgetTest(vec prometheus.Collector, testName string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	prefix := testName + labelSep
+	for key := range g.series[vec] {
+		if key == testName || strings.HasPrefix(key, prefix) {
+			delete(g.series[vec], key)
+		}
+	}
+}
+
+// setLimit sets the number of series each metric may have
+func (g *seriesGuard) setLimit(limit int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limit = limit
+}
+
+// descName extracts the metric name from a Desc, whose String is the only
+// accessor of it
+var descName = regexp.MustCompile(`fqName: "([^"]*)"`)
+
+// metricName returns the name of the metric a vec collects
+func metricName(vec prometheus.Collector) string {
+	ch := make(chan *prometheus.Desc, 1)
+	go func() {
+		vec.Describe(ch)
+		close(ch)
+	}()
+	name := "unknown"
+	for desc := range ch {
+		if m := descName.FindStringSubmatch(desc.String()); m != nil {
+			name = m[1]
+		}
+	}
+	return name
+}
+
+// SetMaxSeries sets the number of series (label combinations) each metric
+// may have; later series are dropped and counted in
+// synth_metric_series_dropped_total. Non-positive values keep the current
+// limit (default DefaultMaxSeries).
+func (c *Collector) SetMaxSeries(limit int) {
+	if limit > 0 {
+		c.guard.setLimit(limit)
+	}
+}
+
+// counter returns the series of a counter vec, or a discarded counter if the
+// metric is at its series limit
+func (c *Collector) counter(vec *prometheus.CounterVec, labels ...string) prometheus.Counter {
+	if !c.guard.admit(vec, labels) {
+		return discardCounter
+	}
+	return vec.WithLabelValues(labels...)
+}
+
+// gauge returns the series of a gauge vec, or a discarded gauge if the
+// metric is at its series limit
+func (c *Collector) gauge(vec *prometheus.GaugeVec, labels ...string) prometheus.Gauge {
+	if !c.guard.admit(vec, labels) {
+		return discardGauge
+	}
+	return vec.WithLabelValues(labels...)
+}
+
+// observer returns the series of a histogram vec, or a discarded observer if
+// the metric is at its series limit
+func (c *Collector) observer(vec *prometheus.HistogramVec, labels ...string) prometheus.Observer {
+	if !c.guard.admit(vec, labels) {
+		return discardObserver
+	}
+	return vec.WithLabelValues(labels...)
+}
@@ -2,18 +2,66 @@ package metrics
 
 import (
 	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ethanadams/synthetics/internal/config"
 	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/subproc"
+	"github.com/ethanadams/synthetics/internal/version"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-// Collector manages Prometheus metrics for synthetic tests
+// Collector manages Prometheus metrics for synthetic tests. Operation metrics
+// are recorded against a *runctx.Run, which supplies the test, executor,
+// endpoint, and bucket labels, so new dimensions are added here rather than
+// at every executor call site.
+//
+// A Collector is safe for concurrent use: every method may be called from
+// any goroutine (cron runs, parallel steps, the expiry loop, scrapes). The
+// Prometheus vectors synchronize themselves; the Collector's own state is
+// guarded by the mutexes below, each owning the fields after it. The only
+// nesting is mu inside gaugeMu: gauge writes check under gaugeMu that their
+// test wasn't unregistered, so runs still in flight when a test is removed
+// don't re-create its series, and every exported series is tracked for
+// expiry.
 type Collector struct {
 	// Test execution metrics
 	testRunsTotal   *prometheus.CounterVec
 	testRunDuration *prometheus.HistogramVec
+	testRetries     *prometheus.CounterVec
+	testErrors      *prometheus.CounterVec
+	diskBudgetSkips *prometheus.CounterVec
+
+	// Scheduled runs that fired while the test's previous run was in flight
+	overlappingRuns *prometheus.CounterVec
+
+	// End-to-end duration of completed multi-step workflows
+	journeyDuration *prometheus.HistogramVec
+
+	// Last run state per test (restored from the scheduler state file)
+	testLastRun             *prometheus.GaugeVec
+	testLastSuccess         *prometheus.GaugeVec
+	testConsecutiveFailures *prometheus.GaugeVec
+
+	// Delay between a scheduled run's cron time and its actual start
+	scheduleDrift *prometheus.HistogramVec
+	startDelay    *prometheus.HistogramVec
+
+	// Recency-weighted success ratio per endpoint and satellite
+	availabilityRatio *prometheus.GaugeVec
+
+	// Config loads and hot reloads
+	configReloads     *prometheus.CounterVec
+	configLastSuccess prometheus.Gauge
 
 	// Unified Storj operation metrics
 	storjDuration         *prometheus.HistogramVec
@@ -27,6 +75,189 @@ type Collector struct {
 	// Live/instant metrics (Gauges for real-time visibility)
 	lastDuration  *prometheus.GaugeVec
 	lastHTTPPhase *prometheus.GaugeVec
+
+	// TLS handshake details (for HTTP S3 executor)
+	tlsConnections *prometheus.CounterVec
+	tlsChecks      *prometheus.CounterVec
+
+	// Gateway identity (info metric, one series per endpoint)
+	serverInfo *prometheus.GaugeVec
+
+	// Time from PUT completion until the object is first readable
+	readAfterWrite      *prometheus.HistogramVec
+	readAfterWriteTotal *prometheus.CounterVec
+
+	// Multipart upload requests (initiate, each part, complete)
+	multipartDuration *prometheus.HistogramVec
+	multipartTotal    *prometheus.CounterVec
+
+	// Downloads whose content differs from what the run uploaded
+	verificationFailures *prometheus.CounterVec
+	metadataMismatches   *prometheus.CounterVec
+
+	// Request signing cost of the probe itself
+	signDuration *prometheus.HistogramVec
+
+	// Authenticated HEAD benchmark (head-bench step)
+	headBench     *prometheus.HistogramVec
+	headBenchRate *prometheus.GaugeVec
+
+	// Ranged reads at random offsets (range-seek step)
+	rangeSeek *prometheus.HistogramVec
+
+	// Objects returned by the latest list step
+	listObjects *prometheus.GaugeVec
+
+	// Pairwise step latency deltas for compare tests
+	compareDelta *prometheus.GaugeVec
+
+	// Anomaly detection metrics
+	anomalyScore    *prometheus.GaugeVec
+	anomalyBaseline *prometheus.GaugeVec
+	anomalies       *prometheus.CounterVec
+
+	// Bakeoff metrics
+	bakeoffRuns     *prometheus.CounterVec
+	bakeoffDuration *prometheus.GaugeVec
+	bakeoffRelative *prometheus.GaugeVec
+
+	// Round-trip time and packet loss baseline (rtt executor)
+	rtt       *prometheus.HistogramVec
+	rttLast   *prometheus.GaugeVec
+	rttLoss   *prometheus.GaugeVec
+	rttProbes *prometheus.CounterVec
+
+	// Long-lived canary objects (canary executor)
+	canaryChecks *prometheus.CounterVec
+	canaryAge    *prometheus.GaugeVec
+	canaryTTFB   *prometheus.HistogramVec
+
+	// First-byte SLA of small hot objects (ttfb executor)
+	ttfb          *prometheus.HistogramVec
+	ttfbRequests  *prometheus.CounterVec
+	ttfbThreshold *prometheus.GaugeVec
+
+	// Network path traces (mtr/traceroute)
+	pathTraces  *prometheus.CounterVec
+	pathHops    *prometheus.GaugeVec
+	pathHopRTT  *prometheus.GaugeVec
+	pathHopLoss *prometheus.GaugeVec
+
+	// Running k6/curl subprocesses, and their duration and resource usage
+	subprocesses       *prometheus.GaugeVec
+	subprocessDuration *prometheus.HistogramVec
+	subprocessMaxRSS   *prometheus.HistogramVec
+	subprocessKills    *prometheus.CounterVec
+	orphansKilled      *prometheus.CounterVec
+
+	// Series limit of every metric above
+	guard *seriesGuard
+
+	// Per-test options (tag labels, verbosity), and the shadow comparison
+	// results are also fed to, as the shadow side or the active one
+	mu         sync.RWMutex
+	tests      map[string]testOptions
+	removed    map[string]bool // Tests unregistered and not registered again since
+	comparison *ShadowComparison
+	shadow     bool
+
+	// Last server identity seen per endpoint
+	serverMu   sync.Mutex
+	lastServer map[string]ServerIdentity
+
+	// Hop count of the last path trace per target
+	pathMu       sync.Mutex
+	lastPathHops map[string]int
+
+	// Last update of each live gauge series, for aging out stale ones
+	gaugeMu   sync.Mutex
+	gaugeSeen map[gaugeSeries]time.Time
+
+	// Decayed run counts behind synthetics_availability_ratio
+	availMu       sync.Mutex
+	availHalfLife time.Duration
+	avail         map[availabilityTarget]*availability
+}
+
+// TLSInfo holds the parameters negotiated in a TLS handshake
+type TLSInfo struct {
+	Version     string // e.g. "TLS 1.3"
+	CipherSuite string // e.g. "TLS_AES_128_GCM_SHA256"
+	ALPN        string // Negotiated application protocol (e.g. "h2", "http/1.1")
+	Resumed     bool   // Whether the session was resumed
+}
+
+// PathHop summarizes one hop of a network path trace
+type PathHop struct {
+	RTT  time.Duration // Average round-trip time
+	Loss float64       // Fraction of probes lost (0-1)
+}
+
+// ServerIdentity holds the identity headers returned by a gateway
+type ServerIdentity struct {
+	Server string // Server header
+	Via    string // Via header (proxies/CDN hops)
+	PoP    string // Point of presence (CDN edge location)
+}
+
+// popHeaders lists headers that identify the serving edge location, in priority order
+var popHeaders = []string{"X-Amz-Cf-Pop", "X-Served-By", "X-Pop", "CF-Ray"}
+
+// ServerIdentityFromHeader extracts server identity from response headers
+func ServerIdentityFromHeader(h http.Header) ServerIdentity {
+	id := ServerIdentity{
+		Server: h.Get("Server"),
+		Via:    h.Get("Via"),
+	}
+	for _, name := range popHeaders {
+		value := h.Get(name)
+		if value == "" {
+			continue
+		}
+		// CF-Ray has the form "<ray-id>-<pop>"
+		if name == "CF-Ray" {
+			if i := strings.LastIndex(value, "-"); i >= 0 {
+				value = value[i+1:]
+			}
+		}
+		id.PoP = value
+		break
+	}
+	return id
+}
+
+// Verbosity controls which optional metrics are emitted for a test
+type Verbosity int
+
+const (
+	// VerbosityMinimal emits only run, duration, and success/count metrics
+	VerbosityMinimal Verbosity = iota
+	// VerbosityStandard adds bytes counters and live gauges
+	VerbosityStandard
+	// VerbosityDetailed adds HTTP phase timings (default)
+	VerbosityDetailed
+)
+
+// ParseVerbosity converts a config value to a Verbosity (default: detailed)
+func ParseVerbosity(s string) (Verbosity, error) {
+	switch strings.ToLower(s) {
+	case "minimal":
+		return VerbosityMinimal, nil
+	case "standard":
+		return VerbosityStandard, nil
+	case "detailed", "":
+		return VerbosityDetailed, nil
+	default:
+		return VerbosityDetailed, fmt.Errorf("unknown metrics verbosity '%s' (supported: minimal, standard, detailed)", s)
+	}
+}
+
+// testOptions holds per-test labeling and verbosity settings
+type testOptions struct {
+	tenant     string // Added as the tenant label by WithTenants
+	tags       string // Comma-joined, sorted
+	verbosity  Verbosity
+	staleAfter time.Duration // Age out live gauges not updated for this long (0 = never)
 }
 
 // HTTPTimings holds detailed HTTP timing breakdown
@@ -37,78 +268,887 @@ type HTTPTimings struct {
 	TTFB         time.Duration // Time to first byte (from request sent to first response byte)
 	Transfer     time.Duration // Data transfer time
 	Total        time.Duration
+	ConnReused   bool // Request was sent on an existing (keep-alive) connection
+}
+
+// connLabel returns the conn label value for the timings
+// Phases returns the non-zero phase durations in seconds, keyed by the
+// phase label used in synth_http_timing_seconds
+func (t HTTPTimings) Phases() map[string]float64 {
+	phases := make(map[string]float64)
+	for phase, d := range map[string]time.Duration{
+		"dns":      t.DNSLookup,
+		"connect":  t.TCPConnect,
+		"tls":      t.TLSHandshake,
+		"ttfb":     t.TTFB,
+		"transfer": t.Transfer,
+		"total":    t.Total,
+	} {
+		if d > 0 {
+			phases[phase] = d.Seconds()
+		}
+	}
+	return phases
+}
+
+func (t HTTPTimings) connLabel() string {
+	if t.ConnReused {
+		return "reused"
+	}
+	return "new"
 }
 
-// NewCollector creates a new metrics collector
+// NewCollector creates a new metrics collector registered with the default
+// Prometheus registry
 func NewCollector() *Collector {
+	return NewCollectorWith(prometheus.DefaultRegisterer)
+}
+
+// NewCollectorWith creates a metrics collector registered with reg, e.g. a
+// separate registry whose series are served with an extra label
+func NewCollectorWith(reg prometheus.Registerer) *Collector {
+	factory := promauto.With(reg)
+	dropped := factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "synth_metric_series_dropped_total",
+			Help: "Recordings dropped because their metric reached its series limit (metrics.max_series)",
+		},
+		[]string{"metric"},
+	)
 	return &Collector{
-		testRunsTotal: promauto.NewCounterVec(
+		testRunsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "synthetics_test_runs_total",
 				Help: "Total number of synthetic test runs",
 			},
-			[]string{"test_name", "step_name", "executor", "status"},
+			[]string{"test_name", "step_name", "executor", "tags", "status"},
 		),
-		testRunDuration: promauto.NewHistogramVec(
+		testRunDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "synthetics_test_duration_seconds",
 				Help:    "Duration of synthetic test runs",
 				Buckets: prometheus.DefBuckets,
 			},
-			[]string{"test_name", "step_name", "executor"},
+			[]string{"test_name", "step_name", "executor", "tags"},
 		),
-		storjDuration: promauto.NewHistogramVec(
+		journeyDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synthetics_journey_duration_seconds",
+				Help:    "Time spent in the steps of a completed multi-step test (the user journey), excluding jitter and waits between steps",
+				Buckets: []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300, 600},
+			},
+			[]string{"test_name", "executor", "tags"},
+		),
+		storjDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "synth_duration_seconds",
 				Help:    "Duration of Storj operations (upload, download, etc.)",
 				Buckets: []float64{0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 30.0},
 			},
-			[]string{"test_name", "action", "executor", "bucket", "file_size"},
+			[]string{"test_name", "action", "executor", "bucket", "satellite", "file_size"},
 		),
-		storjBytes: promauto.NewCounterVec(
+		storjBytes: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "synth_bytes_total",
 				Help: "Total bytes transferred (uploaded/downloaded) to/from Storj",
 			},
-			[]string{"test_name", "action", "executor", "bucket"},
+			[]string{"test_name", "action", "executor", "bucket", "satellite"},
 		),
-		storjOperationCount: promauto.NewCounterVec(
+		storjOperationCount: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "synth_operation_count_total",
 				Help: "Total count of Storj operations",
 			},
-			[]string{"test_name", "action", "executor", "bucket"},
+			[]string{"test_name", "action", "executor", "bucket", "satellite"},
 		),
-		storjOperationSuccess: promauto.NewCounterVec(
+		storjOperationSuccess: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "synth_operation_success_total",
 				Help: "Total successful Storj operations",
 			},
-			[]string{"test_name", "action", "executor", "status"},
+			[]string{"test_name", "action", "executor", "satellite", "status"},
 		),
-		httpTiming: promauto.NewHistogramVec(
+		httpTiming: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "synth_http_timing_seconds",
 				Help:    "Granular HTTP timing breakdown (dns, connect, tls, ttfb, transfer)",
 				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0},
 			},
-			[]string{"test_name", "action", "executor", "phase"},
+			[]string{"test_name", "action", "executor", "phase", "conn"},
 		),
-		lastDuration: promauto.NewGaugeVec(
+		lastDuration: factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "synth_last_duration_seconds",
 				Help: "Duration of the most recent operation (live/instant value)",
 			},
 			[]string{"test_name", "action", "executor"},
 		),
-		lastHTTPPhase: promauto.NewGaugeVec(
+		lastHTTPPhase: factory.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "synth_last_http_phase_seconds",
 				Help: "Most recent HTTP phase timing (live/instant value)",
 			},
 			[]string{"test_name", "action", "executor", "phase"},
 		),
+		tlsConnections: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_tls_connections_total",
+				Help: "TLS handshakes by negotiated version, cipher suite, ALPN protocol, and session resumption",
+			},
+			[]string{"test_name", "executor", "version", "cipher", "alpn", "resumed"},
+		),
+		tlsChecks: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_tls_check_total",
+				Help: "Certificate chain/hostname/revocation check results (ok, dial, chain, expired, hostname, revoked, revocation_unavailable)",
+			},
+			[]string{"test_name", "executor", "result"},
+		),
+		signDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_sign_seconds",
+				Help:    "Time spent signing requests (SigV4) by signing key source (cached, derived) and payload mode (signed, unsigned)",
+				Buckets: []float64{0.000005, 0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.01},
+			},
+			[]string{"executor", "key", "payload"},
+		),
+		headBench: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_head_bench_seconds",
+				Help:    "Latency of each authenticated HEAD request in a head-bench step",
+				Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0},
+			},
+			[]string{"test_name", "executor"},
+		),
+		headBenchRate: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_head_bench_requests_per_second",
+				Help: "Request rate of the latest head-bench step",
+			},
+			[]string{"test_name", "executor"},
+		),
+		rangeSeek: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_range_seek_seconds",
+				Help:    "Latency of each ranged read at a random offset in a range-seek step, to the last byte",
+				Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0},
+			},
+			[]string{"test_name", "executor"},
+		),
+		listObjects: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_last_list_objects",
+				Help: "Objects returned by the latest list step",
+			},
+			[]string{"test_name", "executor"},
+		),
+		serverInfo: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_server_info",
+				Help: "Identity headers (Server, Via, PoP) most recently returned by each gateway endpoint (always 1)",
+			},
+			[]string{"endpoint", "server", "via", "pop"},
+		),
+		readAfterWrite: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_read_after_write_seconds",
+				Help:    "Delay between an upload completing and the object first being readable",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0},
+			},
+			[]string{"test_name", "executor", "method"},
+		),
+		readAfterWriteTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_read_after_write_total",
+				Help: "Read-after-write probes by outcome (failure: not readable before the step timeout)",
+			},
+			[]string{"test_name", "executor", "method", "status"},
+		),
+		multipartDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_multipart_request_duration_seconds",
+				Help:    "Duration of the requests of multipart uploads (initiate, upload_part, complete)",
+				Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0, 60.0},
+			},
+			[]string{"test_name", "executor", "request"},
+		),
+		multipartTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_multipart_requests_total",
+				Help: "Requests of multipart uploads by outcome",
+			},
+			[]string{"test_name", "executor", "request", "status"},
+		),
+		verificationFailures: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_verification_failures_total",
+				Help: "Downloads with verify_content whose SHA-256 differs from the content the run uploaded",
+			},
+			[]string{"test_name", "executor"},
+		),
+		metadataMismatches: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_metadata_mismatches_total",
+				Help: "Stat steps with verify_metadata whose object size or user metadata differs from what the run uploaded",
+			},
+			[]string{"test_name", "executor", "field"},
+		),
+		compareDelta: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_compare_delta_seconds",
+				Help: "Step duration of endpoint_b minus endpoint_a from the most recent compare run",
+			},
+			[]string{"test_name", "step_name", "endpoint_a", "endpoint_b"},
+		),
+		anomalyScore: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_anomaly_score",
+				Help: "Standard deviations between the duration of the test's latest successful run and its baseline (negative when faster)",
+			},
+			[]string{"test_name"},
+		),
+		anomalyBaseline: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_anomaly_baseline_seconds",
+				Help: "Rolling baseline (EWMA) of the test's successful run durations",
+			},
+			[]string{"test_name"},
+		),
+		anomalies: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_anomalies_total",
+				Help: "Runs whose duration deviated from the test's baseline by more than anomaly.threshold standard deviations",
+			},
+			[]string{"test_name", "direction"},
+		),
+		bakeoffRuns: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_bakeoff_runs_total",
+				Help: "Executor runs of bakeoff tests by outcome",
+			},
+			[]string{"test_name", "executor", "status"},
+		),
+		bakeoffDuration: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_bakeoff_duration_seconds",
+				Help: "Run duration of each executor in the most recent bakeoff run",
+			},
+			[]string{"test_name", "executor"},
+		),
+		bakeoffRelative: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_bakeoff_relative_duration",
+				Help: "Run duration of each executor over the fastest one's in the most recent bakeoff run (1 = fastest)",
+			},
+			[]string{"test_name", "executor"},
+		),
+		testRetries: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synthetics_test_retries_total",
+				Help: "Whole-test retries after a failure, by retry attempt and outcome",
+			},
+			[]string{"test_name", "attempt", "status"},
+		),
+		testErrors: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synthetics_test_errors_total",
+				Help: "Failed test steps by error class (S3 error code, timeout, tls, ...)",
+			},
+			[]string{"test_name", "step_name", "executor", "error_class"},
+		),
+		diskBudgetSkips: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synthetics_disk_budget_skips_total",
+				Help: "Runs skipped because they would exceed the work directory disk budget",
+			},
+			[]string{"test_name"},
+		),
+		overlappingRuns: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synthetics_overlapping_runs_total",
+				Help: "Scheduled runs that fired while the test's previous run was still in flight, by the concurrency policy applied (allow, forbid, replace)",
+			},
+			[]string{"test_name", "policy"},
+		),
+		testLastRun: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synthetics_test_last_run_timestamp_seconds",
+				Help: "Unix time the test last ran",
+			},
+			[]string{"test_name"},
+		),
+		testLastSuccess: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synthetics_test_last_success_timestamp_seconds",
+				Help: "Unix time the test last succeeded",
+			},
+			[]string{"test_name"},
+		),
+		testConsecutiveFailures: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synthetics_test_consecutive_failures",
+				Help: "Number of consecutive failed runs of the test (0 after a success)",
+			},
+			[]string{"test_name"},
+		),
+		configReloads: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_config_reload_total",
+				Help: "Config loads and reloads by status (success, fetch_error, invalid, apply_error)",
+			},
+			[]string{"status"},
+		),
+		configLastSuccess: factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "synth_config_last_success_timestamp",
+				Help: "Unix time the config in effect was last successfully loaded or reloaded",
+			},
+		),
+		availabilityRatio: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synthetics_availability_ratio",
+				Help: "Success ratio of all tests against an endpoint or satellite, each run weighted by recency",
+			},
+			[]string{"target_type", "target"},
+		),
+		scheduleDrift: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synthetics_schedule_drift_seconds",
+				Help:    "Time from a scheduled run's cron time until it started, including jitter and waiting for a run slot",
+				Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+			},
+			[]string{"test_name"},
+		),
+		startDelay: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_start_delay_seconds",
+				Help:    "Time from a scheduled run's cron time until its first step started: jitter, waiting for a run slot, and executor setup such as bucket checks",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+			},
+			[]string{"test_name", "executor"},
+		),
+		rtt: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_rtt_seconds",
+				Help:    "Round-trip time of each successful probe (TCP connect or ICMP echo)",
+				Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0},
+			},
+			[]string{"test_name", "target", "method"},
+		),
+		rttLast: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_rtt_last_seconds",
+				Help: "Average round-trip time of the most recent run's probes",
+			},
+			[]string{"test_name", "target", "method"},
+		),
+		rttLoss: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_packet_loss_ratio",
+				Help: "Fraction of probes lost in the most recent run (0-1)",
+			},
+			[]string{"test_name", "target", "method"},
+		),
+		rttProbes: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_rtt_probes_total",
+				Help: "Round-trip time probes by outcome (success, lost)",
+			},
+			[]string{"test_name", "target", "method", "status"},
+		),
+		canaryChecks: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_canary_checks_total",
+				Help: "Canary object checks by result (ok, seeded, missing, corrupt, error)",
+			},
+			[]string{"test_name", "object", "result"},
+		),
+		canaryAge: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_canary_age_seconds",
+				Help: "Age of each canary object (time since it was last written) at its latest check",
+			},
+			[]string{"test_name", "object"},
+		),
+		canaryTTFB: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_canary_ttfb_seconds",
+				Help:    "Time to first byte of canary downloads by object age bucket (fresh, 1d, 7d, 30d, 90d+)",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0},
+			},
+			[]string{"test_name", "object", "age"},
+		),
+		ttfb: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_ttfb_seconds",
+				Help:    "Time to first byte of each GET of a ttfb test's small hot object",
+				Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.015, 0.025, 0.05, 0.075, 0.1, 0.15, 0.25, 0.5, 1.0},
+			},
+			[]string{"test_name"},
+		),
+		ttfbRequests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_ttfb_requests_total",
+				Help: "GETs of ttfb tests by whether their time to first byte met the test's threshold (met, breached)",
+			},
+			[]string{"test_name", "sla"},
+		),
+		ttfbThreshold: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_ttfb_threshold_seconds",
+				Help: "Time to first byte threshold of each ttfb test",
+			},
+			[]string{"test_name"},
+		),
+		pathTraces: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_path_traces_total",
+				Help: "Network path traces (mtr/traceroute) by trigger (interval, failure) and outcome",
+			},
+			[]string{"target", "trigger", "status"},
+		),
+		pathHops: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_path_hops",
+				Help: "Number of hops in the most recent path trace to the target",
+			},
+			[]string{"target"},
+		),
+		pathHopRTT: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_path_hop_rtt_seconds",
+				Help: "Average round-trip time to each hop in the most recent path trace",
+			},
+			[]string{"target", "hop"},
+		),
+		pathHopLoss: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_path_hop_loss_ratio",
+				Help: "Fraction of probes lost at each hop in the most recent path trace",
+			},
+			[]string{"target", "hop"},
+		),
+		subprocesses: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "synth_probe_subprocesses",
+				Help: "Number of running subprocesses (k6, curl) started by executors",
+			},
+			[]string{"command"},
+		),
+		subprocessDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_probe_subprocess_duration_seconds",
+				Help:    "Wall-clock duration of subprocesses",
+				Buckets: prometheus.ExponentialBuckets(0.01, 4, 10), // 10ms to ~43m
+			},
+			[]string{"command"},
+		),
+		subprocessMaxRSS: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "synth_probe_subprocess_max_rss_bytes",
+				Help:    "Peak resident memory of subprocesses",
+				Buckets: prometheus.ExponentialBuckets(1<<20, 4, 9), // 1MiB to 64GiB
+			},
+			[]string{"command"},
+		),
+		subprocessKills: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_probe_subprocess_kills_total",
+				Help: "Subprocesses terminated by a signal (timeout, resource limit, OOM killer)",
+			},
+			[]string{"command", "signal"},
+		),
+		orphansKilled: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "synth_probe_orphaned_subprocesses_killed_total",
+				Help: "Orphaned subprocesses of ended runs or exited probes that were killed",
+			},
+			[]string{"command"},
+		),
+		guard:        newSeriesGuard(dropped),
+		tests:        make(map[string]testOptions),
+		removed:      make(map[string]bool),
+		lastServer:   make(map[string]ServerIdentity),
+		gaugeSeen:    make(map[gaugeSeries]time.Time),
+		lastPathHops: make(map[string]int),
+
+		availHalfLife: config.DefaultAvailabilityHalfLife,
+		avail:         make(map[availabilityTarget]*availability),
+	}
+}
+
+// RecordTLSConnection records the negotiated parameters of a TLS handshake
+func (c *Collector) RecordTLSConnection(run *runctx.Run, info TLSInfo) {
+	if !c.enabled(run.Test, VerbosityDetailed) {
+		return
 	}
+	alpn := info.ALPN
+	if alpn == "" {
+		alpn = "none"
+	}
+	c.counter(c.tlsConnections, run.Test, run.Executor, info.Version, info.CipherSuite, alpn, strconv.FormatBool(info.Resumed)).Inc()
+}
+
+// RecordTLSCheck records the result class of a certificate validation check
+func (c *Collector) RecordTLSCheck(run *runctx.Run, class string) {
+	c.counter(c.tlsChecks, run.Test, run.Executor, class).Inc()
+}
+
+// RecordCompareDelta records the latency difference between two endpoints for a step
+func (c *Collector) RecordCompareDelta(run *runctx.Run, stepName, endpointA, endpointB string, delta time.Duration) {
+	c.setGauge(c.compareDelta, delta.Seconds(), run.Test, stepName, endpointA, endpointB)
+}
+
+// Anomaly directions
+const (
+	AnomalySlow = "slow"
+	AnomalyFast = "fast"
+)
+
+// RecordAnomalyScore records how far a successful run's duration is from the
+// test's baseline, in standard deviations, and the baseline
+func (c *Collector) RecordAnomalyScore(testName string, score float64, baseline time.Duration) {
+	c.setGauge(c.anomalyScore, score, testName)
+	c.setGauge(c.anomalyBaseline, baseline.Seconds(), testName)
+}
+
+// RecordAnomaly counts a run flagged as anomalous, in direction AnomalySlow
+// or AnomalyFast
+func (c *Collector) RecordAnomaly(testName, direction string) {
+	c.counter(c.anomalies, testName, direction).Inc()
+}
+
+// RecordBakeoff records the executor runs of a bakeoff run. The gauges of an
+// executor that failed are deleted, so they never show a stale comparison.
+func (c *Collector) RecordBakeoff(run *runctx.Run, entries []result.BakeoffEntry) {
+	for _, entry := range entries {
+		status := "success"
+		if !entry.Success {
+			status = "failure"
+		}
+		c.counter(c.bakeoffRuns, run.Test, entry.Executor, status).Inc()
+		if !entry.Success {
+			c.deleteGauge(c.bakeoffDuration, run.Test, entry.Executor)
+			c.deleteGauge(c.bakeoffRelative, run.Test, entry.Executor)
+			continue
+		}
+		c.setGauge(c.bakeoffDuration, entry.DurationSeconds, run.Test, entry.Executor)
+		c.setGauge(c.bakeoffRelative, entry.Relative, run.Test, entry.Executor)
+	}
+}
+
+// RecordReadAfterWrite records how long an uploaded object took to become
+// readable. The delay is only observed when it did become readable.
+func (c *Collector) RecordReadAfterWrite(run *runctx.Run, method string, delay time.Duration, success bool) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	c.counter(c.readAfterWriteTotal, run.Test, run.Executor, method, status).Inc()
+	if success {
+		c.observer(c.readAfterWrite, run.Test, run.Executor, method).Observe(delay.Seconds())
+	}
+}
+
+// Multipart upload requests
+const (
+	MultipartInitiate   = "initiate"
+	MultipartUploadPart = "upload_part"
+	MultipartComplete   = "complete"
+	MultipartAbort      = "abort"
+)
+
+// RecordMultipart records one request of a multipart upload. Each part is
+// recorded separately, so the part latency distribution shows stragglers.
+func (c *Collector) RecordMultipart(run *runctx.Run, request string, duration time.Duration, success bool) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	c.counter(c.multipartTotal, run.Test, run.Executor, request, status).Inc()
+	if success {
+		c.observer(c.multipartDuration, run.Test, run.Executor, request).Observe(duration.Seconds())
+	}
+}
+
+// RecordVerificationFailure records a download whose content differs from
+// what the run uploaded
+func (c *Collector) RecordVerificationFailure(run *runctx.Run) {
+	c.counter(c.verificationFailures, run.Test, run.Executor).Inc()
+}
+
+// RecordMetadataMismatch records a stat step that read a different size or
+// user metadata ("size" or "metadata" field) than the run uploaded
+func (c *Collector) RecordMetadataMismatch(run *runctx.Run, field string) {
+	c.counter(c.metadataMismatches, run.Test, run.Executor, field).Inc()
+}
+
+// RecordSign records the time spent signing one request. key is "cached" or
+// "derived" and payload is "signed" or "unsigned".
+func (c *Collector) RecordSign(executor, key, payload string, duration time.Duration) {
+	c.observer(c.signDuration, executor, key, payload).Observe(duration.Seconds())
+}
+
+// RecordHeadBench records the latency of each request of a head-bench step
+// and the request rate it achieved
+func (c *Collector) RecordHeadBench(run *runctx.Run, latencies []time.Duration, elapsed time.Duration) {
+	h := c.observer(c.headBench, run.Test, run.Executor)
+	for _, d := range latencies {
+		h.Observe(d.Seconds())
+	}
+	if elapsed > 0 {
+		c.setGauge(c.headBenchRate, float64(len(latencies))/elapsed.Seconds(), run.Test, run.Executor)
+	}
+}
+
+// RecordRangeSeek records the latency of each read of a range-seek step
+func (c *Collector) RecordRangeSeek(run *runctx.Run, latencies []time.Duration) {
+	h := c.observer(c.rangeSeek, run.Test, run.Executor)
+	for _, d := range latencies {
+		h.Observe(d.Seconds())
+	}
+}
+
+// RecordRTT records the round-trip times of one run's probes to a target.
+// sent is the number of probes sent, including lost ones.
+func (c *Collector) RecordRTT(run *runctx.Run, target, method string, rtts []time.Duration, sent int) {
+	if sent == 0 {
+		return
+	}
+	var sum time.Duration
+	for _, d := range rtts {
+		c.observer(c.rtt, run.Test, target, method).Observe(d.Seconds())
+		sum += d
+	}
+	c.counter(c.rttProbes, run.Test, target, method, "success").Add(float64(len(rtts)))
+	c.counter(c.rttProbes, run.Test, target, method, "lost").Add(float64(sent - len(rtts)))
+	c.setGauge(c.rttLoss, float64(sent-len(rtts))/float64(sent), run.Test, target, method)
+	if len(rtts) > 0 {
+		c.setGauge(c.rttLast, (sum / time.Duration(len(rtts))).Seconds(), run.Test, target, method)
+	}
+}
+
+// RecordCanaryCheck records the result of checking a canary object. age is
+// skipped when unknown (zero).
+func (c *Collector) RecordCanaryCheck(run *runctx.Run, object, outcome string, age time.Duration) {
+	c.counter(c.canaryChecks, run.Test, object, outcome).Inc()
+	if age > 0 {
+		c.setGauge(c.canaryAge, age.Seconds(), run.Test, object)
+	}
+}
+
+// canaryAgeBuckets are the lower bounds of the age buckets of
+// synth_canary_ttfb_seconds, oldest first
+var canaryAgeBuckets = []struct {
+	label string
+	min   time.Duration
+}{
+	{"90d+", 90 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"1d", 24 * time.Hour},
+}
+
+// CanaryAgeBucket returns the age bucket label of an object written age ago:
+// "fresh" under a day, otherwise the largest of 1d, 7d, 30d and 90d+ it has
+// reached
+func CanaryAgeBucket(age time.Duration) string {
+	for _, b := range canaryAgeBuckets {
+		if age >= b.min {
+			return b.label
+		}
+	}
+	return "fresh"
+}
+
+// RecordCanaryTTFB records the time to first byte of a canary download,
+// labeled by the object's age bucket so cold-data latency can be told apart
+// from recently written objects
+func (c *Collector) RecordCanaryTTFB(run *runctx.Run, object string, age, ttfb time.Duration) {
+	c.observer(c.canaryTTFB, run.Test, object, CanaryAgeBucket(age)).Observe(ttfb.Seconds())
+}
+
+// RecordTTFB records the time to first byte of each GET of a ttfb test
+// run, and whether each met the threshold
+func (c *Collector) RecordTTFB(run *runctx.Run, ttfbs []time.Duration, threshold time.Duration) {
+	h := c.observer(c.ttfb, run.Test)
+	for _, d := range ttfbs {
+		h.Observe(d.Seconds())
+		sla := "met"
+		if d > threshold {
+			sla = "breached"
+		}
+		c.counter(c.ttfbRequests, run.Test, sla).Inc()
+	}
+	c.setGauge(c.ttfbThreshold, threshold.Seconds(), run.Test)
+}
+
+// RecordPathTrace records a network path trace. Hop series beyond the path's
+// current length are removed so a shortened path leaves no stale hops.
+func (c *Collector) RecordPathTrace(target, trigger string, hops []PathHop, success bool) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	c.counter(c.pathTraces, target, trigger, status).Inc()
+	if !success {
+		return
+	}
+
+	c.pathMu.Lock()
+	defer c.pathMu.Unlock()
+	for i := len(hops); i < c.lastPathHops[target]; i++ {
+		hop := strconv.Itoa(i + 1)
+		c.pathHopRTT.DeleteLabelValues(target, hop)
+		c.pathHopLoss.DeleteLabelValues(target, hop)
+		c.guard.forget(c.pathHopRTT, target, hop)
+		c.guard.forget(c.pathHopLoss, target, hop)
+	}
+	c.lastPathHops[target] = len(hops)
+
+	c.gauge(c.pathHops, target).Set(float64(len(hops)))
+	for i, h := range hops {
+		hop := strconv.Itoa(i + 1)
+		c.gauge(c.pathHopRTT, target, hop).Set(h.RTT.Seconds())
+		c.gauge(c.pathHopLoss, target, hop).Set(h.Loss)
+	}
+}
+
+// TrackSubprocess counts a running subprocess; call the returned func with
+// its exit state (nil if it didn't start or is unavailable) when it exits
+func (c *Collector) TrackSubprocess(command string) func(*os.ProcessState) {
+	g := c.gauge(c.subprocesses, command)
+	g.Inc()
+	start := time.Now()
+	return func(state *os.ProcessState) {
+		g.Dec()
+		c.observer(c.subprocessDuration, command).Observe(time.Since(start).Seconds())
+		if state == nil {
+			return
+		}
+		if rss := subproc.MaxRSS(state); rss > 0 {
+			c.observer(c.subprocessMaxRSS, command).Observe(float64(rss))
+		}
+		if signal := subproc.KillSignal(state); signal != "" {
+			c.counter(c.subprocessKills, command, signal).Inc()
+		}
+	}
+}
+
+// RecordOrphansKilled counts orphaned subprocesses that were killed
+func (c *Collector) RecordOrphansKilled(command string, n int) {
+	c.counter(c.orphansKilled, command).Add(float64(n))
+}
+
+// RecordServerIdentity records the identity headers returned by the run's
+// endpoint. When the identity changes, the previous series is removed so only
+// the current identity is exported per endpoint.
+func (c *Collector) RecordServerIdentity(run *runctx.Run, id ServerIdentity) {
+	endpoint := run.Endpoint
+	c.serverMu.Lock()
+	defer c.serverMu.Unlock()
+
+	if last, ok := c.lastServer[endpoint]; ok {
+		if last == id {
+			return
+		}
+		c.serverInfo.DeleteLabelValues(endpoint, last.Server, last.Via, last.PoP)
+		c.guard.forget(c.serverInfo, endpoint, last.Server, last.Via, last.PoP)
+		logging.Info("Server identity changed for %s: server=%q via=%q pop=%q (was server=%q via=%q pop=%q)",
+			endpoint, id.Server, id.Via, id.PoP, last.Server, last.Via, last.PoP)
+	}
+	c.gauge(c.serverInfo, endpoint, id.Server, id.Via, id.PoP).Set(1)
+	c.lastServer[endpoint] = id
+}
+
+// RegisterTest sets the tenant label (empty for none), tags label, metric
+// verbosity, and live gauge age-out (0 for never) used for a test
+func (c *Collector) RegisterTest(testName, tenant string, tags []string, verbosity Verbosity, staleAfter time.Duration) {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.removed, testName)
+	c.tests[testName] = testOptions{
+		tenant:     tenant,
+		tags:       strings.Join(sorted, ","),
+		verbosity:  verbosity,
+		staleAfter: staleAfter,
+	}
+}
+
+// tagsLabel returns the tags label for a test (empty if untagged)
+func (c *Collector) tagsLabel(testName string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tests[testName].tags
+}
+
+// isRemoved returns whether a test was unregistered, so its runs still in
+// flight don't re-create its gauges
+func (c *Collector) isRemoved(testName string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.removed[testName]
+}
+
+// enabled returns whether metrics at the given verbosity are emitted for a test.
+// Unregistered tests default to detailed.
+func (c *Collector) enabled(testName string, level Verbosity) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	opts, ok := c.tests[testName]
+	if !ok {
+		return true
+	}
+	return opts.verbosity >= level
+}
+
+// RecordResult records the run and step metrics of a finished test run. A
+// successful run is counted under an empty step name; a failed run under the
+// step that failed (empty if it failed outside a step). Failures are also
+// counted by error class.
+func (c *Collector) RecordResult(res *result.Result) {
+	for _, step := range res.Steps {
+		executor := res.Executor
+		if step.Executor != "" {
+			executor = step.Executor
+		}
+		c.RecordTestRun(res.Test, step.Name, executor, step.Success, step.Duration())
+		if !step.Success {
+			c.counter(c.testErrors, res.Test, step.Name, executor, step.ErrorClass).Inc()
+		}
+	}
+	c.RecordTestRun(res.Test, res.FailedStep, res.Executor, res.Success, res.Duration())
+	if !res.Success && res.FailedStep == "" {
+		c.counter(c.testErrors, res.Test, "", res.Executor, res.ErrorClass).Inc()
+	}
+	c.recordJourney(res)
+	c.recordAvailability(res)
+
+	c.mu.RLock()
+	cmp, shadow := c.comparison, c.shadow
+	c.mu.RUnlock()
+	if cmp != nil {
+		cmp.observe(shadow, res)
+	}
+}
+
+// recordJourney records the end-to-end duration of a completed multi-step
+// run: the sum of its steps, so step jitter, run slot waits, and retries of
+// other runs don't count against the journey. Failed journeys are counted in
+// synthetics_test_runs_total instead, since a partial duration isn't one a
+// customer experiences.
+func (c *Collector) recordJourney(res *result.Result) {
+	if !res.Success || len(res.Steps) < 2 {
+		return
+	}
+	var journey float64
+	for _, step := range res.Steps {
+		journey += step.DurationSeconds
+	}
+	c.observer(c.journeyDuration, res.Test, res.Executor, c.tagsLabel(res.Test)).Observe(journey)
 }
 
 // RecordTestRun records a test execution
@@ -117,33 +1157,90 @@ func (c *Collector) RecordTestRun(testName, stepName, executor string, success b
 	if !success {
 		status = "failure"
 	}
-	c.testRunsTotal.WithLabelValues(testName, stepName, executor, status).Inc()
-	c.testRunDuration.WithLabelValues(testName, stepName, executor).Observe(duration.Seconds())
+	tags := c.tagsLabel(testName)
+	c.counter(c.testRunsTotal, testName, stepName, executor, tags, status).Inc()
+	c.observer(c.testRunDuration, testName, stepName, executor, tags).Observe(duration.Seconds())
+}
+
+// RecordTestRetry records the outcome of a whole-test retry (attempt is 1-based)
+func (c *Collector) RecordTestRetry(testName string, attempt int, success bool) {
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	c.counter(c.testRetries, testName, strconv.Itoa(attempt), status).Inc()
+}
+
+// RecordDiskBudgetSkip counts a run skipped for the work directory disk budget
+func (c *Collector) RecordDiskBudgetSkip(testName string) {
+	c.counter(c.diskBudgetSkips, testName).Inc()
+}
+
+// RecordOverlappingRun counts a scheduled run that fired while the test's
+// previous run was still in flight
+func (c *Collector) RecordOverlappingRun(testName, policy string) {
+	c.counter(c.overlappingRuns, testName, policy).Inc()
+}
+
+// RecordTestState records when a test last ran and last succeeded. A zero
+// lastSuccess (never succeeded) leaves the success timestamp unset.
+func (c *Collector) RecordTestState(testName string, lastRun, lastSuccess time.Time, consecutiveFailures int) {
+	c.gaugeMu.Lock()
+	defer c.gaugeMu.Unlock()
+	if c.isRemoved(testName) {
+		return
+	}
+	c.gauge(c.testLastRun, testName).Set(float64(lastRun.Unix()))
+	if !lastSuccess.IsZero() {
+		c.gauge(c.testLastSuccess, testName).Set(float64(lastSuccess.Unix()))
+	}
+	c.gauge(c.testConsecutiveFailures, testName).Set(float64(consecutiveFailures))
+}
+
+// RecordConfigReload records the outcome of loading or reloading the config
+func (c *Collector) RecordConfigReload(status string) {
+	c.counter(c.configReloads, status).Inc()
+	if status == config.ReloadSuccess {
+		c.configLastSuccess.SetToCurrentTime()
+	}
+}
+
+// RecordScheduleDrift records how late a scheduled run started
+func (c *Collector) RecordScheduleDrift(testName string, drift time.Duration) {
+	c.observer(c.scheduleDrift, testName).Observe(drift.Seconds())
+}
+
+// RecordStartDelay records how long after its cron time a scheduled run's
+// first step started
+func (c *Collector) RecordStartDelay(testName, executor string, delay time.Duration) {
+	c.observer(c.startDelay, testName, executor).Observe(delay.Seconds())
 }
 
 // RecordStorjUpload records a Storj upload operation
-func (c *Collector) RecordStorjUpload(testName, executor, bucket, fileSize string, duration time.Duration, bytes int64, success bool) {
+func (c *Collector) RecordStorjUpload(run *runctx.Run, fileSize string, duration time.Duration, bytes int64, success bool) {
 	const action = "upload"
 	if fileSize != "" && duration > 0 {
-		c.storjDuration.WithLabelValues(testName, action, executor, bucket, fileSize).Observe(duration.Seconds())
-		logging.Debug("    RecordStorjUpload histogram: test=%s executor=%s fileSize=%s duration=%v", testName, executor, fileSize, duration)
+		c.observer(c.storjDuration, run.Test, action, run.Executor, run.Bucket, run.Satellite, fileSize).Observe(duration.Seconds())
+		run.Log().Debug("    RecordStorjUpload histogram: run=%s executor=%s fileSize=%s duration=%v", run, run.Executor, fileSize, duration)
 	}
 	// Update live duration gauge only when duration is provided
-	if duration > 0 {
-		c.lastDuration.WithLabelValues(testName, action, executor).Set(duration.Seconds())
-		logging.Debug("    RecordStorjUpload gauge: test=%s executor=%s duration=%v", testName, executor, duration)
+	if duration > 0 && c.enabled(run.Test, VerbosityStandard) {
+		c.setGauge(c.lastDuration, duration.Seconds(), run.Test, action, run.Executor)
+		run.Log().Debug("    RecordStorjUpload gauge: run=%s executor=%s duration=%v", run, run.Executor, duration)
 	}
 	if success {
-		c.storjBytes.WithLabelValues(testName, action, executor, bucket).Add(float64(bytes))
-		c.storjOperationCount.WithLabelValues(testName, action, executor, bucket).Inc()
-		c.storjOperationSuccess.WithLabelValues(testName, action, executor, "success").Inc()
+		if c.enabled(run.Test, VerbosityStandard) {
+			c.counter(c.storjBytes, run.Test, action, run.Executor, run.Bucket, run.Satellite).Add(float64(bytes))
+		}
+		c.counter(c.storjOperationCount, run.Test, action, run.Executor, run.Bucket, run.Satellite).Inc()
+		c.counter(c.storjOperationSuccess, run.Test, action, run.Executor, run.Satellite, "success").Inc()
 	} else {
-		c.storjOperationSuccess.WithLabelValues(testName, action, executor, "failure").Inc()
+		c.counter(c.storjOperationSuccess, run.Test, action, run.Executor, run.Satellite, "failure").Inc()
 	}
 }
 
 // RecordStorjDownload records a Storj download operation
-func (c *Collector) RecordStorjDownload(testName, executor, bucket, fileSize string, duration time.Duration, bytes int64, success bool) {
+func (c *Collector) RecordStorjDownload(run *runctx.Run, fileSize string, duration time.Duration, bytes int64, success bool) {
 	const action = "download"
 	// If no file size provided, derive from bytes (for downloads without config)
 	if fileSize == "" && bytes > 0 {
@@ -152,28 +1249,29 @@ func (c *Collector) RecordStorjDownload(testName, executor, bucket, fileSize str
 	// Fallback to "unknown" if we still don't have a file size (ensures histogram is always recorded)
 	if fileSize == "" {
 		fileSize = "unknown"
-		logging.Debug("    RecordStorjDownload: no file size available (bytes=%d), using 'unknown' label", bytes)
+		run.Log().Debug("    RecordStorjDownload: no file size available (bytes=%d), using 'unknown' label", bytes)
 	}
 
 	if duration > 0 {
-		c.storjDuration.WithLabelValues(testName, action, executor, bucket, fileSize).Observe(duration.Seconds())
-		logging.Debug("    RecordStorjDownload histogram: test=%s executor=%s fileSize=%s duration=%v", testName, executor, fileSize, duration)
+		c.observer(c.storjDuration, run.Test, action, run.Executor, run.Bucket, run.Satellite, fileSize).Observe(duration.Seconds())
+		run.Log().Debug("    RecordStorjDownload histogram: run=%s executor=%s fileSize=%s duration=%v", run, run.Executor, fileSize, duration)
 	}
 	// Update live duration gauge only when duration is provided
-	if duration > 0 {
-		c.lastDuration.WithLabelValues(testName, action, executor).Set(duration.Seconds())
-		logging.Debug("    RecordStorjDownload gauge: test=%s executor=%s duration=%v", testName, executor, duration)
+	if duration > 0 && c.enabled(run.Test, VerbosityStandard) {
+		c.setGauge(c.lastDuration, duration.Seconds(), run.Test, action, run.Executor)
+		run.Log().Debug("    RecordStorjDownload gauge: run=%s executor=%s duration=%v", run, run.Executor, duration)
 	}
 	if success {
-		c.storjBytes.WithLabelValues(testName, action, executor, bucket).Add(float64(bytes))
-		c.storjOperationCount.WithLabelValues(testName, action, executor, bucket).Inc()
-		c.storjOperationSuccess.WithLabelValues(testName, action, executor, "success").Inc()
+		if c.enabled(run.Test, VerbosityStandard) {
+			c.counter(c.storjBytes, run.Test, action, run.Executor, run.Bucket, run.Satellite).Add(float64(bytes))
+		}
+		c.counter(c.storjOperationCount, run.Test, action, run.Executor, run.Bucket, run.Satellite).Inc()
+		c.counter(c.storjOperationSuccess, run.Test, action, run.Executor, run.Satellite, "success").Inc()
 	} else {
-		c.storjOperationSuccess.WithLabelValues(testName, action, executor, "failure").Inc()
+		c.counter(c.storjOperationSuccess, run.Test, action, run.Executor, run.Satellite, "failure").Inc()
 	}
 }
 
-
 // formatBytesLabel converts bytes to human-readable label matching configured sizes
 func formatBytesLabel(bytes int64) string {
 	const (
@@ -194,67 +1292,105 @@ func formatBytesLabel(bytes int64) string {
 	}
 }
 
-// RecordStorjList records a Storj list operation
-func (c *Collector) RecordStorjList(testName, executor, bucket string, success bool) {
+// RecordStorjList records a list operation and the number of objects it
+// returned. Listings have no object size, so their durations carry an empty
+// file_size label.
+func (c *Collector) RecordStorjList(run *runctx.Run, duration time.Duration, objects int, success bool) {
 	const action = "list"
 	status := "success"
 	if !success {
 		status = "failure"
 	}
-	c.storjOperationSuccess.WithLabelValues(testName, action, executor, status).Inc()
-	if success {
-		c.storjOperationCount.WithLabelValues(testName, action, executor, bucket).Inc()
+	c.counter(c.storjOperationSuccess, run.Test, action, run.Executor, run.Satellite, status).Inc()
+	if !success {
+		return
+	}
+	c.counter(c.storjOperationCount, run.Test, action, run.Executor, run.Bucket, run.Satellite).Inc()
+	if duration > 0 {
+		c.observer(c.storjDuration, run.Test, action, run.Executor, run.Bucket, run.Satellite, "").Observe(duration.Seconds())
+	}
+	if c.enabled(run.Test, VerbosityStandard) {
+		if duration > 0 {
+			c.setGauge(c.lastDuration, duration.Seconds(), run.Test, action, run.Executor)
+		}
+		c.setGauge(c.listObjects, float64(objects), run.Test, run.Executor)
+	}
+}
+
+// RecordStorjStat records a stat (HeadObject or StatObject) of the run's
+// object. Like listings, stats carry an empty file_size label.
+func (c *Collector) RecordStorjStat(run *runctx.Run, duration time.Duration, success bool) {
+	const action = "stat"
+	status := "success"
+	if !success {
+		status = "failure"
+	}
+	c.counter(c.storjOperationSuccess, run.Test, action, run.Executor, run.Satellite, status).Inc()
+	if !success {
+		return
+	}
+	c.counter(c.storjOperationCount, run.Test, action, run.Executor, run.Bucket, run.Satellite).Inc()
+	if duration > 0 {
+		c.observer(c.storjDuration, run.Test, action, run.Executor, run.Bucket, run.Satellite, "").Observe(duration.Seconds())
+		if c.enabled(run.Test, VerbosityStandard) {
+			c.setGauge(c.lastDuration, duration.Seconds(), run.Test, action, run.Executor)
+		}
 	}
 }
 
 // RecordHTTPTiming records granular HTTP timing breakdown
-func (c *Collector) RecordHTTPTiming(testName, action, executor string, timings HTTPTimings) {
+func (c *Collector) RecordHTTPTiming(run *runctx.Run, action string, timings HTTPTimings) {
+	if !c.enabled(run.Test, VerbosityDetailed) {
+		return
+	}
+	conn := timings.connLabel()
 	if timings.DNSLookup > 0 {
-		c.httpTiming.WithLabelValues(testName, action, executor, "dns").Observe(timings.DNSLookup.Seconds())
-		c.lastHTTPPhase.WithLabelValues(testName, action, executor, "dns").Set(timings.DNSLookup.Seconds())
+		c.observer(c.httpTiming, run.Test, action, run.Executor, "dns", conn).Observe(timings.DNSLookup.Seconds())
+		c.setGauge(c.lastHTTPPhase, timings.DNSLookup.Seconds(), run.Test, action, run.Executor, "dns")
 	}
 	if timings.TCPConnect > 0 {
-		c.httpTiming.WithLabelValues(testName, action, executor, "connect").Observe(timings.TCPConnect.Seconds())
-		c.lastHTTPPhase.WithLabelValues(testName, action, executor, "connect").Set(timings.TCPConnect.Seconds())
+		c.observer(c.httpTiming, run.Test, action, run.Executor, "connect", conn).Observe(timings.TCPConnect.Seconds())
+		c.setGauge(c.lastHTTPPhase, timings.TCPConnect.Seconds(), run.Test, action, run.Executor, "connect")
 	}
 	if timings.TLSHandshake > 0 {
-		c.httpTiming.WithLabelValues(testName, action, executor, "tls").Observe(timings.TLSHandshake.Seconds())
-		c.lastHTTPPhase.WithLabelValues(testName, action, executor, "tls").Set(timings.TLSHandshake.Seconds())
+		c.observer(c.httpTiming, run.Test, action, run.Executor, "tls", conn).Observe(timings.TLSHandshake.Seconds())
+		c.setGauge(c.lastHTTPPhase, timings.TLSHandshake.Seconds(), run.Test, action, run.Executor, "tls")
 	}
 	if timings.TTFB > 0 {
-		c.httpTiming.WithLabelValues(testName, action, executor, "ttfb").Observe(timings.TTFB.Seconds())
-		c.lastHTTPPhase.WithLabelValues(testName, action, executor, "ttfb").Set(timings.TTFB.Seconds())
+		c.observer(c.httpTiming, run.Test, action, run.Executor, "ttfb", conn).Observe(timings.TTFB.Seconds())
+		c.setGauge(c.lastHTTPPhase, timings.TTFB.Seconds(), run.Test, action, run.Executor, "ttfb")
 	}
 	if timings.Transfer > 0 {
-		c.httpTiming.WithLabelValues(testName, action, executor, "transfer").Observe(timings.Transfer.Seconds())
-		c.lastHTTPPhase.WithLabelValues(testName, action, executor, "transfer").Set(timings.Transfer.Seconds())
+		c.observer(c.httpTiming, run.Test, action, run.Executor, "transfer", conn).Observe(timings.Transfer.Seconds())
+		c.setGauge(c.lastHTTPPhase, timings.Transfer.Seconds(), run.Test, action, run.Executor, "transfer")
 	}
 	if timings.Total > 0 {
-		c.httpTiming.WithLabelValues(testName, action, executor, "total").Observe(timings.Total.Seconds())
-		c.lastHTTPPhase.WithLabelValues(testName, action, executor, "total").Set(timings.Total.Seconds())
+		c.observer(c.httpTiming, run.Test, action, run.Executor, "total", conn).Observe(timings.Total.Seconds())
+		c.setGauge(c.lastHTTPPhase, timings.Total.Seconds(), run.Test, action, run.Executor, "total")
 	}
 }
 
-// RecordHTTPTimingPhase records a single timing phase (e.g., "sign")
-func (c *Collector) RecordHTTPTimingPhase(testName, action, executor, phase string, duration time.Duration) {
-	if duration > 0 {
-		c.httpTiming.WithLabelValues(testName, action, executor, phase).Observe(duration.Seconds())
-		c.lastHTTPPhase.WithLabelValues(testName, action, executor, phase).Set(duration.Seconds())
+// RecordHTTPTimingPhase records a single timing phase (e.g., "sign").
+// Phases recorded here happen before a connection is chosen, so conn is empty.
+func (c *Collector) RecordHTTPTimingPhase(run *runctx.Run, action, phase string, duration time.Duration) {
+	if duration > 0 && c.enabled(run.Test, VerbosityDetailed) {
+		c.observer(c.httpTiming, run.Test, action, run.Executor, phase, "").Observe(duration.Seconds())
+		c.setGauge(c.lastHTTPPhase, duration.Seconds(), run.Test, action, run.Executor, phase)
 	}
 }
 
 // RecordStorjDelete records a Storj delete operation
-func (c *Collector) RecordStorjDelete(testName, executor, bucket, fileSize string, duration time.Duration, count int, success bool) {
+func (c *Collector) RecordStorjDelete(run *runctx.Run, fileSize string, duration time.Duration, count int, success bool) {
 	const action = "delete"
 
 	// Record duration histogram (if file size label provided)
 	if fileSize != "" && duration > 0 {
-		c.storjDuration.WithLabelValues(testName, action, executor, bucket, fileSize).Observe(duration.Seconds())
+		c.observer(c.storjDuration, run.Test, action, run.Executor, run.Bucket, run.Satellite, fileSize).Observe(duration.Seconds())
 	}
 
-	// Always update the live duration gauge
-	if duration > 0 {
-		c.lastDuration.WithLabelValues(testName, action, executor).Set(duration.Seconds())
+	// Update the live duration gauge
+	if duration > 0 && c.enabled(run.Test, VerbosityStandard) {
+		c.setGauge(c.lastDuration, duration.Seconds(), run.Test, action, run.Executor)
 	}
 
 	// Record success/failure status
@@ -262,10 +1398,22 @@ func (c *Collector) RecordStorjDelete(testName, executor, bucket, fileSize strin
 	if !success {
 		status = "failure"
 	}
-	c.storjOperationSuccess.WithLabelValues(testName, action, executor, status).Inc()
+	c.counter(c.storjOperationSuccess, run.Test, action, run.Executor, run.Satellite, status).Inc()
 
 	// Record operation count
 	if success && count > 0 {
-		c.storjOperationCount.WithLabelValues(testName, action, executor, bucket).Add(float64(count))
+		c.counter(c.storjOperationCount, run.Test, action, run.Executor, run.Bucket, run.Satellite).Add(float64(count))
 	}
 }
+
+// RegisterBuildInfo exports synth_build_info with the running build's version
+// labels and the probe profile
+func RegisterBuildInfo(info version.Info, profile string) {
+	promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "synth_build_info",
+			Help: "Build information of the running probe (always 1)",
+		},
+		[]string{"version", "commit", "date", "go_version", "profile"},
+	).WithLabelValues(info.Version, info.Commit, info.Date, info.GoVersion, profile).Set(1)
+}
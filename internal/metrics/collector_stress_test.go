@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// stressTests is the number of test names the stress workers share, small so
+// that registering, recording, expiring, and unregistering collide often
+const stressTests = 4
+
+// TestCollectorConcurrent exercises a Collector on its own registry from
+// concurrent workers, as cron runs, parallel steps, expiry, and scrapes do:
+// each cycles through every kind of Collector call (results, live gauges,
+// server identity, path traces, test registration, expiry, series limits)
+// and scrapes, on a few shared test names. Run under the race detector
+// (make race, go test -race) it checks the Collector's locking.
+func TestCollectorConcurrent(t *testing.T) {
+	duration := 2 * time.Second
+	if testing.Short() {
+		duration = 200 * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	reg := prometheus.NewRegistry()
+	c := NewCollectorWith(reg)
+
+	const workers = 16
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ctx.Err() == nil; i++ {
+				if err := stressOp(c, reg, w+i); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	// With the workers stopped, unregistered tests must have no gauges left
+	for i := range stressTests {
+		c.UnregisterTest(stressTestName(i))
+	}
+	for _, vec := range c.testGauges() {
+		if name, ok := firstSeries(vec); ok {
+			t.Errorf("gauge series of unregistered test %s survived", name)
+		}
+	}
+}
+
+func stressTestName(i int) string {
+	return fmt.Sprintf("stress-%d", i%stressTests)
+}
+
+// stressOp makes the n-th call of the cycle
+func stressOp(c *Collector, reg *prometheus.Registry, n int) error {
+	name := stressTestName(n / 16)
+	run := &runctx.Run{
+		ID:       fmt.Sprint(n),
+		Test:     name,
+		Executor: "s3",
+		Endpoint: "https://gateway.example.com",
+		Start:    time.Now(),
+	}
+	d := time.Duration(n%100) * time.Millisecond
+
+	switch n % 16 {
+	case 0:
+		c.RegisterTest(name, "", []string{"stress"}, VerbosityDetailed, time.Millisecond)
+	case 1:
+		c.UnregisterTest(name)
+	case 2:
+		res := result.New(run)
+		res.Steps = append(res.Steps, result.Step{Name: "upload", Success: n%3 != 0, DurationSeconds: d.Seconds()})
+		res.Finish(nil)
+		c.RecordResult(res)
+	case 3:
+		c.RecordTestState(name, time.Now(), time.Now(), n%5)
+	case 4:
+		c.RecordStorjUpload(run, "1KB", d, 1024, n%7 != 0)
+	case 5:
+		c.RecordStorjDownload(run, "1KB", d, 1024, true)
+	case 6:
+		c.RecordHTTPTiming(run, "upload", HTTPTimings{DNSLookup: d, TTFB: d, Total: 2 * d})
+	case 7:
+		c.RecordRTT(run, "gateway.example.com", "tcp", []time.Duration{d, 2 * d}, 3)
+	case 8:
+		c.RecordCanaryCheck(run, "canary.bin", "ok", d)
+	case 9:
+		c.RecordCompareDelta(run, "download", "a", "b", d)
+	case 10:
+		c.RecordServerIdentity(run, ServerIdentity{Server: "gateway"})
+	case 11:
+		c.RecordPathTrace("gateway.example.com", "schedule", []PathHop{{RTT: d}}, true)
+	case 12:
+		c.ExpireStale(time.Now())
+	case 13:
+		c.SetAvailabilityHalfLife(time.Duration(1+n%3) * time.Hour)
+		c.SetMaxSeries(1 + n%50) // Often low enough to drop series
+	case 14:
+		c.CompareWith(NewShadowComparison(time.Minute), n%2 == 0)
+	case 15:
+		if _, err := reg.Gather(); err != nil {
+			return fmt.Errorf("gather: %w", err)
+		}
+	}
+	return nil
+}
+
+// firstSeries returns the test_name of a series of vec, if it has any
+func firstSeries(vec *prometheus.GaugeVec) (string, bool) {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+	var name string
+	found := false
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil || found {
+			continue
+		}
+		for _, l := range pb.Label {
+			if l.GetName() == "test_name" {
+				name, found = l.GetValue(), true
+			}
+		}
+	}
+	return name, found
+}
@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ShadowComparison compares the runs of shadow tests with those of the active
+// tests of the same name over a sliding window, so promoting a candidate
+// config is decided on data: a changed test whose success ratio drops or
+// whose latency rises shows it in the deltas. It is a prometheus.Collector
+// exporting the deltas at scrape time.
+type ShadowComparison struct {
+	window time.Duration
+
+	mu   sync.Mutex
+	runs map[comparisonKey][]comparedRun
+
+	deltaSuccess  *prometheus.Desc
+	deltaDuration *prometheus.Desc
+	comparedRuns  *prometheus.Desc
+}
+
+type comparisonKey struct {
+	test   string
+	shadow bool
+}
+
+// comparedRun is the outcome of one run of a compared test
+type comparedRun struct {
+	at      time.Time // When the run finished
+	seconds float64
+	success bool
+}
+
+// TestComparison is the comparison of one test present in both configs.
+// Delta is shadow minus active; its durations are 0 unless both sides had
+// successful runs.
+type TestComparison struct {
+	Test          string         `json:"test"`
+	WindowSeconds float64        `json:"window_seconds"`
+	Active        ComparisonSide `json:"active"`
+	Shadow        ComparisonSide `json:"shadow"`
+	Delta         ComparisonSide `json:"delta"`
+}
+
+// ComparisonSide summarizes the runs of one side over the window. Durations
+// are of successful runs, 0 if there were none.
+type ComparisonSide struct {
+	Runs         int     `json:"runs"`
+	SuccessRatio float64 `json:"success_ratio"`
+	P50Seconds   float64 `json:"p50_seconds"`
+	P95Seconds   float64 `json:"p95_seconds"`
+}
+
+// NewShadowComparison creates a comparison over the given window
+func NewShadowComparison(window time.Duration) *ShadowComparison {
+	return &ShadowComparison{
+		window: window,
+		runs:   make(map[comparisonKey][]comparedRun),
+		deltaSuccess: prometheus.NewDesc("synthetics_shadow_delta_success_ratio",
+			"Success ratio of the shadow test minus that of the active test of the same name, over the comparison window",
+			[]string{"test_name"}, nil),
+		deltaDuration: prometheus.NewDesc("synthetics_shadow_delta_duration_seconds",
+			"Run duration quantile of the shadow test minus that of the active test of the same name, over the comparison window (successful runs)",
+			[]string{"test_name", "quantile"}, nil),
+		comparedRuns: prometheus.NewDesc("synthetics_shadow_compared_runs",
+			"Runs in the comparison window of each side of a test present in both the active and shadow configs",
+			[]string{"test_name", "side"}, nil),
+	}
+}
+
+// CompareWith feeds the collector's results to the comparison, as the shadow
+// side or the active one
+func (c *Collector) CompareWith(cmp *ShadowComparison, shadow bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.comparison = cmp
+	c.shadow = shadow
+}
+
+// observe records the outcome of a run
+func (s *ShadowComparison) observe(shadow bool, res *result.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := comparisonKey{test: res.Test, shadow: shadow}
+	s.runs[key] = append(s.prune(s.runs[key], time.Now()), comparedRun{at: time.Now(), seconds: res.DurationSeconds, success: res.Success})
+}
+
+// prune drops the runs that finished before the window. Callers must hold s.mu.
+func (s *ShadowComparison) prune(runs []comparedRun, now time.Time) []comparedRun {
+	cutoff := now.Add(-s.window)
+	i := 0
+	for i < len(runs) && runs[i].at.Before(cutoff) {
+		i++
+	}
+	return runs[i:]
+}
+
+// Compare returns the tests with runs on both sides within the window,
+// sorted by name
+func (s *ShadowComparison) Compare(now time.Time) []TestComparison {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := []TestComparison{}
+	for key, runs := range s.runs {
+		runs = s.prune(runs, now)
+		if len(runs) == 0 {
+			delete(s.runs, key)
+			continue
+		}
+		s.runs[key] = runs
+	}
+	for key, shadowRuns := range s.runs {
+		if !key.shadow {
+			continue
+		}
+		activeRuns, ok := s.runs[comparisonKey{test: key.test}]
+		if !ok {
+			continue
+		}
+		active, shadow := summarize(activeRuns), summarize(shadowRuns)
+		cmp := TestComparison{
+			Test:          key.test,
+			WindowSeconds: s.window.Seconds(),
+			Active:        active,
+			Shadow:        shadow,
+			Delta: ComparisonSide{
+				Runs:         shadow.Runs - active.Runs,
+				SuccessRatio: shadow.SuccessRatio - active.SuccessRatio,
+			},
+		}
+		if active.SuccessRatio > 0 && shadow.SuccessRatio > 0 {
+			cmp.Delta.P50Seconds = shadow.P50Seconds - active.P50Seconds
+			cmp.Delta.P95Seconds = shadow.P95Seconds - active.P95Seconds
+		}
+		out = append(out, cmp)
+	}
+	slices.SortFunc(out, func(a, b TestComparison) int { return strings.Compare(a.Test, b.Test) })
+	return out
+}
+
+// summarize computes the success ratio and duration quantiles of runs
+func summarize(runs []comparedRun) ComparisonSide {
+	side := ComparisonSide{Runs: len(runs)}
+	var durations []float64
+	for _, run := range runs {
+		if run.success {
+			durations = append(durations, run.seconds)
+		}
+	}
+	side.SuccessRatio = float64(len(durations)) / float64(len(runs))
+	if len(durations) > 0 {
+		slices.Sort(durations)
+		side.P50Seconds = quantile(durations, 0.5)
+		side.P95Seconds = quantile(durations, 0.95)
+	}
+	return side
+}
+
+// quantile returns the nearest-rank q-quantile of sorted values
+func quantile(sorted []float64, q float64) float64 {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// Describe implements prometheus.Collector
+func (s *ShadowComparison) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.deltaSuccess
+	ch <- s.deltaDuration
+	ch <- s.comparedRuns
+}
+
+// Collect implements prometheus.Collector
+func (s *ShadowComparison) Collect(ch chan<- prometheus.Metric) {
+	for _, cmp := range s.Compare(time.Now()) {
+		ch <- prometheus.MustNewConstMetric(s.deltaSuccess, prometheus.GaugeValue, cmp.Delta.SuccessRatio, cmp.Test)
+		if cmp.Active.SuccessRatio > 0 && cmp.Shadow.SuccessRatio > 0 {
+			ch <- prometheus.MustNewConstMetric(s.deltaDuration, prometheus.GaugeValue, cmp.Delta.P50Seconds, cmp.Test, "0.5")
+			ch <- prometheus.MustNewConstMetric(s.deltaDuration, prometheus.GaugeValue, cmp.Delta.P95Seconds, cmp.Test, "0.95")
+		}
+		ch <- prometheus.MustNewConstMetric(s.comparedRuns, prometheus.GaugeValue, float64(cmp.Active.Runs), cmp.Test, "active")
+		ch <- prometheus.MustNewConstMetric(s.comparedRuns, prometheus.GaugeValue, float64(cmp.Shadow.Runs), cmp.Test, "shadow")
+	}
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gaugeSeries identifies one series of a live gauge. Every live gauge has
+// test_name as its first label.
+type gaugeSeries struct {
+	vec    *prometheus.GaugeVec
+	labels string // Label values joined by labelSep
+}
+
+const labelSep = "\xff"
+
+// setGauge sets a live gauge series and notes when, so series a test stops
+// updating can be aged out. Both happen under gaugeMu, so expiry and
+// UnregisterTest see the series and its update time together.
+func (c *Collector) setGauge(vec *prometheus.GaugeVec, value float64, labels ...string) {
+	c.gaugeMu.Lock()
+	defer c.gaugeMu.Unlock()
+	if c.isRemoved(labels[0]) {
+		return
+	}
+	c.gauge(vec, labels...).Set(value)
+	c.gaugeSeen[gaugeSeries{vec, strings.Join(labels, labelSep)}] = time.Now()
+}
+
+// deleteGauge deletes a live gauge series, e.g. one whose last value no longer
+// describes the test
+func (c *Collector) deleteGauge(vec *prometheus.GaugeVec, labels ...string) {
+	c.gaugeMu.Lock()
+	defer c.gaugeMu.Unlock()
+	vec.DeleteLabelValues(labels...)
+	c.guard.forget(vec, labels...)
+	delete(c.gaugeSeen, gaugeSeries{vec, strings.Join(labels, labelSep)})
+}
+
+// testGauges returns every gauge labeled by test_name. Gauges keep their last
+// value until deleted, so a removed test would otherwise export stale values
+// forever.
+func (c *Collector) testGauges() []*prometheus.GaugeVec {
+	return []*prometheus.GaugeVec{
+		c.testLastRun, c.testLastSuccess, c.testConsecutiveFailures,
+		c.lastDuration, c.lastHTTPPhase, c.headBenchRate, c.listObjects, c.compareDelta,
+		c.bakeoffDuration, c.bakeoffRelative, c.anomalyScore, c.anomalyBaseline,
+		c.rttLast, c.rttLoss, c.canaryAge, c.ttfbThreshold,
+	}
+}
+
+// UnregisterTest forgets a test removed from the config and deletes its gauge
+// series. Counters and histograms are kept; they simply stop increasing.
+func (c *Collector) UnregisterTest(testName string) {
+	c.mu.Lock()
+	delete(c.tests, testName)
+	c.removed[testName] = true
+	c.mu.Unlock()
+
+	c.gaugeMu.Lock()
+	deleted := 0
+	for _, vec := range c.testGauges() {
+		deleted += vec.DeletePartialMatch(prometheus.Labels{"test_name": testName})
+		c.guard.forgetTest(vec, testName)
+	}
+	for series := range c.gaugeSeen {
+		if testOf(series) == testName {
+			delete(c.gaugeSeen, series)
+		}
+	}
+	c.gaugeMu.Unlock()
+	logging.Debug("Deleted %d gauge series of removed test %s", deleted, testName)
+}
+
+// ExpireStale deletes live gauge series (last durations, HTTP phases, RTT,
+// ...) not updated within their test's stale age, e.g. an action a changed
+// test no longer performs. Last-run timestamps are never aged out, since
+// alerts on a test that stopped running depend on them. It returns the
+// number of series deleted.
+func (c *Collector) ExpireStale(now time.Time) int {
+	c.mu.RLock()
+	staleAfter := make(map[string]time.Duration, len(c.tests))
+	for name, opts := range c.tests {
+		staleAfter[name] = opts.staleAfter
+	}
+	c.mu.RUnlock()
+
+	c.gaugeMu.Lock()
+	defer c.gaugeMu.Unlock()
+	deleted := 0
+	for series, seen := range c.gaugeSeen {
+		age := staleAfter[testOf(series)]
+		if age <= 0 || now.Sub(seen) < age {
+			continue
+		}
+		labels := strings.Split(series.labels, labelSep)
+		series.vec.DeleteLabelValues(labels...)
+		c.guard.forget(series.vec, labels...)
+		delete(c.gaugeSeen, series)
+		deleted++
+	}
+	return deleted
+}
+
+// RunExpiry ages out stale gauge series every minute until ctx is done
+func (c *Collector) RunExpiry(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if n := c.ExpireStale(now); n > 0 {
+				logging.Debug("Aged out %d stale gauge series", n)
+			}
+		}
+	}
+}
+
+// testOf returns the test_name label of a live gauge series
+func testOf(series gaugeSeries) string {
+	test, _, _ := strings.Cut(series.labels, labelSep)
+	return test
+}
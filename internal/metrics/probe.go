@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/workdir"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dirUsageTTL limits how often directory sizes are recomputed
+const dirUsageTTL = 30 * time.Second
+
+// probeCollector exports the probe's own resource usage at scrape time, so
+// probe saturation can be ruled out when latencies spike
+type probeCollector struct {
+	goroutines *prometheus.Desc
+	heapInuse  *prometheus.Desc
+	openFDs    *prometheus.Desc
+	dirUsage   *prometheus.Desc
+
+	dirs []string
+
+	mu        sync.Mutex
+	usage     map[string]int64
+	usageTime time.Time
+}
+
+// RegisterProbeMetrics exports goroutine count, heap in use, open file
+// descriptors, and disk usage of the given directories (e.g. test data and temp dirs)
+func RegisterProbeMetrics(dirs ...string) {
+	prometheus.MustRegister(&probeCollector{
+		goroutines: prometheus.NewDesc("synth_probe_goroutines", "Number of goroutines in the probe process", nil, nil),
+		heapInuse:  prometheus.NewDesc("synth_probe_heap_inuse_bytes", "Heap bytes in use by the probe process", nil, nil),
+		openFDs:    prometheus.NewDesc("synth_probe_open_fds", "Open file descriptors of the probe process", nil, nil),
+		dirUsage:   prometheus.NewDesc("synth_probe_dir_usage_bytes", "Disk usage of probe working directories", []string{"dir"}, nil),
+		dirs:       dirs,
+	})
+}
+
+// Describe implements prometheus.Collector
+func (p *probeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.goroutines
+	ch <- p.heapInuse
+	ch <- p.openFDs
+	ch <- p.dirUsage
+}
+
+// Collect implements prometheus.Collector
+func (p *probeCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(p.goroutines, prometheus.GaugeValue, float64(runtime.NumGoroutine()))
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	ch <- prometheus.MustNewConstMetric(p.heapInuse, prometheus.GaugeValue, float64(ms.HeapInuse))
+
+	// Only available on Linux
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		ch <- prometheus.MustNewConstMetric(p.openFDs, prometheus.GaugeValue, float64(len(fds)))
+	}
+
+	for dir, bytes := range p.dirUsageBytes() {
+		ch <- prometheus.MustNewConstMetric(p.dirUsage, prometheus.GaugeValue, float64(bytes), dir)
+	}
+}
+
+// dirUsageBytes returns cached directory sizes, recomputing them at most every dirUsageTTL
+func (p *probeCollector) dirUsageBytes() map[string]int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.usage != nil && time.Since(p.usageTime) < dirUsageTTL {
+		return p.usage
+	}
+
+	usage := make(map[string]int64, len(p.dirs))
+	for _, dir := range p.dirs {
+		if _, err := os.Stat(dir); err == nil {
+			usage[dir] = workdir.Size(dir)
+		}
+	}
+	p.usage = usage
+	p.usageTime = time.Now()
+	return usage
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// ShadowLabel is added, set to "true", to every series of tests run from a
+// shadow config, so dashboards and alerts can tell them from the active tests
+const ShadowLabel = "shadow"
+
+// NewShadow creates a collector for the tests of a shadow config on its own
+// registry, and returns a gatherer serving its series with shadow="true".
+// Served next to the default registry, the shadow series share metric names
+// with the active ones.
+func NewShadow() (*Collector, prometheus.Gatherer) {
+	reg := prometheus.NewRegistry()
+	c := NewCollectorWith(reg)
+	return c, withLabel(c.WithTenants(reg), ShadowLabel, "true")
+}
+
+// withLabel returns a gatherer adding a constant label to every series of g
+func withLabel(g prometheus.Gatherer, name, value string) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		for _, mf := range families {
+			for _, m := range mf.Metric {
+				m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+				sort.Slice(m.Label, func(i, j int) bool { return m.Label[i].GetName() < m.Label[j].GetName() })
+			}
+		}
+		return families, err
+	})
+}
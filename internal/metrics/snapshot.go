@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// snapshotVersion is the format version of counter snapshot files
+const snapshotVersion = 1
+
+// counterSnapshot is the JSON file counters are saved to and restored from
+type counterSnapshot struct {
+	Version  int                         `json:"version"`
+	Time     time.Time                   `json:"time"`
+	Counters map[string][]snapshotSeries `json:"counters"` // By metric name
+}
+
+// snapshotSeries is the value of one counter series
+type snapshotSeries struct {
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// snapshotCounters returns the counters saved in snapshots, by metric name.
+// Histograms and gauges are not saved: gauges describe the latest run, and
+// restoring histogram buckets would misstate when their observations happened.
+func (c *Collector) snapshotCounters() map[string]*prometheus.CounterVec {
+	return map[string]*prometheus.CounterVec{
+		"synthetics_test_runs_total":                     c.testRunsTotal,
+		"synthetics_test_retries_total":                  c.testRetries,
+		"synthetics_test_errors_total":                   c.testErrors,
+		"synthetics_disk_budget_skips_total":             c.diskBudgetSkips,
+		"synthetics_overlapping_runs_total":              c.overlappingRuns,
+		"synth_config_reload_total":                      c.configReloads,
+		"synth_bytes_total":                              c.storjBytes,
+		"synth_operation_count_total":                    c.storjOperationCount,
+		"synth_operation_success_total":                  c.storjOperationSuccess,
+		"synth_tls_connections_total":                    c.tlsConnections,
+		"synth_tls_check_total":                          c.tlsChecks,
+		"synth_read_after_write_total":                   c.readAfterWriteTotal,
+		"synth_multipart_requests_total":                 c.multipartTotal,
+		"synth_verification_failures_total":              c.verificationFailures,
+		"synth_metadata_mismatches_total":                c.metadataMismatches,
+		"synth_anomalies_total":                          c.anomalies,
+		"synth_bakeoff_runs_total":                       c.bakeoffRuns,
+		"synth_rtt_probes_total":                         c.rttProbes,
+		"synth_canary_checks_total":                      c.canaryChecks,
+		"synth_ttfb_requests_total":                      c.ttfbRequests,
+		"synth_path_traces_total":                        c.pathTraces,
+		"synth_probe_subprocess_kills_total":             c.subprocessKills,
+		"synth_probe_orphaned_subprocesses_killed_total": c.orphansKilled,
+	}
+}
+
+// SaveSnapshot writes the current value of every counter series to path,
+// atomically. It is called at shutdown, once the counters are final; see
+// RestoreSnapshot.
+func (c *Collector) SaveSnapshot(path string) error {
+	snap := counterSnapshot{
+		Version:  snapshotVersion,
+		Time:     time.Now().UTC(),
+		Counters: make(map[string][]snapshotSeries),
+	}
+	for name, vec := range c.snapshotCounters() {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			vec.Collect(ch)
+			close(ch)
+		}()
+		for m := range ch {
+			var pb dto.Metric
+			if err := m.Write(&pb); err != nil {
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			series := snapshotSeries{Labels: make(map[string]string, len(pb.GetLabel())), Value: pb.GetCounter().GetValue()}
+			for _, label := range pb.GetLabel() {
+				series.Labels[label.GetName()] = label.GetValue()
+			}
+			snap.Counters[name] = append(snap.Counters[name], series)
+		}
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metrics snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write metrics snapshot: %w", err)
+	}
+	return nil
+}
+
+// RestoreSnapshot adds the counter values saved at path to the counters, so
+// they continue from where the previous process left off. It must be called
+// after the configured tests are registered and before any are recorded:
+// series of tests no longer configured, or with tags that have since
+// changed, are not restored. A missing file restores nothing.
+//
+// Restoring removes the file, so a snapshot is restored at most once and
+// only the snapshot of a clean shutdown is ever restored. After a crash the
+// counters may have been scraped above the values the process started from,
+// and restoring those again would read as a counter reset, making increase()
+// count every restored value again; a restart from zero is a reset
+// Prometheus handles.
+func (c *Collector) RestoreSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read metrics snapshot %s: %w", path, err)
+	}
+	var snap counterSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to parse metrics snapshot %s: %w", path, err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("metrics snapshot %s has unsupported version %d", path, snap.Version)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("not restoring metrics snapshot %s, it could not be removed: %w", path, err)
+	}
+
+	counters := c.snapshotCounters()
+	restored, skipped := 0, 0
+	for name, series := range snap.Counters {
+		vec, ok := counters[name]
+		if !ok {
+			skipped += len(series) // A counter since removed
+			continue
+		}
+		for _, s := range series {
+			if !c.restorable(s.Labels) || s.Value < 0 {
+				skipped++
+				continue
+			}
+			// Not counted towards the series limit until recorded again; the
+			// snapshot was taken under the same limit
+			counter, err := vec.GetMetricWith(s.Labels)
+			if err != nil {
+				skipped++ // Labels since changed
+				continue
+			}
+			counter.Add(s.Value)
+			restored++
+		}
+	}
+	log.Printf("Restored %d counter series from metrics snapshot %s (saved %s)", restored, path, snap.Time.Format(time.RFC3339))
+	if skipped > 0 {
+		logging.Debug("Skipped %d counter series of removed tests or changed metrics", skipped)
+	}
+	return nil
+}
+
+// restorable reports whether a saved series belongs to a configured test,
+// with its current tags, or to no test
+func (c *Collector) restorable(labels map[string]string) bool {
+	test, ok := labels["test_name"]
+	if !ok {
+		return true
+	}
+	c.mu.RLock()
+	opts, registered := c.tests[test]
+	c.mu.RUnlock()
+	if !registered {
+		return false
+	}
+	tags, ok := labels["tags"]
+	return !ok || tags == opts.tags
+}
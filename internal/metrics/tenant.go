@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// TenantLabel is added to every series of a tenant's tests, set to the
+// tenant's name, so alerts can be routed to the team that owns the test
+const TenantLabel = "tenant"
+
+// WithTenants returns a gatherer adding the tenant label to the series of g
+// whose test_name is a test registered with a tenant
+func (c *Collector) WithTenants(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		for _, mf := range families {
+			for _, m := range mf.Metric {
+				tenant := c.tenantOf(m)
+				if tenant == "" {
+					continue
+				}
+				m.Label = append(m.Label, &dto.LabelPair{Name: proto.String(TenantLabel), Value: proto.String(tenant)})
+				sort.Slice(m.Label, func(i, j int) bool { return m.Label[i].GetName() < m.Label[j].GetName() })
+			}
+		}
+		return families, err
+	})
+}
+
+// tenantOf returns the tenant of the test a series belongs to, if any. The
+// caller holds c.mu.
+func (c *Collector) tenantOf(m *dto.Metric) string {
+	for _, label := range m.Label {
+		if label.GetName() == "test_name" {
+			return c.tests[label.GetValue()].tenant
+		}
+	}
+	return ""
+}
@@ -0,0 +1,484 @@
+// Package netpath traces the network path to gateway and satellite hosts with
+// mtr or traceroute, so network problems can be told apart from service
+// problems when a test fails.
+package netpath
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/subproc"
+	"storj.io/uplink"
+)
+
+// What started a trace
+const (
+	TriggerInterval = "interval" // Periodic trace of all targets
+	TriggerFailure  = "failure"  // A test touching the target failed
+)
+
+// maxTraces is the number of recent traces kept for the API
+const maxTraces = 100
+
+// Hop summarizes the probes sent to one hop of the path
+type Hop struct {
+	Hop          int     `json:"hop"`
+	Host         string  `json:"host,omitempty"` // Empty if the hop did not reply
+	LossPercent  float64 `json:"loss_percent"`
+	Sent         int     `json:"sent"`
+	AvgSeconds   float64 `json:"avg_seconds"`
+	BestSeconds  float64 `json:"best_seconds"`
+	WorstSeconds float64 `json:"worst_seconds"`
+}
+
+// Trace is the outcome of one path trace
+type Trace struct {
+	Target          string    `json:"target"`
+	Trigger         string    `json:"trigger"`
+	Test            string    `json:"test,omitempty"` // Failed test that triggered the trace
+	Command         string    `json:"command"`
+	Time            time.Time `json:"time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Hops            []Hop     `json:"hops"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// Tracer runs path traces periodically and after test failures, keeping the
+// most recent ones for the API
+type Tracer struct {
+	metrics *metrics.Collector
+
+	mu          sync.Mutex
+	cfg         *config.Config
+	traces      []Trace              // Oldest first
+	running     map[string]bool      // Targets being traced
+	lastFailure map[string]time.Time // Last failure-triggered trace per target
+}
+
+// New creates a tracer. It does nothing unless traceroute.enabled is set.
+func New(cfg *config.Config, mc *metrics.Collector) *Tracer {
+	return &Tracer{
+		metrics:     mc,
+		cfg:         cfg,
+		running:     make(map[string]bool),
+		lastFailure: make(map[string]time.Time),
+	}
+}
+
+// Reload applies a new configuration
+func (t *Tracer) Reload(cfg *config.Config) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cfg = cfg
+}
+
+// config returns the configuration in effect
+func (t *Tracer) config() *config.Config {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cfg
+}
+
+// Run traces every target each traceroute.interval until ctx is done
+func (t *Tracer) Run(ctx context.Context) {
+	for {
+		cfg := t.config()
+		wait := cfg.Traceroute.IntervalDuration()
+		enabled := cfg.Traceroute.Enabled && wait > 0
+		if enabled {
+			for _, target := range Targets(cfg) {
+				t.trace(ctx, cfg, target, TriggerInterval, "")
+			}
+		} else {
+			wait = time.Minute // Re-check after a config reload
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// OnFailure traces the hosts a failed test talks to in the background. Each
+// target is traced at most once per traceroute.min_interval.
+func (t *Tracer) OnFailure(ctx context.Context, test *config.Test) {
+	if t == nil || ctx.Err() != nil {
+		return
+	}
+	cfg := t.config()
+	if !cfg.Traceroute.Enabled || !cfg.Traceroute.OnFailure {
+		return
+	}
+
+	for _, target := range TestTargets(cfg, test) {
+		t.mu.Lock()
+		recent := time.Since(t.lastFailure[target]) < cfg.Traceroute.MinIntervalDuration()
+		if !recent {
+			t.lastFailure[target] = time.Now()
+		}
+		t.mu.Unlock()
+
+		if recent {
+			logging.With("test_name", test.Name).Debug("Skipping path trace of %s after %s failed: traced recently", target, test.Name)
+			continue
+		}
+		go t.trace(ctx, cfg, target, TriggerFailure, test.Name)
+	}
+}
+
+// Traces returns recent traces, oldest first, optionally only for one target
+func (t *Tracer) Traces(target string, limit int) []Trace {
+	out := []Trace{}
+	if t == nil {
+		return out
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tr := range t.traces {
+		if target == "" || tr.Target == target {
+			out = append(out, tr)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+// trace runs one trace of target and records it, unless one is already running
+func (t *Tracer) trace(ctx context.Context, cfg *config.Config, target, trigger, test string) {
+	t.mu.Lock()
+	if t.running[target] {
+		t.mu.Unlock()
+		logging.Debug("Skipping path trace of %s: already running", target)
+		return
+	}
+	t.running[target] = true
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.running, target)
+		t.mu.Unlock()
+	}()
+
+	tc := cfg.Traceroute
+	ctx, cancel := context.WithTimeout(ctx, tc.TimeoutDuration())
+	defer cancel()
+
+	tr := Trace{
+		Target:  target,
+		Trigger: trigger,
+		Test:    test,
+		Command: tc.GetCommand(),
+		Time:    time.Now().UTC(),
+	}
+	done := t.metrics.TrackSubprocess(tr.Command)
+	hops, err := run(ctx, tc, target)
+	done(nil)
+	tr.DurationSeconds = time.Since(tr.Time).Seconds()
+	tr.Hops = hops
+	if tr.Hops == nil {
+		tr.Hops = []Hop{}
+	}
+
+	pathHops := make([]metrics.PathHop, len(hops))
+	for i, h := range hops {
+		pathHops[i] = metrics.PathHop{
+			RTT:  time.Duration(h.AvgSeconds * float64(time.Second)),
+			Loss: h.LossPercent / 100,
+		}
+	}
+	t.metrics.RecordPathTrace(target, trigger, pathHops, err == nil)
+
+	if err != nil {
+		tr.Error = err.Error()
+		log.Printf("Path trace of %s (%s) failed: %v", target, trigger, err)
+	} else {
+		log.Printf("Path trace of %s (%s): %d hops%s", target, trigger, len(hops), lastHopSummary(hops))
+	}
+
+	t.mu.Lock()
+	t.traces = append(t.traces, tr)
+	if len(t.traces) > maxTraces {
+		t.traces = t.traces[len(t.traces)-maxTraces:]
+	}
+	t.mu.Unlock()
+}
+
+// lastHopSummary describes the final hop for log lines
+func lastHopSummary(hops []Hop) string {
+	if len(hops) == 0 {
+		return ""
+	}
+	last := hops[len(hops)-1]
+	return fmt.Sprintf(", last %s avg %.1fms loss %.0f%%", last.Host, last.AvgSeconds*1000, last.LossPercent)
+}
+
+// Targets returns the hosts traced periodically: traceroute.targets if set,
+// otherwise the S3 endpoint and every satellite host
+func Targets(cfg *config.Config) []string {
+	if len(cfg.Traceroute.Targets) > 0 {
+		return cfg.Traceroute.Targets
+	}
+	var targets []string
+	if host := endpointHost(cfg.S3.Endpoint); host != "" {
+		targets = append(targets, host)
+	}
+	for _, sat := range cfg.AllSatellites() {
+		if host := satelliteHost(sat.AccessGrant); host != "" && !slices.Contains(targets, host) {
+			targets = append(targets, host)
+		}
+	}
+	return targets
+}
+
+// TestTargets returns the hosts a test talks to
+func TestTargets(cfg *config.Config, test *config.Test) []string {
+	var targets []string
+	switch test.GetExecutor() {
+	case "uplink":
+		sat, _ := cfg.GetSatellite(test.Satellite)
+		targets = append(targets, satelliteHost(sat.AccessGrant))
+	case "compare":
+		for _, ep := range test.Compare {
+			targets = append(targets, endpointHost(ep.Endpoint))
+		}
+	case "bakeoff":
+		if slices.Contains(test.GetBakeoff(), "uplink") {
+			sat, _ := cfg.GetSatellite(test.Satellite)
+			targets = append(targets, satelliteHost(sat.AccessGrant))
+		}
+		gw, _ := cfg.GetGateway(test.Gateway)
+		targets = append(targets, endpointHost(gw.Endpoint))
+	case "rtt":
+		if test.RTT != nil && len(test.RTT.Targets) > 0 {
+			for _, target := range test.RTT.Targets {
+				targets = append(targets, Host(target))
+			}
+		} else {
+			targets = append(targets, endpointHost(cfg.S3.Endpoint))
+			for _, sat := range cfg.AllSatellites() {
+				targets = append(targets, satelliteHost(sat.AccessGrant))
+			}
+		}
+	default:
+		gw, _ := cfg.GetGateway(test.Gateway)
+		targets = append(targets, endpointHost(gw.Endpoint))
+	}
+
+	out := targets[:0]
+	for _, target := range targets {
+		if target != "" {
+			out = append(out, target)
+		}
+	}
+	return out
+}
+
+// EndpointAddr returns the host:port of a gateway URL, with the port
+// defaulting by scheme
+func EndpointAddr(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// SatelliteAddr returns the satellite host:port encoded in an access grant
+func SatelliteAddr(accessGrant string) string {
+	if accessGrant == "" {
+		return ""
+	}
+	access, err := uplink.ParseAccess(accessGrant)
+	if err != nil {
+		return ""
+	}
+	addr := access.SatelliteAddress()
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		addr = addr[i+1:] // Strip the node ID
+	}
+	return addr
+}
+
+// Host strips the port from a host:port address
+func Host(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// endpointHost returns the host of a gateway URL
+func endpointHost(endpoint string) string {
+	return Host(EndpointAddr(endpoint))
+}
+
+// satelliteHost returns the satellite host encoded in an access grant
+func satelliteHost(accessGrant string) string {
+	return Host(SatelliteAddr(accessGrant))
+}
+
+// run traces target with the configured command and parses its hops
+func run(ctx context.Context, tc config.TracerouteConfig, target string) ([]Hop, error) {
+	binary := tc.BinaryPath
+	if binary == "" {
+		binary = tc.GetCommand()
+	}
+	count := strconv.Itoa(tc.GetCount())
+	maxHops := strconv.Itoa(tc.GetMaxHops())
+
+	var args []string
+	switch tc.GetCommand() {
+	case config.TraceCommandMTR:
+		args = []string{"--json", "-n", "-c", count, "-m", maxHops, target}
+	case config.TraceCommandTraceroute:
+		args = []string{"-n", "-q", count, "-m", maxHops, target}
+	default:
+		return nil, fmt.Errorf("unknown traceroute command %q (expected mtr or traceroute)", tc.GetCommand())
+	}
+
+	out, err := subproc.Output(subproc.Command(ctx, binary, args...))
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%s failed: %w: %s", binary, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("%s failed: %w", binary, err)
+	}
+
+	if tc.GetCommand() == config.TraceCommandMTR {
+		return parseMTR(out)
+	}
+	return parseTraceroute(out), nil
+}
+
+// mtrReport is the part of `mtr --json` output used here. Older mtr versions
+// report the hop count as a string.
+type mtrReport struct {
+	Report struct {
+		Hubs []struct {
+			Count json.RawMessage `json:"count"`
+			Host  string          `json:"host"`
+			Loss  float64         `json:"Loss%"`
+			Sent  int             `json:"Snt"`
+			Avg   float64         `json:"Avg"`
+			Best  float64         `json:"Best"`
+			Worst float64         `json:"Wrst"`
+		} `json:"hubs"`
+	} `json:"report"`
+}
+
+// parseMTR parses `mtr --json` output (times in milliseconds)
+func parseMTR(data []byte) ([]Hop, error) {
+	var report mtrReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse mtr output: %w", err)
+	}
+
+	hops := make([]Hop, 0, len(report.Report.Hubs))
+	for i, hub := range report.Report.Hubs {
+		n, err := strconv.Atoi(strings.Trim(string(hub.Count), `"`))
+		if err != nil {
+			n = i + 1
+		}
+		host := hub.Host
+		if host == "???" {
+			host = ""
+		}
+		hops = append(hops, Hop{
+			Hop:          n,
+			Host:         host,
+			LossPercent:  hub.Loss,
+			Sent:         hub.Sent,
+			AvgSeconds:   hub.Avg / 1000,
+			BestSeconds:  hub.Best / 1000,
+			WorstSeconds: hub.Worst / 1000,
+		})
+	}
+	return hops, nil
+}
+
+// parseTraceroute parses `traceroute -n` output, e.g.
+//
+//	traceroute to 10.0.0.9 (10.0.0.9), 30 hops max, 60 byte packets
+//	 1  10.0.0.1  0.345 ms  0.300 ms  0.280 ms
+//	 2  * * *
+func parseTraceroute(data []byte) []Hop {
+	var hops []Hop
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue // Header line
+		}
+
+		hop := Hop{Hop: n}
+		var rtts []float64
+		lost := 0
+		for i := 1; i < len(fields); i++ {
+			f := fields[i]
+			switch {
+			case f == "*":
+				lost++
+			case i+1 < len(fields) && fields[i+1] == "ms":
+				if v, err := strconv.ParseFloat(f, 64); err == nil {
+					rtts = append(rtts, v/1000)
+				}
+				i++
+			case hop.Host == "" && net.ParseIP(f) != nil:
+				hop.Host = f
+			}
+		}
+
+		hop.Sent = len(rtts) + lost
+		if hop.Sent > 0 {
+			hop.LossPercent = float64(lost) / float64(hop.Sent) * 100
+		}
+		if len(rtts) > 0 {
+			hop.BestSeconds, hop.WorstSeconds = rtts[0], rtts[0]
+			var sum float64
+			for _, v := range rtts {
+				sum += v
+				hop.BestSeconds = min(hop.BestSeconds, v)
+				hop.WorstSeconds = max(hop.WorstSeconds, v)
+			}
+			hop.AvgSeconds = sum / float64(len(rtts))
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
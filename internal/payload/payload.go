@@ -0,0 +1,142 @@
+// Package payload generates upload data for every executor and for the k6
+// test data files. crypto/rand generation dominates CPU for multi-GB uploads
+// on small probes, so cheaper sources can be selected per test step.
+package payload
+
+import (
+	cryptorand "crypto/rand"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sync"
+
+	"github.com/ethanadams/synthetics/internal/config"
+)
+
+// Source fills buffers with payload data
+type Source interface {
+	// Fill fills p with the object's bytes starting at offset off, so
+	// deterministic sources continue seamlessly across calls
+	Fill(p []byte, off int64) error
+}
+
+// New returns the source described by cfg
+func New(cfg config.PayloadConfig) (Source, error) {
+	switch cfg.GetSource() {
+	case config.PayloadCrypto:
+		return cryptoSource{}, nil
+	case config.PayloadMath:
+		return mathSource{}, nil
+	case config.PayloadZeros:
+		return zeroSource{}, nil
+	case config.PayloadPattern:
+		return repeatSource(cfg.GetPattern()), nil
+	case config.PayloadFile:
+		return fileSource(cfg.File)
+	default:
+		return nil, fmt.Errorf("unknown payload source %q", cfg.Source)
+	}
+}
+
+// Reader returns a reader of size bytes from src, generated as they are read
+func Reader(src Source, size int64) io.Reader {
+	return &reader{src: src, size: size}
+}
+
+type reader struct {
+	src  Source
+	off  int64
+	size int64
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.off >= r.size {
+		return 0, io.EOF
+	}
+	if remaining := r.size - r.off; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	if err := r.src.Fill(p, r.off); err != nil {
+		return 0, fmt.Errorf("failed to generate payload: %w", err)
+	}
+	r.off += int64(len(p))
+	return len(p), nil
+}
+
+// cryptoSource is incompressible and unpredictable, but the slowest
+type cryptoSource struct{}
+
+func (cryptoSource) Fill(p []byte, _ int64) error {
+	_, err := cryptorand.Read(p)
+	return err
+}
+
+// mathSource is incompressible and several times faster than crypto/rand.
+// Each fill is seeded independently, so it is safe for concurrent use.
+type mathSource struct{}
+
+func (mathSource) Fill(p []byte, _ int64) error {
+	var seed [32]byte
+	for i := 0; i < len(seed); i += 8 {
+		v := rand.Uint64()
+		for j := range 8 {
+			seed[i+j] = byte(v >> (8 * j))
+		}
+	}
+	_, err := rand.NewChaCha8(seed).Read(p)
+	return err
+}
+
+// zeroSource costs nothing to generate, but compresses and dedupes trivially
+type zeroSource struct{}
+
+func (zeroSource) Fill(p []byte, _ int64) error {
+	clear(p)
+	return nil
+}
+
+// repeatSource repeats a pattern or the contents of a file
+type repeatSource []byte
+
+func (s repeatSource) Fill(p []byte, off int64) error {
+	n := copy(p, s[off%int64(len(s)):])
+	if n == len(p) {
+		return nil
+	}
+	// p[n:] starts on a pattern boundary: copy one pattern, then keep
+	// doubling the whole patterns already written
+	base := n
+	n += copy(p[n:], s)
+	for n < len(p) {
+		n += copy(p[n:], p[base:n])
+	}
+	return nil
+}
+
+// maxFileSize bounds payload files, which are held in memory
+const maxFileSize = 64 << 20
+
+// files caches payload file contents by path
+var files sync.Map
+
+// fileSource repeats the contents of a file, e.g. real-world data with
+// realistic compressibility. The file is read once per process.
+func fileSource(path string) (Source, error) {
+	if data, ok := files.Load(path); ok {
+		return repeatSource(data.([]byte)), nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("payload file: %w", err)
+	}
+	if info.Size() == 0 || info.Size() > maxFileSize {
+		return nil, fmt.Errorf("payload file %s must be 1B to 64MB, got %d bytes", path, info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("payload file: %w", err)
+	}
+	files.Store(path, data)
+	return repeatSource(data), nil
+}
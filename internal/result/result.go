@@ -0,0 +1,175 @@
+// Package result defines the record of a single test run. Executors build a
+// Result while running; the scheduler, metrics, and APIs all consume it.
+package result
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/runctx"
+	"github.com/ethanadams/synthetics/internal/tlscheck"
+)
+
+// Error classes
+const (
+	ClassTimeout  = "timeout"
+	ClassCanceled = "canceled"
+	ClassTLS      = "tls"
+	ClassError    = "error" // Anything not classified more specifically
+)
+
+// codedError is an error carrying its own class: s3err.Error from the raw
+// HTTP executors, smithy.APIError from the AWS SDK, a curl exit code, or a
+// failed executor check (e.g. a corrupt canary)
+type codedError interface {
+	ErrorCode() string
+}
+
+// Result is the outcome of one test run
+type Result struct {
+	RunID           string    `json:"run_id"`
+	Test            string    `json:"test"`
+	Executor        string    `json:"executor"`
+	Endpoint        string    `json:"endpoint,omitempty"`  // Gateway URL; empty for uplink, compare, and rtt
+	Satellite       string    `json:"satellite,omitempty"` // Satellite name; set for uplink
+	Start           time.Time `json:"start"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	SetupSeconds    float64   `json:"setup_seconds,omitempty"` // From the start to the first step: object claim, TLS and bucket checks
+	Success         bool      `json:"success"`
+	FailedStep      string    `json:"failed_step,omitempty"` // Empty if the run failed outside a step
+	Error           string    `json:"error,omitempty"`
+	ErrorClass      string    `json:"error_class,omitempty"`
+	Steps           []Step    `json:"steps"`
+
+	Bakeoff []BakeoffEntry `json:"bakeoff,omitempty"` // Set by the bakeoff executor: one entry per executor, in run order
+}
+
+// BakeoffEntry compares one executor's run of a bakeoff with the others
+type BakeoffEntry struct {
+	Executor        string  `json:"executor"`
+	RunID           string  `json:"run_id,omitempty"` // Empty if the executor is not configured
+	Success         bool    `json:"success"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Relative        float64 `json:"relative,omitempty"` // Duration over the fastest successful executor's (1 = fastest); unset on failure
+	Error           string  `json:"error,omitempty"`
+}
+
+// Step is the outcome of one step of a run
+type Step struct {
+	Name            string             `json:"name"`
+	Executor        string             `json:"executor,omitempty"` // Set when it differs from the run's (compare endpoints)
+	Success         bool               `json:"success"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	Bytes           int64              `json:"bytes,omitempty"`
+	StatusCode      int                `json:"status_code,omitempty"` // HTTP status of the step's response; unset if none was received
+	Phases          map[string]float64 `json:"phases,omitempty"`      // HTTP phase durations in seconds
+	RequestID       string             `json:"request_id,omitempty"`
+	Outputs         map[string]string  `json:"outputs,omitempty"` // Values later steps may reference
+	Error           string             `json:"error,omitempty"`
+	ErrorClass      string             `json:"error_class,omitempty"`
+}
+
+// New starts the result of a run
+func New(run *runctx.Run) *Result {
+	return &Result{
+		RunID:     run.ID,
+		Test:      run.Test,
+		Executor:  run.Executor,
+		Endpoint:  run.Endpoint,
+		Satellite: run.Satellite,
+		Start:     run.Start,
+		Steps:     []Step{},
+	}
+}
+
+// Finish completes the result with the run's error (nil on success) and
+// returns both, so executors can end with "return res.Finish(err)"
+func (r *Result) Finish(err error) (*Result, error) {
+	r.DurationSeconds = time.Since(r.Start).Seconds()
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+		r.ErrorClass = Classify(err)
+	}
+	return r, err
+}
+
+// Duration returns the run duration
+func (r *Result) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds * float64(time.Second))
+}
+
+// WithoutOutputs returns a copy of the result without its steps' outputs,
+// for keeping or serving beyond the run: outputs can hold credentials such
+// as presigned URLs
+func (r *Result) WithoutOutputs() *Result {
+	out := *r
+	out.Steps = make([]Step, len(r.Steps))
+	for i, step := range r.Steps {
+		step.Outputs = nil
+		out.Steps[i] = step
+	}
+	return &out
+}
+
+// StepsStarting notes that the run's setup is done and its first step begins
+func (r *Result) StepsStarting() {
+	if r.SetupSeconds == 0 {
+		r.SetupSeconds = time.Since(r.Start).Seconds()
+	}
+}
+
+// StepsStart returns when the run's first step began
+func (r *Result) StepsStart() time.Time {
+	return r.Start.Add(time.Duration(r.SetupSeconds * float64(time.Second)))
+}
+
+// Finish completes the step with its duration and error (nil on success)
+func (s *Step) Finish(start time.Time, err error) {
+	s.DurationSeconds = time.Since(start).Seconds()
+	s.Success = err == nil
+	if err != nil {
+		s.Error = err.Error()
+		s.ErrorClass = Classify(err)
+	}
+}
+
+// SetOutput exports a value later steps of the run may reference; empty
+// values are not exported
+func (s *Step) SetOutput(name, value string) {
+	if value == "" {
+		return
+	}
+	if s.Outputs == nil {
+		s.Outputs = make(map[string]string)
+	}
+	s.Outputs[name] = value
+}
+
+// Duration returns the step duration
+func (s *Step) Duration() time.Duration {
+	return time.Duration(s.DurationSeconds * float64(time.Second))
+}
+
+// Classify returns the error class of a run or step error. S3 errors are
+// classified by their S3 error code (e.g. "AccessDenied", "SlowDown") and curl
+// failures by their exit code (e.g. "dns", "connect").
+func Classify(err error) string {
+	var tlsErr *tlscheck.Error
+	var coded codedError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ClassCanceled
+	case errors.As(err, &tlsErr):
+		return ClassTLS
+	case errors.As(err, &coded) && coded.ErrorCode() != "":
+		return coded.ErrorCode()
+	default:
+		return ClassError
+	}
+}
@@ -0,0 +1,84 @@
+package result
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Summary is the compact record of a run logged as one line when it
+// completes, enough to reconstruct the run without metrics. Unlike Result it
+// leaves out step outputs, which may hold presigned URLs.
+type Summary struct {
+	RunID           string        `json:"run_id"`
+	Test            string        `json:"test"`
+	Executor        string        `json:"executor"`
+	Endpoint        string        `json:"endpoint,omitempty"`
+	Satellite       string        `json:"satellite,omitempty"`
+	Success         bool          `json:"success"`
+	DurationSeconds float64       `json:"duration_seconds"`
+	SetupSeconds    float64       `json:"setup_seconds,omitempty"`
+	FailedStep      string        `json:"failed_step,omitempty"`
+	ErrorClass      string        `json:"error_class,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	Steps           []StepSummary `json:"steps"`
+}
+
+// StepSummary is the record of one step in a Summary
+type StepSummary struct {
+	Name            string             `json:"name"`
+	Executor        string             `json:"executor,omitempty"`
+	Success         bool               `json:"success"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	Bytes           int64              `json:"bytes,omitempty"`
+	Phases          map[string]float64 `json:"phases,omitempty"`
+	ErrorClass      string             `json:"error_class,omitempty"`
+}
+
+// Summary returns the run's summary, with durations rounded to microseconds
+func (r *Result) Summary() Summary {
+	s := Summary{
+		RunID:           r.RunID,
+		Test:            r.Test,
+		Executor:        r.Executor,
+		Endpoint:        r.Endpoint,
+		Satellite:       r.Satellite,
+		Success:         r.Success,
+		DurationSeconds: roundMicros(r.DurationSeconds),
+		SetupSeconds:    roundMicros(r.SetupSeconds),
+		FailedStep:      r.FailedStep,
+		ErrorClass:      r.ErrorClass,
+		Error:           r.Error,
+		Steps:           make([]StepSummary, len(r.Steps)),
+	}
+	for i, step := range r.Steps {
+		ss := StepSummary{
+			Name:            step.Name,
+			Executor:        step.Executor,
+			Success:         step.Success,
+			DurationSeconds: roundMicros(step.DurationSeconds),
+			Bytes:           step.Bytes,
+			ErrorClass:      step.ErrorClass,
+		}
+		if len(step.Phases) > 0 {
+			ss.Phases = make(map[string]float64, len(step.Phases))
+			for phase, seconds := range step.Phases {
+				ss.Phases[phase] = roundMicros(seconds)
+			}
+		}
+		s.Steps[i] = ss
+	}
+	return s
+}
+
+// SummaryLine returns the run's summary as one line of JSON
+func (r *Result) SummaryLine() string {
+	b, err := json.Marshal(r.Summary())
+	if err != nil {
+		return "{}" // Unreachable: every field encodes
+	}
+	return string(b)
+}
+
+func roundMicros(seconds float64) float64 {
+	return math.Round(seconds*1e6) / 1e6
+}
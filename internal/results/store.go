@@ -0,0 +1,292 @@
+// Package results persists every test run and its steps to SQLite, so the
+// details Prometheus histograms aggregate away (which step of which run
+// failed at 03:14, with what error and request ID, and where its time went)
+// can be looked up later.
+package results
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/result"
+	_ "github.com/mattn/go-sqlite3" // Registers the "sqlite3" driver
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	run_id           TEXT PRIMARY KEY,
+	test             TEXT NOT NULL,
+	executor         TEXT NOT NULL,
+	endpoint         TEXT NOT NULL DEFAULT '',
+	satellite        TEXT NOT NULL DEFAULT '',
+	start            INTEGER NOT NULL, -- Unix nanoseconds
+	duration_seconds REAL NOT NULL,
+	success          INTEGER NOT NULL,
+	failed_step      TEXT NOT NULL DEFAULT '',
+	error            TEXT NOT NULL DEFAULT '',
+	error_class      TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS runs_test_start ON runs (test, start);
+CREATE INDEX IF NOT EXISTS runs_start ON runs (start);
+
+CREATE TABLE IF NOT EXISTS steps (
+	run_id           TEXT NOT NULL REFERENCES runs (run_id) ON DELETE CASCADE,
+	seq              INTEGER NOT NULL, -- Position of the step in the run
+	name             TEXT NOT NULL,
+	executor         TEXT NOT NULL DEFAULT '',
+	success          INTEGER NOT NULL,
+	duration_seconds REAL NOT NULL,
+	bytes            INTEGER NOT NULL DEFAULT 0,
+	status_code      INTEGER NOT NULL DEFAULT 0, -- HTTP status of the step's response, 0 if none
+	phases           TEXT NOT NULL DEFAULT '', -- JSON object of HTTP phase seconds
+	request_id       TEXT NOT NULL DEFAULT '',
+	error            TEXT NOT NULL DEFAULT '',
+	error_class      TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (run_id, seq)
+);
+`
+
+// Store records test runs in a SQLite database. A nil *Store records
+// nothing, so callers need not check whether persistence is enabled.
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultLimit is the number of runs a query returns when its filter sets
+// no limit
+const DefaultLimit = 100
+
+// MaxLimit bounds the runs a query returns, which are all loaded into memory
+const MaxLimit = 1000
+
+// Filter selects runs. Zero values match everything, up to DefaultLimit runs.
+type Filter struct {
+	Test   string
+	Failed bool // Only failed runs
+	Since  time.Time
+	Until  time.Time
+	Limit  int // Most recent runs to return (default DefaultLimit, at most MaxLimit)
+}
+
+// Open opens (creating if needed) the database at path
+func Open(path string) (*Store, error) {
+	// WAL lets API queries read while runs are recorded; the busy timeout
+	// covers the brief write locks
+	dsn := "file:" + path + "?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results store %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1) // SQLite allows one writer; serialize in the pool instead of on SQLITE_BUSY
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open results store %s: %w", path, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open results store %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// migrate adds the columns introduced since the database was created.
+// CREATE TABLE IF NOT EXISTS leaves existing tables as they were.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('steps')`)
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !columns["status_code"] {
+		if _, err := db.Exec(`ALTER TABLE steps ADD COLUMN status_code INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Record stores a run and its steps
+func (s *Store) Record(res *result.Result) error {
+	if s == nil || res == nil {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to record run %s: %w", res.RunID, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO runs
+		(run_id, test, executor, endpoint, satellite, start, duration_seconds, success, failed_step, error, error_class)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		res.RunID, res.Test, res.Executor, res.Endpoint, res.Satellite, res.Start.UnixNano(),
+		res.DurationSeconds, res.Success, res.FailedStep, res.Error, res.ErrorClass); err != nil {
+		return fmt.Errorf("failed to record run %s: %w", res.RunID, err)
+	}
+	for i, step := range res.Steps {
+		phases := ""
+		if len(step.Phases) > 0 {
+			b, _ := json.Marshal(step.Phases)
+			phases = string(b)
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO steps
+			(run_id, seq, name, executor, success, duration_seconds, bytes, status_code, phases, request_id, error, error_class)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			res.RunID, i, step.Name, step.Executor, step.Success, step.DurationSeconds, step.Bytes, step.StatusCode,
+			phases, step.RequestID, step.Error, step.ErrorClass); err != nil {
+			return fmt.Errorf("failed to record run %s: %w", res.RunID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record run %s: %w", res.RunID, err)
+	}
+	return nil
+}
+
+// Query returns the runs matching the filter with their steps, most recent
+// first
+func (s *Store) Query(ctx context.Context, f Filter) ([]*result.Result, error) {
+	var where []string
+	var args []any
+	if f.Test != "" {
+		where = append(where, "test = ?")
+		args = append(args, f.Test)
+	}
+	if f.Failed {
+		where = append(where, "success = 0")
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "start >= ?")
+		args = append(args, f.Since.UnixNano())
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "start < ?")
+		args = append(args, f.Until.UnixNano())
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+	selected := "FROM runs"
+	if len(where) > 0 {
+		selected += " WHERE " + strings.Join(where, " AND ")
+	}
+	selected += fmt.Sprintf(" ORDER BY start DESC LIMIT %d", limit)
+
+	// One read transaction, so the steps are those of the runs selected even
+	// while runs are recorded and pruned
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query results: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT run_id, test, executor, endpoint, satellite, start, duration_seconds, success, failed_step, error, error_class `+selected, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query results: %w", err)
+	}
+	defer rows.Close()
+	runs := []*result.Result{}
+	byID := make(map[string]*result.Result)
+	for rows.Next() {
+		res := &result.Result{Steps: []result.Step{}}
+		var start int64
+		if err := rows.Scan(&res.RunID, &res.Test, &res.Executor, &res.Endpoint, &res.Satellite, &start,
+			&res.DurationSeconds, &res.Success, &res.FailedStep, &res.Error, &res.ErrorClass); err != nil {
+			return nil, fmt.Errorf("failed to query results: %w", err)
+		}
+		res.Start = time.Unix(0, start).UTC()
+		runs = append(runs, res)
+		byID[res.RunID] = res
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query results: %w", err)
+	}
+	rows.Close()
+	if len(runs) == 0 {
+		return runs, nil
+	}
+	return runs, loadSteps(ctx, tx, selected, args, byID)
+}
+
+// loadSteps adds their steps, in order, to the runs selected by the runs
+// query clause selected
+func loadSteps(ctx context.Context, tx *sql.Tx, selected string, args []any, byID map[string]*result.Result) error {
+	rows, err := tx.QueryContext(ctx, `SELECT run_id, name, executor, success, duration_seconds, bytes, status_code, phases, request_id, error, error_class
+		FROM steps WHERE run_id IN (SELECT run_id `+selected+`) ORDER BY run_id, seq`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query results: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var runID, phases string
+		var step result.Step
+		if err := rows.Scan(&runID, &step.Name, &step.Executor, &step.Success, &step.DurationSeconds, &step.Bytes,
+			&step.StatusCode, &phases, &step.RequestID, &step.Error, &step.ErrorClass); err != nil {
+			return fmt.Errorf("failed to query results: %w", err)
+		}
+		if phases != "" {
+			_ = json.Unmarshal([]byte(phases), &step.Phases)
+		}
+		if res, ok := byID[runID]; ok {
+			res.Steps = append(res.Steps, step)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query results: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes the runs that started before cutoff, and returns how many
+func (s *Store) Prune(cutoff time.Time) (int64, error) {
+	r, err := s.db.Exec(`DELETE FROM runs WHERE start < ?`, cutoff.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune results: %w", err)
+	}
+	return r.RowsAffected()
+}
+
+// RunPrune deletes runs older than retention every hour until ctx is done
+func (s *Store) RunPrune(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if n, err := s.Prune(time.Now().Add(-retention)); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d run(s) older than %v from the results store", n, retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,216 @@
+// Package runctx identifies a single run of a test. A Run is created once per
+// RunTest call and passed explicitly to every step, so concurrent runs of the
+// same test never share object keys, temp files, or log lines.
+package runctx
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/version"
+	"github.com/ethanadams/synthetics/internal/workdir"
+	"github.com/oklog/ulid/v2"
+)
+
+// Run holds the identity of one test run. Metrics are recorded against it,
+// so it carries every dimension a metric may be labeled with.
+type Run struct {
+	ID        string // ULID, unique per run
+	Test      string
+	Executor  string
+	Endpoint  string // Gateway URL; empty for uplink
+	Region    string
+	Bucket    string
+	Satellite string // Satellite name; empty for gateway executors
+	Filename  string // Object key shared by the run's steps
+	Tags      []string
+	Start     time.Time
+	Shared    bool // Reads a fixture object shared with other tests and never modifies it
+
+	uploads *uploads // Content uploaded by the run; nil unless the test verifies content or metadata
+}
+
+// Content is the SHA-256 of an object a run uploaded: of the whole object,
+// or of each part of a multipart upload
+type Content struct {
+	Size     int64
+	PartSize int64             // Size of each part but the last; 0 for a single PUT
+	Sums     [][]byte          // SHA-256 of each part; nil if not hashed (uplink)
+	Metadata map[string]string // User metadata written with the object
+}
+
+// uploads holds the content a run uploaded, by endpoint and object key
+type uploads struct {
+	mu    sync.Mutex
+	byKey map[string]*Content
+}
+
+// New creates a run of the test on the named executor
+func New(test *config.Test, executor, defaultBucket string) *Run {
+	start := time.Now()
+	id := ulid.MustNew(ulid.Timestamp(start), ulid.Monotonic(rand.Reader, 0)).String()
+	return &Run{
+		ID:       id,
+		Test:     test.Name,
+		Executor: executor,
+		Bucket:   test.GetBucket(defaultBucket),
+		Filename: test.GetFilename(id),
+		Tags:     test.Tags,
+		Start:    start,
+		Shared:   test.Fixture != "",
+		uploads:  newUploads(test),
+	}
+}
+
+// newUploads returns the record of uploaded content if a step of the test
+// verifies it
+func newUploads(test *config.Test) *uploads {
+	if !test.VerifiesContent() {
+		return nil
+	}
+	return &uploads{byKey: make(map[string]*Content)}
+}
+
+// VerifiesContent reports whether the run records the content it uploads,
+// for download steps with verify_content or stat steps with verify_metadata
+func (r *Run) VerifiesContent() bool {
+	return r.uploads != nil
+}
+
+// RecordContent records the content the run uploaded to its object. It does
+// nothing unless the run verifies content.
+func (r *Run) RecordContent(c *Content) {
+	if r.uploads == nil {
+		return
+	}
+	r.uploads.mu.Lock()
+	defer r.uploads.mu.Unlock()
+	r.uploads.byKey[r.contentKey()] = c
+}
+
+// UploadedContent returns the content the run last uploaded to its object
+func (r *Run) UploadedContent() (*Content, bool) {
+	if r.uploads == nil {
+		return nil, false
+	}
+	r.uploads.mu.Lock()
+	defer r.uploads.mu.Unlock()
+	c, ok := r.uploads.byKey[r.contentKey()]
+	return c, ok
+}
+
+// contentKey identifies the run's object across the endpoints of a compare
+// test
+func (r *Run) contentKey() string {
+	return r.Endpoint + " " + r.Bucket + "/" + r.Filename
+}
+
+// On returns a copy of the run as executed by another executor against
+// another endpoint. Compare tests use it so every endpoint shares the run's
+// ID and object key but is labeled separately.
+func (r *Run) On(executor, endpoint, region string) *Run {
+	cp := *r
+	cp.Executor = executor
+	cp.Endpoint = endpoint
+	cp.Region = region
+	return &cp
+}
+
+// String identifies the run in log lines
+func (r *Run) String() string {
+	return r.Test + "/" + r.ID
+}
+
+// Log returns a logger whose lines carry the run's test_name, executor, ulid,
+// and bucket fields
+func (r *Run) Log() logging.Logger {
+	return logging.With("test_name", r.Test, "executor", r.Executor, "ulid", r.ID, "bucket", r.Bucket)
+}
+
+// StepLog returns a logger whose lines carry the run's fields and the step
+func (r *Run) StepLog(step string) logging.Logger {
+	return r.Log().With("step", step)
+}
+
+// UserAgent renders a User-Agent template (see config.DefaultUserAgent) for
+// the run. Substituted values are reduced to HTTP token characters, so any
+// test name yields a valid header.
+func (r *Run) UserAgent(template string) string {
+	return strings.NewReplacer(
+		"{version}", userAgentToken(version.Version),
+		"{run_id}", r.ID,
+		"{test}", userAgentToken(r.Test),
+		"{executor}", userAgentToken(r.Executor),
+	).Replace(template)
+}
+
+// userAgentToken replaces characters not allowed in an HTTP token with "-"
+func userAgentToken(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c < 0x80 && (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return c
+		}
+		return '-'
+	}, s)
+}
+
+// TempPath returns a path in the work directory's temp dir unique to this
+// run and step (e.g. "k6-output-<test>-<step>-<ULID>.json")
+func (r *Run) TempPath(prefix, step, ext string) string {
+	return filepath.Join(workdir.TempDir(), fmt.Sprintf("%s-%s-%s-%s.%s", prefix, r.Test, step, r.ID, ext))
+}
+
+// Object keys held by active runs
+var (
+	claimsMu sync.Mutex
+	claims   = make(map[string]*claim)
+)
+
+// claim is an active run's hold on an object key
+type claim struct {
+	run  string
+	done chan struct{}
+}
+
+// Claim reserves the run's bucket/filename until release is called, waiting
+// while another active run holds it. ULID filenames never collide; this
+// serializes overlapping runs of tests with a fixed filename so one run's
+// delete cannot remove the object another run just uploaded. Shared runs
+// only read, so they don't claim the object and never wait.
+func (r *Run) Claim(ctx context.Context) (release func(), err error) {
+	if r.Shared {
+		return func() {}, nil
+	}
+
+	key := r.Bucket + "/" + r.Filename
+	for {
+		claimsMu.Lock()
+		held, busy := claims[key]
+		if !busy {
+			c := &claim{run: r.String(), done: make(chan struct{})}
+			claims[key] = c
+			claimsMu.Unlock()
+			return func() {
+				claimsMu.Lock()
+				delete(claims, key)
+				claimsMu.Unlock()
+				close(c.done)
+			}, nil
+		}
+		claimsMu.Unlock()
+
+		r.Log().Printf("Run %s waiting for object %s held by run %s", r, key, held.run)
+		select {
+		case <-held.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for object %s: %w", key, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package s3err parses S3 XML error responses so failures can be classified
+// by their S3 error code (AccessDenied, SlowDown, ...) instead of only the
+// HTTP status.
+package s3err
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxBody bounds how much of an error response is read
+const maxBody = 64 << 10
+
+// Error is a non-2xx S3 response
+type Error struct {
+	StatusCode int
+	Code       string // S3 error code; empty if the body was not an S3 error
+	Message    string
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("status %d", e.StatusCode)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request id " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// ErrorCode returns the S3 error code, or http_<status> when the response had
+// none (e.g. HEAD requests or a proxy error page). It has the same signature
+// as the AWS SDK's smithy.APIError, so both classify the same way.
+func (e *Error) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return fmt.Sprintf("http_%d", e.StatusCode)
+}
+
+// Parse builds an Error from a response status and body. Bodies that are not
+// S3 XML errors leave Code and Message empty.
+func Parse(statusCode int, body []byte) *Error {
+	var doc struct {
+		Code      string `xml:"Code"`
+		Message   string `xml:"Message"`
+		RequestID string `xml:"RequestId"`
+	}
+	_ = xml.Unmarshal(body, &doc)
+	return &Error{
+		StatusCode: statusCode,
+		Code:       strings.TrimSpace(doc.Code),
+		Message:    strings.TrimSpace(doc.Message),
+		RequestID:  strings.TrimSpace(doc.RequestID),
+	}
+}
+
+// FromResponse reads the body of a non-2xx response and parses it. The
+// request ID falls back to the X-Amz-Request-Id header.
+func FromResponse(resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	e := Parse(resp.StatusCode, body)
+	if e.RequestID == "" {
+		e.RequestID = resp.Header.Get("X-Amz-Request-Id")
+	}
+	return e
+}
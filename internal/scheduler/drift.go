@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// driftWeight is the weight of the latest run in the moving average
+const driftWeight = 0.3
+
+// driftTracker keeps a moving average of how long each test's scheduled runs
+// waited for a run slot. Jitter compensation subtracts it from the jitter
+// budget, so jitter plus queueing stays close to the configured maximum
+// instead of adding up to runs starting far behind their schedule.
+type driftTracker struct {
+	mu     sync.Mutex
+	queued map[string]time.Duration
+}
+
+func newDriftTracker() *driftTracker {
+	return &driftTracker{queued: make(map[string]time.Duration)}
+}
+
+// observe records the queueing delay of a scheduled run
+func (d *driftTracker) observe(test string, queued time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	avg, ok := d.queued[test]
+	if !ok {
+		d.queued[test] = queued
+		return
+	}
+	d.queued[test] = avg + time.Duration(driftWeight*float64(queued-avg))
+}
+
+// jitter returns maxJitter reduced by the test's average queueing delay
+func (d *driftTracker) jitter(test string, maxJitter time.Duration) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return max(maxJitter-d.queued[test], 0)
+}
+
+// forget drops a removed test's history
+func (d *driftTracker) forget(test string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.queued, test)
+}
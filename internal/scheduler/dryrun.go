@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/workdir"
+)
+
+// PlannedRun is one cron firing of a test in a dry run, and whether it
+// would start a run
+type PlannedRun struct {
+	Time   time.Time `json:"time"`             // Cron time
+	Latest time.Time `json:"latest,omitzero"`  // Latest start after jitter; zero without jitter
+	Run    bool      `json:"run"`              // False if the firing would be skipped
+	Reason string    `json:"reason,omitempty"` // Why it would be skipped, as on skipped events
+	Detail string    `json:"detail,omitempty"`
+}
+
+// PlannedTest is what a dry run expects of one test
+type PlannedTest struct {
+	Test             string       `json:"test"`
+	Executor         string       `json:"executor"`
+	Schedule         string       `json:"schedule"`
+	Scheduled        bool         `json:"scheduled"`        // Has a cron entry
+	Reason           string       `json:"reason,omitempty"` // Why it has none, as on skipped events
+	Detail           string       `json:"detail,omitempty"`
+	MaxJitterSeconds float64      `json:"max_jitter_seconds,omitempty"` // After queueing compensation
+	WouldRun         int          `json:"would_run"`                    // Firings that would start a run
+	Runs             []PlannedRun `json:"runs"`
+}
+
+// DryRun is the outcome of evaluating the schedule over a window without
+// running anything
+type DryRun struct {
+	From  time.Time     `json:"from"`
+	To    time.Time     `json:"to"`
+	Tests []PlannedTest `json:"tests"`
+}
+
+// DryRun evaluates which tests would run between now and now+window, from
+// the cron entries and the current state: disabled tests and tags, when
+// conditions at each firing, fixtures not yet uploaded, a run still in flight
+// under concurrency_policy forbid, and the disk budget. State that changes
+// before a firing (a tag enabled, a run finishing, disk freed) changes the
+// outcome; runs waiting for a slot under scheduler.max_concurrent start late
+// rather than being skipped.
+func (s *Scheduler) DryRun(now time.Time, window time.Duration) DryRun {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	to := now.Add(window)
+	out := DryRun{From: now, To: to, Tests: []PlannedTest{}}
+	for _, test := range s.config.Tests {
+		pt := PlannedTest{Test: test.Name, Executor: test.ExecutorKey(), Schedule: test.Schedule, Runs: []PlannedRun{}}
+		id, ok := s.entries[test.Name]
+		if !ok {
+			pt.Reason = ReasonTestDisabled
+			if _, known := s.executors[test.ExecutorKey()]; test.Enabled && !known {
+				pt.Reason, pt.Detail = ReasonUnknownExecutor, test.ExecutorKey()
+			}
+			out.Tests = append(out.Tests, pt)
+			continue
+		}
+		pt.Scheduled = true
+		entry := s.cron.Entry(id)
+
+		// The same jitter the cron job applies
+		var maxJitter time.Duration
+		if jitter := test.GetTestJitter(s.config.Jitter); jitter.IsEnabled() {
+			interval, _ := config.ParseCronInterval(test.Schedule)
+			maxJitter, _ = jitter.ParseMaxJitter(interval)
+			if jitter.Compensates() {
+				maxJitter = s.drift.jitter(test.Name, maxJitter)
+			}
+		}
+		pt.MaxJitterSeconds = maxJitter.Seconds()
+
+		// Reasons that hold for every firing until the state changes
+		var reason, detail string
+		if i := slices.IndexFunc(test.Tags, func(tag string) bool { return s.disabledTags[tag] }); i >= 0 {
+			reason, detail = ReasonTagDisabled, test.Tags[i]
+		} else if test.Fixture != "" && !s.fixtureReady(test.Fixture) {
+			reason, detail = ReasonFixtureNotReady, test.Fixture
+		} else if err := workdir.Fits(test.DiskNeed()); err != nil {
+			reason, detail = ReasonDiskBudget, err.Error()
+		}
+		inFlight := test.GetConcurrencyPolicy(s.config.Scheduler.ConcurrencyPolicy) == config.ConcurrencyForbid && s.overlap.running(test.Name)
+
+		next := entry.Next
+		if next.IsZero() || next.Before(now) {
+			next = entry.Schedule.Next(now) // Not started yet, or about to fire
+		}
+		for t := next; !t.IsZero() && t.Before(to); t = entry.Schedule.Next(t) {
+			run := PlannedRun{Time: t, Reason: reason, Detail: detail}
+			if maxJitter > 0 {
+				run.Latest = t.Add(maxJitter)
+			}
+			if run.Reason == "" {
+				if _, err := test.Applicable(t, s.config.S3.Endpoint); err != nil {
+					run.Reason, run.Detail = ReasonConditionUnmet, err.Error()
+				} else if inFlight && len(pt.Runs) == 0 {
+					run.Reason, run.Detail = ReasonStillRunning, "previous run in flight now"
+				}
+			}
+			run.Run = run.Reason == ""
+			if run.Run {
+				pt.WouldRun++
+			}
+			pt.Runs = append(pt.Runs, run)
+		}
+		out.Tests = append(out.Tests, pt)
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event types recorded in the event log
+const (
+	EventScheduled   = "scheduled"   // Cron entry added for a test
+	EventUnscheduled = "unscheduled" // Cron entry removed (test removed or changed)
+	EventFired       = "fired"       // Run started (by cron or on demand)
+	EventSkipped     = "skipped"     // Run or scheduling skipped; see Reason
+	EventCompleted   = "completed"   // Run finished successfully
+	EventFailed      = "failed"      // Run finished with an error
+	EventRetrying    = "retrying"    // Failed run will be retried after a backoff
+)
+
+// Reasons attached to skipped and unscheduled events
+const (
+	ReasonTestDisabled      = "test-disabled"
+	ReasonUnknownExecutor   = "unknown-executor"
+	ReasonTagDisabled       = "tag-disabled"
+	ReasonJitterInterrupted = "jitter-interrupted"
+	ReasonRemoved           = "removed"
+	ReasonChanged           = "changed"
+	ReasonCanceled          = "canceled" // Shut down while queued for a run slot
+	ReasonFixtureNotReady   = "fixture-not-ready"
+	ReasonConditionUnmet    = "condition-unmet" // A when condition doesn't hold on this probe
+	ReasonDiskBudget        = "disk-budget"     // The run would exceed the work directory disk budget
+	ReasonStillRunning      = "still-running"   // The previous run is in flight and concurrency_policy is forbid
+)
+
+// Event is a single scheduler lifecycle event
+type Event struct {
+	Time            time.Time `json:"time"`
+	Type            string    `json:"type"`
+	Test            string    `json:"test"`
+	RunID           string    `json:"run_id,omitempty"` // Set on completed and failed events
+	Reason          string    `json:"reason,omitempty"`
+	Detail          string    `json:"detail,omitempty"`
+	QueuedSeconds   float64   `json:"queued_seconds,omitempty"` // Time spent waiting for a run slot
+	DriftSeconds    float64   `json:"drift_seconds,omitempty"`  // Start delay after the cron time, including jitter (scheduled runs)
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// EventFilter selects events from the log. Zero values match everything.
+type EventFilter struct {
+	Test  string
+	Type  string
+	Since time.Time
+	Limit int // Most recent events to return
+}
+
+// EventLog keeps the most recent scheduler events in memory and optionally
+// appends them to a JSON Lines file, which is reloaded (and trimmed to the
+// in-memory size) on startup so history survives restarts.
+type EventLog struct {
+	mu     sync.Mutex
+	events []Event // Ring buffer
+	next   int     // Index of the next write
+	full   bool
+	file   *os.File
+}
+
+// NewEventLog creates an event log holding size events. If path is set,
+// existing events are loaded from it and new events are appended.
+func NewEventLog(size int, path string) (*EventLog, error) {
+	l := &EventLog{events: make([]Event, size)}
+	if path == "" {
+		return l, nil
+	}
+
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	if err := l.rewrite(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log %s: %w", path, err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// load reads the most recent events from a JSON Lines file
+func (l *EventLog) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read event log %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	loaded := 0
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // Skip partial writes
+		}
+		l.add(e)
+		loaded++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event log %s: %w", path, err)
+	}
+	if loaded > 0 {
+		log.Printf("Loaded %d scheduler event(s) from %s", min(loaded, len(l.events)), path)
+	}
+	return nil
+}
+
+// rewrite replaces the file with the events held in memory, bounding its size
+func (l *EventLog) rewrite(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write event log %s: %w", path, err)
+	}
+	enc := json.NewEncoder(f)
+	for _, e := range l.Events(EventFilter{}) {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write event log %s: %w", path, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write event log %s: %w", path, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Record adds an event, stamping it with the current time
+func (l *EventLog) Record(e Event) {
+	if l == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.add(e)
+
+	if l.file != nil {
+		data, err := json.Marshal(e)
+		if err == nil {
+			_, err = l.file.Write(append(data, '\n'))
+		}
+		if err != nil {
+			log.Printf("Warning: failed to persist scheduler event: %v", err)
+		}
+	}
+}
+
+// add appends an event to the ring buffer. Callers must hold l.mu.
+func (l *EventLog) add(e Event) {
+	l.events[l.next] = e
+	l.next = (l.next + 1) % len(l.events)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Events returns matching events, oldest first
+func (l *EventLog) Events(f EventFilter) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ordered := l.events[:l.next]
+	if l.full {
+		ordered = append(append([]Event{}, l.events[l.next:]...), l.events[:l.next]...)
+	}
+
+	out := []Event{}
+	for _, e := range ordered {
+		if f.Test != "" && e.Test != f.Test {
+			continue
+		}
+		if f.Type != "" && e.Type != f.Type {
+			continue
+		}
+		if !f.Since.IsZero() && e.Time.Before(f.Since) {
+			continue
+		}
+		out = append(out, e)
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+// Close closes the persistent event file, if any
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
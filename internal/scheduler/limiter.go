@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// limiter caps the number of concurrently running tests. When all slots are
+// taken, waiting runs are admitted by priority (highest first), then in
+// arrival order, so availability canaries are not stuck behind bulk tests.
+type limiter struct {
+	mu      sync.Mutex
+	max     int // <= 0 means unlimited
+	running int
+	waiting waitQueue
+	seq     uint64
+}
+
+// waiter is a run queued for a slot
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{} // Closed when the slot is granted
+	index    int           // Heap index, -1 once granted
+}
+
+// newLimiter creates a limiter allowing max concurrent runs
+func newLimiter(max int) *limiter {
+	return &limiter{max: max}
+}
+
+// acquire blocks until a slot is available or ctx is done. It returns
+// whether the caller had to wait.
+func (l *limiter) acquire(ctx context.Context, priority int) (bool, error) {
+	l.mu.Lock()
+	if l.max <= 0 || (l.running < l.max && l.waiting.Len() == 0) {
+		l.running++
+		l.mu.Unlock()
+		return false, nil
+	}
+
+	l.seq++
+	w := &waiter{priority: priority, seq: l.seq, ready: make(chan struct{})}
+	heap.Push(&l.waiting, w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if w.index < 0 {
+			// Granted while being cancelled; hand the slot on
+			l.mu.Unlock()
+			l.release()
+		} else {
+			heap.Remove(&l.waiting, w.index)
+			l.mu.Unlock()
+		}
+		return true, ctx.Err()
+	}
+}
+
+// release frees a slot, handing it to the highest-priority waiter if any
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running--
+	l.admit()
+}
+
+// setMax changes the concurrency limit, admitting waiters if it was raised
+func (l *limiter) setMax(max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = max
+	l.admit()
+}
+
+// admit grants slots to waiters while capacity allows. Callers must hold l.mu.
+func (l *limiter) admit() {
+	for l.waiting.Len() > 0 && (l.max <= 0 || l.running < l.max) {
+		w := heap.Pop(&l.waiting).(*waiter)
+		l.running++
+		close(w.ready)
+	}
+}
+
+// waitQueue is a heap of waiters ordered by priority, then arrival
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/ethanadams/synthetics/internal/config"
+)
+
+// flight is a scheduled run in progress
+type flight struct {
+	cancel context.CancelFunc
+	done   chan struct{} // Closed when the run finishes
+}
+
+// overlapTracker keeps the scheduled runs in flight of each test, so a cron
+// tick that fires while the previous run is still going (a large upload on a
+// slow link) is handled by the test's concurrency policy instead of stacking
+// runs up.
+type overlapTracker struct {
+	mu      sync.Mutex
+	flights map[string][]*flight
+}
+
+func newOverlapTracker() *overlapTracker {
+	return &overlapTracker{flights: make(map[string][]*flight)}
+}
+
+// begin registers a scheduled run of the test under policy. With runs in
+// flight, forbid returns ok false, replace cancels them and waits for them
+// to finish, and allow starts alongside them. overlapped reports whether
+// there were runs in flight. The returned context is canceled if a later run
+// replaces this one, and end must be called when the run finishes.
+func (o *overlapTracker) begin(ctx context.Context, test, policy string) (runCtx context.Context, end func(), overlapped, ok bool) {
+	o.mu.Lock()
+	for len(o.flights[test]) > 0 {
+		overlapped = true
+		if policy == config.ConcurrencyForbid {
+			o.mu.Unlock()
+			return nil, nil, true, false
+		}
+		if policy != config.ConcurrencyReplace {
+			break
+		}
+		running := slices.Clone(o.flights[test])
+		o.mu.Unlock()
+		for _, f := range running {
+			f.cancel()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return nil, nil, true, false
+			}
+		}
+		o.mu.Lock()
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	f := &flight{cancel: cancel, done: make(chan struct{})}
+	o.flights[test] = append(o.flights[test], f)
+	o.mu.Unlock()
+
+	end = func() {
+		o.mu.Lock()
+		o.flights[test] = slices.DeleteFunc(o.flights[test], func(other *flight) bool { return other == f })
+		if len(o.flights[test]) == 0 {
+			delete(o.flights, test)
+		}
+		o.mu.Unlock()
+		cancel()
+		close(f.done)
+	}
+	return runCtx, end, overlapped, true
+}
+
+// running reports whether a scheduled run of the test is in flight
+func (o *overlapTracker) running(test string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.flights[test]) > 0
+}
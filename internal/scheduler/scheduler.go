@@ -2,13 +2,26 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethanadams/synthetics/internal/anomaly"
 	"github.com/ethanadams/synthetics/internal/config"
 	"github.com/ethanadams/synthetics/internal/executor"
 	"github.com/ethanadams/synthetics/internal/jitter"
+	"github.com/ethanadams/synthetics/internal/logging"
+	"github.com/ethanadams/synthetics/internal/metrics"
+	"github.com/ethanadams/synthetics/internal/netpath"
+	"github.com/ethanadams/synthetics/internal/result"
+	"github.com/ethanadams/synthetics/internal/results"
+	"github.com/ethanadams/synthetics/internal/workdir"
 	"github.com/robfig/cron/v3"
 )
 
@@ -17,99 +30,384 @@ type Scheduler struct {
 	cron      *cron.Cron
 	executors map[string]executor.TestExecutor
 	config    *config.Config
+	ctx       context.Context
+	metrics   *metrics.Collector
+	events    *EventLog
+	state     *StateStore
+	limiter   *limiter
+	tracer    *netpath.Tracer
+	drift     *driftTracker
+	status    *statusTracker
+	overlap   *overlapTracker
+	results   *results.Store    // Nil unless the results store is enabled
+	anomaly   *anomaly.Detector // Nil unless set with WithAnomaly
+	started   atomic.Bool
+
+	mu           sync.RWMutex
+	disabledTags map[string]bool         // Tags disabled via config or the admin API
+	entries      map[string]cron.EntryID // Scheduled cron entry per test name
 }
 
-// New creates a new scheduler
-func New(cfg *config.Config, executors map[string]executor.TestExecutor) *Scheduler {
+// New creates a new scheduler. events may be nil to disable the event log,
+// and tracer may be nil to disable path traces of failed tests.
+func New(cfg *config.Config, executors map[string]executor.TestExecutor, mc *metrics.Collector, events *EventLog, state *StateStore, tracer *netpath.Tracer) *Scheduler {
+	disabledTags := make(map[string]bool)
+	for _, tag := range cfg.DisabledTags {
+		disabledTags[tag] = true
+	}
+
 	return &Scheduler{
-		cron:      cron.New(),
-		executors: executors,
-		config:    cfg,
+		cron:         cron.New(),
+		executors:    executors,
+		config:       cfg,
+		ctx:          context.Background(),
+		metrics:      mc,
+		events:       events,
+		state:        state,
+		limiter:      newLimiter(cfg.Scheduler.MaxConcurrent),
+		tracer:       tracer,
+		drift:        newDriftTracker(),
+		status:       newStatusTracker(),
+		overlap:      newOverlapTracker(),
+		disabledTags: disabledTags,
+		entries:      make(map[string]cron.EntryID),
 	}
 }
 
+// WithResults records every run's result in the store
+func (s *Scheduler) WithResults(store *results.Store) *Scheduler {
+	s.results = store
+	return s
+}
+
+// WithAnomaly flags each run whose duration strays from its test's baseline
+func (s *Scheduler) WithAnomaly(detector *anomaly.Detector) *Scheduler {
+	s.anomaly = detector
+	return s
+}
+
 // Start begins scheduling tests
 func (s *Scheduler) Start(ctx context.Context) error {
+	s.ctx = ctx
+
+	s.mu.Lock()
 	enabledCount := 0
+	for _, test := range s.config.Tests {
+		// Restore last-run metrics saved before a restart
+		if st, ok := s.state.Get(test.Name); ok {
+			s.metrics.RecordTestState(test.Name, st.LastRun, st.LastSuccess, st.ConsecutiveFailures)
+		}
 
-	// Schedule all tests (single-step and multi-step)
+		scheduled, err := s.schedule(test)
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		if scheduled {
+			enabledCount++
+		}
+	}
+	s.mu.Unlock()
+
+	if enabledCount == 0 {
+		log.Println("Warning: No tests enabled in configuration")
+	} else {
+		log.Printf("Successfully scheduled %d test(s)", enabledCount)
+	}
+
+	// Start the cron scheduler
+	s.cron.Start()
+	s.started.Store(true)
+	log.Println("Scheduler started")
+
+	return nil
+}
+
+// Ready returns an error until the scheduler has started, and while an
+// enabled test has no executor to run it (e.g. missing S3 credentials)
+func (s *Scheduler) Ready() error {
+	if !s.started.Load() {
+		return errors.New("scheduler not started")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var missing []string
 	for _, test := range s.config.Tests {
-		if !test.Enabled {
-			log.Printf("Skipping disabled test: %s", test.Name)
-			continue
+		if _, ok := s.executors[test.ExecutorKey()]; test.Enabled && !ok {
+			missing = append(missing, fmt.Sprintf("%s (%s)", test.Name, test.ExecutorKey()))
 		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no executor for tests %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
 
-		// Capture loop variable
-		testCopy := test
+// schedule adds a cron entry for the test. It returns false if the test is
+// disabled or its executor is unknown. Callers must hold s.mu.
+func (s *Scheduler) schedule(test config.Test) (bool, error) {
+	if !test.Enabled {
+		testLog(&test).Printf("Skipping disabled test: %s", test.Name)
+		s.events.Record(Event{Type: EventSkipped, Test: test.Name, Reason: ReasonTestDisabled})
+		return false, nil
+	}
 
-		// Get the executor for this test
-		executorType := testCopy.GetExecutor()
-		exec, ok := s.executors[executorType]
-		if !ok {
-			log.Printf("Skipping test %s: unknown executor type '%s'", testCopy.Name, executorType)
-			continue
+	// Capture loop variable
+	testCopy := test
+
+	// Get the executor for this test
+	executorType := testCopy.ExecutorKey()
+	exec, ok := s.executors[executorType]
+	if !ok {
+		testLog(&testCopy).Printf("Skipping test %s: unknown executor type '%s'", testCopy.Name, executorType)
+		s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonUnknownExecutor, Detail: executorType})
+		return false, nil
+	}
+
+	// Determine test type for logging
+	testType := "single-step"
+	if len(testCopy.Steps) > 1 {
+		testType = fmt.Sprintf("%d-step", len(testCopy.Steps))
+	}
+
+	// Calculate effective jitter for this test
+	effectiveJitter := testCopy.GetTestJitter(s.config.Jitter)
+	var maxJitter time.Duration
+	if effectiveJitter.IsEnabled() {
+		scheduleInterval, _ := config.ParseCronInterval(testCopy.Schedule)
+		maxJitter, _ = effectiveJitter.ParseMaxJitter(scheduleInterval)
+	}
+
+	// Capture maxJitter for closure
+	testMaxJitter := maxJitter
+	compensate := effectiveJitter.Compensates()
+	ctx := s.ctx
+
+	// Schedule the test
+	entryID, err := s.cron.AddFunc(test.Schedule, func() {
+		scheduled := time.Now()
+		if tag, disabled := s.disabledTag(&testCopy); disabled {
+			testLog(&testCopy).Printf("Skipping test %s: tag '%s' is disabled", testCopy.Name, tag)
+			s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonTagDisabled, Detail: tag})
+			return
+		}
+		test, err := s.applicable(&testCopy)
+		if err != nil {
+			testLog(&testCopy).Printf("Skipping test %s: %v", testCopy.Name, err)
+			s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonConditionUnmet, Detail: err.Error()})
+			return
+		}
+		if testCopy.Fixture != "" && !s.fixtureReady(testCopy.Fixture) {
+			testLog(&testCopy).Printf("Skipping test %s: fixture '%s' has not been uploaded yet", testCopy.Name, testCopy.Fixture)
+			s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonFixtureNotReady, Detail: testCopy.Fixture})
+			return
 		}
 
-		// Determine test type for logging
-		testType := "single-step"
-		if len(testCopy.Steps) > 1 {
-			testType = fmt.Sprintf("%d-step", len(testCopy.Steps))
+		// Apply test-level jitter if configured, less recent queueing delay
+		// with compensation on
+		maxJitter := testMaxJitter
+		if compensate {
+			maxJitter = s.drift.jitter(testCopy.Name, testMaxJitter)
+			if maxJitter < testMaxJitter {
+				testLog(&testCopy).Debug("Test %s jitter reduced to max %v to compensate for queueing", testCopy.Name, maxJitter.Round(time.Millisecond))
+			}
+		}
+		if maxJitter > 0 {
+			if err := jitter.Apply(ctx, maxJitter, fmt.Sprintf("test %s", testCopy.Name)); err != nil {
+				testLog(&testCopy).Printf("Test %s jitter interrupted: %v", testCopy.Name, err)
+				s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonJitterInterrupted})
+				return
+			}
 		}
 
-		// Calculate effective jitter for this test
-		effectiveJitter := testCopy.GetTestJitter(s.config.Jitter)
-		var maxJitter time.Duration
-		if effectiveJitter.IsEnabled() {
-			scheduleInterval, _ := config.ParseCronInterval(testCopy.Schedule)
-			maxJitter, _ = effectiveJitter.ParseMaxJitter(scheduleInterval)
+		// Apply the concurrency policy if the previous run is still in flight
+		policy := testCopy.GetConcurrencyPolicy(s.Config().Scheduler.ConcurrencyPolicy)
+		runCtx, end, overlapped, ok := s.overlap.begin(ctx, testCopy.Name, policy)
+		if overlapped {
+			s.metrics.RecordOverlappingRun(testCopy.Name, policy)
+		}
+		if !ok {
+			if ctx.Err() != nil {
+				s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonCanceled, Detail: "cron"})
+				return
+			}
+			testLog(&testCopy).Printf("Skipping test %s: previous run still in flight (concurrency_policy: forbid)", testCopy.Name)
+			s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonStillRunning})
+			return
+		}
+		defer end()
+		if overlapped {
+			testLog(&testCopy).Printf("Test %s fired while its previous run was still in flight (concurrency_policy: %s)", testCopy.Name, policy)
 		}
 
-		// Capture maxJitter for closure
-		testMaxJitter := maxJitter
+		testLog(&testCopy).Printf("Scheduled execution: %s (executor: %s)", testCopy.Name, executorType)
+		if _, err := s.run(runCtx, exec, test, "cron", scheduled); err != nil {
+			testLog(&testCopy).Printf("Test %s failed (%s): %v", testCopy.Name, result.Classify(err), err)
+		}
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to schedule test %s: %w", test.Name, err)
+	}
+	s.entries[test.Name] = entryID
 
-		// Schedule the test
-		entryID, err := s.cron.AddFunc(test.Schedule, func() {
-			// Apply test-level jitter if configured
-			if testMaxJitter > 0 {
-				if err := jitter.Apply(ctx, testMaxJitter, fmt.Sprintf("test %s", testCopy.Name)); err != nil {
-					log.Printf("Test %s jitter interrupted: %v", testCopy.Name, err)
-					return
-				}
+	// Fixtures are uploaded as soon as they are scheduled, then every refresh
+	if testCopy.FixtureUpload != "" {
+		go func() {
+			testLog(&testCopy).Printf("Uploading fixture: %s (executor: %s)", testCopy.FixtureUpload, executorType)
+			if _, err := s.run(ctx, exec, &testCopy, "fixture", time.Time{}); err != nil {
+				testLog(&testCopy).Printf("Fixture %s upload failed (%s): %v", testCopy.FixtureUpload, result.Classify(err), err)
 			}
+		}()
+	}
 
-			log.Printf("Scheduled execution: %s (executor: %s)", testCopy.Name, executorType)
-			if err := exec.RunTest(ctx, &testCopy); err != nil {
-				log.Printf("Test %s failed: %v", testCopy.Name, err)
-			}
-		})
+	detail := "schedule " + test.Schedule
+	if testMaxJitter > 0 {
+		detail += fmt.Sprintf(", jitter max %v", testMaxJitter)
+		if compensate {
+			detail += " (compensated)"
+		}
+	}
+	s.events.Record(Event{Type: EventScheduled, Test: test.Name, Detail: detail})
 
-		if err != nil {
-			return err
+	if testMaxJitter > 0 {
+		testLog(&testCopy).Printf("Scheduled test: %s (%s, executor: %s, schedule: %s, jitter: max %v, entry ID: %d)",
+			test.Name, testType, executorType, test.Schedule, testMaxJitter, entryID)
+	} else {
+		testLog(&testCopy).Printf("Scheduled test: %s (%s, executor: %s, schedule: %s, entry ID: %d)",
+			test.Name, testType, executorType, test.Schedule, entryID)
+	}
+	return true, nil
+}
+
+// Reload reconciles the scheduled tests with a new configuration. Tests that
+// are unchanged keep their cron entries; removed or changed tests are
+// unscheduled and new or changed tests are scheduled. Tests, jitter,
+// disabled_tags, and scheduler.max_concurrent are applied; executors, if not
+// nil, replace the current ones (built from the new config's executor
+// settings), which reschedules every test. Runs in progress finish on the
+// executor they started on.
+func (s *Scheduler) Reload(cfg *config.Config, executors map[string]executor.TestExecutor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A schedule that fails to parse would leave the reload half-applied, so
+	// the config is rejected before any state changes
+	for _, test := range cfg.Tests {
+		if !test.Enabled {
+			continue
+		}
+		if _, err := cron.ParseStandard(test.Schedule); err != nil {
+			return fmt.Errorf("test %s: invalid schedule %q: %w", test.Name, test.Schedule, err)
 		}
+	}
 
-		enabledCount++
-		if testMaxJitter > 0 {
-			log.Printf("Scheduled test: %s (%s, executor: %s, schedule: %s, jitter: max %v, entry ID: %d)",
-				test.Name, testType, executorType, test.Schedule, testMaxJitter, entryID)
-		} else {
-			log.Printf("Scheduled test: %s (%s, executor: %s, schedule: %s, entry ID: %d)",
-				test.Name, testType, executorType, test.Schedule, entryID)
+	rescheduleAll := executors != nil || !reflect.DeepEqual(s.config.Jitter, cfg.Jitter)
+	if executors != nil {
+		s.executors = executors
+	}
+
+	oldTests := make(map[string]config.Test, len(s.config.Tests))
+	for _, test := range s.config.Tests {
+		oldTests[test.Name] = test
+	}
+	newTests := make(map[string]bool, len(cfg.Tests))
+	for _, test := range cfg.Tests {
+		newTests[test.Name] = true
+	}
+
+	// Unschedule removed and changed tests
+	var removed, changed, added int
+	for name, id := range s.entries {
+		old := oldTests[name]
+		current, stillExists := findTest(cfg.Tests, name)
+		if stillExists && !rescheduleAll && reflect.DeepEqual(old, current) {
+			continue
+		}
+		s.cron.Remove(id)
+		delete(s.entries, name)
+
+		reason := ReasonChanged
+		if !stillExists {
+			reason = ReasonRemoved
+		}
+		s.events.Record(Event{Type: EventUnscheduled, Test: name, Reason: reason})
+	}
+	for name, old := range oldTests {
+		if current, ok := findTest(cfg.Tests, name); !ok || !reflect.DeepEqual(old, current) {
+			s.anomaly.Forget(name) // A changed test's durations start a new baseline
+		}
+		if !newTests[name] {
+			s.drift.forget(name)
+			s.status.forget(name)
+			s.metrics.UnregisterTest(name)
+			removed++
+			log.Printf("Removed test: %s", name)
 		}
 	}
 
-	if enabledCount == 0 {
-		log.Println("Warning: No tests enabled in configuration")
-	} else {
-		log.Printf("Successfully scheduled %d test(s)", enabledCount)
+	// Disabled tags from the new config are added; tags toggled via the API are kept
+	for _, tag := range cfg.DisabledTags {
+		s.disabledTags[tag] = true
 	}
 
-	// Start the cron scheduler
-	s.cron.Start()
-	log.Println("Scheduler started")
+	s.config = cfg
+	s.limiter.setMax(cfg.Scheduler.MaxConcurrent)
+	s.tracer.Reload(cfg)
+	s.anomaly.Reload(cfg)
+	for _, test := range cfg.Tests {
+		if _, ok := s.entries[test.Name]; ok {
+			continue
+		}
+		old, existed := oldTests[test.Name]
+		if existed && !rescheduleAll && reflect.DeepEqual(old, test) {
+			// Unchanged and still not schedulable (e.g. disabled)
+			continue
+		}
+		if _, err := s.schedule(test); err != nil {
+			return err
+		}
+		if existed {
+			changed++
+		} else {
+			added++
+		}
+	}
 
+	log.Printf("Reloaded configuration: %d test(s), %d added, %d changed, %d removed",
+		len(cfg.Tests), added, changed, removed)
 	return nil
 }
 
+// executorFor returns the executor of a type
+func (s *Scheduler) executorFor(executorType string) (executor.TestExecutor, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	exec, ok := s.executors[executorType]
+	return exec, ok
+}
+
+// applicable returns the test with only the steps whose when conditions hold
+// on this probe now, or an error if the test's own conditions don't hold
+func (s *Scheduler) applicable(test *config.Test) (*config.Test, error) {
+	return test.Applicable(time.Now(), s.Config().S3.Endpoint)
+}
+
+// fixtureReady reports whether the fixture's object has been uploaded
+func (s *Scheduler) fixtureReady(name string) bool {
+	st, ok := s.state.Get(config.FixtureTestName(name))
+	return ok && !st.LastSuccess.IsZero()
+}
+
+// findTest returns the test with the given name
+func findTest(tests []config.Test, name string) (config.Test, bool) {
+	for _, test := range tests {
+		if test.Name == name {
+			return test, true
+		}
+	}
+	return config.Test{}, false
+}
+
 // Stop stops the scheduler
 func (s *Scheduler) Stop() {
 	log.Println("Stopping scheduler...")
@@ -118,18 +416,335 @@ func (s *Scheduler) Stop() {
 	log.Println("Scheduler stopped")
 }
 
-// RunNow immediately runs a specific test (useful for testing)
-func (s *Scheduler) RunNow(ctx context.Context, testName string) error {
-	for _, test := range s.config.Tests {
+// Errors of RunNow
+var (
+	ErrTestNotFound = errors.New("test not found")
+	ErrTestSkipped  = errors.New("skipped") // Conditions unmet or no room in the disk budget
+)
+
+// RunNow immediately runs a specific test, retrying it like a scheduled run,
+// and returns the result of its last attempt. The result is nil if the test
+// did not run.
+func (s *Scheduler) RunNow(ctx context.Context, testName string) (*result.Result, error) {
+	s.mu.RLock()
+	tests := s.config.Tests
+	s.mu.RUnlock()
+
+	for _, test := range tests {
 		if test.Name == testName {
-			executorType := test.GetExecutor()
-			exec, ok := s.executors[executorType]
+			executorType := test.ExecutorKey()
+			exec, ok := s.executorFor(executorType)
 			if !ok {
-				return fmt.Errorf("unknown executor type '%s' for test %s", executorType, testName)
+				return nil, fmt.Errorf("unknown executor type '%s' for test %s", executorType, testName)
+			}
+			applicable, err := s.applicable(&test)
+			if err != nil {
+				return nil, fmt.Errorf("test %s %w: %w", testName, ErrTestSkipped, err)
+			}
+			testLog(&test).Printf("Running test on demand: %s (executor: %s)", testName, executorType)
+			res, err := s.run(ctx, exec, applicable, "on-demand", time.Time{})
+			if res == nil && err == nil {
+				return nil, fmt.Errorf("test %s %w: %w", testName, ErrTestSkipped, workdir.ErrOverBudget)
 			}
-			log.Printf("Running test on demand: %s (executor: %s)", testName, executorType)
-			return exec.RunTest(ctx, &test)
+			return res, err
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTestNotFound, testName)
+}
+
+// run executes a test, re-running it with exponential backoff after a
+// failure if retry_on_failure is set. Retry outcomes are recorded separately
+// from the first attempt, which alone feeds the run metrics, status, and run
+// state (see attempt). trigger describes what started the run (e.g.
+// "cron" or "on-demand"), and scheduled is the cron time of a scheduled run
+// (zero otherwise). A test that still fails has its network path traced. The
+// result is the last attempt's, nil if the test never started.
+func (s *Scheduler) run(ctx context.Context, exec executor.TestExecutor, test *config.Test, trigger string, scheduled time.Time) (res *result.Result, err error) {
+	res, err = s.attempt(ctx, exec, test, trigger, scheduled, false)
+	if res == nil {
+		return nil, err // Never started (shutdown or disk budget): nothing to retry or trace
+	}
+	defer func() {
+		if err != nil {
+			s.tracer.OnFailure(ctx, test)
+		}
+	}()
+	if err == nil || test.RetryOnFailure == nil {
+		return res, err
+	}
+
+	retries := test.RetryOnFailure.Count
+	for retry := 1; retry <= retries; retry++ {
+		backoff := test.RetryOnFailure.BackoffDuration(retry)
+		testLog(test).Printf("Test %s failed, retry %d/%d in %v", test.Name, retry, retries, backoff)
+		s.events.Record(Event{Type: EventRetrying, Test: test.Name, Detail: fmt.Sprintf("retry %d/%d in %v", retry, retries, backoff)})
+
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-time.After(backoff):
+		}
+
+		var retried *result.Result
+		retried, err = s.attempt(ctx, exec, test, fmt.Sprintf("%s, retry %d/%d", trigger, retry, retries), time.Time{}, true)
+		if retried != nil {
+			res = retried
+		}
+		s.metrics.RecordTestRetry(test.Name, retry, err == nil)
+		if err == nil {
+			testLog(test).Printf("Test %s recovered on retry %d/%d", test.Name, retry, retries)
+			return res, nil
+		}
+	}
+	return res, err
+}
+
+// attempt executes a test once a run slot is free and the work directory has
+// room for it, recording fired and completed/failed events. Scheduled runs
+// also record how late they started. The result is nil if the run never
+// started, with no error if it was skipped for the disk budget. A retry is
+// recorded in events and the results store but not in the run metrics
+// (synthetics_test_runs_total and the series derived from results), status,
+// anomaly baseline, or run state, so retries don't inflate them; run counts
+// its outcome in synthetics_test_retries_total.
+func (s *Scheduler) attempt(ctx context.Context, exec executor.TestExecutor, test *config.Test, trigger string, scheduled time.Time, retry bool) (*result.Result, error) {
+	queueStart := time.Now()
+	queued, err := s.limiter.acquire(ctx, test.Priority)
+	if err != nil {
+		s.events.Record(Event{Type: EventSkipped, Test: test.Name, Reason: ReasonCanceled, Detail: trigger})
+		return nil, fmt.Errorf("test %s not started: %w", test.Name, err)
+	}
+	defer s.limiter.release()
+
+	release, err := workdir.Reserve(test.DiskNeed())
+	if err != nil {
+		testLog(test).Printf("Skipping test %s: %v", test.Name, err)
+		s.events.Record(Event{Type: EventSkipped, Test: test.Name, Reason: ReasonDiskBudget, Detail: err.Error()})
+		s.metrics.RecordDiskBudgetSkip(test.Name)
+		return nil, nil // A skip, like an unmet condition, is not a failure
+	}
+	defer release()
+
+	fired := Event{Type: EventFired, Test: test.Name, Detail: trigger}
+	if queued {
+		fired.QueuedSeconds = time.Since(queueStart).Seconds()
+		testLog(test).Printf("Test %s waited %v for a run slot (priority %d)", test.Name, time.Since(queueStart).Round(time.Millisecond), test.Priority)
+	}
+	if !scheduled.IsZero() {
+		fired.DriftSeconds = time.Since(scheduled).Seconds()
+		s.metrics.RecordScheduleDrift(test.Name, time.Since(scheduled))
+		s.drift.observe(test.Name, time.Since(queueStart))
+	}
+	s.events.Record(fired)
+
+	res, err := exec.RunTest(ctx, test)
+	if !scheduled.IsZero() && len(res.Steps) > 0 {
+		s.metrics.RecordStartDelay(test.Name, res.Executor, res.StepsStart().Sub(scheduled))
+	}
+	if err := s.results.Record(res); err != nil {
+		runLog(res).Printf("Warning: %v", err)
+	}
+	if s.Config().Logging.RunSummaryEnabled() {
+		runLog(res).Printf("Run summary: %s", res.SummaryLine())
+	}
+	if !retry {
+		s.metrics.RecordResult(res)
+		s.status.record(res)
+		s.anomaly.Observe(res)
+		st := s.state.Record(test.Name, res, err)
+		s.metrics.RecordTestState(test.Name, st.LastRun, st.LastSuccess, st.ConsecutiveFailures)
+	}
+
+	event := Event{Type: EventCompleted, Test: test.Name, RunID: res.RunID, Detail: trigger, DurationSeconds: res.DurationSeconds}
+	if err != nil {
+		event.Type = EventFailed
+		event.Reason = res.ErrorClass
+		event.Error = err.Error()
+	}
+	s.events.Record(event)
+	return res, err
+}
+
+// testLog returns a logger whose lines carry the test's test_name and
+// executor fields
+func testLog(test *config.Test) logging.Logger {
+	return logging.With("test_name", test.Name, "executor", test.ExecutorKey())
+}
+
+// runLog returns a logger whose lines carry the fields of a finished run
+func runLog(res *result.Result) logging.Logger {
+	return logging.With("test_name", res.Test, "executor", res.Executor, "ulid", res.RunID)
+}
+
+// TaggedTests returns the enabled tests carrying the tag
+func (s *Scheduler) TaggedTests(tag string) []config.Test {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var tests []config.Test
+	for _, test := range s.config.Tests {
+		if test.Enabled && test.HasTag(tag) {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+// RunTest runs a test once, without retries, and returns its result. It
+// waits for a run slot like scheduled runs and records the same events, with
+// the given trigger.
+func (s *Scheduler) RunTest(ctx context.Context, test *config.Test, trigger string) (*result.Result, error) {
+	exec, ok := s.executorFor(test.ExecutorKey())
+	if !ok {
+		return nil, fmt.Errorf("unknown executor type '%s' for test %s", test.ExecutorKey(), test.Name)
+	}
+	applicable, err := s.applicable(test)
+	if err != nil {
+		return nil, fmt.Errorf("test %s skipped: %w", test.Name, err)
+	}
+	return s.attempt(ctx, exec, applicable, trigger, time.Time{}, false)
+}
+
+// LastFailure returns the configured test and its run state, whose
+// LastFailedRun is nil if the test hasn't failed since the state was kept
+func (s *Scheduler) LastFailure(testName string) (*config.Test, TestState, error) {
+	s.mu.RLock()
+	tests := s.config.Tests
+	s.mu.RUnlock()
+	for _, test := range tests {
+		if test.Name == testName {
+			st, _ := s.state.Get(testName)
+			return &test, st, nil
 		}
 	}
-	return fmt.Errorf("test not found: %s", testName)
+	return nil, TestState{}, fmt.Errorf("%w: %s", ErrTestNotFound, testName)
+}
+
+// Events returns matching events from the event log, oldest first
+func (s *Scheduler) Events(f EventFilter) []Event {
+	if s.events == nil {
+		return []Event{}
+	}
+	return s.events.Events(f)
+}
+
+// Traces returns recent network path traces, oldest first, optionally only for one target
+func (s *Scheduler) Traces(target string, limit int) []netpath.Trace {
+	return s.tracer.Traces(target, limit)
+}
+
+// Status returns the current state of every endpoint and satellite tests
+// have run against
+func (s *Scheduler) Status() []TargetStatus {
+	return s.status.status(time.Now())
+}
+
+// Config returns the configuration currently in effect
+func (s *Scheduler) Config() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// disabledTag returns the first disabled tag carried by the test, if any
+func (s *Scheduler) disabledTag(test *config.Test) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, tag := range test.Tags {
+		if s.disabledTags[tag] {
+			return tag, true
+		}
+	}
+	return "", false
+}
+
+// EnableTag re-enables scheduled runs of all tests carrying the tag
+func (s *Scheduler) EnableTag(tag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.disabledTags, tag)
+	log.Printf("Enabled tag: %s", tag)
+}
+
+// DisableTag skips scheduled runs of all tests carrying the tag
+func (s *Scheduler) DisableTag(tag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.disabledTags[tag] = true
+	log.Printf("Disabled tag: %s", tag)
+}
+
+// TagStatus describes a tag and the tests that carry it
+type TagStatus struct {
+	Tag      string   `json:"tag"`
+	Disabled bool     `json:"disabled"`
+	Tests    []string `json:"tests"`
+}
+
+// Tags returns every known tag (from tests or the disabled set), sorted by name
+func (s *Scheduler) Tags() []TagStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byTag := make(map[string][]string)
+	for _, test := range s.config.Tests {
+		for _, tag := range test.Tags {
+			byTag[tag] = append(byTag[tag], test.Name)
+		}
+	}
+	for tag := range s.disabledTags {
+		if _, ok := byTag[tag]; !ok {
+			byTag[tag] = nil
+		}
+	}
+
+	tags := make([]TagStatus, 0, len(byTag))
+	for tag, tests := range byTag {
+		tags = append(tags, TagStatus{Tag: tag, Disabled: s.disabledTags[tag], Tests: tests})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags
+}
+
+// RunTag triggers an immediate run of every enabled test carrying the tag.
+// Tests run in the background; the names of the triggered tests are returned.
+func (s *Scheduler) RunTag(tag string) ([]string, error) {
+	return s.RunTagFrom(tag, "tag "+tag)
+}
+
+// RunTagFrom is RunTag with the trigger recorded on the runs' events, e.g.
+// the pipeline that called a webhook
+func (s *Scheduler) RunTagFrom(tag, trigger string) ([]string, error) {
+	s.mu.RLock()
+	tests := s.config.Tests
+	s.mu.RUnlock()
+
+	var triggered []string
+	for _, test := range tests {
+		if !test.Enabled || !test.HasTag(tag) {
+			continue
+		}
+		testCopy := test
+		exec, ok := s.executorFor(testCopy.ExecutorKey())
+		if !ok {
+			testLog(&testCopy).Printf("Skipping test %s: unknown executor type '%s'", testCopy.Name, testCopy.ExecutorKey())
+			continue
+		}
+		applicable, err := s.applicable(&testCopy)
+		if err != nil {
+			testLog(&testCopy).Printf("Skipping test %s: %v", testCopy.Name, err)
+			s.events.Record(Event{Type: EventSkipped, Test: testCopy.Name, Reason: ReasonConditionUnmet, Detail: err.Error()})
+			continue
+		}
+		triggered = append(triggered, testCopy.Name)
+		go func() {
+			testLog(&testCopy).Printf("Running test on demand (%s): %s", trigger, testCopy.Name)
+			if _, err := s.run(s.ctx, exec, applicable, trigger, time.Time{}); err != nil {
+				testLog(&testCopy).Printf("Test %s failed (%s): %v", testCopy.Name, result.Classify(err), err)
+			}
+		}()
+	}
+	if len(triggered) == 0 {
+		return nil, fmt.Errorf("no enabled tests with tag: %s", tag)
+	}
+	return triggered, nil
 }
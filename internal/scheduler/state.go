@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/result"
+)
+
+// TestState is the outcome of a test's most recent runs
+type TestState struct {
+	LastRun             time.Time `json:"last_run"`
+	LastSuccess         time.Time `json:"last_success,omitzero"`
+	LastFailure         time.Time `json:"last_failure,omitzero"`
+	LastStatus          string    `json:"last_status"` // "success" or "failure"
+	LastError           string    `json:"last_error,omitempty"`
+	LastDurationSeconds float64   `json:"last_duration_seconds"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+
+	LastFailedRun *result.Result `json:"last_failed_run,omitempty"` // The most recent failed run, for triage, without step outputs
+}
+
+// StateStore tracks per-test run state and optionally saves it to a JSON
+// file so it survives restarts
+type StateStore struct {
+	mu    sync.Mutex
+	path  string
+	tests map[string]TestState
+}
+
+// NewStateStore creates a state store. If path is set, state is loaded from
+// it and saved back after every run.
+func NewStateStore(path string) (*StateStore, error) {
+	s := &StateStore{path: path, tests: make(map[string]TestState)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &s.tests); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	for test, st := range s.tests {
+		if st.LastFailedRun != nil {
+			st.LastFailedRun = st.LastFailedRun.WithoutOutputs() // Saved by versions that kept them
+			s.tests[test] = st
+		}
+	}
+	log.Printf("Restored run state for %d test(s) from %s", len(s.tests), path)
+	return s, nil
+}
+
+// Record updates a test's state with the outcome of a run and returns the new state
+func (s *StateStore) Record(test string, res *result.Result, runErr error) TestState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.tests[test]
+	st.LastRun = res.Start.UTC()
+	st.LastDurationSeconds = res.Duration().Seconds()
+	if runErr == nil {
+		st.LastSuccess = st.LastRun
+		st.LastStatus = "success"
+		st.LastError = ""
+		st.ConsecutiveFailures = 0
+	} else {
+		st.LastFailure = st.LastRun
+		st.LastStatus = "failure"
+		st.LastError = runErr.Error()
+		st.ConsecutiveFailures++
+		st.LastFailedRun = res.WithoutOutputs()
+	}
+	s.tests[test] = st
+
+	if err := s.save(); err != nil {
+		log.Printf("Warning: failed to save run state: %v", err)
+	}
+	return st
+}
+
+// Get returns the state of a test
+func (s *StateStore) Get(test string) (TestState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.tests[test]
+	return st, ok
+}
+
+// save writes the state file atomically. Callers must hold s.mu.
+func (s *StateStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.tests, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/result"
+)
+
+// Target states on the status page
+const (
+	StatusOK       = "ok"       // Every test's last run against the target passed
+	StatusDegraded = "degraded" // Some tests' last runs failed
+	StatusDown     = "down"     // Every test's last run failed
+)
+
+// statusHours is how far back status availability looks
+const statusHours = 24
+
+// TargetStatus is the current state of a gateway endpoint or satellite,
+// across all tests that run against it
+type TargetStatus struct {
+	Type            string    `json:"type"`   // "endpoint" or "satellite"
+	Target          string    `json:"target"` // Gateway URL or satellite name
+	State           string    `json:"state"`
+	LastCheck       time.Time `json:"last_check"`
+	Availability24h float64   `json:"availability_24h"` // Share of runs in the last 24h that passed (0-1)
+	Runs24h         int       `json:"runs_24h"`
+	FailingTests    []string  `json:"failing_tests,omitempty"` // Tests whose last run failed
+}
+
+type statusKey struct {
+	kind, target string
+}
+
+// hourBucket counts the runs of one hour
+type hourBucket struct {
+	hour            int64 // Unix hour
+	runs, successes int
+}
+
+// targetHistory is what the status page knows about one target
+type targetHistory struct {
+	lastCheck time.Time
+	lastRun   map[string]bool // Test name -> last run passed
+	hours     [statusHours]hourBucket
+}
+
+// statusTracker rolls run results up by the endpoint and satellite they ran
+// against. Runs against several endpoints (compare) or none (rtt) aren't
+// rolled up.
+type statusTracker struct {
+	mu      sync.Mutex
+	targets map[statusKey]*targetHistory
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{targets: make(map[statusKey]*targetHistory)}
+}
+
+// record adds a run to its targets' history
+func (t *statusTracker) record(res *result.Result) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for _, key := range []statusKey{{"endpoint", res.Endpoint}, {"satellite", res.Satellite}} {
+		if key.target == "" {
+			continue
+		}
+		h := t.targets[key]
+		if h == nil {
+			h = &targetHistory{lastRun: make(map[string]bool)}
+			t.targets[key] = h
+		}
+		h.lastCheck = now
+		h.lastRun[res.Test] = res.Success
+
+		hour := now.Unix() / 3600
+		b := &h.hours[hour%statusHours]
+		if b.hour != hour {
+			*b = hourBucket{hour: hour}
+		}
+		b.runs++
+		if res.Success {
+			b.successes++
+		}
+	}
+}
+
+// forget drops a removed test from every target's current state
+func (t *statusTracker) forget(test string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, h := range t.targets {
+		delete(h.lastRun, test)
+	}
+}
+
+// status returns every target's state, sorted by type and target
+func (t *statusTracker) status(now time.Time) []TargetStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]TargetStatus, 0, len(t.targets))
+	oldest := now.Unix()/3600 - statusHours
+	for key, h := range t.targets {
+		ts := TargetStatus{Type: key.kind, Target: key.target, LastCheck: h.lastCheck}
+		for _, b := range h.hours {
+			if b.hour > oldest {
+				ts.Runs24h += b.runs
+				ts.Availability24h += float64(b.successes)
+			}
+		}
+		if ts.Runs24h > 0 {
+			ts.Availability24h /= float64(ts.Runs24h)
+		}
+		for test, passed := range h.lastRun {
+			if !passed {
+				ts.FailingTests = append(ts.FailingTests, test)
+			}
+		}
+		sort.Strings(ts.FailingTests)
+		switch {
+		case len(ts.FailingTests) == 0:
+			ts.State = StatusOK
+		case len(ts.FailingTests) == len(h.lastRun):
+			ts.State = StatusDown
+		default:
+			ts.State = StatusDegraded
+		}
+		out = append(out, ts)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return out[i].Target < out[j].Target
+	})
+	return out
+}
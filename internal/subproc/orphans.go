@@ -0,0 +1,80 @@
+package subproc
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// MarkerEnv is set in the environment of the subprocesses of runs, so
+// orphans can be told from other processes and from the subprocesses of runs
+// in progress. Its value is "<owner>/<run ID>", where the owner identifies the
+// probe process that started the subprocess.
+const MarkerEnv = "SYNTHETICS_RUN"
+
+// Markers of the runs whose subprocesses are running, with their count
+var (
+	activeMu sync.Mutex
+	active   = make(map[string]int)
+)
+
+// Mark adds the marker of the run to cmd's environment, which must be set
+// first, and counts the run as in progress until release is called after
+// the command exits
+func Mark(cmd *exec.Cmd, runID string) (release func()) {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	marker := selfOwner() + "/" + runID
+	cmd.Env = append(cmd.Env, MarkerEnv+"="+marker)
+
+	activeMu.Lock()
+	active[marker]++
+	activeMu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			activeMu.Lock()
+			defer activeMu.Unlock()
+			if active[marker]--; active[marker] <= 0 {
+				delete(active, marker)
+			}
+		})
+	}
+}
+
+// inProgress reports whether the run of a marker owned by this probe still
+// has subprocesses running
+func inProgress(marker string) bool {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return active[marker] > 0
+}
+
+// RunReaper kills orphaned processes whose command line matches pattern now
+// and then every interval until ctx is done, passing the number killed in
+// each pass to reaped
+func RunReaper(ctx context.Context, interval time.Duration, pattern *regexp.Regexp, reaped func(n int)) {
+	reap := func() {
+		if n := ReapOrphans(pattern); n > 0 {
+			log.Printf("Killed %d orphaned subprocesses", n)
+			reaped(n)
+		}
+	}
+	reap()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reap()
+		}
+	}
+}
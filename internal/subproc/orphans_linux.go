@@ -0,0 +1,117 @@
+//go:build linux
+
+package subproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// selfOwner returns the owner of this probe's markers: its pid and start
+// time, which tells it from a later process that reuses the pid
+var selfOwner = sync.OnceValue(func() string {
+	start, _ := startTime(os.Getpid())
+	return strconv.Itoa(os.Getpid()) + ":" + start
+})
+
+// startTime returns the start time of a process, in clock ticks since boot
+func startTime(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", err
+	}
+	// The command name (field 2) may contain spaces, so count fields from
+	// its closing parenthesis: state is field 3, starttime field 22
+	fields := strings.Fields(string(data[bytes.LastIndexByte(data, ')')+1:]))
+	if len(fields) < 20 {
+		return "", errors.New("malformed stat")
+	}
+	return fields[19], nil
+}
+
+// ReapOrphans kills the marked processes whose command line matches pattern
+// and that no run in progress owns: those of a run of this probe that has
+// ended, and those of a probe process that has exited. It returns the number
+// of processes killed.
+func ReapOrphans(pattern *regexp.Regexp) int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0
+	}
+	killed := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+		if err != nil {
+			continue
+		}
+		command := bytes.ReplaceAll(bytes.TrimRight(cmdline, "\x00"), []byte{0}, []byte{' '})
+		if !pattern.Match(command) {
+			continue
+		}
+		// A process of another user is unreadable, and so never killed
+		environ, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+		if err != nil {
+			continue
+		}
+		marker, ok := markerOf(environ)
+		if !ok || !isOrphan(marker) {
+			continue
+		}
+		if err := kill(pid); err != nil {
+			log.Printf("Warning: failed to kill orphaned process %d: %v", pid, err)
+			continue
+		}
+		_, runID, _ := strings.Cut(marker, "/")
+		log.Printf("Killed orphaned process %d of run %s: %s", pid, runID, command)
+		killed++
+	}
+	return killed
+}
+
+// markerOf returns the value of MarkerEnv in a process's environment
+func markerOf(environ []byte) (string, bool) {
+	for _, kv := range bytes.Split(environ, []byte{0}) {
+		if value, ok := bytes.CutPrefix(kv, []byte(MarkerEnv+"=")); ok {
+			return string(value), true
+		}
+	}
+	return "", false
+}
+
+// isOrphan reports whether the process with marker belongs to no run in
+// progress
+func isOrphan(marker string) bool {
+	owner, _, _ := strings.Cut(marker, "/")
+	if owner == selfOwner() {
+		return !inProgress(marker)
+	}
+	pidStr, start, _ := strings.Cut(owner, ":")
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return false
+	}
+	current, err := startTime(pid)
+	return err != nil || current != start
+}
+
+// kill kills a process, and its process group if it leads one, as the
+// subprocesses of runs do
+func kill(pid int) error {
+	if pgid, err := unix.Getpgid(pid); err == nil && pgid == pid {
+		return unix.Kill(-pid, unix.SIGKILL)
+	}
+	return unix.Kill(pid, unix.SIGKILL)
+}
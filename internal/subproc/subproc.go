@@ -0,0 +1,92 @@
+// Package subproc runs the probe's subprocesses (k6, curl, ping,
+// mtr/traceroute) in their own process group with optional resource limits.
+// A run that is canceled or times out kills the whole group, so processes a
+// script spawned don't outlive it, and rlimits or a cgroup keep a runaway
+// process from exhausting the node's memory or CPU.
+package subproc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+)
+
+// WrapperArg is the hidden command line argument that makes the probe binary
+// a limits wrapper: it sets its rlimits and then execs the subprocess, so
+// the limits hold from the subprocess's first instruction (see Exec)
+const WrapperArg = "__subproc-exec"
+
+// waitDelay bounds how long Wait waits for output pipes after the process
+// group is killed, in case a process that left the group still holds them
+const waitDelay = 5 * time.Second
+
+// Limits of subprocesses, set by Configure
+var (
+	limits   config.SubprocessConfig
+	cgroupFD = -1
+)
+
+// Configure sets the limits of subprocesses started after it returns. With a
+// cgroup path set, the cgroup is created and its limits are written.
+func Configure(cfg config.SubprocessConfig) error {
+	if !limitsSupported && (hasRlimits(cfg) || cfg.Cgroup.Path != "") {
+		return errors.New("subprocess limits are only supported on Linux")
+	}
+	limits = cfg
+	if cfg.Cgroup.Path == "" {
+		return nil
+	}
+	fd, err := openCgroup(cfg.Cgroup)
+	if err != nil {
+		return fmt.Errorf("failed to set up subprocess cgroup: %w", err)
+	}
+	cgroupFD = fd
+	return nil
+}
+
+// hasRlimits reports whether any per-subprocess rlimit is configured
+func hasRlimits(cfg config.SubprocessConfig) bool {
+	return cfg.MaxMemory > 0 || cfg.MaxCPUTimeDuration() > 0 || cfg.MaxOpenFiles > 0
+}
+
+// Command returns a command like exec.CommandContext, except that it runs
+// with the configured limits in its own process group (and cgroup, if
+// configured), and the whole group is killed when ctx is done
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	if hasRlimits(limits) {
+		name, args = wrap(name, args)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	isolate(cmd)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// Output runs cmd and returns its standard output, like cmd.Output. A
+// nonzero exit is an *exec.ExitError carrying the standard error output.
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs cmd and returns its standard output and standard
+// error, like cmd.CombinedOutput
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return output.Bytes(), err
+}
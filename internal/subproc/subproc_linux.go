@@ -0,0 +1,123 @@
+//go:build linux
+
+package subproc
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+// limitsSupported is true where rlimits and cgroups are applied
+const limitsSupported = true
+
+// cpuPeriod is the cpu.max period, in microseconds
+const cpuPeriod = 100000
+
+// isolate runs cmd in a new process group, killed as a whole on cancel
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if cgroupFD >= 0 {
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = cgroupFD
+	}
+	cmd.Cancel = func() error {
+		// A negative pid signals the process group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// wrap returns the command line that runs name through the limits wrapper
+func wrap(name string, args []string) (string, []string) {
+	wrapped := []string{WrapperArg,
+		"-as", strconv.FormatInt(limits.MaxMemory.Int64(), 10),
+		"-cpu", strconv.FormatInt(int64(limits.MaxCPUTimeDuration().Seconds()), 10),
+		"-nofile", strconv.Itoa(limits.MaxOpenFiles),
+		"--", name,
+	}
+	return "/proc/self/exe", append(wrapped, args...)
+}
+
+// Exec is the limits wrapper: it sets the rlimits given in args on its own
+// process and replaces itself with the command that follows them. It only
+// returns, with an exit code, if that fails.
+func Exec(args []string) int {
+	fs := flag.NewFlagSet(WrapperArg, flag.ContinueOnError)
+	as := fs.Uint64("as", 0, "RLIMIT_AS in bytes")
+	cpu := fs.Uint64("cpu", 0, "RLIMIT_CPU in seconds")
+	nofile := fs.Uint64("nofile", 0, "RLIMIT_NOFILE")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return 2
+	}
+
+	set := func(resource int, soft, hard uint64) error {
+		return unix.Setrlimit(resource, &unix.Rlimit{Cur: soft, Max: hard})
+	}
+	var err error
+	if *as > 0 {
+		err = set(unix.RLIMIT_AS, *as, *as)
+	}
+	if *cpu > 0 && err == nil {
+		// SIGXCPU at the soft limit tells a CPU limit kill from a timeout
+		err = set(unix.RLIMIT_CPU, *cpu, *cpu+1)
+	}
+	if *nofile > 0 && err == nil {
+		err = set(unix.RLIMIT_NOFILE, *nofile, *nofile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set resource limits: %v\n", err)
+		return 126
+	}
+
+	path, err := exec.LookPath(fs.Arg(0))
+	if err == nil {
+		err = unix.Exec(path, fs.Args(), os.Environ())
+	}
+	fmt.Fprintf(os.Stderr, "failed to run %s: %v\n", fs.Arg(0), err)
+	return 127
+}
+
+// openCgroup creates the cgroup, writes its limits, and returns a descriptor
+// subprocesses are started in it with
+func openCgroup(cg config.CgroupConfig) (int, error) {
+	if err := os.MkdirAll(cg.Path, 0755); err != nil {
+		return -1, err
+	}
+	if cg.MemoryMax > 0 {
+		if err := os.WriteFile(filepath.Join(cg.Path, "memory.max"), []byte(strconv.FormatInt(cg.MemoryMax.Int64(), 10)), 0644); err != nil {
+			return -1, err
+		}
+	}
+	if cg.CPUs > 0 {
+		quota := fmt.Sprintf("%d %d", int(cg.CPUs*cpuPeriod), cpuPeriod)
+		if err := os.WriteFile(filepath.Join(cg.Path, "cpu.max"), []byte(quota), 0644); err != nil {
+			return -1, err
+		}
+	}
+	return syscall.Open(cg.Path, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+}
+
+// MaxRSS returns the peak resident memory of an exited process, in bytes
+func MaxRSS(state *os.ProcessState) int64 {
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return usage.Maxrss * 1024 // Reported in KiB on Linux
+	}
+	return 0
+}
+
+// KillSignal returns the name of the signal that terminated a process (e.g.
+// "SIGKILL" for a timeout or the OOM killer, "SIGXCPU" for max_cpu_time), or
+// "" if it exited normally
+func KillSignal(state *os.ProcessState) string {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return unix.SignalName(status.Signal())
+	}
+	return ""
+}
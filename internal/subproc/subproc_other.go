@@ -0,0 +1,42 @@
+//go:build !linux
+
+package subproc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+
+	"github.com/ethanadams/synthetics/internal/config"
+)
+
+// limitsSupported is true where rlimits and cgroups are applied
+const limitsSupported = false
+
+// isolate leaves cmd as is; process groups are only used on Linux
+func isolate(cmd *exec.Cmd) {}
+
+// wrap returns name and args as is; Configure rejects limits on this platform
+func wrap(name string, args []string) (string, []string) {
+	return name, args
+}
+
+// Exec fails; the limits wrapper is only used on Linux
+func Exec(args []string) int {
+	return 127
+}
+
+// openCgroup fails; cgroups are only supported on Linux
+func openCgroup(cg config.CgroupConfig) (int, error) {
+	return -1, errors.New("cgroups are only supported on Linux")
+}
+
+// MaxRSS returns 0; peak memory is only reported on Linux
+func MaxRSS(state *os.ProcessState) int64 {
+	return 0
+}
+
+// KillSignal returns ""; kill signals are only reported on Linux
+func KillSignal(state *os.ProcessState) string {
+	return ""
+}
@@ -1,46 +1,35 @@
 package testdata
 
 import (
-	"crypto/rand"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/payload"
+	"github.com/ethanadams/synthetics/internal/workdir"
 )
 
-const dataDir = "/tmp/test-data"
+// DataDir returns the directory where test data files are generated
+func DataDir() string {
+	return workdir.DataDir()
+}
 
 // EnsureTestDataFiles generates test data files for all configured tests
 // if they don't already exist. This is called once at startup.
 func EnsureTestDataFiles(cfg *config.Config) error {
 	// Create data directory if it doesn't exist
+	dataDir := DataDir()
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return fmt.Errorf("failed to create test data directory: %w", err)
 	}
 
 	log.Printf("Ensuring test data files in %s...", dataDir)
 
-	// Collect unique (testName, fileSize) combinations from config
-	fileSizes := make(map[string]int64)
-
-	for _, test := range cfg.Tests {
-		for _, step := range test.Steps {
-			// Only care about upload steps
-			if filepath.Base(step.Script) != "upload.js" {
-				continue
-			}
-
-			// Get file size from step config
-			if step.FileSize != nil && step.FileSize.Int64() > 0 {
-				size := step.FileSize.Int64()
-				key := fmt.Sprintf("%s-%d", test.Name, size)
-				fileSizes[key] = size
-			}
-		}
-	}
-
+	fileSizes, sources := dataFiles(cfg)
 	if len(fileSizes) == 0 {
 		log.Printf("No upload tests found in config, skipping test data generation")
 		return nil
@@ -49,7 +38,7 @@ func EnsureTestDataFiles(cfg *config.Config) error {
 	// Generate each unique file
 	for key, size := range fileSizes {
 		filename := filepath.Join(dataDir, key+".bin")
-		if err := ensureFile(filename, size); err != nil {
+		if err := ensureFile(filename, size, sources[key]); err != nil {
 			log.Printf("Warning: failed to generate %s: %v", filename, err)
 		}
 	}
@@ -68,8 +57,59 @@ func EnsureTestDataFiles(cfg *config.Config) error {
 	return nil
 }
 
+// dataFiles returns the size and payload source of each test data file the
+// config needs, keyed by file name without ".bin"
+func dataFiles(cfg *config.Config) (map[string]int64, map[string]config.PayloadConfig) {
+	// Collect unique (testName, fileSize) combinations from config
+	fileSizes := make(map[string]int64)
+	sources := make(map[string]config.PayloadConfig)
+
+	for _, test := range cfg.Tests {
+		for _, step := range test.Steps {
+			// Only care about upload steps
+			if filepath.Base(step.Script) != "upload.js" {
+				continue
+			}
+
+			// Get file size from step config
+			if step.FileSize != nil && step.FileSize.Int64() > 0 {
+				size := step.FileSize.Int64()
+				key := fmt.Sprintf("%s-%d", test.Name, size)
+				fileSizes[key] = size
+				sources[key] = cfg.GetPayload(&step)
+			}
+		}
+	}
+	return fileSizes, sources
+}
+
+// RemoveStale deletes test data files no test in the config uses any more,
+// e.g. of a removed test or an old file size, so they don't accumulate in
+// the work directory. Only call it with the full config.
+func RemoveStale(cfg *config.Config) {
+	fileSizes, _ := dataFiles(cfg)
+	entries, err := os.ReadDir(DataDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".bin")
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		if _, used := fileSizes[name]; used {
+			continue
+		}
+		if err := os.Remove(filepath.Join(DataDir(), entry.Name())); err != nil {
+			log.Printf("Warning: failed to remove stale test data file %s: %v", entry.Name(), err)
+			continue
+		}
+		log.Printf("  Removed stale test data file: %s", entry.Name())
+	}
+}
+
 // ensureFile creates a test data file if it doesn't exist or is wrong size
-func ensureFile(filename string, size int64) error {
+func ensureFile(filename string, size int64, source config.PayloadConfig) error {
 	// Check if file exists with correct size
 	if info, err := os.Stat(filename); err == nil {
 		if info.Size() == size {
@@ -81,8 +121,20 @@ func ensureFile(filename string, size int64) error {
 		os.Remove(filename)
 	}
 
+	src, err := payload.New(source)
+	if err != nil {
+		return err
+	}
+
+	// Generated files count against the work directory disk budget
+	release, err := workdir.Reserve(size)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Generate new file
-	log.Printf("  Generating: %s (%s)", filepath.Base(filename), formatBytes(size))
+	log.Printf("  Generating: %s (%s, %s payload)", filepath.Base(filename), formatBytes(size), source.GetSource())
 
 	f, err := os.Create(filename)
 	if err != nil {
@@ -90,26 +142,10 @@ func ensureFile(filename string, size int64) error {
 	}
 	defer f.Close()
 
-	// Generate random data in chunks to avoid memory issues with large files
-	const chunkSize = 1024 * 1024 // 1MB chunks
-	buf := make([]byte, chunkSize)
-	remaining := size
-
-	for remaining > 0 {
-		toWrite := chunkSize
-		if remaining < int64(chunkSize) {
-			toWrite = int(remaining)
-		}
-
-		if _, err := rand.Read(buf[:toWrite]); err != nil {
-			return fmt.Errorf("failed to generate random data: %w", err)
-		}
-
-		if _, err := f.Write(buf[:toWrite]); err != nil {
-			return fmt.Errorf("failed to write data: %w", err)
-		}
-
-		remaining -= int64(toWrite)
+	// Payload data is generated as it is written, so large files don't
+	// need to fit in memory
+	if _, err := io.Copy(f, payload.Reader(src, size)); err != nil {
+		return fmt.Errorf("failed to write data: %w", err)
 	}
 
 	return nil
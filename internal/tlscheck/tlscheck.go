@@ -0,0 +1,272 @@
+// Package tlscheck performs deeper TLS validation of a gateway endpoint:
+// full chain verification against configured roots, hostname SAN
+// verification, and OCSP/CRL revocation checks.
+package tlscheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// Failure classes reported by Check
+const (
+	ClassOK                    = "ok"
+	ClassDial                  = "dial"
+	ClassChain                 = "chain"
+	ClassExpired               = "expired"
+	ClassHostname              = "hostname"
+	ClassRevoked               = "revoked"
+	ClassRevocationUnavailable = "revocation_unavailable"
+)
+
+// Options configures a TLS check
+type Options struct {
+	RootsFile       string        // PEM bundle of trusted roots (empty = system roots)
+	CheckRevocation bool          // Check OCSP (stapled or responder), falling back to CRL
+	Timeout         time.Duration // Dial and revocation request timeout
+}
+
+// Error is a TLS check failure with a class suitable for metric labels
+type Error struct {
+	Class string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("TLS check failed (%s): %v", e.Class, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Result describes a completed TLS check
+type Result struct {
+	Class    string    // ClassOK or ClassRevocationUnavailable (soft failure)
+	NotAfter time.Time // Leaf certificate expiry
+}
+
+// Check connects to the endpoint and validates its certificate chain,
+// hostname, and (optionally) revocation status. Revocation status that
+// cannot be determined is reported in the result but is not an error.
+func Check(ctx context.Context, endpoint string, opts Options) (*Result, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, &Error{Class: ClassDial, Err: fmt.Errorf("invalid endpoint %q: %w", endpoint, err)}
+	}
+	if u.Scheme != "https" {
+		return nil, &Error{Class: ClassDial, Err: fmt.Errorf("endpoint %q is not https", endpoint)}
+	}
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	roots, err := loadRoots(opts.RootsFile)
+	if err != nil {
+		return nil, &Error{Class: ClassChain, Err: err}
+	}
+
+	// Skip built-in verification so chain and hostname failures can be classified separately
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    &tls.Config{ServerName: host, InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, &Error{Class: ClassDial, Err: err}
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, &Error{Class: ClassChain, Err: errors.New("no peer certificates presented")}
+	}
+	leaf := state.PeerCertificates[0]
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		var invalid x509.CertificateInvalidError
+		if errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+			return nil, &Error{Class: ClassExpired, Err: err}
+		}
+		return nil, &Error{Class: ClassChain, Err: err}
+	}
+
+	if err := leaf.VerifyHostname(host); err != nil {
+		return nil, &Error{Class: ClassHostname, Err: err}
+	}
+
+	result := &Result{Class: ClassOK, NotAfter: leaf.NotAfter}
+	if !opts.CheckRevocation {
+		return result, nil
+	}
+
+	// Verified chains always end in a root; the issuer is the second element
+	if len(chains[0]) < 2 {
+		result.Class = ClassRevocationUnavailable
+		return result, nil
+	}
+	issuer := chains[0][1]
+
+	revoked, err := checkRevocation(ctx, leaf, issuer, state.OCSPResponse, timeout)
+	if err != nil {
+		result.Class = ClassRevocationUnavailable
+		return result, nil
+	}
+	if revoked {
+		return nil, &Error{Class: ClassRevoked, Err: fmt.Errorf("certificate %s is revoked", leaf.SerialNumber)}
+	}
+	return result, nil
+}
+
+// loadRoots returns the configured root pool (nil means system roots)
+func loadRoots(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roots file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in roots file %s", path)
+	}
+	return pool, nil
+}
+
+// checkRevocation checks the stapled OCSP response, then the OCSP responder,
+// then the CRL distribution points. It returns an error only if no source
+// could determine the status.
+func checkRevocation(ctx context.Context, leaf, issuer *x509.Certificate, stapled []byte, timeout time.Duration) (bool, error) {
+	if len(stapled) > 0 {
+		if resp, err := ocsp.ParseResponseForCert(stapled, leaf, issuer); err == nil && resp.Status != ocsp.Unknown {
+			return resp.Status == ocsp.Revoked, nil
+		}
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	var lastErr error
+	for _, server := range leaf.OCSPServer {
+		revoked, err := queryOCSP(ctx, client, server, leaf, issuer)
+		if err == nil {
+			return revoked, nil
+		}
+		lastErr = err
+	}
+
+	for _, dp := range leaf.CRLDistributionPoints {
+		revoked, err := queryCRL(ctx, client, dp, leaf, issuer)
+		if err == nil {
+			return revoked, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("certificate has no OCSP responder or CRL distribution point")
+	}
+	return false, lastErr
+}
+
+// queryOCSP asks an OCSP responder for the leaf certificate's status
+func queryOCSP(ctx context.Context, client *http.Client, server string, leaf, issuer *x509.Certificate) (bool, error) {
+	reqBytes, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create OCSP request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(reqBytes))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("OCSP request to %s failed: %w", server, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("OCSP responder %s returned status %d", server, resp.StatusCode)
+	}
+
+	ocspResp, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		return false, fmt.Errorf("invalid OCSP response from %s: %w", server, err)
+	}
+	if ocspResp.Status == ocsp.Unknown {
+		return false, fmt.Errorf("OCSP responder %s returned unknown status", server)
+	}
+	return ocspResp.Status == ocsp.Revoked, nil
+}
+
+// queryCRL downloads a CRL and checks whether the leaf serial is listed
+func queryCRL(ctx context.Context, client *http.Client, dp string, leaf, issuer *x509.Certificate) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dp, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("CRL request to %s failed: %w", dp, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("CRL %s returned status %d", dp, resp.StatusCode)
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return false, fmt.Errorf("invalid CRL from %s: %w", dp, err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return false, fmt.Errorf("CRL %s signature invalid: %w", dp, err)
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
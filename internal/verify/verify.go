@@ -0,0 +1,156 @@
+// Package verify runs a test group repeatedly and judges the results against
+// availability and latency thresholds, for automated rollout gates (e.g.
+// after a gateway deploy).
+package verify
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ethanadams/synthetics/internal/config"
+	"github.com/ethanadams/synthetics/internal/result"
+)
+
+// Options selects how many times tests run and the thresholds they must meet
+type Options struct {
+	Runs            int           // Runs of each test (default: 3)
+	Interval        time.Duration // Pause between rounds of runs
+	MinAvailability float64       // Minimum share of passed runs per test, in percent
+	MaxP95          time.Duration // Maximum p95 duration of passed runs per test (0 = no limit)
+}
+
+// DefaultRuns is the default Options.Runs
+const DefaultRuns = 3
+
+// Validate applies defaults and checks ranges
+func (o *Options) Validate() error {
+	if o.Runs == 0 {
+		o.Runs = DefaultRuns
+	}
+	switch {
+	case o.Runs < 1 || o.Runs > 1000:
+		return fmt.Errorf("runs must be 1-1000, got %d", o.Runs)
+	case o.MinAvailability < 0 || o.MinAvailability > 100:
+		return fmt.Errorf("min availability must be 0-100%%, got %g", o.MinAvailability)
+	case o.Interval < 0 || o.MaxP95 < 0:
+		return fmt.Errorf("interval and max p95 must not be negative")
+	}
+	return nil
+}
+
+// RunFunc runs a test once
+type RunFunc func(ctx context.Context, test *config.Test) (*result.Result, error)
+
+// TestVerdict is the outcome of one test's runs
+type TestVerdict struct {
+	Test                string   `json:"test"`
+	Runs                int      `json:"runs"`
+	Successes           int      `json:"successes"`
+	AvailabilityPercent float64  `json:"availability_percent"`
+	P95Seconds          float64  `json:"p95_seconds"` // Over passed runs; 0 if none passed
+	Pass                bool     `json:"pass"`
+	Reasons             []string `json:"reasons,omitempty"` // Thresholds missed
+	Errors              []string `json:"errors,omitempty"`  // Errors of failed runs
+	durations           []float64
+}
+
+// Verdict is the outcome of a verification
+type Verdict struct {
+	Tag                 string        `json:"tag"`
+	Start               time.Time     `json:"start"`
+	DurationSeconds     float64       `json:"duration_seconds"`
+	Runs                int           `json:"runs"` // Per test
+	MinAvailability     float64       `json:"min_availability_percent"`
+	MaxP95Seconds       float64       `json:"max_p95_seconds,omitempty"`
+	AvailabilityPercent float64       `json:"availability_percent"` // Across all tests
+	Pass                bool          `json:"pass"`
+	Canceled            bool          `json:"canceled,omitempty"` // Stopped before all runs finished; never passes
+	Tests               []TestVerdict `json:"tests"`
+}
+
+// Run runs every test opts.Runs times, one round of tests after another, and
+// judges each test against the thresholds. The verdict passes only if every
+// test does.
+func Run(ctx context.Context, tag string, tests []config.Test, opts Options, run RunFunc) *Verdict {
+	v := &Verdict{
+		Tag:             tag,
+		Start:           time.Now(),
+		Runs:            opts.Runs,
+		MinAvailability: opts.MinAvailability,
+		MaxP95Seconds:   opts.MaxP95.Seconds(),
+		Tests:           make([]TestVerdict, len(tests)),
+	}
+	for i := range tests {
+		v.Tests[i].Test = tests[i].Name
+	}
+
+rounds:
+	for round := 0; round < opts.Runs; round++ {
+		if round > 0 && opts.Interval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.Interval):
+			}
+		}
+		for i := range tests {
+			if ctx.Err() != nil {
+				v.Canceled = true
+				break rounds
+			}
+			tv := &v.Tests[i]
+			res, err := run(ctx, &tests[i])
+			tv.Runs++
+			if err != nil {
+				tv.Errors = append(tv.Errors, err.Error())
+				continue
+			}
+			tv.Successes++
+			tv.durations = append(tv.durations, res.DurationSeconds)
+		}
+	}
+
+	runs, successes := 0, 0
+	v.Pass = !v.Canceled
+	for i := range v.Tests {
+		tv := &v.Tests[i]
+		tv.judge(opts)
+		runs += tv.Runs
+		successes += tv.Successes
+		v.Pass = v.Pass && tv.Pass
+	}
+	if runs > 0 {
+		v.AvailabilityPercent = 100 * float64(successes) / float64(runs)
+	}
+	v.DurationSeconds = time.Since(v.Start).Seconds()
+	return v
+}
+
+// judge computes the test's availability and p95 and checks the thresholds
+func (tv *TestVerdict) judge(opts Options) {
+	if tv.Runs > 0 {
+		tv.AvailabilityPercent = 100 * float64(tv.Successes) / float64(tv.Runs)
+	}
+	tv.P95Seconds = percentile(tv.durations, 0.95)
+
+	if tv.AvailabilityPercent < opts.MinAvailability {
+		tv.Reasons = append(tv.Reasons, fmt.Sprintf("availability %.1f%% below %g%%", tv.AvailabilityPercent, opts.MinAvailability))
+	}
+	if opts.MaxP95 > 0 && tv.P95Seconds > opts.MaxP95.Seconds() {
+		tv.Reasons = append(tv.Reasons, fmt.Sprintf("p95 %.3fs above %v", tv.P95Seconds, opts.MaxP95))
+	}
+	tv.Pass = tv.Runs > 0 && len(tv.Reasons) == 0
+}
+
+// percentile returns the nearest-rank percentile of values (0 if empty)
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
@@ -0,0 +1,65 @@
+// Package version holds build information injected at link time:
+//
+//	go build -ldflags "-X github.com/ethanadams/synthetics/internal/version.Version=1.2.0 \
+//	  -X github.com/ethanadams/synthetics/internal/version.Commit=abc1234 \
+//	  -X github.com/ethanadams/synthetics/internal/version.Date=2024-01-01T00:00:00Z"
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set via -ldflags at build time
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information. Commit and date fall back to the VCS
+// stamp embedded by the Go toolchain when not set via ldflags.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+				if len(info.Commit) > 12 {
+					info.Commit = info.Commit[:12]
+				}
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// String returns a one-line version description
+func (i Info) String() string {
+	return fmt.Sprintf("synthetics %s (commit %s, built %s, %s)", i.Version, i.Commit, i.Date, i.GoVersion)
+}
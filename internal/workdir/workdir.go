@@ -0,0 +1,196 @@
+// Package workdir places every file the probe writes during runs (k6 output,
+// curl transfer files, generated test data) under one configurable
+// directory, so the probe can run with a read-only root filesystem and a
+// single volume bounds its disk usage.
+package workdir
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultDir is the work directory unless work_dir.path is set
+const DefaultDir = "/tmp/synthetics"
+
+// root is the work directory set by Init
+var root = DefaultDir
+
+// Init makes dir the work directory, creating its temp and test data
+// subdirectories, and sets its disk budget in bytes (0: unlimited). It also
+// points TMPDIR at the temp directory, so temp files of libraries and of k6
+// and curl subprocesses land there too.
+func Init(dir string, maxSize int64) error {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	for _, sub := range []string{filepath.Join(dir, "tmp"), filepath.Join(dir, "test-data")} {
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+	}
+	root = dir
+	SetBudget(maxSize)
+	return os.Setenv("TMPDIR", TempDir())
+}
+
+// Dir returns the work directory
+func Dir() string {
+	return root
+}
+
+// TempDir returns the directory for per-run temp files
+func TempDir() string {
+	return filepath.Join(root, "tmp")
+}
+
+// DataDir returns the directory for generated test data files
+func DataDir() string {
+	return filepath.Join(root, "test-data")
+}
+
+// Usage returns the total size of the files in the work directory
+func Usage() int64 {
+	return Size(root)
+}
+
+// Size returns the total size of the regular files under dir
+func Size(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// Disk budget: the most the work directory may hold, including space
+// reserved by runs in progress. Zero means unlimited.
+var (
+	budgetMu sync.Mutex
+	budget   int64
+	reserved int64
+)
+
+// ErrOverBudget is wrapped by the errors of Reserve
+var ErrOverBudget = errors.New("work directory disk budget exceeded")
+
+// SetBudget sets the disk budget in bytes (0: unlimited)
+func SetBudget(bytes int64) {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	budget = bytes
+}
+
+// Reserve sets aside bytes of the disk budget for files about to be written,
+// until release is called. It fails with ErrOverBudget if the work
+// directory's usage plus outstanding reservations would exceed the budget.
+func Reserve(bytes int64) (release func(), err error) {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	if budget <= 0 {
+		return func() {}, nil
+	}
+	if used := Usage() + reserved; used+bytes > budget {
+		return nil, fmt.Errorf("%w: needs %d bytes, %d of %d in use", ErrOverBudget, bytes, used, budget)
+	}
+	reserved += bytes
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			budgetMu.Lock()
+			reserved -= bytes
+			budgetMu.Unlock()
+		})
+	}, nil
+}
+
+// Fits returns the error Reserve would fail with for bytes, without
+// reserving them
+func Fits(bytes int64) error {
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+	if budget <= 0 {
+		return nil
+	}
+	if used := Usage() + reserved; used+bytes > budget {
+		return fmt.Errorf("%w: needs %d bytes, %d of %d in use", ErrOverBudget, bytes, used, budget)
+	}
+	return nil
+}
+
+// tempPatterns match the names of the temp files runs create (curl upload
+// and download files) or created before k6 output was streamed (k6 output)
+var tempPatterns = []string{"k6-output-*", "curl-*"}
+
+// CleanTemp removes temp files last modified more than maxAge ago. Runs
+// remove their own temp files, so these are orphans of a crashed or killed
+// probe or subprocess. It returns the number of files removed.
+func CleanTemp(maxAge time.Duration) int {
+	entries, err := os.ReadDir(TempDir())
+	if err != nil {
+		return 0
+	}
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isTempFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(TempDir(), entry.Name())); err != nil {
+			log.Printf("Warning: failed to remove stale temp file %s: %v", entry.Name(), err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// RunCleanup removes stale temp files now and then periodically until ctx
+// is done
+func RunCleanup(ctx context.Context, maxAge time.Duration) {
+	clean := func() {
+		if n := CleanTemp(maxAge); n > 0 {
+			log.Printf("Removed %d stale temp files older than %v", n, maxAge)
+		}
+	}
+	clean()
+
+	ticker := time.NewTicker(max(maxAge/2, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			clean()
+		}
+	}
+}
+
+// isTempFile reports whether name is a temp file of a run
+func isTempFile(name string) bool {
+	for _, pattern := range tempPatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -114,6 +114,9 @@ BUILD_CMD=(
     docker buildx build
     --platform "$BUILD_PLATFORMS"
     --file "$ROOT_DIR/deployments/Dockerfile"
+    --build-arg "VERSION=$VERSION"
+    --build-arg "COMMIT=$(git -C "$ROOT_DIR" rev-parse --short HEAD 2>/dev/null || echo unknown)"
+    --build-arg "BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
     "${TAG_ARGS[@]}"
 )
 
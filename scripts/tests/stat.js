@@ -0,0 +1,98 @@
+import storj from 'k6/x/storj';
+import { check } from 'k6';
+import { Rate, Trend } from 'k6/metrics';
+
+// Custom metrics for stat operations
+const statDuration = new Trend('storj_stat_duration_ms');
+const statSuccess = new Rate('storj_stat_success');
+const sizeMatch = new Rate('storj_stat_size_match');         // Only with EXPECT_SIZE
+const metadataMatch = new Rate('storj_stat_metadata_match'); // Only with EXPECT_METADATA
+
+export const options = {
+    vus: 1,
+    iterations: 1,
+    thresholds: {
+        'storj_stat_success': ['rate>0.95'],
+    },
+};
+
+export default function () {
+    const accessGrant = __ENV.STORJ_ACCESS_GRANT;
+    const bucketName = __ENV.STORJ_BUCKET || 'synthetics-test';
+    const sharedFile = __ENV.SHARED_FILE; // Object of this test run (includes ULID)
+    const expectSize = __ENV.EXPECT_SIZE; // Set by verify_metadata: size the run uploaded
+    const expectMetadata = __ENV.EXPECT_METADATA; // Set by verify_metadata: custom metadata the run uploaded (JSON)
+
+    if (!accessGrant) {
+        console.error('STORJ_ACCESS_GRANT environment variable is required');
+        return;
+    }
+    if (!sharedFile) {
+        console.error('SHARED_FILE environment variable is required');
+        return;
+    }
+
+    // Create Storj client
+    const client = storj.newClient(accessGrant);
+
+    try {
+        console.log(`Stat ${bucketName}/${sharedFile}`);
+
+        const statStart = Date.now();
+        let statErr = null;
+        let info = null;
+        try {
+            info = client.stat(bucketName, sharedFile);
+        } catch (err) {
+            statErr = err;
+            console.error('Stat failed:', err);
+        }
+        const statDurationMs = Date.now() - statStart;
+
+        statDuration.add(statDurationMs);
+        statSuccess.add(statErr === null);
+
+        check(statErr, {
+            'stat succeeded': (err) => err === null,
+        });
+        if (statErr !== null) {
+            return;
+        }
+        console.log(`Stat completed in ${statDurationMs}ms (${info.size} bytes)`);
+
+        if (expectSize !== undefined) {
+            const ok = info.size === parseInt(expectSize);
+            sizeMatch.add(ok);
+            if (!ok) {
+                console.error(`Size ${info.size}, uploaded ${expectSize}`);
+            }
+        }
+        if (expectMetadata !== undefined) {
+            const diffs = metadataDiffs(info.metadata || {}, JSON.parse(expectMetadata));
+            metadataMatch.add(diffs.length === 0);
+            for (const diff of diffs) {
+                console.error(diff);
+            }
+        }
+
+    } finally {
+        // Always close the client
+        try {
+            client.close();
+        } catch (err) {
+            console.warn('Failed to close client:', err);
+        }
+    }
+}
+
+// Describe each metadata key whose value differs from what was uploaded
+function metadataDiffs(got, uploaded) {
+    const keys = new Set([...Object.keys(got), ...Object.keys(uploaded)]);
+    const diffs = [];
+    for (const key of [...keys].sort()) {
+        if (got[key] !== uploaded[key]) {
+            diffs.push(`Metadata ${key} ${JSON.stringify(got[key])}, uploaded ${JSON.stringify(uploaded[key])}`);
+        }
+    }
+    return diffs;
+}
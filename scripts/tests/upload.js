@@ -26,6 +26,7 @@ export default function () {
     const testName = __ENV.TEST_NAME; // Test name
     const testULID = __ENV.TEST_ULID; // ULID for this test run
     const ttlSeconds = parseInt(__ENV.TTL_SECONDS || '0'); // TTL in seconds (0 = no expiration)
+    const metadata = JSON.parse(__ENV.METADATA || '{}'); // Custom metadata committed with the object
 
     if (!accessGrant) {
         console.error('STORJ_ACCESS_GRANT environment variable is required');
@@ -57,7 +58,7 @@ export default function () {
         const uploadStart = Date.now();
         let uploadErr = null;
         try {
-            client.upload(bucketName, testKey, testData, ttlSeconds);
+            client.upload(bucketName, testKey, testData, ttlSeconds, metadata);
         } catch (err) {
             uploadErr = err;
             console.error('Upload failed:', err);